@@ -10,6 +10,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	_ "time/tzdata" // база часовых поясов для профилей пользователей (в alpine её нет)
 
 	"github.com/EgorLis/my-subs/internal/app"
 )
@@ -0,0 +1,23 @@
+// subsctl — клиент командной строки my-subs.
+// Адрес API и учётные данные берутся из ~/.config/subsctl/config.yaml, переменных SUBSCTL_* или флагов;
+// автодополнение — subsctl completion bash|zsh|fish|powershell.
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/EgorLis/my-subs/internal/cli"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	root := cli.NewRootCmd()
+	if err := root.ExecuteContext(ctx); err != nil {
+		root.PrintErrln("Error:", err)
+		os.Exit(1)
+	}
+}
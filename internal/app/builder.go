@@ -4,27 +4,39 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"time"
 
 	"github.com/EgorLis/my-subs/internal/config"
 	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/i18n"
 	"github.com/EgorLis/my-subs/internal/infra/database/mock"
 	"github.com/EgorLis/my-subs/internal/infra/database/postgres"
+	"github.com/EgorLis/my-subs/internal/jobs/anomaly"
+	"github.com/EgorLis/my-subs/internal/jobs/purge"
+	"github.com/EgorLis/my-subs/internal/jobs/webhook"
+	"github.com/EgorLis/my-subs/internal/transport/rpc"
 	"github.com/EgorLis/my-subs/internal/transport/web"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
 )
 
 type App struct {
-	config *config.Config
-	db     domain.SubscriptionRepository
-	server *web.Server
-	log    *log.Logger
+	config    *config.Config
+	db        domain.Repository
+	server    *web.Server
+	grpc      *rpc.Server
+	anomalies *anomaly.Detector
+	purger    *purge.Purger
+	webhooks  *webhook.Dispatcher
+	log       *log.Logger
 }
 
 func Build(ctx context.Context) (*App, error) {
 	base := log.New(os.Stdout, "[app] ", log.LstdFlags)
 
 	serverLog := log.New(base.Writer(), base.Prefix()+"[server] ", base.Flags())
+	grpcLog := log.New(base.Writer(), base.Prefix()+"[grpc] ", base.Flags())
 	pgLog := log.New(base.Writer(), base.Prefix()+"[postgres] ", base.Flags())
 
 	cfg, err := config.LoadFromEnv()
@@ -44,16 +56,24 @@ func Build(ctx context.Context) (*App, error) {
 	base.Println("PostgreSQL is initialized")
 
 	base.Println("init Server")
+	if err := loadMessages(cfg); err != nil {
+		return nil, err
+	}
 	server := web.New(serverLog, cfg, pgRepo)
+	grpcServer := rpc.New(grpcLog, cfg, pgRepo, server.Events())
 	base.Println("Server is initialized")
 
 	base.Println("build ended")
 
 	return &App{
-		config: cfg,
-		server: server,
-		db:     pgRepo,
-		log:    base,
+		config:    cfg,
+		server:    server,
+		grpc:      grpcServer,
+		db:        pgRepo,
+		anomalies: newAnomalyDetector(base, cfg, pgRepo),
+		purger:    newPurger(base, cfg, pgRepo),
+		webhooks:  newDispatcher(base, cfg, pgRepo),
+		log:       base,
 	}, nil
 }
 
@@ -61,6 +81,7 @@ func BuildMock(ctx context.Context) (*App, error) {
 	base := log.New(os.Stdout, "[app] ", log.LstdFlags)
 
 	serverLog := log.New(base.Writer(), base.Prefix()+"[server] ", base.Flags())
+	grpcLog := log.New(base.Writer(), base.Prefix()+"[grpc] ", base.Flags())
 
 	cfg, err := config.LoadFromEnv()
 	if err != nil {
@@ -71,20 +92,80 @@ func BuildMock(ctx context.Context) (*App, error) {
 
 	mockDB := mock.NewMockRepo()
 
+	if err := loadMessages(cfg); err != nil {
+		return nil, err
+	}
 	server := web.New(serverLog, cfg, mockDB)
+	grpcServer := rpc.New(grpcLog, cfg, mockDB, server.Events())
 
 	return &App{
-		config: cfg,
-		server: server,
-		db:     mockDB,
-		log:    base,
+		config:    cfg,
+		server:    server,
+		grpc:      grpcServer,
+		db:        mockDB,
+		anomalies: newAnomalyDetector(base, cfg, mockDB),
+		purger:    newPurger(base, cfg, mockDB),
+		webhooks:  newDispatcher(base, cfg, mockDB),
+		log:       base,
 	}, nil
 }
 
+// loadMessages подключает каталоги сообщений об ошибках: вшитые и из LOCALES_DIR
+func loadMessages(cfg *config.Config) error {
+	cat, err := i18n.Load(cfg.LocalesDir, cfg.DefaultLocale)
+	if err != nil {
+		return fmt.Errorf("failed load locales: %w", err)
+	}
+	v1.Messages = cat
+	return nil
+}
+
+func newAnomalyDetector(base *log.Logger, cfg *config.Config, repo domain.Repository) *anomaly.Detector {
+	return &anomaly.Detector{
+		Log:       log.New(base.Writer(), base.Prefix()+"[anomalies] ", base.Flags()),
+		Subs:      repo,
+		Store:     repo,
+		Tenants:   repo,
+		Window:    cfg.AnomalyWindow,
+		Threshold: cfg.AnomalyThreshold,
+		Lookback:  cfg.AnomalyLookback,
+		Interval:  cfg.AnomalyInterval,
+	}
+}
+
+func newPurger(base *log.Logger, cfg *config.Config, repo domain.Repository) *purge.Purger {
+	return &purge.Purger{
+		Log:        log.New(base.Writer(), base.Prefix()+"[purge] ", base.Flags()),
+		Keys:       repo,
+		Tombstones: repo,
+		Trash:      repo,
+		// надгробие переживает токены GET /v1/sync на сутки: удаление, зафиксированное чуть позже
+		// выдачи токена, могло начаться раньше, и его deleted_at старше токена
+		TombstoneTTL:   cfg.SyncTokenTTL + 24*time.Hour,
+		TrashRetention: cfg.TrashRetention,
+		Interval:       cfg.PurgeInterval,
+	}
+}
+
+func newDispatcher(base *log.Logger, cfg *config.Config, repo domain.Repository) *webhook.Dispatcher {
+	return &webhook.Dispatcher{
+		Log:         log.New(base.Writer(), base.Prefix()+"[webhooks] ", base.Flags()),
+		Repo:        repo,
+		Client:      &http.Client{Timeout: cfg.WebhookTimeout},
+		Interval:    cfg.WebhookInterval,
+		MaxAttempts: cfg.WebhookMaxAttempts,
+		Backoff:     cfg.WebhookBackoff,
+	}
+}
+
 func (a *App) Run(ctx context.Context) error {
 	a.log.Println("start application...")
 
 	go a.server.Run()
+	go a.grpc.Run()
+	go a.anomalies.Run(ctx)
+	go a.purger.Run(ctx)
+	go a.webhooks.Run(ctx)
 
 	<-ctx.Done()
 	a.log.Println("stop application...")
@@ -95,6 +176,7 @@ func (a *App) Run(ctx context.Context) error {
 	defer cancel()
 
 	a.server.Close(stopCtx)
+	a.grpc.Close(stopCtx)
 
 	return nil
 }
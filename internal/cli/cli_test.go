@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/config"
+	"github.com/EgorLis/my-subs/internal/domain"
+	mockrepo "github.com/EgorLis/my-subs/internal/infra/database/mock"
+	"github.com/EgorLis/my-subs/internal/transport/web"
+)
+
+// setup поднимает API на mock-репозитории и пишет config.yaml с его адресом
+func setup(t *testing.T) (cfgPath string, userID string) {
+	t.Helper()
+	repo := mockrepo.NewMockRepo()
+	u, _ := repo.AddUser(context.Background(), domain.User{DisplayName: "Ivan", Currency: "RUB"})
+	cfg := &config.Config{UserDeletePolicy: "restrict", IdempotencyTTL: time.Hour,
+		CalendarHorizonMonths: 12, GraphQLMaxDepth: 8, GraphQLMaxComplexity: 5000}
+	srv := httptest.NewServer(web.New(log.New(io.Discard, "", 0), cfg, repo).Handler())
+	t.Cleanup(srv.Close)
+
+	cfgPath = filepath.Join(t.TempDir(), "config.yaml")
+	yaml := "server: " + srv.URL + "\ntenant_id: " + domain.DefaultTenantID + "\n"
+	if err := os.WriteFile(cfgPath, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return cfgPath, u.ID
+}
+
+func run(t *testing.T, cfgPath string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	root := NewRootCmd()
+	root.SetArgs(append([]string{"--config", cfgPath}, args...))
+	root.SetOut(&out)
+	root.SetErr(io.Discard)
+	err := root.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+func TestCommands(t *testing.T) {
+	cfgPath, userID := setup(t)
+
+	for _, s := range []struct{ service, price string }{{"Netflix", "500"}, {"Spotify", "300"}} {
+		out, err := run(t, cfgPath, "add", "--user", userID, "--service", s.service, "--price", s.price,
+			"--start", "01-2025", "--end", "12-2025", "--tag", "media")
+		if err != nil {
+			t.Fatalf("add: %v", err)
+		}
+		if len(strings.TrimSpace(out)) != 36 {
+			t.Fatalf("add: want subscription id, got %q", out)
+		}
+	}
+
+	cases := []struct {
+		name    string
+		args    []string
+		wantErr string
+		check   func(t *testing.T, out string)
+	}{
+		{name: "List_Table", args: []string{"list", "--user", userID, "--sort", "price"},
+			check: func(t *testing.T, out string) {
+				lines := strings.Split(strings.TrimSpace(out), "\n")
+				if len(lines) != 3 || !strings.HasPrefix(lines[0], "ID") || !strings.Contains(lines[1], "Spotify") {
+					t.Fatalf("unexpected table:\n%s", out)
+				}
+			}},
+		{name: "List_JSON", args: []string{"list", "--service", "Netflix", "-o", "json"},
+			check: func(t *testing.T, out string) {
+				var subs []map[string]any
+				if err := json.Unmarshal([]byte(out), &subs); err != nil || len(subs) != 1 || subs[0]["start_date"] != "01-2025" {
+					t.Fatalf("unexpected json: %v %s", err, out)
+				}
+			}},
+		{name: "List_CSV_Limit", args: []string{"list", "--limit", "1", "-o", "csv"},
+			check: func(t *testing.T, out string) {
+				recs, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+				if err != nil || len(recs) != 2 || recs[0][1] != "SERVICE" {
+					t.Fatalf("unexpected csv: %v %q", err, out)
+				}
+			}},
+		{name: "Cost", args: []string{"cost", "--user", userID, "--service", "Netflix", "--from", "01-2025", "--to", "12-2025", "-o", "json"},
+			check: func(t *testing.T, out string) {
+				var v struct {
+					TotalCost int `json:"total_cost"`
+				}
+				if err := json.Unmarshal([]byte(out), &v); err != nil || v.TotalCost != 500 {
+					t.Fatalf("unexpected cost: %v %s", err, out)
+				}
+			}},
+		{name: "Users", args: []string{"users"},
+			check: func(t *testing.T, out string) {
+				if !strings.Contains(out, "Ivan") {
+					t.Fatalf("unexpected users:\n%s", out)
+				}
+			}},
+		{name: "Cost_MissingFlag", args: []string{"cost", "--user", userID}, wantErr: "required flag"},
+		{name: "BadMonth", args: []string{"list", "--from", "2025-01"}, wantErr: "expected MM-YYYY"},
+		{name: "BadOutput", args: []string{"users", "-o", "xml"}, wantErr: "output: must be one of"},
+		{name: "API_Error", args: []string{"get", "not-a-guid"}, wantErr: "400"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := run(t, cfgPath, tc.args...)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("want error with %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tc.check(t, out)
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	cfgPath, userID := setup(t)
+	file := filepath.Join(t.TempDir(), "subs.csv")
+	data := "service_name,price,user_id,start_date,end_date\n" +
+		"Netflix,500," + userID + ",01-2025,12-2025\n" +
+		"Spotify,-1," + userID + ",01-2025,12-2025\n"
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := run(t, cfgPath, "import", file, "--dry-run")
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if !strings.Contains(out, "total: 2, valid: 1, imported: 0, dry run: true") || !strings.Contains(out, "price") {
+		t.Fatalf("unexpected import report:\n%s", out)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("SUBSCTL_TOKEN", "secret")
+
+	cfg, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"), false)
+	if err != nil || cfg.Server != DefaultServer || cfg.Token != "secret" {
+		t.Fatalf("want defaults and env, got %+v %v", cfg, err)
+	}
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml"), true); err == nil {
+		t.Fatal("want error for explicit missing config")
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	cfgPath, _ := setup(t)
+	out, err := run(t, cfgPath, "__complete", "list", "--output", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range outputFormats {
+		if !strings.Contains(out, f) {
+			t.Fatalf("want %q in completions, got:\n%s", f, out)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/viper"
+)
+
+const DefaultServer = "http://localhost:8001"
+
+// Config — настройки subsctl: файл config.yaml, переменные SUBSCTL_* и флаги (в порядке возрастания приоритета)
+type Config struct {
+	Server   string `mapstructure:"server"`
+	TenantID string `mapstructure:"tenant_id"`
+	Token    string `mapstructure:"token"`
+}
+
+// DefaultConfigPath — $XDG_CONFIG_HOME/subsctl/config.yaml (или аналог для ОС)
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "subsctl", "config.yaml")
+}
+
+// LoadConfig читает файл конфигурации. Файла по умолчанию может не быть;
+// явно указанный путь должен существовать.
+func LoadConfig(path string, explicit bool) (Config, error) {
+	v := viper.New()
+	v.SetEnvPrefix("SUBSCTL")
+	for _, k := range []string{"server", "tenant_id", "token"} {
+		_ = v.BindEnv(k)
+	}
+	v.SetDefault("server", DefaultServer)
+
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			var notFound *os.PathError
+			if explicit || !errors.As(err, &notFound) {
+				return Config{}, fmt.Errorf("read config %s: %w", path, err)
+			}
+		}
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return Config{}, fmt.Errorf("decode config: %w", err)
+	}
+	return cfg, nil
+}
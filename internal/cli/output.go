@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/EgorLis/my-subs/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputCSV   = "csv"
+)
+
+var outputFormats = []string{OutputTable, OutputJSON, OutputCSV}
+
+// table — данные для вывода: заголовок и строки для table/csv, value — для json
+type table struct {
+	header []string
+	rows   [][]string
+	value  any
+}
+
+func addOutputFlag(cmd *cobra.Command, dst *string) {
+	cmd.Flags().StringVarP(dst, "output", "o", OutputTable, "формат вывода: "+strings.Join(outputFormats, "|"))
+	_ = cmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+}
+
+func render(w io.Writer, format string, t table) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(t.value)
+	case OutputCSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write(t.header)
+		_ = cw.WriteAll(t.rows)
+		return cw.Error()
+	case OutputTable, "":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+		for _, r := range t.rows {
+			fmt.Fprintln(tw, strings.Join(r, "\t"))
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("output: must be one of %s, got %q", strings.Join(outputFormats, ", "), format)
+}
+
+func subscriptionsTable(subs []client.Subscription) table {
+	t := table{
+		header: []string{"ID", "SERVICE", "PRICE", "USER", "START", "END", "TAGS", "VERSION"},
+		value:  subs,
+	}
+	if subs == nil {
+		t.value = []client.Subscription{}
+	}
+	for _, s := range subs {
+		t.rows = append(t.rows, []string{
+			s.ID, s.ServiceName, strconv.Itoa(s.Price), s.UserID,
+			s.StartDate.String(), s.EndDate.String(), strings.Join(s.Tags, ","), strconv.Itoa(s.Version),
+		})
+	}
+	return t
+}
+
+func usersTable(users []client.User) table {
+	t := table{
+		header: []string{"ID", "NAME", "EMAIL", "CURRENCY", "LOCALE", "TIMEZONE"},
+		value:  users,
+	}
+	if users == nil {
+		t.value = []client.User{}
+	}
+	for _, u := range users {
+		t.rows = append(t.rows, []string{u.ID, u.DisplayName, u.Email, u.Currency, u.Locale, u.Timezone})
+	}
+	return t
+}
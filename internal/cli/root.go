@@ -0,0 +1,70 @@
+// Package cli — команды subsctl, клиента командной строки для HTTP API
+package cli
+
+import (
+	"strings"
+
+	"github.com/EgorLis/my-subs/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+// app — общее состояние команд: настройки и клиент API, создаются перед запуском команды
+type app struct {
+	configPath string
+	server     string
+	tenant     string
+	client     *client.Client
+}
+
+// NewRootCmd собирает дерево команд; вывод команд идёт в cmd.OutOrStdout()
+func NewRootCmd() *cobra.Command {
+	a := &app{}
+	root := &cobra.Command{
+		Use:           "subsctl",
+		Short:         "Клиент командной строки my-subs",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return a.init(cmd)
+		},
+	}
+
+	pf := root.PersistentFlags()
+	pf.StringVar(&a.configPath, "config", "", "файл конфигурации (по умолчанию "+DefaultConfigPath()+")")
+	pf.StringVar(&a.server, "server", "", "адрес API, например "+DefaultServer)
+	pf.StringVar(&a.tenant, "tenant", "", "ID тенанта (X-Tenant-ID)")
+	_ = root.MarkPersistentFlagFilename("config", "yaml", "yml")
+
+	root.AddCommand(
+		newListCmd(a),
+		newGetCmd(a),
+		newAddCmd(a),
+		newDeleteCmd(a),
+		newCostCmd(a),
+		newImportCmd(a),
+		newUsersCmd(a),
+	)
+	return root
+}
+
+func (a *app) init(cmd *cobra.Command) error {
+	path, explicit := a.configPath, a.configPath != ""
+	if !explicit {
+		path = DefaultConfigPath()
+	}
+	cfg, err := LoadConfig(path, explicit)
+	if err != nil {
+		return err
+	}
+	if a.server != "" {
+		cfg.Server = a.server
+	}
+	if a.tenant != "" {
+		cfg.TenantID = a.tenant
+	}
+
+	a.client = client.New(strings.TrimRight(cfg.Server, "/"))
+	a.client.TenantID = cfg.TenantID
+	a.client.Token = cfg.Token
+	return nil
+}
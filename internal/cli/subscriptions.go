@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/EgorLis/my-subs/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+// ymFlag — флаг месяца в формате MM-YYYY
+type ymFlag struct{ v *client.YearMonth }
+
+func (f ymFlag) String() string {
+	if f.v == nil {
+		return ""
+	}
+	return f.v.String()
+}
+
+func (f ymFlag) Set(s string) error {
+	ym, err := client.ParseYearMonth(s)
+	if err != nil {
+		return err
+	}
+	*f.v = ym
+	return nil
+}
+
+func (ymFlag) Type() string { return "MM-YYYY" }
+
+// listPageSize — размер страницы, которой list выбирает все подписки
+const listPageSize = 500
+
+func newListCmd(a *app) *cobra.Command {
+	var (
+		p                  client.ListParams
+		limit              int
+		priceMin, priceMax int
+		output             string
+	)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Список подписок",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("price-min") {
+				p.PriceMin = &priceMin
+			}
+			if cmd.Flags().Changed("price-max") {
+				p.PriceMax = &priceMax
+			}
+
+			var subs []client.Subscription
+			for {
+				p.Limit = listPageSize
+				if limit > 0 {
+					p.Limit = min(listPageSize, limit-len(subs))
+				}
+				page, err := a.client.ListSubscriptions(cmd.Context(), p)
+				if err != nil {
+					return err
+				}
+				subs = append(subs, page.Subscriptions...)
+				if page.NextCursor == "" || limit > 0 && len(subs) >= limit {
+					break
+				}
+				p.Cursor = page.NextCursor
+			}
+			return render(cmd.OutOrStdout(), output, subscriptionsTable(subs))
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&p.UserID, "user", "", "ID пользователя")
+	f.StringVar(&p.ServiceName, "service", "", "название сервиса")
+	f.Var(ymFlag{&p.ActiveAt}, "active-at", "активна в месяце")
+	f.Var(ymFlag{&p.From}, "from", "пересекает период с")
+	f.Var(ymFlag{&p.To}, "to", "пересекает период по")
+	f.IntVar(&priceMin, "price-min", 0, "минимальная цена")
+	f.IntVar(&priceMax, "price-max", 0, "максимальная цена")
+	f.StringVar(&p.Sort, "sort", "", "сортировка, например price,-start_date")
+	f.IntVar(&limit, "limit", 0, "сколько подписок вывести (0 — все)")
+	addOutputFlag(cmd, &output)
+	return cmd
+}
+
+func newGetCmd(a *app) *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "get <id>",
+		Short: "Показать подписку",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sub, err := a.client.GetSubscription(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			t := subscriptionsTable([]client.Subscription{sub})
+			t.value = sub
+			return render(cmd.OutOrStdout(), output, t)
+		},
+	}
+	addOutputFlag(cmd, &output)
+	return cmd
+}
+
+func newAddCmd(a *app) *cobra.Command {
+	var in client.SubscriptionInput
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "Добавить подписку",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := a.client.CreateSubscription(cmd.Context(), in)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), m.ID)
+			return nil
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&in.UserID, "user", "", "ID пользователя")
+	f.StringVar(&in.ServiceName, "service", "", "название сервиса")
+	f.IntVar(&in.Price, "price", 0, "цена в месяц")
+	f.Var(ymFlag{&in.StartDate}, "start", "первый месяц")
+	f.Var(ymFlag{&in.EndDate}, "end", "последний месяц")
+	f.StringVar(&in.Notes, "notes", "", "заметка")
+	f.StringSliceVar(&in.Tags, "tag", nil, "метка (можно несколько)")
+	for _, name := range []string{"user", "service", "price", "start", "end"} {
+		_ = cmd.MarkFlagRequired(name)
+	}
+	return cmd
+}
+
+func newDeleteCmd(a *app) *cobra.Command {
+	var version int
+	cmd := &cobra.Command{
+		Use:   "delete <id>",
+		Short: "Удалить подписку",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.client.DeleteSubscription(cmd.Context(), args[0], version)
+		},
+	}
+	cmd.Flags().IntVar(&version, "version", 0, "ожидаемая версия подписки (0 — без проверки)")
+	return cmd
+}
+
+func newCostCmd(a *app) *cobra.Command {
+	var (
+		p      client.TotalCostParams
+		output string
+	)
+	cmd := &cobra.Command{
+		Use:   "cost",
+		Short: "Суммарная стоимость подписок за период",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			total, err := a.client.TotalCost(cmd.Context(), p)
+			if err != nil {
+				return err
+			}
+			return render(cmd.OutOrStdout(), output, table{
+				header: []string{"USER", "SERVICE", "FROM", "TO", "TOTAL"},
+				rows:   [][]string{{p.UserID, p.ServiceName, p.From.String(), p.To.String(), fmt.Sprint(total)}},
+				value: map[string]any{
+					"user_id": p.UserID, "service_name": p.ServiceName,
+					"from": p.From, "to": p.To, "total_cost": total,
+				},
+			})
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&p.UserID, "user", "", "ID пользователя")
+	f.StringVar(&p.ServiceName, "service", "", "название сервиса")
+	f.Var(ymFlag{&p.From}, "from", "начало периода")
+	f.Var(ymFlag{&p.To}, "to", "конец периода")
+	for _, name := range []string{"user", "service", "from", "to"} {
+		_ = cmd.MarkFlagRequired(name)
+	}
+	addOutputFlag(cmd, &output)
+	return cmd
+}
+
+func newImportCmd(a *app) *cobra.Command {
+	var (
+		opts   client.ImportOptions
+		output string
+	)
+	cmd := &cobra.Command{
+		Use:   "import <file.csv>",
+		Short: "Импорт подписок из CSV",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			res, err := a.client.ImportSubscriptions(cmd.Context(), data, opts)
+			if err != nil {
+				return err
+			}
+			t := table{header: []string{"LINE", "ERROR"}, value: res}
+			for _, e := range res.Errors {
+				t.rows = append(t.rows, []string{fmt.Sprint(e.Line), e.Error})
+			}
+			if output == OutputTable {
+				fmt.Fprintf(cmd.OutOrStdout(), "total: %d, valid: %d, imported: %d, dry run: %t\n",
+					res.Total, res.Valid, res.Imported, res.DryRun)
+				if len(t.rows) == 0 {
+					return nil
+				}
+			}
+			return render(cmd.OutOrStdout(), output, t)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return []string{"csv"}, cobra.ShellCompDirectiveFilterFileExt
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVar(&opts.DryRun, "dry-run", false, "только проверить файл, не записывая")
+	f.StringVar(&opts.Columns, "columns", "", "соответствие полей колонкам: service_name:Сервис,price:Цена")
+	f.StringVar(&opts.DateFormat, "date-format", "", "формат дат, например DD.MM.YYYY")
+	f.StringVar(&opts.Delimiter, "delimiter", "", "разделитель колонок")
+	addOutputFlag(cmd, &output)
+	return cmd
+}
@@ -0,0 +1,21 @@
+package cli
+
+import "github.com/spf13/cobra"
+
+func newUsersCmd(a *app) *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "users",
+		Short: "Список пользователей",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			users, err := a.client.ListUsers(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return render(cmd.OutOrStdout(), output, usersTable(users))
+		},
+	}
+	addOutputFlag(cmd, &output)
+	return cmd
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
@@ -18,6 +19,40 @@ type Config struct {
 	DBName     string `mapstructure:"DB_NAME"`
 	DBScheme   string `mapstructure:"DB_SCHEME"`
 	AppPort    string `mapstructure:"APP_PORT"`
+	GRPCPort   string `mapstructure:"GRPC_PORT"`
+
+	AnomalyInterval  time.Duration `mapstructure:"ANOMALY_INTERVAL"`
+	AnomalyWindow    int           `mapstructure:"ANOMALY_WINDOW"`
+	AnomalyThreshold float64       `mapstructure:"ANOMALY_THRESHOLD"`
+	AnomalyLookback  int           `mapstructure:"ANOMALY_LOOKBACK"`
+
+	UserDeletePolicy string `mapstructure:"USER_DELETE_POLICY"`
+
+	IdempotencyTTL time.Duration `mapstructure:"IDEMPOTENCY_TTL"`
+	PurgeInterval  time.Duration `mapstructure:"PURGE_INTERVAL"`
+
+	CalendarAlarmDays     int `mapstructure:"CALENDAR_ALARM_DAYS"`
+	CalendarHorizonMonths int `mapstructure:"CALENDAR_HORIZON_MONTHS"`
+
+	GraphQLMaxDepth      int `mapstructure:"GRAPHQL_MAX_DEPTH"`
+	GraphQLMaxComplexity int `mapstructure:"GRAPHQL_MAX_COMPLEXITY"`
+
+	DefaultLocale string `mapstructure:"DEFAULT_LOCALE"`
+	LocalesDir    string `mapstructure:"LOCALES_DIR"`
+
+	WebhookInterval    time.Duration `mapstructure:"WEBHOOK_INTERVAL"`
+	WebhookMaxAttempts int           `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	WebhookBackoff     time.Duration `mapstructure:"WEBHOOK_BACKOFF"`
+	WebhookTimeout     time.Duration `mapstructure:"WEBHOOK_TIMEOUT"`
+
+	StreamReplaySize int           `mapstructure:"STREAM_REPLAY_SIZE"`
+	StreamHeartbeat  time.Duration `mapstructure:"STREAM_HEARTBEAT"`
+
+	ExportWriteGrace time.Duration `mapstructure:"EXPORT_WRITE_GRACE"`
+
+	SyncTokenTTL time.Duration `mapstructure:"SYNC_TOKEN_TTL"`
+
+	TrashRetention time.Duration `mapstructure:"TRASH_RETENTION"`
 }
 
 // String реализует интерфейс Stringer
@@ -30,6 +65,29 @@ func (c *Config) String() string {
 	sb.WriteString(fmt.Sprintf("  DBName: %s\n", c.DBName))
 	sb.WriteString(fmt.Sprintf("  DBScheme : %s\n", c.DBScheme))
 	sb.WriteString(fmt.Sprintf("  AppPort: %s\n", c.AppPort))
+	sb.WriteString(fmt.Sprintf("  GRPCPort: %s\n", c.GRPCPort))
+	sb.WriteString(fmt.Sprintf("  AnomalyInterval: %s\n", c.AnomalyInterval))
+	sb.WriteString(fmt.Sprintf("  AnomalyWindow: %d\n", c.AnomalyWindow))
+	sb.WriteString(fmt.Sprintf("  AnomalyThreshold: %.2f\n", c.AnomalyThreshold))
+	sb.WriteString(fmt.Sprintf("  AnomalyLookback: %d\n", c.AnomalyLookback))
+	sb.WriteString(fmt.Sprintf("  UserDeletePolicy: %s\n", c.UserDeletePolicy))
+	sb.WriteString(fmt.Sprintf("  IdempotencyTTL: %s\n", c.IdempotencyTTL))
+	sb.WriteString(fmt.Sprintf("  PurgeInterval: %s\n", c.PurgeInterval))
+	sb.WriteString(fmt.Sprintf("  CalendarAlarmDays: %d\n", c.CalendarAlarmDays))
+	sb.WriteString(fmt.Sprintf("  CalendarHorizonMonths: %d\n", c.CalendarHorizonMonths))
+	sb.WriteString(fmt.Sprintf("  GraphQLMaxDepth: %d\n", c.GraphQLMaxDepth))
+	sb.WriteString(fmt.Sprintf("  GraphQLMaxComplexity: %d\n", c.GraphQLMaxComplexity))
+	sb.WriteString(fmt.Sprintf("  DefaultLocale: %s\n", c.DefaultLocale))
+	sb.WriteString(fmt.Sprintf("  LocalesDir: %s\n", c.LocalesDir))
+	sb.WriteString(fmt.Sprintf("  WebhookInterval: %s\n", c.WebhookInterval))
+	sb.WriteString(fmt.Sprintf("  WebhookMaxAttempts: %d\n", c.WebhookMaxAttempts))
+	sb.WriteString(fmt.Sprintf("  WebhookBackoff: %s\n", c.WebhookBackoff))
+	sb.WriteString(fmt.Sprintf("  WebhookTimeout: %s\n", c.WebhookTimeout))
+	sb.WriteString(fmt.Sprintf("  StreamReplaySize: %d\n", c.StreamReplaySize))
+	sb.WriteString(fmt.Sprintf("  StreamHeartbeat: %s\n", c.StreamHeartbeat))
+	sb.WriteString(fmt.Sprintf("  ExportWriteGrace: %s\n", c.ExportWriteGrace))
+	sb.WriteString(fmt.Sprintf("  SyncTokenTTL: %s\n", c.SyncTokenTTL))
+	sb.WriteString(fmt.Sprintf("  TrashRetention: %s\n", c.TrashRetention))
 
 	// Пароль обычно маскируют в логах
 	if c.DBPassword != "" {
@@ -55,18 +113,105 @@ func LoadFromEnv() (*Config, error) {
 
 	// регистрируем интересующие ключи окружения
 	keys := []string{
-		"APP_ENV", "APP_PORT",
+		"APP_ENV", "APP_PORT", "GRPC_PORT",
 		"DB_HOST", "DB_PORT", "DB_USER", "DB_PASSWORD", "DB_NAME", "DB_SCHEME",
+		"ANOMALY_INTERVAL", "ANOMALY_WINDOW", "ANOMALY_THRESHOLD", "ANOMALY_LOOKBACK",
+		"USER_DELETE_POLICY",
+		"IDEMPOTENCY_TTL", "PURGE_INTERVAL",
+		"CALENDAR_ALARM_DAYS", "CALENDAR_HORIZON_MONTHS",
+		"GRAPHQL_MAX_DEPTH", "GRAPHQL_MAX_COMPLEXITY",
+		"DEFAULT_LOCALE", "LOCALES_DIR",
+		"WEBHOOK_INTERVAL", "WEBHOOK_MAX_ATTEMPTS", "WEBHOOK_BACKOFF", "WEBHOOK_TIMEOUT",
+		"STREAM_REPLAY_SIZE", "STREAM_HEARTBEAT",
+		"EXPORT_WRITE_GRACE",
+		"SYNC_TOKEN_TTL",
+		"TRASH_RETENTION",
 	}
 
 	for _, k := range keys {
 		_ = v.BindEnv(k)
 	}
 
+	// значения по умолчанию для необязательных настроек
+	v.SetDefault("GRPC_PORT", ":9090")
+	v.SetDefault("ANOMALY_INTERVAL", "1h")
+	v.SetDefault("ANOMALY_WINDOW", 3)
+	v.SetDefault("ANOMALY_THRESHOLD", 1.5)
+	v.SetDefault("ANOMALY_LOOKBACK", 12)
+	v.SetDefault("USER_DELETE_POLICY", "restrict")
+	v.SetDefault("IDEMPOTENCY_TTL", "24h")
+	v.SetDefault("PURGE_INTERVAL", "1h")
+	v.SetDefault("CALENDAR_ALARM_DAYS", 3)
+	v.SetDefault("CALENDAR_HORIZON_MONTHS", 12)
+	v.SetDefault("GRAPHQL_MAX_DEPTH", 8)
+	v.SetDefault("GRAPHQL_MAX_COMPLEXITY", 5000)
+	v.SetDefault("DEFAULT_LOCALE", "en")
+	v.SetDefault("WEBHOOK_INTERVAL", "5s")
+	v.SetDefault("WEBHOOK_MAX_ATTEMPTS", 8)
+	v.SetDefault("WEBHOOK_BACKOFF", "30s")
+	v.SetDefault("WEBHOOK_TIMEOUT", "10s")
+	v.SetDefault("STREAM_REPLAY_SIZE", 1000)
+	v.SetDefault("STREAM_HEARTBEAT", "15s")
+	v.SetDefault("EXPORT_WRITE_GRACE", "1m")
+	v.SetDefault("SYNC_TOKEN_TTL", "720h")
+	v.SetDefault("TRASH_RETENTION", "720h")
+
 	var cfg Config
 	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("unable to decode config: %w", err)
 	}
+
+	if cfg.AnomalyInterval <= 0 {
+		return nil, fmt.Errorf("ANOMALY_INTERVAL: must be > 0, got %s", cfg.AnomalyInterval)
+	}
+	if cfg.AnomalyWindow < 1 {
+		return nil, fmt.Errorf("ANOMALY_WINDOW: must be >= 1, got %d", cfg.AnomalyWindow)
+	}
+	// при пороге <= 1 аномалией считался бы любой месяц не дешевле среднего
+	if cfg.AnomalyThreshold <= 1 {
+		return nil, fmt.Errorf("ANOMALY_THRESHOLD: must be > 1, got %g", cfg.AnomalyThreshold)
+	}
+	if cfg.AnomalyLookback < 1 {
+		return nil, fmt.Errorf("ANOMALY_LOOKBACK: must be >= 1, got %d", cfg.AnomalyLookback)
+	}
+	switch cfg.UserDeletePolicy {
+	case "restrict", "cascade":
+	default:
+		return nil, fmt.Errorf("USER_DELETE_POLICY: must be restrict or cascade, got %q", cfg.UserDeletePolicy)
+	}
+	if cfg.CalendarAlarmDays < 0 {
+		return nil, fmt.Errorf("CALENDAR_ALARM_DAYS: must be >= 0, got %d", cfg.CalendarAlarmDays)
+	}
+	if cfg.CalendarHorizonMonths < 1 {
+		return nil, fmt.Errorf("CALENDAR_HORIZON_MONTHS: must be >= 1, got %d", cfg.CalendarHorizonMonths)
+	}
+	if cfg.GraphQLMaxDepth < 1 {
+		return nil, fmt.Errorf("GRAPHQL_MAX_DEPTH: must be >= 1, got %d", cfg.GraphQLMaxDepth)
+	}
+	if cfg.GraphQLMaxComplexity < 1 {
+		return nil, fmt.Errorf("GRAPHQL_MAX_COMPLEXITY: must be >= 1, got %d", cfg.GraphQLMaxComplexity)
+	}
+	if cfg.WebhookMaxAttempts < 1 {
+		return nil, fmt.Errorf("WEBHOOK_MAX_ATTEMPTS: must be >= 1, got %d", cfg.WebhookMaxAttempts)
+	}
+	if cfg.WebhookInterval <= 0 || cfg.WebhookBackoff <= 0 || cfg.WebhookTimeout <= 0 {
+		return nil, fmt.Errorf("WEBHOOK_INTERVAL, WEBHOOK_BACKOFF, WEBHOOK_TIMEOUT: must be > 0")
+	}
+	if cfg.StreamReplaySize < 1 {
+		return nil, fmt.Errorf("STREAM_REPLAY_SIZE: must be >= 1, got %d", cfg.StreamReplaySize)
+	}
+	if cfg.StreamHeartbeat <= 0 {
+		return nil, fmt.Errorf("STREAM_HEARTBEAT: must be > 0, got %s", cfg.StreamHeartbeat)
+	}
+	if cfg.ExportWriteGrace <= 0 {
+		return nil, fmt.Errorf("EXPORT_WRITE_GRACE: must be > 0, got %s", cfg.ExportWriteGrace)
+	}
+	if cfg.SyncTokenTTL <= 0 {
+		return nil, fmt.Errorf("SYNC_TOKEN_TTL: must be > 0, got %s", cfg.SyncTokenTTL)
+	}
+	if cfg.TrashRetention <= 0 {
+		return nil, fmt.Errorf("TRASH_RETENTION: must be > 0, got %s", cfg.TrashRetention)
+	}
 	return &cfg, nil
 }
 
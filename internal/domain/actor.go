@@ -0,0 +1,30 @@
+package domain
+
+import "context"
+
+type actorCtxKey struct{}
+
+// WithActor кладёт в контекст того, кто выполняет запрос (заполняется слоем аутентификации)
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorCtxKey{}, actor)
+}
+
+// ActorFromCtx возвращает автора изменений или пустую строку, если он неизвестен
+func ActorFromCtx(ctx context.Context) string {
+	v, _ := ctx.Value(actorCtxKey{}).(string)
+	return v
+}
+
+type requestIDCtxKey struct{}
+
+// WithRequestID кладёт в контекст идентификатор запроса (X-Request-ID или метаданные gRPC);
+// по нему запись журнала изменений связывается со строками лога
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDCtxKey{}, id)
+}
+
+// RequestIDFromCtx возвращает идентификатор запроса или пустую строку
+func RequestIDFromCtx(ctx context.Context) string {
+	v, _ := ctx.Value(requestIDCtxKey{}).(string)
+	return v
+}
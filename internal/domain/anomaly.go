@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+// AnomalyKind — по какому разрезу найдена аномалия
+type AnomalyKind string
+
+const (
+	// AnomalyServicePrice — скачок средней цены сервиса за месяц
+	AnomalyServicePrice AnomalyKind = "service_price"
+	// AnomalyUserSpend — всплеск месячных трат пользователя
+	AnomalyUserSpend AnomalyKind = "user_spend"
+)
+
+type Severity string
+
+const (
+	SeverityLow    Severity = "low"
+	SeverityMedium Severity = "medium"
+	SeverityHigh   Severity = "high"
+)
+
+// Anomaly — месяц, в котором значение заметно превысило скользящее среднее предыдущих месяцев
+type Anomaly struct {
+	ID         string
+	TenantID   string
+	Kind       AnomalyKind
+	Subject    string // service_name или user_id, в зависимости от Kind
+	Month      time.Time
+	Amount     int
+	Baseline   float64
+	Ratio      float64
+	Severity   Severity
+	DetectedAt time.Time
+}
+
+// AnomalyFilter — необязательные фильтры выборки; пустые поля не применяются
+type AnomalyFilter struct {
+	Kind     AnomalyKind
+	Subject  string
+	Severity Severity
+	From     time.Time
+	To       time.Time
+}
@@ -0,0 +1,13 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type AnomalyRepository interface {
+	// ReplaceAnomalies заменяет аномалии тенанта за месяцы [from, to] найденными в этом проходе:
+	// то, что больше не подтверждается (например, после правки цены), удаляется. Выполняется атомарно.
+	ReplaceAnomalies(ctx context.Context, from, to time.Time, anomalies []Anomaly) error
+	ListAnomalies(ctx context.Context, f AnomalyFilter) ([]Anomaly, error)
+}
@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Действия в журнале изменений подписок
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete" // перенос в корзину
+	AuditRestore = "restore"
+)
+
+// AuditActions — допустимые значения AuditEntry.Action
+var AuditActions = []string{AuditCreate, AuditUpdate, AuditDelete, AuditRestore}
+
+// Поля подписки, изменения которых показывает журнал; служебные (version, updated_at...) не учитываются
+const (
+	AuditFieldServiceName = "service_name"
+	AuditFieldPrice       = "price"
+	AuditFieldUserID      = "user_id"
+	AuditFieldStartDate   = "start_date"
+	AuditFieldEndDate     = "end_date"
+	AuditFieldNotes       = "notes"
+	AuditFieldTags        = "tags"
+	AuditFieldDeletedAt   = "deleted_at"
+)
+
+// AuditFields — поля, по которым можно искать изменения (AuditQuery.Field)
+var AuditFields = []string{AuditFieldServiceName, AuditFieldPrice, AuditFieldUserID, AuditFieldStartDate,
+	AuditFieldEndDate, AuditFieldNotes, AuditFieldTags, AuditFieldDeletedAt}
+
+// AuditEntry — запись журнала: одно изменение подписки. Before — состояние до изменения (nil при создании),
+// After — после него; у удалённой подписки это состояние в корзине, с DeletedAt.
+type AuditEntry struct {
+	ID             int64 // растёт с каждой записью
+	TenantID       string
+	SubscriptionID string
+	UserID         string // владелец подписки после изменения
+	Action         string
+	Actor          string // пусто, если автор неизвестен (см. ActorFromCtx)
+	RequestID      string // пусто, если изменение сделано не из запроса (см. RequestIDFromCtx)
+	Before         *Subscription
+	After          *Subscription
+	CreatedAt      time.Time
+}
+
+// Changed — поля из AuditFields, значения которых отличаются в Before и After.
+// При создании это все поля, кроме deleted_at.
+func (e AuditEntry) Changed() []string {
+	out := make([]string, 0, len(AuditFields))
+	for _, f := range AuditFields {
+		if auditValue(e.Before, f) != auditValue(e.After, f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// auditValue — значение поля для сравнения; nil, если снимка нет или поле не заполнено
+// (так же сравнивает отсутствующие ключи и Postgres, см. AuditQuery.Field)
+func auditValue(s *Subscription, field string) any {
+	if s == nil {
+		return nil
+	}
+	switch field {
+	case AuditFieldServiceName:
+		return s.ServiceName
+	case AuditFieldPrice:
+		return s.Price
+	case AuditFieldUserID:
+		return s.UserID
+	case AuditFieldStartDate:
+		return s.StartDate.UTC()
+	case AuditFieldEndDate:
+		return s.EndDate.UTC()
+	case AuditFieldNotes:
+		return s.Notes
+	case AuditFieldTags:
+		return strings.Join(s.Tags, "\x00") // nil и пустой список одинаковы
+	case AuditFieldDeletedAt:
+		if s.DeletedAt.IsZero() {
+			return nil
+		}
+		return s.DeletedAt.UTC()
+	}
+	return nil
+}
+
+// AuditQuery — выборка журнала тенанта из контекста, новые записи первыми; пустые поля не применяются.
+type AuditQuery struct {
+	SubscriptionID string
+	UserID         string
+	Actor          string
+	Action         string
+	RequestID      string
+	Field          string    // только изменения этого поля из AuditFields
+	From, To       time.Time // время изменения, [From, To)
+	BeforeID       int64     // курсор: записи с ID меньше BeforeID; 0 — с самой новой
+	Limit          int
+}
+
+// Matches сообщает, подходит ли запись под фильтры запроса (кроме BeforeID и Limit)
+func (q AuditQuery) Matches(e AuditEntry) bool {
+	switch {
+	case q.SubscriptionID != "" && e.SubscriptionID != q.SubscriptionID,
+		q.UserID != "" && e.UserID != q.UserID,
+		q.Actor != "" && e.Actor != q.Actor,
+		q.Action != "" && e.Action != q.Action,
+		q.RequestID != "" && e.RequestID != q.RequestID,
+		!q.From.IsZero() && e.CreatedAt.Before(q.From),
+		!q.To.IsZero() && !e.CreatedAt.Before(q.To):
+		return false
+	}
+	return q.Field == "" || auditValue(e.Before, q.Field) != auditValue(e.After, q.Field)
+}
+
+type AuditPage struct {
+	Entries []AuditEntry
+	HasMore bool
+}
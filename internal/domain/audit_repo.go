@@ -0,0 +1,9 @@
+package domain
+
+import "context"
+
+// AuditRepository читает журнал изменений подписок. Записи в него добавляет SubscriptionRepository
+// в той же транзакции, что и само изменение, поэтому журнал не расходится с данными.
+type AuditRepository interface {
+	ListAudit(ctx context.Context, q AuditQuery) (AuditPage, error)
+}
@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+// Charge — ежемесячное списание по подписке: подписка активна в месяце m, если start_date <= m <= end_date
+type Charge struct {
+	SubscriptionID string
+	UserID         string
+	ServiceName    string
+	Month          time.Time // первое число месяца, UTC
+	Amount         int
+}
+
+// Charges возвращает списания подписки в месяцах [from, to]; нулевые from/to не ограничивают период
+func (s Subscription) Charges(from, to time.Time) []Charge {
+	start, end := monthStart(s.StartDate), monthStart(s.EndDate)
+	if !from.IsZero() && monthStart(from).After(start) {
+		start = monthStart(from)
+	}
+	if !to.IsZero() && monthStart(to).Before(end) {
+		end = monthStart(to)
+	}
+	var out []Charge
+	for m := start; !m.After(end); m = m.AddDate(0, 1, 0) {
+		out = append(out, Charge{SubscriptionID: s.ID, UserID: s.UserID, ServiceName: s.ServiceName, Month: m, Amount: s.Price})
+	}
+	return out
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
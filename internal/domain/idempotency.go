@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRecord — сохранённый ответ на запрос с Idempotency-Key.
+// Status == 0, пока исходный запрос ещё выполняется.
+type IdempotencyRecord struct {
+	Key         string
+	Fingerprint string // хеш метода, пути и тела запроса
+	Status      int
+	Header      map[string]string
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// IdempotencyRepository хранит ответы по ключам идемпотентности; ключи уникальны в пределах тенанта из контекста
+type IdempotencyRepository interface {
+	// ReserveIdempotencyKey атомарно занимает ключ. Если ключ уже занят и не истёк,
+	// возвращает существующую запись и reserved=false.
+	ReserveIdempotencyKey(ctx context.Context, rec IdempotencyRecord) (existing IdempotencyRecord, reserved bool, err error)
+	// CompleteIdempotencyKey сохраняет ответ для занятого ключа
+	CompleteIdempotencyKey(ctx context.Context, rec IdempotencyRecord) error
+	// ReleaseIdempotencyKey освобождает ключ, чтобы запрос можно было выполнить заново
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+	// PurgeIdempotencyKeys удаляет истёкшие записи всех тенантов
+	PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int, error)
+}
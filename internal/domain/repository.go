@@ -0,0 +1,12 @@
+package domain
+
+// Repository — всё хранилище приложения, его реализуют и Postgres, и mock
+type Repository interface {
+	SubscriptionRepository
+	AnomalyRepository
+	TenantRepository
+	UserRepository
+	IdempotencyRepository
+	WebhookRepository
+	AuditRepository
+}
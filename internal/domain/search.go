@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SearchThreshold — минимальная похожесть слова запроса на текст подписки,
+// то же значение задаётся pg_trgm.word_similarity_threshold в PGRepo
+const SearchThreshold = 0.5
+
+// SearchQuery — нечёткий поиск по названию, заметкам и меткам подписок
+type SearchQuery struct {
+	Text   string
+	UserID string // пусто — по всем пользователям тенанта
+	Limit  int
+}
+
+// SearchHit — найденная подписка; Score от 0 до 1, больше — ближе к запросу
+type SearchHit struct {
+	Sub   Subscription
+	Score float64
+}
+
+// SearchWords разбивает текст на слова в нижнем регистре (буквы и цифры)
+func SearchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// trigrams — триграммы слова как в pg_trgm: слово дополняется двумя пробелами слева и одним справа
+func trigrams(word string) map[string]struct{} {
+	r := []rune("  " + word + " ")
+	out := make(map[string]struct{}, len(r))
+	for i := 0; i+3 <= len(r); i++ {
+		out[string(r[i:i+3])] = struct{}{}
+	}
+	return out
+}
+
+// WordSimilarity — какая доля триграмм term есть в word (упрощённый word_similarity из pg_trgm):
+// опечатки и незаконченное слово дают высокую похожесть, посторонние слова — низкую
+func WordSimilarity(term, word string) float64 {
+	t := trigrams(term)
+	if len(t) == 0 {
+		return 0
+	}
+	w := trigrams(word)
+	common := 0
+	for g := range t {
+		if _, ok := w[g]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(t))
+}
+
+// SearchText — текст подписки, по которому идёт поиск
+func (s Subscription) SearchText() string {
+	return s.ServiceName + " " + s.Notes + " " + strings.Join(s.Tags, " ")
+}
+
+// SearchScore оценивает подписку для запроса: каждое слово запроса сравнивается с самым похожим словом подписки,
+// результат — среднее; 0, если хотя бы одно слово запроса не набрало SearchThreshold
+func (s Subscription) SearchScore(query string) float64 {
+	terms := SearchWords(query)
+	words := SearchWords(s.SearchText())
+	if len(terms) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, t := range terms {
+		best := 0.0
+		for _, w := range words {
+			best = max(best, WordSimilarity(t, w))
+		}
+		if best < SearchThreshold {
+			return 0
+		}
+		total += best
+	}
+	return total / float64(len(terms))
+}
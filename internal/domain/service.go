@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+)
+
+// ServiceSuggestion — название сервиса, которое уже встречается в подписках тенанта.
+// Названия сравниваются без учёта регистра; Name — самое частое написание.
+type ServiceSuggestion struct {
+	Name          string
+	Subscriptions int // сколько подписок на сервис
+	TypicalPrice  int // медиана цены (нижняя, как percentile_disc(0.5))
+}
+
+// ServiceKey — ключ сервиса для подсказок: название в нижнем регистре без крайних пробелов
+func ServiceKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// NewServiceSuggestion считает подсказку по написаниям названия и ценам подписок одного сервиса.
+// При равной частоте написаний выбирается первое по алфавиту.
+func NewServiceSuggestion(names []string, prices []int) ServiceSuggestion {
+	counts := make(map[string]int, len(names))
+	s := ServiceSuggestion{Subscriptions: len(names)}
+	for _, n := range names {
+		counts[n]++
+		if c := counts[n]; c > counts[s.Name] || (c == counts[s.Name] && n < s.Name) {
+			s.Name = n
+		}
+	}
+	if len(prices) > 0 {
+		sorted := append([]int(nil), prices...)
+		sort.Ints(sorted)
+		s.TypicalPrice = sorted[(len(sorted)-1)/2]
+	}
+	return s
+}
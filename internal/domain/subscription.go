@@ -4,9 +4,18 @@ import "time"
 
 type Subscription struct {
 	ID          string
+	TenantID    string
 	ServiceName string
 	Price       int
 	UserID      string
 	StartDate   time.Time
 	EndDate     time.Time
+	Notes       string   // свободный комментарий пользователя
+	Tags        []string // метки в нижнем регистре, без повторов
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CreatedBy   string // пусто, если автор неизвестен (см. ActorFromCtx)
+	UpdatedBy   string
+	Version     int       // растёт на 1 при каждом изменении, используется для оптимистичной блокировки
+	DeletedAt   time.Time // когда подписка попала в корзину; ноль — не удалена
 }
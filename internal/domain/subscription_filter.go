@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Поля, по которым можно сортировать список подписок
+const (
+	SortServiceName = "service_name"
+	SortPrice       = "price"
+	SortStartDate   = "start_date"
+	SortEndDate     = "end_date"
+	SortCreatedAt   = "created_at"
+	SortUpdatedAt   = "updated_at"
+	SortDeletedAt   = "deleted_at" // только для корзины: у остальных подписок поле пустое
+)
+
+var SortableFields = []string{SortServiceName, SortPrice, SortStartDate, SortEndDate, SortCreatedAt, SortUpdatedAt}
+
+// TrashSortableFields — поля сортировки корзины (SubscriptionFilter.Trashed)
+var TrashSortableFields = []string{SortServiceName, SortPrice, SortStartDate, SortEndDate, SortCreatedAt, SortUpdatedAt, SortDeletedAt}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// DefaultSort — порядок списка, если sort не задан
+var DefaultSort = []SortField{{Field: SortStartDate}}
+
+// DefaultTrashSort — корзина по умолчанию начинается с недавно удалённых
+var DefaultTrashSort = []SortField{{Field: SortDeletedAt, Desc: true}}
+
+// SubscriptionFilter — параметры выборки списка подписок; нулевые значения не применяются.
+// Порядок всегда дополняется id по возрастанию, поэтому он однозначен и пригоден для keyset-пагинации.
+type SubscriptionFilter struct {
+	UserID      string
+	ServiceName string
+	ActiveAt    time.Time // подписка активна в месяце: start_date <= ActiveAt <= end_date
+	PriceMin    *int
+	PriceMax    *int
+	From        time.Time // подписка пересекает период [From, To], как в TotalCost
+	To          time.Time
+
+	Trashed bool // только подписки в корзине; без него удалённые подписки не выбираются
+
+	Sort  []SortField
+	Limit int           // 0 — без ограничения
+	After *Subscription // keyset: вернуть подписки строго после этой
+}
+
+// SubscriptionPage — страница списка; HasMore — есть ли подписки после последней в Subs
+type SubscriptionPage struct {
+	Subs    []Subscription
+	HasMore bool
+}
+
+// Matches проверяет фильтры (без сортировки и пагинации)
+func (f SubscriptionFilter) Matches(s Subscription) bool {
+	if f.UserID != "" && s.UserID != f.UserID {
+		return false
+	}
+	if f.ServiceName != "" && s.ServiceName != f.ServiceName {
+		return false
+	}
+	if !f.ActiveAt.IsZero() && (s.StartDate.After(f.ActiveAt) || s.EndDate.Before(f.ActiveAt)) {
+		return false
+	}
+	if f.PriceMin != nil && s.Price < *f.PriceMin {
+		return false
+	}
+	if f.PriceMax != nil && s.Price > *f.PriceMax {
+		return false
+	}
+	if !f.To.IsZero() && s.StartDate.After(f.To) {
+		return false
+	}
+	if !f.From.IsZero() && s.EndDate.Before(f.From) {
+		return false
+	}
+	return true
+}
+
+// OrderBy возвращает порядок сортировки с учётом значения по умолчанию
+func (f SubscriptionFilter) OrderBy() []SortField {
+	if len(f.Sort) == 0 {
+		if f.Trashed {
+			return DefaultTrashSort
+		}
+		return DefaultSort
+	}
+	return f.Sort
+}
+
+// CompareSubs сравнивает подписки в порядке sort, при равенстве — по id
+func CompareSubs(a, b Subscription, sort []SortField) int {
+	for _, sf := range sort {
+		c := compareField(a, b, sf.Field)
+		if sf.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+func compareField(a, b Subscription, field string) int {
+	switch field {
+	case SortServiceName:
+		return strings.Compare(a.ServiceName, b.ServiceName)
+	case SortPrice:
+		return a.Price - b.Price
+	case SortStartDate:
+		return a.StartDate.Compare(b.StartDate)
+	case SortEndDate:
+		return a.EndDate.Compare(b.EndDate)
+	case SortCreatedAt:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case SortUpdatedAt:
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	case SortDeletedAt:
+		return a.DeletedAt.Compare(b.DeletedAt)
+	}
+	return 0
+}
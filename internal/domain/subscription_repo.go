@@ -6,15 +6,69 @@ import (
 	"time"
 )
 
-var ErrNotFound = errors.New("subscription not found")
+var (
+	ErrNotFound        = errors.New("subscription not found")
+	ErrVersionMismatch = errors.New("subscription version mismatch")
+)
+
+// AnyVersion — изменение без проверки версии
+const AnyVersion = 0
 
+// SubscriptionRepository — все операции ограничены тенантом из контекста (см. WithTenant).
+// Подписки в корзине (см. DeleteSub) не видны ни одному методу, кроме ListSubs с Trashed, RestoreSub и PurgeTrash:
+// для остальных их нет (ErrNotFound).
+// AddSub, UpdateSub и PatchSub возвращают ErrUserNotFound, если пользователя нет в этом тенанте.
+// UpdateSub, PatchSub и DeleteSub условные: если version != AnyVersion и текущая версия подписки другая,
+// изменение не выполняется и возвращается ErrVersionMismatch.
 type SubscriptionRepository interface {
 	Ping(ctx context.Context) error
 	Close()
 	AddSub(ctx context.Context, sub Subscription) (Subscription, error)
-	UpdateSub(ctx context.Context, sub Subscription) error
-	DeleteSub(ctx context.Context, id string) error
+	// ImportSubs добавляет подписки одной пачкой: либо все, либо ни одной.
+	// Возвращает добавленные подписки; ErrUserNotFound, если хотя бы одного пользователя нет в тенанте.
+	ImportSubs(ctx context.Context, subs []Subscription) ([]Subscription, error)
+	UpdateSub(ctx context.Context, sub Subscription, version int) (Subscription, error)
+	// PatchSub атомарно читает подписку, передаёт её в apply и сохраняет результат.
+	// Ошибка apply отменяет изменение и возвращается как есть.
+	PatchSub(ctx context.Context, id string, version int, apply func(cur Subscription) (Subscription, error)) (Subscription, error)
+	// DeleteSub переносит подписку в корзину (DeletedAt) и возвращает её последнее состояние.
+	// Для SyncSubs подписка в корзине — надгробие.
+	DeleteSub(ctx context.Context, id string, version int) (Subscription, error)
+	// RestoreSub возвращает подписку из корзины с новой версией; ErrNotFound, если в корзине её нет
+	RestoreSub(ctx context.Context, id string) (Subscription, error)
+	// PurgeTrash окончательно удаляет подписки, попавшие в корзину до before, во всех тенантах;
+	// возвращает число удалённых
+	PurgeTrash(ctx context.Context, before time.Time) (int, error)
 	GetSub(ctx context.Context, id string) (Subscription, error)
-	ListSubs(ctx context.Context) ([]Subscription, error)
+	ListSubs(ctx context.Context, f SubscriptionFilter) (SubscriptionPage, error)
+	// StreamSubs передаёт в fn подписки по фильтру и в его порядке, не собирая их в память; Limit и After не применяются.
+	// Ошибка fn или отмена ctx прекращает выборку и возвращается как есть.
+	StreamSubs(ctx context.Context, f SubscriptionFilter, fn func(Subscription) error) error
+	// SearchSubs ищет подписки по названию, заметкам и меткам с учётом опечаток; результат отсортирован по Score
+	SearchSubs(ctx context.Context, q SearchQuery) ([]SearchHit, error)
+	// SuggestServices возвращает сервисы, название которых начинается с prefix (без учёта регистра),
+	// по убыванию числа подписок; не больше limit, если limit > 0
+	SuggestServices(ctx context.Context, prefix string, limit int) ([]ServiceSuggestion, error)
 	TotalCost(ctx context.Context, serviceName, userID string, start, end time.Time) (int, error)
+	// SyncSubs возвращает подписки, созданные или изменённые после q.After, и надгробия удалённых, в порядке журнала.
+	// Изменение попадает в журнал только после фиксации всех транзакций, начатых до него, поэтому
+	// клиент, продолжающий с SyncPage.Next, не пропускает изменения медленных транзакций.
+	SyncSubs(ctx context.Context, q SyncQuery) (SyncPage, error)
+	// GetTombstone возвращает надгробие удалённой подписки (в том числе лежащей в корзине) или ErrNotFound
+	GetTombstone(ctx context.Context, id string) (Tombstone, error)
+	// PurgeTombstones удаляет надгробия, оставленные до before; возвращает число удалённых
+	PurgeTombstones(ctx context.Context, before time.Time) (int, error)
+	// InTx выполняет fn в одной транзакции: если fn вернул ошибку, откатываются все его изменения.
+	// Ошибка отдельной операции tx не прерывает транзакцию — fn сам решает, продолжать ли.
+	InTx(ctx context.Context, fn func(tx SubscriptionTx) error) error
+}
+
+// SubscriptionTx — чтение и изменение подписок внутри транзакции InTx; семантика как у одноимённых методов репозитория.
+// Чтения видят изменения, уже сделанные в этой транзакции.
+type SubscriptionTx interface {
+	GetSub(ctx context.Context, id string) (Subscription, error)
+	GetTombstone(ctx context.Context, id string) (Tombstone, error)
+	AddSub(ctx context.Context, sub Subscription) (Subscription, error)
+	UpdateSub(ctx context.Context, sub Subscription, version int) (Subscription, error)
+	DeleteSub(ctx context.Context, id string, version int) (Subscription, error)
 }
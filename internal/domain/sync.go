@@ -0,0 +1,59 @@
+package domain
+
+import "time"
+
+// Tombstone — след удалённой подписки: по нему офлайн-клиенты узнают об удалении (см. SyncSubs)
+type Tombstone struct {
+	ID        string
+	TenantID  string
+	UserID    string
+	DeletedAt time.Time
+}
+
+// SyncCursor — позиция в журнале изменений подписок: SyncSubs выдаёт изменения строго после (Seq, ID).
+// Нулевой курсор — начало журнала.
+type SyncCursor struct {
+	Seq uint64
+	ID  string
+}
+
+// Before сообщает, что c раньше o в порядке журнала
+func (c SyncCursor) Before(o SyncCursor) bool {
+	if c.Seq != o.Seq {
+		return c.Seq < o.Seq
+	}
+	return c.ID < o.ID
+}
+
+// SyncChange — последнее состояние подписки или, если Deleted != nil, её надгробие.
+// Seq растёт с каждым изменением; несколько изменений одной транзакции могут иметь одинаковый Seq.
+type SyncChange struct {
+	Seq     uint64
+	Sub     Subscription
+	Deleted *Tombstone
+}
+
+// Cursor — позиция изменения в журнале
+func (c SyncChange) Cursor() SyncCursor {
+	if c.Deleted != nil {
+		return SyncCursor{Seq: c.Seq, ID: c.Deleted.ID}
+	}
+	return SyncCursor{Seq: c.Seq, ID: c.Sub.ID}
+}
+
+// SyncQuery — выборка изменений после After. Tombstones=false — только живые подписки:
+// так выгружается первая копия, клиенту без данных надгробия не нужны.
+type SyncQuery struct {
+	After      SyncCursor
+	UserID     string // пусто — все пользователи тенанта
+	Tombstones bool
+	Limit      int
+}
+
+// SyncPage — изменения в порядке журнала. Next — позиция для следующего запроса:
+// при HasMore это последнее выданное изменение, иначе граница, до которой журнал уже прочитан целиком.
+type SyncPage struct {
+	Changes []SyncChange
+	Next    SyncCursor
+	HasMore bool
+}
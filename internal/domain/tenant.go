@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultTenantID — организация, к которой относятся данные, созданные до появления тенантов,
+// и запросы без явного указания тенанта
+const DefaultTenantID = "00000000-0000-0000-0000-000000000001"
+
+var ErrTenantNotFound = errors.New("tenant not found")
+
+type Tenant struct {
+	ID        string
+	Name      string
+	CreatedAt time.Time
+}
+
+type tenantCtxKey struct{}
+
+// WithTenant кладёт тенант в контекст; все запросы репозиториев ограничиваются им
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantCtxKey{}, tenantID)
+}
+
+// TenantFromCtx возвращает тенант из контекста и признак того, что он был задан явно
+func TenantFromCtx(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(tenantCtxKey{}).(string)
+	return v, ok && v != ""
+}
+
+// TenantOrDefault возвращает тенант из контекста или DefaultTenantID
+func TenantOrDefault(ctx context.Context) string {
+	if v, ok := TenantFromCtx(ctx); ok {
+		return v
+	}
+	return DefaultTenantID
+}
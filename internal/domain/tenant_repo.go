@@ -0,0 +1,9 @@
+package domain
+
+import "context"
+
+type TenantRepository interface {
+	AddTenant(ctx context.Context, t Tenant) (Tenant, error)
+	GetTenant(ctx context.Context, id string) (Tenant, error)
+	ListTenants(ctx context.Context) ([]Tenant, error)
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrUserNotFound         = errors.New("user not found")
+	ErrUserHasSubscriptions = errors.New("user has subscriptions")
+	ErrEmailTaken           = errors.New("email already taken")
+)
+
+// Значения настроек профиля по умолчанию
+const (
+	DefaultCurrency = "RUB"
+	DefaultLocale   = "ru"
+	DefaultTimezone = "UTC"
+)
+
+type User struct {
+	ID          string
+	TenantID    string
+	DisplayName string
+	Email       string
+	Currency    string // ISO 4217, например RUB
+	Locale      string // BCP 47, например ru или en-US
+	Timezone    string // IANA, например Europe/Moscow
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// UserDeletePolicy — что делать с подписками при удалении пользователя
+type UserDeletePolicy string
+
+const (
+	// UserDeleteRestrict — запретить удаление, пока у пользователя есть подписки
+	UserDeleteRestrict UserDeletePolicy = "restrict"
+	// UserDeleteCascade — удалить пользователя вместе с подписками: каждая удаляется как DELETE /v1/subscriptions/{id}
+	// (запись в журнале, событие subscription.deleted), корзина пользователя очищается
+	UserDeleteCascade UserDeletePolicy = "cascade"
+)
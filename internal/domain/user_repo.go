@@ -0,0 +1,22 @@
+package domain
+
+import "context"
+
+// UserRepository — как и подписки, пользователи ограничены тенантом из контекста
+type UserRepository interface {
+	AddUser(ctx context.Context, u User) (User, error)
+	UpdateUser(ctx context.Context, u User) (User, error)
+	// DeleteUser удаляет пользователя; при cascade=false и наличии подписок возвращает ErrUserHasSubscriptions.
+	// При cascade=true подписки удаляются как DeleteSub (с записью в журнале) и возвращаются;
+	// в корзине они не остаются: восстановить подписку без владельца нельзя
+	DeleteUser(ctx context.Context, id string, cascade bool) ([]Subscription, error)
+	GetUser(ctx context.Context, id string) (User, error)
+	ListUsers(ctx context.Context) ([]User, error)
+	// UsersByIDs возвращает пользователей тенанта с указанными id одним запросом; отсутствующие пропускаются
+	UsersByIDs(ctx context.Context, ids []string) ([]User, error)
+	// SetCalendarToken сохраняет хэш секретного токена ленты календаря; пустой хэш закрывает ленту
+	SetCalendarToken(ctx context.Context, id, tokenHash string) error
+	// UserByCalendarToken ищет пользователя по хэшу токена во всех тенантах:
+	// клиент календаря не передаёт заголовков, тенант берётся из найденного пользователя
+	UserByCalendarToken(ctx context.Context, tokenHash string) (User, error)
+}
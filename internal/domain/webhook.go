@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// События об изменениях подписок, на которые можно подписать вебхук
+const (
+	EventSubscriptionCreated  = "subscription.created"
+	EventSubscriptionUpdated  = "subscription.updated"
+	EventSubscriptionDeleted  = "subscription.deleted"
+	EventSubscriptionRestored = "subscription.restored" // возвращена из корзины
+)
+
+// WebhookEvents — все события, доступные для подписки
+var WebhookEvents = []string{EventSubscriptionCreated, EventSubscriptionUpdated, EventSubscriptionDeleted, EventSubscriptionRestored}
+
+// Webhook — адрес, на который POST-ом отправляются события тенанта
+type Webhook struct {
+	ID        string
+	TenantID  string
+	URL       string
+	Secret    string   // ключ HMAC-SHA256 для подписи доставок
+	Events    []string // на какие события подписан
+	CreatedAt time.Time
+}
+
+// Subscribed — подписан ли вебхук на событие
+func (wh Webhook) Subscribed(event string) bool {
+	for _, e := range wh.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvent — событие для очереди доставок: его ID и тело, которое получат вебхуки
+type WebhookEvent struct {
+	ID      string
+	Payload []byte
+}
+
+// DeliveryStatus — состояние доставки события
+type DeliveryStatus string
+
+const (
+	// DeliveryPending — ждёт отправки или повтора после NextAttemptAt
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliverySucceeded — получатель ответил 2xx
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryFailed — попытки исчерпаны
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// WebhookDelivery — одна доставка события на вебхук вместе с итогом последней попытки
+type WebhookDelivery struct {
+	ID             string
+	TenantID       string
+	WebhookID      string
+	EventID        string // одинаков у всех доставок события, в том числе повторных
+	Event          string
+	Payload        []byte // тело запроса, подписывается как есть
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	LastStatusCode int    // 0 — ответа не было
+	LastError      string // ошибка соединения или краткое описание ответа
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// WebhookRepository хранит вебхуки тенанта из контекста и очередь их доставок
+type WebhookRepository interface {
+	AddWebhook(ctx context.Context, wh Webhook) (Webhook, error)
+	GetWebhook(ctx context.Context, id string) (Webhook, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	// DeleteWebhook удаляет вебхук вместе с журналом его доставок
+	DeleteWebhook(ctx context.Context, id string) error
+	// EnqueueDeliveries одним запросом ставит события типа event в очередь всем вебхукам тенанта,
+	// подписанным на него; возвращает число созданных доставок
+	EnqueueDeliveries(ctx context.Context, event string, events []WebhookEvent) (int, error)
+	// ListDeliveries — журнал доставок вебхука, новые первыми
+	ListDeliveries(ctx context.Context, webhookID string, limit int) ([]WebhookDelivery, error)
+	GetDelivery(ctx context.Context, webhookID, deliveryID string) (WebhookDelivery, error)
+	// RedeliverWebhook ставит в очередь новую доставку с тем же событием и телом
+	RedeliverWebhook(ctx context.Context, webhookID, deliveryID string) (WebhookDelivery, error)
+
+	// ClaimDeliveries забирает до limit доставок всех тенантов, у которых подошло время попытки,
+	// и откладывает их на lease, чтобы другой экземпляр не отправил их параллельно
+	ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]WebhookDelivery, error)
+	// UpdateDelivery сохраняет итог попытки: Status, Attempts, NextAttemptAt, LastStatusCode, LastError
+	UpdateDelivery(ctx context.Context, d WebhookDelivery) error
+}
@@ -0,0 +1,182 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage — язык сообщений, если клиент не прислал Accept-Language или прислал неизвестный
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var builtin embed.FS
+
+// Args — параметры сообщения: {max} в тексте заменяется на Args["max"]
+type Args map[string]any
+
+// Catalog — тексты сообщений по языкам; язык — имя файла каталога без .json (en, ru, pt-br)
+type Catalog struct {
+	def  string
+	msgs map[string]map[string]string
+}
+
+// Builtin — каталоги, вшитые в бинарник (locales/*.json)
+func Builtin() *Catalog {
+	c, err := load(builtin, "locales", DefaultLanguage)
+	if err != nil {
+		panic(err) // вшитые каталоги проверяются тестами
+	}
+	return c
+}
+
+// Load читает вшитые каталоги и дополняет их файлами <язык>.json из dir: новый язык добавляется
+// файлом, ключи существующего переопределяются. dir может быть пустым. def — язык по умолчанию.
+func Load(dir, def string) (*Catalog, error) {
+	c, err := load(builtin, "locales", DefaultLanguage)
+	if err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		extra, err := load(os.DirFS(dir), ".", DefaultLanguage)
+		if err != nil {
+			return nil, err
+		}
+		for lang, msgs := range extra.msgs {
+			c.add(lang, msgs)
+		}
+	}
+	def = normalize(def)
+	if _, ok := c.msgs[def]; !ok {
+		return nil, fmt.Errorf("default language %q: no catalog, have %s", def, strings.Join(c.Languages(), ", "))
+	}
+	c.def = def
+	return c, nil
+}
+
+func load(fsys fs.FS, dir, def string) (*Catalog, error) {
+	files, err := fs.Glob(fsys, filepath.ToSlash(filepath.Join(dir, "*.json")))
+	if err != nil {
+		return nil, err
+	}
+	c := &Catalog{def: def, msgs: map[string]map[string]string{}}
+	for _, name := range files {
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		var msgs map[string]string
+		if err := json.Unmarshal(b, &msgs); err != nil {
+			return nil, fmt.Errorf("catalog %s: %w", name, err)
+		}
+		c.add(strings.TrimSuffix(filepath.Base(name), ".json"), msgs)
+	}
+	return c, nil
+}
+
+func (c *Catalog) add(lang string, msgs map[string]string) {
+	lang = normalize(lang)
+	if c.msgs[lang] == nil {
+		c.msgs[lang] = make(map[string]string, len(msgs))
+	}
+	for k, v := range msgs {
+		c.msgs[lang][k] = v
+	}
+}
+
+// Default — язык по умолчанию
+func (c *Catalog) Default() string {
+	return c.def
+}
+
+// Languages — языки, для которых есть каталог, по алфавиту
+func (c *Catalog) Languages() []string {
+	out := make([]string, 0, len(c.msgs))
+	for lang := range c.msgs {
+		out = append(out, lang)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// Keys — ключи каталога языка по алфавиту
+func (c *Catalog) Keys(lang string) []string {
+	msgs := c.msgs[normalize(lang)]
+	out := make([]string, 0, len(msgs))
+	for k := range msgs {
+		out = append(out, k)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// Match выбирает язык по заголовку Accept-Language (с учётом q): сначала точное совпадение,
+// затем основной язык (ru-RU → ru); если ничего не подошло — язык по умолчанию
+func (c *Catalog) Match(acceptLanguage string) string {
+	type pref struct {
+		tag string
+		q   float64
+	}
+	var prefs []pref
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = f
+		}
+		if tag = normalize(tag); tag != "" && q > 0 {
+			prefs = append(prefs, pref{tag, q})
+		}
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+
+	for _, p := range prefs {
+		if p.tag == "*" {
+			return c.def
+		}
+		if _, ok := c.msgs[p.tag]; ok {
+			return p.tag
+		}
+		if base, _, ok := strings.Cut(p.tag, "-"); ok {
+			if _, ok := c.msgs[base]; ok {
+				return base
+			}
+		}
+	}
+	return c.def
+}
+
+// Text — сообщение key на языке lang с подставленными args. Если перевода нет,
+// берётся язык по умолчанию, если нет и его — возвращается сам ключ.
+func (c *Catalog) Text(lang, key string, args Args) string {
+	msg, ok := c.msgs[normalize(lang)][key]
+	if !ok {
+		if msg, ok = c.msgs[c.def][key]; !ok {
+			return key
+		}
+	}
+	if len(args) == 0 || !strings.Contains(msg, "{") {
+		return msg
+	}
+	pairs := make([]string, 0, 2*len(args))
+	for name, v := range args {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(v))
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+// normalize приводит тег к виду "pt-br": нижний регистр, дефис вместо подчёркивания
+func normalize(tag string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(tag)), "_", "-")
+}
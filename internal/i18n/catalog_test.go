@@ -0,0 +1,116 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+var placeholderRe = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// все вшитые каталоги переводят одни и те же ключи с теми же параметрами
+func TestBuiltinCatalogsComplete(t *testing.T) {
+	c := Builtin()
+	base := c.Keys(DefaultLanguage)
+	if len(base) == 0 {
+		t.Fatal("default catalog is empty")
+	}
+	for _, lang := range c.Languages() {
+		keys := c.Keys(lang)
+		if !slices.Equal(keys, base) {
+			t.Fatalf("%s: keys differ from %s:\n%v\n%v", lang, DefaultLanguage, keys, base)
+		}
+		for _, k := range keys {
+			want := placeholderRe.FindAllString(c.msgs[DefaultLanguage][k], -1)
+			got := placeholderRe.FindAllString(c.msgs[lang][k], -1)
+			slices.Sort(want)
+			slices.Sort(got)
+			if !slices.Equal(got, want) {
+				t.Errorf("%s %s: placeholders %v, want %v", lang, k, got, want)
+			}
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	c := Builtin()
+	cases := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"ru", "ru"},
+		{"ru-RU,ru;q=0.9,en;q=0.8", "ru"},
+		{"de-DE, en;q=0.5", "en"},
+		{"en;q=0.3, ru;q=0.7", "ru"},
+		{"fr, *;q=0.1", "en"},
+		{"RU_ru", "ru"},
+		{"ru;q=0, en", "en"},
+		{"ru;q=abc, en", "en"},
+	}
+	for _, tc := range cases {
+		if got := c.Match(tc.header); got != tc.want {
+			t.Errorf("Match(%q) = %q, want %q", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	c := Builtin()
+	if got := c.Text("ru", "field.between", Args{"min": 1, "max": 50}); got != "должно быть от 1 до 50" {
+		t.Fatalf("ru: %q", got)
+	}
+	if got := c.Text("en", "field.between", Args{"min": 1, "max": 50}); got != "must be between 1 and 50" {
+		t.Fatalf("en: %q", got)
+	}
+	// нет языка — язык по умолчанию, нет ключа — сам ключ
+	if got := c.Text("de", "field.required", nil); got != "required" {
+		t.Fatalf("fallback: %q", got)
+	}
+	if got := c.Text("ru", "field.nope", nil); got != "field.nope" {
+		t.Fatalf("missing key: %q", got)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("de.json", `{"field.required": "Pflichtfeld"}`)
+	write("ru.json", `{"field.required": "заполните поле"}`)
+
+	c, err := Load(dir, "ru")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Default() != "ru" || !slices.Contains(c.Languages(), "de") {
+		t.Fatalf("default %q, languages %v", c.Default(), c.Languages())
+	}
+	if got := c.Text(c.Match("de-AT"), "field.required", nil); got != "Pflichtfeld" {
+		t.Fatalf("de: %q", got)
+	}
+	// переопределён один ключ, остальные — из вшитого каталога
+	if got := c.Text("ru", "field.required", nil); got != "заполните поле" {
+		t.Fatalf("ru override: %q", got)
+	}
+	if got := c.Text("ru", "field.integer", nil); got != "должно быть целым числом" {
+		t.Fatalf("ru builtin: %q", got)
+	}
+	// неполный каталог добирает ключи из языка по умолчанию
+	if got := c.Text("de", "field.integer", nil); got != "должно быть целым числом" {
+		t.Fatalf("de fallback: %q", got)
+	}
+
+	if _, err := Load(dir, "fr"); err == nil {
+		t.Fatal("unknown default language should fail")
+	}
+	write("broken.json", `{`)
+	if _, err := Load(dir, "en"); err == nil {
+		t.Fatal("broken catalog should fail")
+	}
+}
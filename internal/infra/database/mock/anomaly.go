@@ -0,0 +1,77 @@
+package mock
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/google/uuid"
+)
+
+func anomalyKey(a domain.Anomaly) string {
+	return a.TenantID + "|" + string(a.Kind) + "|" + a.Subject + "|" + a.Month.Format("2006-01")
+}
+
+func (r *Repo) ReplaceAnomalies(ctx context.Context, from, to time.Time, anomalies []domain.Anomaly) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenantID := domain.TenantOrDefault(ctx)
+	prev := make(map[string]domain.Anomaly)
+	for key, a := range r.anomalies {
+		if a.TenantID == tenantID && !a.Month.Before(from) && !a.Month.After(to) {
+			prev[key] = a
+			delete(r.anomalies, key)
+		}
+	}
+	for _, a := range anomalies {
+		a.TenantID = tenantID
+		key := anomalyKey(a)
+		if p, ok := prev[key]; ok {
+			a.ID = p.ID
+		} else if p, ok := r.anomalies[key]; ok {
+			a.ID = p.ID
+		} else {
+			a.ID = uuid.NewString()
+		}
+		r.anomalies[key] = a
+	}
+	return nil
+}
+
+func (r *Repo) ListAnomalies(ctx context.Context, f domain.AnomalyFilter) ([]domain.Anomaly, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenantID := domain.TenantOrDefault(ctx)
+	out := make([]domain.Anomaly, 0)
+	for _, a := range r.anomalies {
+		if a.TenantID != tenantID {
+			continue
+		}
+		if f.Kind != "" && a.Kind != f.Kind {
+			continue
+		}
+		if f.Subject != "" && a.Subject != f.Subject {
+			continue
+		}
+		if f.Severity != "" && a.Severity != f.Severity {
+			continue
+		}
+		if !f.From.IsZero() && a.Month.Before(f.From) {
+			continue
+		}
+		if !f.To.IsZero() && a.Month.After(f.To) {
+			continue
+		}
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Month.Equal(out[j].Month) {
+			return out[i].Month.After(out[j].Month)
+		}
+		return out[i].Ratio > out[j].Ratio
+	})
+	return out, nil
+}
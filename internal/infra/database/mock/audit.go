@@ -0,0 +1,53 @@
+package mock
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
+
+// record добавляет запись в журнал; вызывается под r.mu вместе с самим изменением
+func (r *Repo) record(ctx context.Context, action string, before, after *domain.Subscription) {
+	e := domain.AuditEntry{
+		ID:        int64(len(r.audit)) + 1,
+		Action:    action,
+		Actor:     domain.ActorFromCtx(ctx),
+		RequestID: domain.RequestIDFromCtx(ctx),
+		Before:    snapshot(before),
+		After:     snapshot(after),
+		CreatedAt: time.Now(),
+	}
+	e.TenantID, e.SubscriptionID, e.UserID = after.TenantID, after.ID, after.UserID
+	r.audit = append(r.audit, e)
+}
+
+// snapshot копирует подписку, чтобы запись журнала не менялась вместе с ней
+func snapshot(s *domain.Subscription) *domain.Subscription {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.Tags = slices.Clone(s.Tags)
+	return &c
+}
+
+func (r *Repo) ListAudit(ctx context.Context, q domain.AuditQuery) (domain.AuditPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenantID := domain.TenantOrDefault(ctx)
+	out := make([]domain.AuditEntry, 0)
+	for i := len(r.audit) - 1; i >= 0; i-- {
+		e := r.audit[i]
+		if e.TenantID != tenantID || (q.BeforeID > 0 && e.ID >= q.BeforeID) || !q.Matches(e) {
+			continue
+		}
+		if q.Limit > 0 && len(out) == q.Limit {
+			return domain.AuditPage{Entries: out, HasMore: true}, nil
+		}
+		out = append(out, e)
+	}
+	return domain.AuditPage{Entries: out}, nil
+}
@@ -0,0 +1,61 @@
+package mock
+
+import (
+	"context"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
+
+func idempotencyKey(ctx context.Context, key string) string {
+	return domain.TenantOrDefault(ctx) + "/" + key
+}
+
+func (r *Repo) ReserveIdempotencyKey(ctx context.Context, rec domain.IdempotencyRecord) (domain.IdempotencyRecord, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := idempotencyKey(ctx, rec.Key)
+	if cur, ok := r.idempotency[k]; ok && cur.ExpiresAt.After(time.Now()) {
+		return cur, false, nil
+	}
+	rec.Status, rec.Header, rec.Body = 0, nil, nil
+	r.idempotency[k] = rec
+	return domain.IdempotencyRecord{}, true, nil
+}
+
+func (r *Repo) CompleteIdempotencyKey(ctx context.Context, rec domain.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k := idempotencyKey(ctx, rec.Key)
+	cur, ok := r.idempotency[k]
+	if !ok {
+		return nil
+	}
+	cur.Status, cur.Header, cur.Body = rec.Status, rec.Header, rec.Body
+	r.idempotency[k] = cur
+	return nil
+}
+
+func (r *Repo) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.idempotency, idempotencyKey(ctx, key))
+	return nil
+}
+
+func (r *Repo) PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for k, rec := range r.idempotency {
+		if !rec.ExpiresAt.After(now) {
+			delete(r.idempotency, k)
+			n++
+		}
+	}
+	return n, nil
+}
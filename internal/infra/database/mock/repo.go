@@ -2,6 +2,7 @@ package mock
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -10,13 +11,40 @@ import (
 )
 
 type Repo struct {
-	mu    sync.RWMutex
-	items map[string]domain.Subscription
+	mu          sync.RWMutex
+	txMu        sync.Mutex // сериализует InTx и изменения подписок вне его, см. InTx
+	items       map[string]domain.Subscription
+	trash       map[string]domain.Subscription // удалённые подписки, см. DeleteSub
+	services    map[string]*serviceTrie        // tenant_id -> названия сервисов, см. putSub
+	anomalies   map[string]domain.Anomaly
+	tenants     map[string]domain.Tenant
+	users       map[string]domain.User
+	calendar    map[string]string                   // хэш токена календаря -> id пользователя
+	idempotency map[string]domain.IdempotencyRecord // ключ: tenant_id/key
+	webhooks    map[string]domain.Webhook
+	deliveries  map[string]domain.WebhookDelivery
+	seq         uint64            // номер последнего изменения подписок, см. SyncSubs
+	changes     map[string]uint64 // id подписки -> номер её последнего изменения
+	tombstones  map[string]tombstone
+	audit       []domain.AuditEntry // журнал изменений по возрастанию ID, см. record
 }
 
 func NewMockRepo() *Repo {
 	return &Repo{
-		items: make(map[string]domain.Subscription),
+		items:       make(map[string]domain.Subscription),
+		trash:       make(map[string]domain.Subscription),
+		services:    make(map[string]*serviceTrie),
+		anomalies:   make(map[string]domain.Anomaly),
+		users:       make(map[string]domain.User),
+		calendar:    make(map[string]string),
+		idempotency: make(map[string]domain.IdempotencyRecord),
+		webhooks:    make(map[string]domain.Webhook),
+		deliveries:  make(map[string]domain.WebhookDelivery),
+		changes:     make(map[string]uint64),
+		tombstones:  make(map[string]tombstone),
+		tenants: map[string]domain.Tenant{
+			domain.DefaultTenantID: {ID: domain.DefaultTenantID, Name: "default", CreatedAt: time.Now()},
+		},
 	}
 }
 
@@ -30,37 +58,179 @@ func (r *Repo) Close() {
 }
 
 func (r *Repo) AddSub(ctx context.Context, sub domain.Subscription) (domain.Subscription, error) {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	sub.ID = uuid.NewString()
+	sub.TenantID = domain.TenantOrDefault(ctx)
+	if _, ok := r.tenants[sub.TenantID]; !ok {
+		return domain.Subscription{}, domain.ErrTenantNotFound
+	}
+	if !r.hasUser(sub.TenantID, sub.UserID) {
+		return domain.Subscription{}, domain.ErrUserNotFound
+	}
 	if sub.StartDate.IsZero() {
 		sub.StartDate = time.Now()
 	}
-	r.items[sub.ID] = sub
+	sub.CreatedAt = time.Now()
+	sub.UpdatedAt = sub.CreatedAt
+	sub.CreatedBy = domain.ActorFromCtx(ctx)
+	sub.UpdatedBy = sub.CreatedBy
+	sub.Version = 1
+	r.putSub(sub)
+	r.record(ctx, domain.AuditCreate, nil, &sub)
 	return sub, nil
 }
 
-func (r *Repo) UpdateSub(ctx context.Context, sub domain.Subscription) error {
+func (r *Repo) ImportSubs(ctx context.Context, subs []domain.Subscription) ([]domain.Subscription, error) {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, ok := r.items[sub.ID]; !ok {
-		return domain.ErrNotFound
+	tenantID := domain.TenantOrDefault(ctx)
+	if _, ok := r.tenants[tenantID]; !ok {
+		return nil, domain.ErrTenantNotFound
 	}
-	r.items[sub.ID] = sub
-	return nil
+	// сначала проверяем всю пачку, чтобы не оставить половину импорта
+	for _, sub := range subs {
+		if !r.hasUser(tenantID, sub.UserID) {
+			return nil, domain.ErrUserNotFound
+		}
+	}
+	now, actor := time.Now(), domain.ActorFromCtx(ctx)
+	out := make([]domain.Subscription, 0, len(subs))
+	for _, sub := range subs {
+		sub.ID, sub.TenantID = uuid.NewString(), tenantID
+		sub.CreatedAt, sub.UpdatedAt = now, now
+		sub.CreatedBy, sub.UpdatedBy = actor, actor
+		sub.Version = 1
+		r.putSub(sub)
+		r.record(ctx, domain.AuditCreate, nil, &sub)
+		out = append(out, sub)
+	}
+	return out, nil
 }
 
-func (r *Repo) DeleteSub(ctx context.Context, id string) error {
+func (r *Repo) UpdateSub(ctx context.Context, sub domain.Subscription, version int) (domain.Subscription, error) {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, ok := r.items[id]; !ok {
-		return domain.ErrNotFound
+	prev, err := r.current(ctx, sub.ID, version)
+	if err != nil {
+		return domain.Subscription{}, err
 	}
-	delete(r.items, id)
-	return nil
+	return r.replace(ctx, prev, sub)
+}
+
+func (r *Repo) PatchSub(ctx context.Context, id string, version int, apply func(cur domain.Subscription) (domain.Subscription, error)) (domain.Subscription, error) {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prev, err := r.current(ctx, id, version)
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+	next, err := apply(prev)
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+	return r.replace(ctx, prev, next)
+}
+
+func (r *Repo) DeleteSub(ctx context.Context, id string, version int) (domain.Subscription, error) {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.deleteSub(ctx, id, version)
+}
+
+// deleteSub переносит подписку в корзину; вызывается под r.mu
+func (r *Repo) deleteSub(ctx context.Context, id string, version int) (domain.Subscription, error) {
+	sub, err := r.current(ctx, id, version)
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+	r.dropSub(id)
+	prev := sub
+	sub.DeletedAt = r.tombstones[id].DeletedAt
+	r.trash[id] = sub
+	r.record(ctx, domain.AuditDelete, &prev, &sub)
+	return sub, nil
+}
+
+func (r *Repo) RestoreSub(ctx context.Context, id string) (domain.Subscription, error) {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sub, ok := r.trash[id]
+	if !ok || sub.TenantID != domain.TenantOrDefault(ctx) {
+		return domain.Subscription{}, domain.ErrNotFound
+	}
+	delete(r.trash, id)
+	delete(r.tombstones, id)
+	prev := sub
+	sub.DeletedAt = time.Time{}
+	sub.UpdatedAt = time.Now()
+	sub.UpdatedBy = domain.ActorFromCtx(ctx)
+	sub.Version++
+	r.putSub(sub)
+	r.record(ctx, domain.AuditRestore, &prev, &sub)
+	return sub, nil
+}
+
+// PurgeTrash — надгробия остаются: их удаляет PurgeTombstones
+func (r *Repo) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for id, s := range r.trash {
+		if s.DeletedAt.Before(before) {
+			delete(r.trash, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// current возвращает подписку тенанта из контекста и проверяет ожидаемую версию; вызывается под r.mu
+func (r *Repo) current(ctx context.Context, id string, version int) (domain.Subscription, error) {
+	sub, ok := r.items[id]
+	if !ok || sub.TenantID != domain.TenantOrDefault(ctx) {
+		return domain.Subscription{}, domain.ErrNotFound
+	}
+	if version != domain.AnyVersion && sub.Version != version {
+		return domain.Subscription{}, domain.ErrVersionMismatch
+	}
+	return sub, nil
+}
+
+// replace сохраняет next вместо prev, сохраняя неизменяемые поля; вызывается под r.mu
+func (r *Repo) replace(ctx context.Context, prev, next domain.Subscription) (domain.Subscription, error) {
+	if !r.hasUser(prev.TenantID, next.UserID) {
+		return domain.Subscription{}, domain.ErrUserNotFound
+	}
+	next.ID, next.TenantID = prev.ID, prev.TenantID
+	next.CreatedAt, next.CreatedBy = prev.CreatedAt, prev.CreatedBy
+	next.UpdatedAt = time.Now()
+	next.UpdatedBy = domain.ActorFromCtx(ctx)
+	next.Version = prev.Version + 1
+	r.putSub(next)
+	r.record(ctx, domain.AuditUpdate, &prev, &next)
+	return next, nil
 }
 
 func (r *Repo) GetSub(ctx context.Context, id string) (domain.Subscription, error) {
@@ -68,20 +238,83 @@ func (r *Repo) GetSub(ctx context.Context, id string) (domain.Subscription, erro
 	defer r.mu.RUnlock()
 
 	sub, ok := r.items[id]
-	if !ok {
+	if !ok || sub.TenantID != domain.TenantOrDefault(ctx) {
 		return domain.Subscription{}, domain.ErrNotFound
 	}
 	return sub, nil
 }
 
-func (r *Repo) ListSubs(ctx context.Context) ([]domain.Subscription, error) {
+func (r *Repo) ListSubs(ctx context.Context, f domain.SubscriptionFilter) (domain.SubscriptionPage, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	out := make([]domain.Subscription, 0, len(r.items))
-	for _, v := range r.items {
+	tenantID := domain.TenantOrDefault(ctx)
+	order := f.OrderBy()
+	src := r.items
+	if f.Trashed {
+		src = r.trash
+	}
+	out := make([]domain.Subscription, 0)
+	for _, v := range src {
+		if v.TenantID != tenantID || !f.Matches(v) {
+			continue
+		}
+		if f.After != nil && domain.CompareSubs(v, *f.After, order) <= 0 {
+			continue
+		}
 		out = append(out, v)
 	}
+	sort.Slice(out, func(i, j int) bool { return domain.CompareSubs(out[i], out[j], order) < 0 })
+
+	page := domain.SubscriptionPage{Subs: out}
+	if f.Limit > 0 && len(out) > f.Limit {
+		page.Subs, page.HasMore = out[:f.Limit], true
+	}
+	return page, nil
+}
+
+func (r *Repo) StreamSubs(ctx context.Context, f domain.SubscriptionFilter, fn func(domain.Subscription) error) error {
+	// fn может работать долго (запись клиенту), поэтому вызываем его на снимке, без блокировки
+	f.Limit, f.After = 0, nil
+	page, err := r.ListSubs(ctx, f)
+	if err != nil {
+		return err
+	}
+	for _, s := range page.Subs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchSubs — перебор с той же оценкой похожести, что у pg_trgm (см. domain.WordSimilarity)
+func (r *Repo) SearchSubs(ctx context.Context, q domain.SearchQuery) ([]domain.SearchHit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenantID := domain.TenantOrDefault(ctx)
+	out := make([]domain.SearchHit, 0)
+	for _, v := range r.items {
+		if v.TenantID != tenantID || (q.UserID != "" && v.UserID != q.UserID) {
+			continue
+		}
+		if score := v.SearchScore(q.Text); score > 0 {
+			out = append(out, domain.SearchHit{Sub: v, Score: score})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Sub.ID < out[j].Sub.ID
+	})
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[:q.Limit]
+	}
 	return out, nil
 }
 
@@ -89,9 +322,10 @@ func (r *Repo) TotalCost(ctx context.Context, serviceName, userID string, start,
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	tenantID := domain.TenantOrDefault(ctx)
 	totalCost := 0
 	for _, v := range r.items {
-		if v.UserID == userID && v.ServiceName == serviceName &&
+		if v.TenantID == tenantID && v.UserID == userID && v.ServiceName == serviceName &&
 			(v.StartDate.After(start) || time.Time.Equal(v.StartDate, start)) &&
 			(end.After(v.EndDate) || time.Time.Equal(v.EndDate, end)) {
 
@@ -0,0 +1,147 @@
+package mock
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
+
+// serviceTrie — префиксное дерево ключей сервисов (domain.ServiceKey) одного тенанта;
+// в узле ключа хранятся id подписок на этот сервис
+type serviceTrie struct {
+	children map[rune]*serviceTrie
+	subs     map[string]struct{}
+}
+
+func (t *serviceTrie) insert(key, id string) {
+	n := t
+	for _, c := range key {
+		if n.children == nil {
+			n.children = make(map[rune]*serviceTrie)
+		}
+		next, ok := n.children[c]
+		if !ok {
+			next = &serviceTrie{}
+			n.children[c] = next
+		}
+		n = next
+	}
+	if n.subs == nil {
+		n.subs = make(map[string]struct{})
+	}
+	n.subs[id] = struct{}{}
+}
+
+// remove убирает id и пустые ветки; возвращает true, если узел t стал пустым
+func (t *serviceTrie) remove(key []rune, id string) bool {
+	if len(key) == 0 {
+		delete(t.subs, id)
+	} else if next, ok := t.children[key[0]]; ok && next.remove(key[1:], id) {
+		delete(t.children, key[0])
+	}
+	return len(t.subs) == 0 && len(t.children) == 0
+}
+
+// find возвращает узел prefix или nil
+func (t *serviceTrie) find(prefix string) *serviceTrie {
+	n := t
+	for _, c := range prefix {
+		if n = n.children[c]; n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// walk обходит узлы с подписками в поддереве
+func (t *serviceTrie) walk(fn func(subs map[string]struct{})) {
+	if len(t.subs) > 0 {
+		fn(t.subs)
+	}
+	for _, next := range t.children {
+		next.walk(fn)
+	}
+}
+
+// indexSub и unindexSub поддерживают r.services вместе с r.items; вызываются под r.mu
+func (r *Repo) indexSub(s domain.Subscription) {
+	t, ok := r.services[s.TenantID]
+	if !ok {
+		t = &serviceTrie{}
+		r.services[s.TenantID] = t
+	}
+	t.insert(domain.ServiceKey(s.ServiceName), s.ID)
+}
+
+func (r *Repo) unindexSub(s domain.Subscription) {
+	if t, ok := r.services[s.TenantID]; ok && t.remove([]rune(domain.ServiceKey(s.ServiceName)), s.ID) {
+		delete(r.services, s.TenantID)
+	}
+}
+
+// через putSub и dropSub меняется r.items; вызываются под r.mu
+func (r *Repo) putSub(s domain.Subscription) {
+	if prev, ok := r.items[s.ID]; ok {
+		r.unindexSub(prev)
+	}
+	r.items[s.ID] = s
+	r.indexSub(s)
+	r.seq++
+	r.changes[s.ID] = r.seq
+}
+
+func (r *Repo) dropSub(id string) {
+	if prev, ok := r.items[id]; ok {
+		r.unindexSub(prev)
+		delete(r.items, id)
+		delete(r.changes, id)
+		r.seq++
+		r.tombstones[id] = tombstone{
+			Tombstone: domain.Tombstone{ID: id, TenantID: prev.TenantID, UserID: prev.UserID, DeletedAt: time.Now()},
+			seq:       r.seq,
+		}
+	}
+}
+
+// reindexSubs перестраивает дерево целиком, например для копии в InTx; вызывается под r.mu
+func (r *Repo) reindexSubs() {
+	r.services = make(map[string]*serviceTrie)
+	for _, s := range r.items {
+		r.indexSub(s)
+	}
+}
+
+func (r *Repo) SuggestServices(ctx context.Context, prefix string, limit int) ([]domain.ServiceSuggestion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]domain.ServiceSuggestion, 0)
+	t, ok := r.services[domain.TenantOrDefault(ctx)]
+	if !ok {
+		return out, nil
+	}
+	node := t.find(domain.ServiceKey(prefix))
+	if node == nil {
+		return out, nil
+	}
+	node.walk(func(subs map[string]struct{}) {
+		names, prices := make([]string, 0, len(subs)), make([]int, 0, len(subs))
+		for id := range subs {
+			names = append(names, r.items[id].ServiceName)
+			prices = append(prices, r.items[id].Price)
+		}
+		out = append(out, domain.NewServiceSuggestion(names, prices))
+	})
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Subscriptions != out[j].Subscriptions {
+			return out[i].Subscriptions > out[j].Subscriptions
+		}
+		return domain.ServiceKey(out[i].Name) < domain.ServiceKey(out[j].Name)
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
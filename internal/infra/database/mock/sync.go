@@ -0,0 +1,81 @@
+package mock
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
+
+// tombstone — надгробие с номером изменения, которым подписка была удалена
+type tombstone struct {
+	domain.Tombstone
+	seq uint64
+}
+
+// SyncSubs — изменения мока видны сразу, поэтому журнал прочитан до r.seq включительно
+func (r *Repo) SyncSubs(ctx context.Context, q domain.SyncQuery) (domain.SyncPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenantID := domain.TenantOrDefault(ctx)
+	out := make([]domain.SyncChange, 0)
+	for id, seq := range r.changes {
+		sub := r.items[id]
+		if sub.TenantID != tenantID || (q.UserID != "" && sub.UserID != q.UserID) {
+			continue
+		}
+		if c := (domain.SyncChange{Seq: seq, Sub: sub}); q.After.Before(c.Cursor()) {
+			out = append(out, c)
+		}
+	}
+	if q.Tombstones {
+		for _, t := range r.tombstones {
+			if t.TenantID != tenantID || (q.UserID != "" && t.UserID != q.UserID) {
+				continue
+			}
+			if c := (domain.SyncChange{Seq: t.seq, Deleted: &t.Tombstone}); q.After.Before(c.Cursor()) {
+				out = append(out, c)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Cursor().Before(out[j].Cursor()) })
+
+	page := domain.SyncPage{Changes: out, Next: domain.SyncCursor{Seq: r.seq + 1}}
+	if q.After.Seq > r.seq {
+		page.Next = q.After
+	}
+	if q.Limit > 0 && len(out) > q.Limit {
+		page.Changes, page.HasMore = out[:q.Limit], true
+		page.Next = out[q.Limit-1].Cursor()
+	}
+	return page, nil
+}
+
+func (r *Repo) GetTombstone(ctx context.Context, id string) (domain.Tombstone, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.tombstones[id]
+	if !ok || t.TenantID != domain.TenantOrDefault(ctx) {
+		return domain.Tombstone{}, domain.ErrNotFound
+	}
+	return t.Tombstone, nil
+}
+
+func (r *Repo) PurgeTombstones(ctx context.Context, before time.Time) (int, error) {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for id, t := range r.tombstones {
+		if t.DeletedAt.Before(before) {
+			delete(r.tombstones, id)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,43 @@
+package mock
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/google/uuid"
+)
+
+func (r *Repo) AddTenant(ctx context.Context, t domain.Tenant) (domain.Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t.ID = uuid.NewString()
+	t.CreatedAt = time.Now()
+	r.tenants[t.ID] = t
+	return t, nil
+}
+
+func (r *Repo) GetTenant(ctx context.Context, id string) (domain.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.tenants[id]
+	if !ok {
+		return domain.Tenant{}, domain.ErrTenantNotFound
+	}
+	return t, nil
+}
+
+func (r *Repo) ListTenants(ctx context.Context) ([]domain.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]domain.Tenant, 0, len(r.tenants))
+	for _, t := range r.tenants {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
@@ -0,0 +1,48 @@
+package mock
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
+
+// InTx выполняет fn над копией подписок и при успехе подменяет ею состояние репозитория:
+// до фиксации изменения fn не видны другим читателям, а откат просто отбрасывает копию.
+// Изменения подписок вне InTx тоже берут txMu, поэтому фиксация не затирает чужих записей.
+func (r *Repo) InTx(ctx context.Context, fn func(tx domain.SubscriptionTx) error) error {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+
+	tx := r.fork()
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items, r.trash, r.services = tx.items, tx.trash, tx.services
+	r.seq, r.changes, r.tombstones = tx.seq, tx.changes, tx.tombstones
+	r.audit = tx.audit
+	return nil
+}
+
+// fork копирует всё, что читают и меняют методы SubscriptionTx; вызывается под r.txMu
+func (r *Repo) fork() *Repo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tx := &Repo{
+		items:      maps.Clone(r.items),
+		trash:      maps.Clone(r.trash),
+		tenants:    maps.Clone(r.tenants),
+		users:      maps.Clone(r.users),
+		seq:        r.seq,
+		changes:    maps.Clone(r.changes),
+		tombstones: maps.Clone(r.tombstones),
+		audit:      slices.Clone(r.audit),
+	}
+	tx.reindexSubs()
+	return tx
+}
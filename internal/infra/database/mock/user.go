@@ -0,0 +1,174 @@
+package mock
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/google/uuid"
+)
+
+// hasUser вызывается под блокировкой
+func (r *Repo) hasUser(tenantID, id string) bool {
+	u, ok := r.users[id]
+	return ok && u.TenantID == tenantID
+}
+
+// emailTaken вызывается под блокировкой
+func (r *Repo) emailTaken(tenantID, email, exceptID string) bool {
+	if email == "" {
+		return false
+	}
+	for _, u := range r.users {
+		if u.TenantID == tenantID && u.ID != exceptID && strings.EqualFold(u.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Repo) AddUser(ctx context.Context, u domain.User) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u.TenantID = domain.TenantOrDefault(ctx)
+	if _, ok := r.tenants[u.TenantID]; !ok {
+		return domain.User{}, domain.ErrTenantNotFound
+	}
+	if r.emailTaken(u.TenantID, u.Email, "") {
+		return domain.User{}, domain.ErrEmailTaken
+	}
+	u.ID = uuid.NewString()
+	u.CreatedAt = time.Now()
+	u.UpdatedAt = u.CreatedAt
+	r.users[u.ID] = u
+	return u, nil
+}
+
+func (r *Repo) UpdateUser(ctx context.Context, u domain.User) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prev, ok := r.users[u.ID]
+	if !ok || prev.TenantID != domain.TenantOrDefault(ctx) {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+	if r.emailTaken(prev.TenantID, u.Email, u.ID) {
+		return domain.User{}, domain.ErrEmailTaken
+	}
+	u.TenantID = prev.TenantID
+	u.CreatedAt = prev.CreatedAt
+	u.UpdatedAt = time.Now()
+	r.users[u.ID] = u
+	return u, nil
+}
+
+func (r *Repo) DeleteUser(ctx context.Context, id string, cascade bool) ([]domain.Subscription, error) {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenantID := domain.TenantOrDefault(ctx)
+	if !r.hasUser(tenantID, id) {
+		return nil, domain.ErrUserNotFound
+	}
+
+	var owned []string
+	for subID, s := range r.items {
+		if s.TenantID == tenantID && s.UserID == id {
+			owned = append(owned, subID)
+		}
+	}
+	if len(owned) > 0 && !cascade {
+		return nil, domain.ErrUserHasSubscriptions
+	}
+	// порядок как в postgres, чтобы события приходили одинаково
+	sort.Strings(owned)
+	deleted := make([]domain.Subscription, 0, len(owned))
+	for _, subID := range owned {
+		sub, err := r.deleteSub(ctx, subID, domain.AnyVersion)
+		if err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, sub)
+	}
+	// корзина не мешает удалить пользователя: восстановить подписку без владельца нельзя
+	for subID, s := range r.trash {
+		if s.TenantID == tenantID && s.UserID == id {
+			delete(r.trash, subID)
+		}
+	}
+	delete(r.users, id)
+	return deleted, nil
+}
+
+func (r *Repo) GetUser(ctx context.Context, id string) (domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.hasUser(domain.TenantOrDefault(ctx), id) {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+	return r.users[id], nil
+}
+
+func (r *Repo) ListUsers(ctx context.Context) ([]domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenantID := domain.TenantOrDefault(ctx)
+	out := make([]domain.User, 0)
+	for _, u := range r.users {
+		if u.TenantID == tenantID {
+			out = append(out, u)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *Repo) UsersByIDs(ctx context.Context, ids []string) ([]domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenantID := domain.TenantOrDefault(ctx)
+	out := make([]domain.User, 0, len(ids))
+	for _, id := range ids {
+		if r.hasUser(tenantID, id) {
+			out = append(out, r.users[id])
+		}
+	}
+	return out, nil
+}
+
+func (r *Repo) SetCalendarToken(ctx context.Context, id, tokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.hasUser(domain.TenantOrDefault(ctx), id) {
+		return domain.ErrUserNotFound
+	}
+	for h, userID := range r.calendar {
+		if userID == id {
+			delete(r.calendar, h)
+		}
+	}
+	if tokenHash != "" {
+		r.calendar[tokenHash] = id
+	}
+	return nil
+}
+
+func (r *Repo) UserByCalendarToken(ctx context.Context, tokenHash string) (domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[r.calendar[tokenHash]]
+	if tokenHash == "" || !ok {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+	return u, nil
+}
@@ -0,0 +1,207 @@
+package mock
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/google/uuid"
+)
+
+func (r *Repo) AddWebhook(ctx context.Context, wh domain.Webhook) (domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wh.TenantID = domain.TenantOrDefault(ctx)
+	if _, ok := r.tenants[wh.TenantID]; !ok {
+		return domain.Webhook{}, domain.ErrTenantNotFound
+	}
+	wh.ID = uuid.NewString()
+	wh.Events = append([]string(nil), wh.Events...)
+	wh.CreatedAt = time.Now()
+	r.webhooks[wh.ID] = wh
+	return wh, nil
+}
+
+func (r *Repo) GetWebhook(ctx context.Context, id string) (domain.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wh, ok := r.webhooks[id]
+	if !ok || wh.TenantID != domain.TenantOrDefault(ctx) {
+		return domain.Webhook{}, domain.ErrWebhookNotFound
+	}
+	return wh, nil
+}
+
+func (r *Repo) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenantID := domain.TenantOrDefault(ctx)
+	out := make([]domain.Webhook, 0)
+	for _, wh := range r.webhooks {
+		if wh.TenantID == tenantID {
+			out = append(out, wh)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func (r *Repo) DeleteWebhook(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wh, ok := r.webhooks[id]
+	if !ok || wh.TenantID != domain.TenantOrDefault(ctx) {
+		return domain.ErrWebhookNotFound
+	}
+	delete(r.webhooks, id)
+	for did, d := range r.deliveries {
+		if d.WebhookID == id {
+			delete(r.deliveries, did)
+		}
+	}
+	return nil
+}
+
+func (r *Repo) EnqueueDeliveries(ctx context.Context, event string, events []domain.WebhookEvent) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenantID := domain.TenantOrDefault(ctx)
+	n := 0
+	for _, wh := range r.webhooks {
+		if wh.TenantID != tenantID || !wh.Subscribed(event) {
+			continue
+		}
+		for _, e := range events {
+			r.newDelivery(domain.WebhookDelivery{
+				TenantID:  tenantID,
+				WebhookID: wh.ID,
+				EventID:   e.ID,
+				Event:     event,
+				Payload:   e.Payload,
+			})
+			n++
+		}
+	}
+	return n, nil
+}
+
+// newDelivery сохраняет доставку, готовую к немедленной отправке; r.mu должен быть захвачен
+func (r *Repo) newDelivery(d domain.WebhookDelivery) domain.WebhookDelivery {
+	now := time.Now()
+	d.ID = uuid.NewString()
+	d.Payload = append([]byte(nil), d.Payload...)
+	d.Status = domain.DeliveryPending
+	d.Attempts, d.LastStatusCode, d.LastError = 0, 0, ""
+	d.NextAttemptAt, d.CreatedAt, d.UpdatedAt = now, now, now
+	r.deliveries[d.ID] = d
+	return d
+}
+
+func (r *Repo) ListDeliveries(ctx context.Context, webhookID string, limit int) ([]domain.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wh, ok := r.webhooks[webhookID]
+	if !ok || wh.TenantID != domain.TenantOrDefault(ctx) {
+		return nil, domain.ErrWebhookNotFound
+	}
+	out := make([]domain.WebhookDelivery, 0)
+	for _, d := range r.deliveries {
+		if d.WebhookID == webhookID {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.After(out[j].CreatedAt)
+		}
+		return out[i].ID > out[j].ID
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *Repo) GetDelivery(ctx context.Context, webhookID, deliveryID string) (domain.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.delivery(ctx, webhookID, deliveryID)
+}
+
+// delivery ищет доставку вебхука тенанта из контекста; r.mu должен быть захвачен
+func (r *Repo) delivery(ctx context.Context, webhookID, deliveryID string) (domain.WebhookDelivery, error) {
+	wh, ok := r.webhooks[webhookID]
+	if !ok || wh.TenantID != domain.TenantOrDefault(ctx) {
+		return domain.WebhookDelivery{}, domain.ErrWebhookNotFound
+	}
+	d, ok := r.deliveries[deliveryID]
+	if !ok || d.WebhookID != webhookID {
+		return domain.WebhookDelivery{}, domain.ErrDeliveryNotFound
+	}
+	return d, nil
+}
+
+func (r *Repo) RedeliverWebhook(ctx context.Context, webhookID, deliveryID string) (domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d, err := r.delivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	return r.newDelivery(d), nil
+}
+
+func (r *Repo) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]domain.WebhookDelivery, 0)
+	for _, d := range r.deliveries {
+		if d.Status == domain.DeliveryPending && !d.NextAttemptAt.After(now) {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].NextAttemptAt.Equal(out[j].NextAttemptAt) {
+			return out[i].NextAttemptAt.Before(out[j].NextAttemptAt)
+		}
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	for _, d := range out {
+		d.NextAttemptAt = now.Add(lease)
+		r.deliveries[d.ID] = d
+	}
+	return out, nil
+}
+
+func (r *Repo) UpdateDelivery(ctx context.Context, d domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cur, ok := r.deliveries[d.ID]
+	if !ok {
+		return domain.ErrDeliveryNotFound
+	}
+	cur.Status, cur.Attempts, cur.NextAttemptAt = d.Status, d.Attempts, d.NextAttemptAt
+	cur.LastStatusCode, cur.LastError = d.LastStatusCode, d.LastError
+	cur.UpdatedAt = time.Now()
+	r.deliveries[d.ID] = cur
+	return nil
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *PGRepo) ReplaceAnomalies(ctx context.Context, from, to time.Time, anomalies []domain.Anomaly) error {
+	r.logger.Printf("replacing anomalies from=%s to=%s count=%d", from.Format("2006-01"), to.Format("2006-01"), len(anomalies))
+	tenantID := domain.TenantOrDefault(ctx)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// неподтверждённые аномалии окна удаляются; оставшиеся обновляются на месте, id не меняется
+	kinds := make([]string, len(anomalies))
+	subjects := make([]string, len(anomalies))
+	months := make([]time.Time, len(anomalies))
+	for i, a := range anomalies {
+		kinds[i], subjects[i], months[i] = string(a.Kind), a.Subject, a.Month
+	}
+	q := fmt.Sprintf(`
+		DELETE FROM %s.anomalies
+		WHERE tenant_id=$1 AND month >= $2 AND month <= $3
+		  AND (kind, subject, month) NOT IN (SELECT * FROM unnest($4::text[], $5::text[], $6::timestamptz[]))`, r.schema)
+	ct, err := tx.Exec(ctx, q, tenantID, from, to, kinds, subjects, months)
+	if err != nil {
+		r.logger.Printf("delete stale anomalies failed: %v", err)
+		return err
+	}
+
+	q = fmt.Sprintf(`
+		INSERT INTO %s.anomalies (id, tenant_id, kind, subject, month, amount, baseline, ratio, severity, detected_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+		ON CONFLICT (tenant_id, kind, subject, month) DO UPDATE
+		SET amount=EXCLUDED.amount, baseline=EXCLUDED.baseline, ratio=EXCLUDED.ratio,
+		    severity=EXCLUDED.severity, detected_at=EXCLUDED.detected_at`, r.schema)
+	batch := &pgx.Batch{}
+	for _, a := range anomalies {
+		batch.Queue(q, uuid.NewString(), tenantID, a.Kind, a.Subject, a.Month, a.Amount, a.Baseline, a.Ratio, a.Severity, a.DetectedAt)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		r.logger.Printf("save anomalies failed: %v", err)
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	r.logger.Printf("anomalies replaced count=%d stale=%d", len(anomalies), ct.RowsAffected())
+	return nil
+}
+
+func (r *PGRepo) ListAnomalies(ctx context.Context, f domain.AnomalyFilter) ([]domain.Anomaly, error) {
+	r.logger.Printf("listing anomalies kind=%s subject=%s severity=%s", f.Kind, f.Subject, f.Severity)
+	q := fmt.Sprintf(`
+        SELECT id, tenant_id, kind, subject, month, amount, baseline, ratio, severity, detected_at
+        FROM %s.anomalies
+        WHERE tenant_id = $1`, r.schema)
+	args := []any{domain.TenantOrDefault(ctx)}
+	idx := 2
+	if f.Kind != "" {
+		q += fmt.Sprintf(" AND kind = $%d", idx)
+		args = append(args, f.Kind)
+		idx++
+	}
+	if f.Subject != "" {
+		q += fmt.Sprintf(" AND subject = $%d", idx)
+		args = append(args, f.Subject)
+		idx++
+	}
+	if f.Severity != "" {
+		q += fmt.Sprintf(" AND severity = $%d", idx)
+		args = append(args, f.Severity)
+		idx++
+	}
+	if !f.From.IsZero() {
+		q += fmt.Sprintf(" AND month >= $%d", idx)
+		args = append(args, f.From)
+		idx++
+	}
+	if !f.To.IsZero() {
+		q += fmt.Sprintf(" AND month <= $%d", idx)
+		args = append(args, f.To)
+		idx++
+	}
+	q += " ORDER BY month DESC, ratio DESC"
+
+	rows, err := r.pool.Query(ctx, q, args...)
+	if err != nil {
+		r.logger.Printf("list anomalies failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]domain.Anomaly, 0)
+	for rows.Next() {
+		var a domain.Anomaly
+		if err := rows.Scan(&a.ID, &a.TenantID, &a.Kind, &a.Subject, &a.Month, &a.Amount, &a.Baseline, &a.Ratio, &a.Severity, &a.DetectedAt); err != nil {
+			r.logger.Printf("scan anomaly failed: %v", err)
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Printf("list anomalies rows error: %v", err)
+		return nil, err
+	}
+	r.logger.Printf("list anomalies complete, count=%d", len(out))
+	return out, nil
+}
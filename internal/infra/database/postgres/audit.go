@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+// auditSnapshot — подписка в колонках before/after журнала. Ключи полей совпадают с domain.AuditFields,
+// поэтому фильтр AuditQuery.Field сравнивает before->'price' и after->'price'; пустой deleted_at не пишется.
+type auditSnapshot struct {
+	ID          string    `json:"id"`
+	ServiceName string    `json:"service_name"`
+	Price       int       `json:"price"`
+	UserID      string    `json:"user_id"`
+	StartDate   time.Time `json:"start_date"`
+	EndDate     time.Time `json:"end_date"`
+	Notes       string    `json:"notes"`
+	Tags        []string  `json:"tags"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	UpdatedBy   string    `json:"updated_by,omitempty"`
+	Version     int       `json:"version"`
+	DeletedAt   time.Time `json:"deleted_at,omitzero"`
+}
+
+// snapshotArg — снимок для колонки JSONB; nil для отсутствующего
+func snapshotArg(s *domain.Subscription) ([]byte, error) {
+	if s == nil {
+		return nil, nil
+	}
+	snap := auditSnapshot{
+		ID: s.ID, ServiceName: s.ServiceName, Price: s.Price, UserID: s.UserID,
+		StartDate: s.StartDate.UTC(), EndDate: s.EndDate.UTC(), Notes: s.Notes, Tags: tagsArg(s.Tags),
+		CreatedAt: s.CreatedAt.UTC(), UpdatedAt: s.UpdatedAt.UTC(), CreatedBy: s.CreatedBy, UpdatedBy: s.UpdatedBy,
+		Version: s.Version,
+	}
+	if !s.DeletedAt.IsZero() {
+		snap.DeletedAt = s.DeletedAt.UTC()
+	}
+	return json.Marshal(snap)
+}
+
+func parseSnapshot(raw []byte, tenantID string) (*domain.Subscription, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	var snap auditSnapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		return nil, err
+	}
+	return &domain.Subscription{
+		ID: snap.ID, TenantID: tenantID, ServiceName: snap.ServiceName, Price: snap.Price, UserID: snap.UserID,
+		StartDate: snap.StartDate, EndDate: snap.EndDate, Notes: snap.Notes, Tags: snap.Tags,
+		CreatedAt: snap.CreatedAt, UpdatedAt: snap.UpdatedAt, CreatedBy: snap.CreatedBy, UpdatedBy: snap.UpdatedBy,
+		Version: snap.Version, DeletedAt: snap.DeletedAt,
+	}, nil
+}
+
+// auditRow — значения колонок журнала (кроме id и created_at) в порядке auditInsertColumns
+func auditRow(ctx context.Context, action string, before, after *domain.Subscription) ([]any, error) {
+	b, err := snapshotArg(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := snapshotArg(after)
+	if err != nil {
+		return nil, err
+	}
+	var actor, reqID any
+	if v := domain.ActorFromCtx(ctx); v != "" {
+		actor = v
+	}
+	if v := domain.RequestIDFromCtx(ctx); v != "" {
+		reqID = v
+	}
+	return []any{after.TenantID, after.ID, after.UserID, action, actor, reqID, b, a}, nil
+}
+
+var auditInsertColumns = []string{"tenant_id", "subscription_id", "user_id", "action", "actor", "request_id", "before", "after"}
+
+// writeAudit добавляет запись журнала; db — транзакция изменения, чтобы запись не пережила его откат
+func (r *PGRepo) writeAudit(ctx context.Context, db querier, action string, before, after *domain.Subscription) error {
+	row, err := auditRow(ctx, action, before, after)
+	if err != nil {
+		return err
+	}
+	q := fmt.Sprintf(`
+		INSERT INTO %s.audit_log (tenant_id, subscription_id, user_id, action, actor, request_id, before, after)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`, r.schema)
+	if _, err := db.Exec(ctx, q, row...); err != nil {
+		r.logger.Printf("audit %s id=%s failed: %v", action, after.ID, err)
+		return err
+	}
+	return nil
+}
+
+const auditColumns = `id, tenant_id, subscription_id, user_id, action, COALESCE(actor, ''), COALESCE(request_id, ''),
+        before, after, created_at`
+
+func scanAudit(row pgx.Row, e *domain.AuditEntry) error {
+	var before, after []byte
+	if err := row.Scan(&e.ID, &e.TenantID, &e.SubscriptionID, &e.UserID, &e.Action, &e.Actor, &e.RequestID,
+		&before, &after, &e.CreatedAt); err != nil {
+		return err
+	}
+	var err error
+	if e.Before, err = parseSnapshot(before, e.TenantID); err != nil {
+		return err
+	}
+	e.After, err = parseSnapshot(after, e.TenantID)
+	return err
+}
+
+func (r *PGRepo) ListAudit(ctx context.Context, q domain.AuditQuery) (domain.AuditPage, error) {
+	r.logger.Printf("listing audit sub=%s user=%s actor=%s action=%s field=%s", q.SubscriptionID, q.UserID, q.Actor, q.Action, q.Field)
+	b := &queryBuilder{}
+	b.where("tenant_id = " + b.arg(domain.TenantOrDefault(ctx)))
+	for _, f := range [][2]string{
+		{"subscription_id", q.SubscriptionID}, {"user_id", q.UserID}, {"actor", q.Actor},
+		{"action", q.Action}, {"request_id", q.RequestID},
+	} {
+		if f[1] != "" {
+			b.where(f[0] + " = " + b.arg(f[1]))
+		}
+	}
+	if q.Field != "" {
+		p := b.arg(q.Field)
+		b.where(fmt.Sprintf("before->(%s::text) IS DISTINCT FROM after->(%s::text)", p, p))
+	}
+	if !q.From.IsZero() {
+		b.where("created_at >= " + b.arg(q.From))
+	}
+	if !q.To.IsZero() {
+		b.where("created_at < " + b.arg(q.To))
+	}
+	if q.BeforeID > 0 {
+		b.where("id < " + b.arg(q.BeforeID))
+	}
+	sql := fmt.Sprintf(`
+        SELECT %s
+        FROM %s.audit_log
+        WHERE %s
+        ORDER BY id DESC`, auditColumns, r.schema, b.whereSQL())
+	if q.Limit > 0 {
+		sql += " LIMIT " + b.arg(q.Limit+1)
+	}
+
+	rows, err := r.pool.Query(ctx, sql, b.args...)
+	if err != nil {
+		r.logger.Printf("list audit failed: %v", err)
+		return domain.AuditPage{}, err
+	}
+	defer rows.Close()
+	out := make([]domain.AuditEntry, 0)
+	for rows.Next() {
+		var e domain.AuditEntry
+		if err := scanAudit(rows, &e); err != nil {
+			r.logger.Printf("scan audit failed: %v", err)
+			return domain.AuditPage{}, err
+		}
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Printf("list audit rows error: %v", err)
+		return domain.AuditPage{}, err
+	}
+
+	page := domain.AuditPage{Entries: out}
+	if q.Limit > 0 && len(out) > q.Limit {
+		page.Entries, page.HasMore = out[:q.Limit], true
+	}
+	r.logger.Printf("list audit complete, count=%d has_more=%t", len(page.Entries), page.HasMore)
+	return page, nil
+}
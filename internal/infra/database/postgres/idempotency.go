@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+func (r *PGRepo) ReserveIdempotencyKey(ctx context.Context, rec domain.IdempotencyRecord) (domain.IdempotencyRecord, bool, error) {
+	tenantID := domain.TenantOrDefault(ctx)
+
+	// истёкшую запись можно занять заново
+	insert := fmt.Sprintf(`
+		INSERT INTO %[1]s.idempotency_keys (tenant_id, key, fingerprint, expires_at)
+		VALUES ($1,$2,$3,$4)
+		ON CONFLICT (tenant_id, key) DO UPDATE
+		SET fingerprint=EXCLUDED.fingerprint, status=NULL, header=NULL, body=NULL,
+		    created_at=now(), expires_at=EXCLUDED.expires_at
+		WHERE %[1]s.idempotency_keys.expires_at <= now()
+		RETURNING key`, r.schema)
+	sel := fmt.Sprintf(`
+		SELECT key, fingerprint, COALESCE(status, 0), COALESCE(header, '{}'::jsonb), COALESCE(body, ''::bytea), expires_at
+		FROM %s.idempotency_keys WHERE tenant_id=$1 AND key=$2`, r.schema)
+
+	// строка может истечь или освободиться между INSERT и SELECT — тогда пробуем ещё раз
+	for attempt := 0; attempt < 2; attempt++ {
+		var key string
+		err := r.pool.QueryRow(ctx, insert, tenantID, rec.Key, rec.Fingerprint, rec.ExpiresAt).Scan(&key)
+		if err == nil {
+			return domain.IdempotencyRecord{}, true, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Printf("reserve idempotency key failed: %v", err)
+			return domain.IdempotencyRecord{}, false, err
+		}
+
+		var cur domain.IdempotencyRecord
+		err = r.pool.QueryRow(ctx, sel, tenantID, rec.Key).
+			Scan(&cur.Key, &cur.Fingerprint, &cur.Status, &cur.Header, &cur.Body, &cur.ExpiresAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			r.logger.Printf("get idempotency key failed: %v", err)
+			return domain.IdempotencyRecord{}, false, err
+		}
+		return cur, false, nil
+	}
+	return domain.IdempotencyRecord{}, false, fmt.Errorf("idempotency key %q: concurrent modification", rec.Key)
+}
+
+func (r *PGRepo) CompleteIdempotencyKey(ctx context.Context, rec domain.IdempotencyRecord) error {
+	q := fmt.Sprintf(`
+		UPDATE %s.idempotency_keys SET status=$3, header=$4, body=$5
+		WHERE tenant_id=$1 AND key=$2`, r.schema)
+	if _, err := r.pool.Exec(ctx, q, domain.TenantOrDefault(ctx), rec.Key, rec.Status, rec.Header, rec.Body); err != nil {
+		r.logger.Printf("complete idempotency key failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *PGRepo) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	q := fmt.Sprintf(`DELETE FROM %s.idempotency_keys WHERE tenant_id=$1 AND key=$2`, r.schema)
+	if _, err := r.pool.Exec(ctx, q, domain.TenantOrDefault(ctx), key); err != nil {
+		r.logger.Printf("release idempotency key failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (r *PGRepo) PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int, error) {
+	q := fmt.Sprintf(`DELETE FROM %s.idempotency_keys WHERE expires_at <= $1`, r.schema)
+	ct, err := r.pool.Exec(ctx, q, now)
+	if err != nil {
+		r.logger.Printf("purge idempotency keys failed: %v", err)
+		return 0, err
+	}
+	r.logger.Printf("purged %d expired idempotency keys", ct.RowsAffected())
+	return int(ct.RowsAffected()), nil
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ImportSubs загружает подписки через COPY: одна команда, поэтому пачка вставляется целиком или не вставляется вовсе.
+// Записи журнала изменений добавляются вторым COPY в той же транзакции.
+func (r *PGRepo) ImportSubs(ctx context.Context, subs []domain.Subscription) ([]domain.Subscription, error) {
+	tenantID := domain.TenantOrDefault(ctx)
+	r.logger.Printf("importing %d subscriptions tenant=%s", len(subs), tenantID)
+
+	var actor any
+	if a := domain.ActorFromCtx(ctx); a != "" {
+		actor = a
+	}
+	ids := make([]string, len(subs))
+	rows := make([][]any, len(subs))
+	for i, s := range subs {
+		ids[i] = uuid.NewString()
+		rows[i] = []any{ids[i], tenantID, s.ServiceName, s.Price, s.UserID, s.StartDate, s.EndDate, actor, actor}
+	}
+
+	created, err := atomically(ctx, r.pool, func(db querier) ([]domain.Subscription, error) {
+		_, err := db.CopyFrom(ctx,
+			pgx.Identifier{r.schema, "subscriptions"},
+			[]string{"id", "tenant_id", "service_name", "price", "user_id", "start_date", "end_date", "created_by", "updated_by"},
+			pgx.CopyFromRows(rows),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return r.auditImport(ctx, db, ids)
+	})
+	if err != nil {
+		r.logger.Printf("import failed: %v", err)
+		switch fkConstraint(err) {
+		case "":
+		case "subscriptions_user_fkey":
+			return nil, domain.ErrUserNotFound
+		default:
+			return nil, domain.ErrTenantNotFound
+		}
+		return nil, err
+	}
+	r.logger.Printf("imported %d subscriptions", len(created))
+	return created, nil
+}
+
+// auditImport записывает создание импортированных подписок и возвращает их: снимки берутся из уже вставленных строк
+func (r *PGRepo) auditImport(ctx context.Context, db querier, ids []string) ([]domain.Subscription, error) {
+	q := fmt.Sprintf(`SELECT %s FROM %s.subscriptions WHERE id = ANY($1)`, subscriptionColumns, r.schema)
+	rows, err := db.Query(ctx, q, ids)
+	if err != nil {
+		return nil, err
+	}
+	subs := make([]domain.Subscription, 0, len(ids))
+	entries := make([][]any, 0, len(ids))
+	for rows.Next() {
+		var s domain.Subscription
+		if err := scanSub(rows, &s); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		row, err := auditRow(ctx, domain.AuditCreate, nil, &s)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		subs = append(subs, s)
+		entries = append(entries, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if _, err := db.CopyFrom(ctx, pgx.Identifier{r.schema, "audit_log"}, auditInsertColumns, pgx.CopyFromRows(entries)); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
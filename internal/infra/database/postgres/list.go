@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
+
+// queryBuilder накапливает условия и позиционные аргументы запроса
+type queryBuilder struct {
+	conds []string
+	args  []any
+}
+
+func (b *queryBuilder) arg(v any) string {
+	b.args = append(b.args, v)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *queryBuilder) where(cond string) {
+	b.conds = append(b.conds, cond)
+}
+
+func (b *queryBuilder) whereSQL() string {
+	if len(b.conds) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(b.conds, " AND ")
+}
+
+// applySubscriptionFilter добавляет тенант и фильтры списка подписок
+func (b *queryBuilder) applySubscriptionFilter(tenantID string, f domain.SubscriptionFilter) {
+	b.where("tenant_id = " + b.arg(tenantID))
+	if f.Trashed {
+		b.where("deleted_at IS NOT NULL")
+	} else {
+		b.where("deleted_at IS NULL")
+	}
+	if f.UserID != "" {
+		b.where("user_id = " + b.arg(f.UserID))
+	}
+	if f.ServiceName != "" {
+		b.where("service_name = " + b.arg(f.ServiceName))
+	}
+	if !f.ActiveAt.IsZero() {
+		p := b.arg(f.ActiveAt)
+		b.where(fmt.Sprintf("start_date <= %s AND end_date >= %s", p, p))
+	}
+	if f.PriceMin != nil {
+		b.where("price >= " + b.arg(*f.PriceMin))
+	}
+	if f.PriceMax != nil {
+		b.where("price <= " + b.arg(*f.PriceMax))
+	}
+	if !f.To.IsZero() {
+		b.where("start_date <= " + b.arg(f.To))
+	}
+	if !f.From.IsZero() {
+		b.where("end_date >= " + b.arg(f.From))
+	}
+}
+
+// applyKeyset добавляет условие «строго после after» для порядка order, дополненного id:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... — для убывающих полей знак меняется на «<»
+func (b *queryBuilder) applyKeyset(order []domain.SortField, after domain.Subscription) {
+	keys := append(append([]domain.SortField{}, order...), domain.SortField{Field: "id"})
+	ors := make([]string, 0, len(keys))
+	for i, k := range keys {
+		ands := make([]string, 0, i+1)
+		for _, prev := range keys[:i] {
+			ands = append(ands, prev.Field+" = "+b.arg(sortValue(after, prev.Field)))
+		}
+		op := ">"
+		if k.Desc {
+			op = "<"
+		}
+		ands = append(ands, k.Field+" "+op+" "+b.arg(sortValue(after, k.Field)))
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	b.where("(" + strings.Join(ors, " OR ") + ")")
+}
+
+func orderBySQL(order []domain.SortField) string {
+	parts := make([]string, 0, len(order)+1)
+	for _, sf := range order {
+		dir := "ASC"
+		if sf.Desc {
+			dir = "DESC"
+		}
+		parts = append(parts, sf.Field+" "+dir)
+	}
+	return strings.Join(append(parts, "id ASC"), ", ")
+}
+
+func sortValue(s domain.Subscription, field string) any {
+	switch field {
+	case domain.SortServiceName:
+		return s.ServiceName
+	case domain.SortPrice:
+		return s.Price
+	case domain.SortStartDate:
+		return s.StartDate
+	case domain.SortEndDate:
+		return s.EndDate
+	case domain.SortCreatedAt:
+		return s.CreatedAt
+	case domain.SortUpdatedAt:
+		return s.UpdatedAt
+	case domain.SortDeletedAt:
+		return s.DeletedAt
+	}
+	return s.ID
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS app.anomalies;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS app.anomalies (
+    id              TEXT PRIMARY KEY,
+    kind            TEXT NOT NULL,
+    subject         TEXT NOT NULL,
+    month           TIMESTAMPTZ NOT NULL,
+    amount          INTEGER NOT NULL,
+    baseline        DOUBLE PRECISION NOT NULL,
+    ratio           DOUBLE PRECISION NOT NULL,
+    severity        TEXT NOT NULL,
+    detected_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (kind, subject, month)
+);
+
+CREATE INDEX IF NOT EXISTS idx_anomalies_month ON app.anomalies(month);
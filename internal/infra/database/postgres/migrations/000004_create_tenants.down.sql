@@ -0,0 +1,8 @@
+ALTER TABLE app.anomalies DROP CONSTRAINT IF EXISTS anomalies_tenant_kind_subject_month_key;
+ALTER TABLE app.anomalies DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE app.anomalies ADD CONSTRAINT anomalies_kind_subject_month_key UNIQUE (kind, subject, month);
+
+DROP INDEX IF EXISTS app.idx_subscriptions_tenant_user;
+ALTER TABLE app.subscriptions DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS app.tenants;
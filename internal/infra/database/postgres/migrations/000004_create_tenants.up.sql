@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS app.tenants (
+    id              TEXT PRIMARY KEY,
+    name            TEXT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO app.tenants (id, name)
+VALUES ('00000000-0000-0000-0000-000000000001', 'default')
+ON CONFLICT (id) DO NOTHING;
+
+-- существующие данные переезжают в тенант по умолчанию
+ALTER TABLE app.subscriptions
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001'
+    REFERENCES app.tenants(id);
+ALTER TABLE app.subscriptions ALTER COLUMN tenant_id DROP DEFAULT;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_user ON app.subscriptions(tenant_id, user_id);
+
+ALTER TABLE app.anomalies
+    ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001'
+    REFERENCES app.tenants(id);
+ALTER TABLE app.anomalies ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE app.anomalies DROP CONSTRAINT IF EXISTS anomalies_kind_subject_month_key;
+ALTER TABLE app.anomalies ADD CONSTRAINT anomalies_tenant_kind_subject_month_key UNIQUE (tenant_id, kind, subject, month);
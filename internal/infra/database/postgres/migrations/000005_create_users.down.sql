@@ -0,0 +1,2 @@
+ALTER TABLE app.subscriptions DROP CONSTRAINT IF EXISTS subscriptions_user_fkey;
+DROP TABLE IF EXISTS app.users;
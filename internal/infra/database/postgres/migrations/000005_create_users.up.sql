@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS app.users (
+    id              TEXT PRIMARY KEY,
+    tenant_id       TEXT NOT NULL REFERENCES app.tenants(id),
+    display_name    TEXT NOT NULL,
+    email           TEXT,
+    currency        TEXT NOT NULL DEFAULT 'RUB',
+    locale          TEXT NOT NULL DEFAULT 'ru',
+    timezone        TEXT NOT NULL DEFAULT 'UTC',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_tenant_email ON app.users(tenant_id, lower(email)) WHERE email IS NOT NULL;
+
+-- пользователи, на которых уже ссылаются подписки, заводятся с пустым профилем
+INSERT INTO app.users (id, tenant_id, display_name)
+SELECT DISTINCT ON (user_id) user_id, tenant_id, ''
+FROM app.subscriptions
+ORDER BY user_id, created_at
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE app.subscriptions
+    ADD CONSTRAINT subscriptions_user_fkey FOREIGN KEY (tenant_id, user_id) REFERENCES app.users(tenant_id, id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS app.idx_subscriptions_tenant_price;
+DROP INDEX IF EXISTS app.idx_subscriptions_tenant_start;
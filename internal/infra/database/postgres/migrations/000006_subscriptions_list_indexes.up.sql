@@ -0,0 +1,3 @@
+-- keyset-пагинация по сортировкам по умолчанию и по цене
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_start ON app.subscriptions(tenant_id, start_date, id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_price ON app.subscriptions(tenant_id, price, id);
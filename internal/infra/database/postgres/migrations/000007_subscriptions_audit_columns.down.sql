@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS app.idx_subscriptions_tenant_created;
+ALTER TABLE app.subscriptions DROP COLUMN IF EXISTS updated_by;
+ALTER TABLE app.subscriptions DROP COLUMN IF EXISTS created_by;
+ALTER TABLE app.subscriptions DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,9 @@
+ALTER TABLE app.subscriptions ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+UPDATE app.subscriptions SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE app.subscriptions ALTER COLUMN updated_at SET NOT NULL;
+ALTER TABLE app.subscriptions ALTER COLUMN updated_at SET DEFAULT now();
+
+ALTER TABLE app.subscriptions ADD COLUMN IF NOT EXISTS created_by TEXT;
+ALTER TABLE app.subscriptions ADD COLUMN IF NOT EXISTS updated_by TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_created ON app.subscriptions(tenant_id, created_at, id);
@@ -0,0 +1 @@
+ALTER TABLE app.subscriptions DROP COLUMN IF EXISTS version;
@@ -0,0 +1 @@
+ALTER TABLE app.subscriptions ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS app.idempotency_keys (
+    tenant_id       TEXT NOT NULL REFERENCES app.tenants(id) ON DELETE CASCADE,
+    key             TEXT NOT NULL,
+    fingerprint     TEXT NOT NULL,
+    status          INTEGER,        -- NULL, пока исходный запрос выполняется
+    header          JSONB,
+    body            BYTEA,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at      TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (tenant_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON app.idempotency_keys(expires_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS app.idx_users_calendar_token;
+ALTER TABLE app.users DROP COLUMN IF EXISTS calendar_token_hash;
@@ -0,0 +1,4 @@
+-- хранится sha256 токена ленты календаря, сам токен знает только пользователь
+ALTER TABLE app.users ADD COLUMN IF NOT EXISTS calendar_token_hash TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_calendar_token ON app.users(calendar_token_hash) WHERE calendar_token_hash IS NOT NULL;
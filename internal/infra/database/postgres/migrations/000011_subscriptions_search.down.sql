@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS app.idx_subscriptions_search_trgm;
+DROP FUNCTION IF EXISTS app.subscription_search_text(TEXT, TEXT, TEXT[]);
+ALTER TABLE app.subscriptions DROP COLUMN IF EXISTS tags;
+ALTER TABLE app.subscriptions DROP COLUMN IF EXISTS notes;
@@ -0,0 +1,14 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE app.subscriptions ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '';
+ALTER TABLE app.subscriptions ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- текст для поиска: название, заметки и метки. array_to_string только STABLE,
+-- поэтому для индекса по выражению нужна обёртка, помеченная IMMUTABLE
+CREATE OR REPLACE FUNCTION app.subscription_search_text(service_name TEXT, notes TEXT, tags TEXT[])
+RETURNS TEXT
+LANGUAGE sql IMMUTABLE PARALLEL SAFE
+AS $$ SELECT lower(service_name || ' ' || notes || ' ' || array_to_string(tags, ' ')) $$;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_search_trgm
+    ON app.subscriptions USING gin (app.subscription_search_text(service_name, notes, tags) gin_trgm_ops);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS app.idx_subscriptions_tenant_service_prefix;
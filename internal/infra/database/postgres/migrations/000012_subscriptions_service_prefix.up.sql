@@ -0,0 +1,4 @@
+-- подсказки названий сервисов: поиск по префиксу lower(btrim(service_name)) внутри тенанта.
+-- text_pattern_ops позволяет использовать индекс для LIKE 'prefix%' при любой collation
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_service_prefix
+    ON app.subscriptions (tenant_id, lower(btrim(service_name)) text_pattern_ops);
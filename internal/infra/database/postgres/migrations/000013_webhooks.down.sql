@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS app.webhook_deliveries;
+DROP TABLE IF EXISTS app.webhooks;
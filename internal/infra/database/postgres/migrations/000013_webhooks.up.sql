@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS app.webhooks (
+    id              TEXT PRIMARY KEY,
+    tenant_id       TEXT NOT NULL REFERENCES app.tenants(id) ON DELETE CASCADE,
+    url             TEXT NOT NULL,
+    secret          TEXT NOT NULL,
+    events          TEXT[] NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_tenant ON app.webhooks(tenant_id, created_at);
+
+CREATE TABLE IF NOT EXISTS app.webhook_deliveries (
+    id                TEXT PRIMARY KEY,
+    tenant_id         TEXT NOT NULL REFERENCES app.tenants(id) ON DELETE CASCADE,
+    webhook_id        TEXT NOT NULL REFERENCES app.webhooks(id) ON DELETE CASCADE,
+    event_id          TEXT NOT NULL,
+    event             TEXT NOT NULL,
+    payload           BYTEA NOT NULL, -- подписывается побайтово, поэтому не JSONB
+    status            TEXT NOT NULL DEFAULT 'pending',
+    attempts          INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_status_code  INTEGER,
+    last_error        TEXT,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON app.webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON app.webhook_deliveries(webhook_id, created_at DESC);
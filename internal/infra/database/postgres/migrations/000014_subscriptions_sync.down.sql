@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS subscriptions_leave_tombstone ON app.subscriptions;
+DROP TRIGGER IF EXISTS subscriptions_track_change ON app.subscriptions;
+DROP FUNCTION IF EXISTS app.subscriptions_leave_tombstone();
+DROP FUNCTION IF EXISTS app.subscriptions_track_change();
+DROP TABLE IF EXISTS app.subscription_tombstones;
+DROP INDEX IF EXISTS app.idx_subscriptions_sync;
+ALTER TABLE app.subscriptions DROP COLUMN IF EXISTS change_xid;
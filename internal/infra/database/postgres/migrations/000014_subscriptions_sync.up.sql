@@ -0,0 +1,50 @@
+-- журнал изменений для GET /v1/sync: строка помнит транзакцию своего последнего изменения,
+-- удалённая подписка оставляет надгробие. Номера транзакций (xid8) не повторяются, а граница
+-- pg_snapshot_xmin позволяет выдавать изменения только после фиксации всех более ранних транзакций
+ALTER TABLE app.subscriptions ADD COLUMN IF NOT EXISTS change_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_sync ON app.subscriptions(tenant_id, change_xid, id);
+
+CREATE TABLE IF NOT EXISTS app.subscription_tombstones (
+    id              TEXT PRIMARY KEY,
+    tenant_id       TEXT NOT NULL REFERENCES app.tenants(id) ON DELETE CASCADE,
+    user_id         TEXT NOT NULL,
+    deleted_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    change_xid      xid8 NOT NULL DEFAULT pg_current_xact_id()
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_tombstones_sync ON app.subscription_tombstones(tenant_id, change_xid, id);
+CREATE INDEX IF NOT EXISTS idx_subscription_tombstones_deleted ON app.subscription_tombstones(deleted_at);
+
+-- триггеры, а не код репозитория: так в журнал попадают изменения из любых запросов (импорт, пакеты, PATCH)
+CREATE OR REPLACE FUNCTION app.subscriptions_track_change()
+RETURNS trigger
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    NEW.change_xid := pg_current_xact_id();
+    RETURN NEW;
+END
+$$;
+
+CREATE OR REPLACE FUNCTION app.subscriptions_leave_tombstone()
+RETURNS trigger
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    INSERT INTO app.subscription_tombstones (id, tenant_id, user_id)
+    VALUES (OLD.id, OLD.tenant_id, OLD.user_id)
+    ON CONFLICT (id) DO UPDATE SET deleted_at = now(), change_xid = pg_current_xact_id();
+    RETURN OLD;
+END
+$$;
+
+DROP TRIGGER IF EXISTS subscriptions_track_change ON app.subscriptions;
+CREATE TRIGGER subscriptions_track_change
+    BEFORE INSERT OR UPDATE ON app.subscriptions
+    FOR EACH ROW EXECUTE FUNCTION app.subscriptions_track_change();
+
+DROP TRIGGER IF EXISTS subscriptions_leave_tombstone ON app.subscriptions;
+CREATE TRIGGER subscriptions_leave_tombstone
+    AFTER DELETE ON app.subscriptions
+    FOR EACH ROW EXECUTE FUNCTION app.subscriptions_leave_tombstone();
@@ -0,0 +1,4 @@
+-- подписки из корзины при откате удаляются: без deleted_at они снова стали бы видны
+DELETE FROM app.subscriptions WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS app.idx_subscriptions_trash;
+ALTER TABLE app.subscriptions DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,4 @@
+-- удаление переносит подписку в корзину; окончательно строки удаляет фоновая очистка через TRASH_RETENTION
+ALTER TABLE app.subscriptions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_trash ON app.subscriptions(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,18 @@
+-- журнал изменений подписок: пишется в той же транзакции, что и изменение.
+-- subscription_id без внешнего ключа — история переживает окончательное удаление подписки из корзины
+CREATE TABLE IF NOT EXISTS app.audit_log (
+    id               BIGSERIAL PRIMARY KEY,
+    tenant_id        TEXT NOT NULL REFERENCES app.tenants(id) ON DELETE CASCADE,
+    subscription_id  TEXT NOT NULL,
+    user_id          TEXT NOT NULL,
+    action           TEXT NOT NULL,
+    actor            TEXT,
+    request_id       TEXT,
+    before           JSONB,
+    after            JSONB,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_tenant ON app.audit_log(tenant_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_subscription ON app.audit_log(tenant_id, subscription_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_request ON app.audit_log(request_id) WHERE request_id IS NOT NULL;
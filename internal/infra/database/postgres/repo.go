@@ -12,6 +12,7 @@ import (
 	"github.com/EgorLis/my-subs/internal/domain"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/jackc/pgx/v5/stdlib"
 
@@ -99,6 +100,38 @@ func runMigrations(dsn string, logger *log.Logger) error {
 
 // ---- Реализация репозитория ----
 
+// subscriptionColumns — колонки подписки в порядке, который ожидает scanSub
+const subscriptionColumns = `id, tenant_id, service_name, price, user_id, start_date, end_date, notes, tags,
+        created_at, updated_at, COALESCE(created_by, ''), COALESCE(updated_by, ''), version, deleted_at`
+
+// scanSub читает колонки subscriptionColumns; extra — вычисляемые колонки, выбранные после них
+func scanSub(row pgx.Row, s *domain.Subscription, extra ...any) error {
+	var deletedAt *time.Time
+	err := row.Scan(append([]any{&s.ID, &s.TenantID, &s.ServiceName, &s.Price, &s.UserID, &s.StartDate, &s.EndDate, &s.Notes, &s.Tags,
+		&s.CreatedAt, &s.UpdatedAt, &s.CreatedBy, &s.UpdatedBy, &s.Version, &deletedAt}, extra...)...)
+	if deletedAt != nil {
+		s.DeletedAt = *deletedAt
+	}
+	return err
+}
+
+// tagsArg — колонка tags NOT NULL, поэтому nil передаётся как пустой массив
+func tagsArg(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// querier — общее у pgxpool.Pool и pgx.Tx: запросы можно выполнять и вне транзакции, и внутри неё
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, rows pgx.CopyFromSource) (int64, error)
+}
+
 func (r *PGRepo) Ping(ctx context.Context) error {
 	r.logger.Println("pinging database...")
 	if err := r.pool.Ping(ctx); err != nil {
@@ -110,66 +143,211 @@ func (r *PGRepo) Ping(ctx context.Context) error {
 }
 
 func (r *PGRepo) AddSub(ctx context.Context, s domain.Subscription) (domain.Subscription, error) {
+	return atomically(ctx, r.pool, func(q querier) (domain.Subscription, error) {
+		return r.addSub(ctx, q, s)
+	})
+}
+
+// addSub, updateSub и deleteSub пишут журнал изменений отдельным запросом, поэтому db — транзакция (см. atomically)
+func (r *PGRepo) addSub(ctx context.Context, db querier, s domain.Subscription) (domain.Subscription, error) {
 	id := uuid.NewString()
-	r.logger.Printf("adding subscription user=%s service=%s price=%d from %s to %s",
-		s.UserID, s.ServiceName, s.Price, s.StartDate.Format("01-2006"), s.EndDate.Format("01-2006"))
+	tenantID := domain.TenantOrDefault(ctx)
+	r.logger.Printf("adding subscription tenant=%s user=%s service=%s price=%d from %s to %s",
+		tenantID, s.UserID, s.ServiceName, s.Price, s.StartDate.Format("01-2006"), s.EndDate.Format("01-2006"))
 	q := fmt.Sprintf(`
-		INSERT INTO %s.subscriptions (id, service_name, price, user_id, start_date, end_date)
-		VALUES ($1,$2,$3,$4,$5,$6)
-		RETURNING id, service_name, price, user_id, start_date, end_date`, r.schema)
+		INSERT INTO %s.subscriptions (id, tenant_id, service_name, price, user_id, start_date, end_date, notes, tags, created_by, updated_by)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,NULLIF($10,''),NULLIF($10,''))
+		RETURNING %s`, r.schema, subscriptionColumns)
 	var out domain.Subscription
-	err := r.pool.QueryRow(ctx, q, id, s.ServiceName, s.Price, s.UserID, s.StartDate, s.EndDate).
-		Scan(&out.ID, &out.ServiceName, &out.Price, &out.UserID, &out.StartDate, &out.EndDate)
+	err := scanSub(db.QueryRow(ctx, q, id, tenantID, s.ServiceName, s.Price, s.UserID, s.StartDate, s.EndDate,
+		s.Notes, tagsArg(s.Tags), domain.ActorFromCtx(ctx)), &out)
 	if err != nil {
 		r.logger.Printf("add subscription failed: %v", err)
+		switch fkConstraint(err) {
+		case "":
+		case "subscriptions_user_fkey":
+			return out, domain.ErrUserNotFound
+		default:
+			return out, domain.ErrTenantNotFound
+		}
 		return out, err
 	}
+	if err := r.writeAudit(ctx, db, domain.AuditCreate, nil, &out); err != nil {
+		return domain.Subscription{}, err
+	}
 	r.logger.Printf("subscription added id=%s", out.ID)
 	return out, nil
 }
 
-func (r *PGRepo) UpdateSub(ctx context.Context, s domain.Subscription) error {
-	r.logger.Printf("updating subscription id=%s", s.ID)
+func (r *PGRepo) UpdateSub(ctx context.Context, s domain.Subscription, version int) (domain.Subscription, error) {
+	return atomically(ctx, r.pool, func(q querier) (domain.Subscription, error) {
+		return r.updateSub(ctx, q, s, version)
+	})
+}
+
+func (r *PGRepo) updateSub(ctx context.Context, db querier, s domain.Subscription, version int) (domain.Subscription, error) {
+	r.logger.Printf("updating subscription id=%s version=%d", s.ID, version)
+	prev, err := r.lockSub(ctx, db, s.ID, version)
+	if err != nil {
+		r.logger.Printf("update: subscription id=%s not changed: %v", s.ID, err)
+		return domain.Subscription{}, err
+	}
+	out, err := r.saveSub(ctx, db, prev, s)
+	if err != nil {
+		r.logger.Printf("update failed for id=%s: %v", s.ID, err)
+		return domain.Subscription{}, err
+	}
+	r.logger.Printf("subscription updated id=%s version=%d", s.ID, out.Version)
+	return out, nil
+}
+
+// PatchSub блокирует строку (SELECT ... FOR UPDATE) на время apply,
+// поэтому параллельные изменения не теряются между чтением и записью.
+func (r *PGRepo) PatchSub(ctx context.Context, id string, version int, apply func(cur domain.Subscription) (domain.Subscription, error)) (domain.Subscription, error) {
+	r.logger.Printf("patching subscription id=%s version=%d", id, version)
+	out, err := atomically(ctx, r.pool, func(q querier) (domain.Subscription, error) {
+		cur, err := r.lockSub(ctx, q, id, version)
+		if err != nil {
+			return domain.Subscription{}, err
+		}
+		next, err := apply(cur)
+		if err != nil {
+			return domain.Subscription{}, err
+		}
+		return r.saveSub(ctx, q, cur, next)
+	})
+	if err != nil {
+		r.logger.Printf("patch id=%s not applied: %v", id, err)
+		return domain.Subscription{}, err
+	}
+	r.logger.Printf("subscription patched id=%s version=%d", id, out.Version)
+	return out, nil
+}
+
+// lockSub читает подписку и блокирует строку до конца транзакции db;
+// ErrNotFound, если подписки нет, ErrVersionMismatch, если её версия не version
+func (r *PGRepo) lockSub(ctx context.Context, db querier, id string, version int) (domain.Subscription, error) {
+	q := fmt.Sprintf(`SELECT %s FROM %s.subscriptions WHERE id=$1 AND tenant_id=$2 AND deleted_at IS NULL FOR UPDATE`, subscriptionColumns, r.schema)
+	var cur domain.Subscription
+	if err := scanSub(db.QueryRow(ctx, q, id, domain.TenantOrDefault(ctx)), &cur); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Subscription{}, domain.ErrNotFound
+		}
+		return domain.Subscription{}, err
+	}
+	if version != domain.AnyVersion && cur.Version != version {
+		return domain.Subscription{}, domain.ErrVersionMismatch
+	}
+	return cur, nil
+}
+
+// saveSub записывает next поверх заблокированной lockSub подписки prev и добавляет запись журнала
+func (r *PGRepo) saveSub(ctx context.Context, db querier, prev, next domain.Subscription) (domain.Subscription, error) {
 	q := fmt.Sprintf(`
 		UPDATE %s.subscriptions
-		SET service_name=$2, price=$3, user_id=$4, start_date=$5, end_date=$6
-		WHERE id=$1`, r.schema)
-	ct, err := r.pool.Exec(ctx, q, s.ID, s.ServiceName, s.Price, s.UserID, s.StartDate, s.EndDate)
+		SET service_name=$3, price=$4, user_id=$5, start_date=$6, end_date=$7, notes=$8, tags=$9,
+		    updated_at=now(), updated_by=NULLIF($10,''), version=version+1
+		WHERE id=$1 AND tenant_id=$2
+		RETURNING %s`, r.schema, subscriptionColumns)
+	var out domain.Subscription
+	err := scanSub(db.QueryRow(ctx, q, prev.ID, prev.TenantID, next.ServiceName, next.Price, next.UserID, next.StartDate, next.EndDate,
+		next.Notes, tagsArg(next.Tags), domain.ActorFromCtx(ctx)), &out)
 	if err != nil {
-		r.logger.Printf("update failed for id=%s: %v", s.ID, err)
-		return err
+		if fkConstraint(err) == "subscriptions_user_fkey" {
+			return domain.Subscription{}, domain.ErrUserNotFound
+		}
+		return domain.Subscription{}, err
 	}
-	if ct.RowsAffected() == 0 {
-		r.logger.Printf("update: subscription not found id=%s", s.ID)
-		return domain.ErrNotFound
+	if err := r.writeAudit(ctx, db, domain.AuditUpdate, &prev, &out); err != nil {
+		return domain.Subscription{}, err
 	}
-	r.logger.Printf("subscription updated id=%s", s.ID)
-	return nil
+	return out, nil
+}
+
+func (r *PGRepo) DeleteSub(ctx context.Context, id string, version int) (domain.Subscription, error) {
+	return atomically(ctx, r.pool, func(q querier) (domain.Subscription, error) {
+		return r.deleteSub(ctx, q, id, version)
+	})
 }
 
-func (r *PGRepo) DeleteSub(ctx context.Context, id string) error {
-	r.logger.Printf("deleting subscription id=%s", id)
-	q := fmt.Sprintf(`DELETE FROM %s.subscriptions WHERE id=$1`, r.schema)
-	ct, err := r.pool.Exec(ctx, q, id)
+func (r *PGRepo) deleteSub(ctx context.Context, db querier, id string, version int) (domain.Subscription, error) {
+	r.logger.Printf("deleting subscription id=%s version=%d", id, version)
+	prev, err := r.lockSub(ctx, db, id, version)
 	if err != nil {
+		r.logger.Printf("delete: subscription id=%s not deleted: %v", id, err)
+		return domain.Subscription{}, err
+	}
+	q := fmt.Sprintf(`
+		UPDATE %s.subscriptions SET deleted_at=now()
+		WHERE id=$1 AND tenant_id=$2
+		RETURNING %s`, r.schema, subscriptionColumns)
+	var out domain.Subscription
+	if err := scanSub(db.QueryRow(ctx, q, id, prev.TenantID), &out); err != nil {
 		r.logger.Printf("delete failed id=%s: %v", id, err)
-		return err
+		return domain.Subscription{}, err
 	}
-	if ct.RowsAffected() == 0 {
-		r.logger.Printf("delete: subscription not found id=%s", id)
-		return domain.ErrNotFound
+	if err := r.writeAudit(ctx, db, domain.AuditDelete, &prev, &out); err != nil {
+		return domain.Subscription{}, err
 	}
-	r.logger.Printf("subscription deleted id=%s", id)
-	return nil
+	r.logger.Printf("subscription moved to trash id=%s", id)
+	return out, nil
+}
+
+// RestoreSub снимает отметку deleted_at; версия растёт, чтобы клиенты с ETag удалённой подписки
+// и офлайн-копии (SyncSubs) увидели восстановление как изменение
+func (r *PGRepo) RestoreSub(ctx context.Context, id string) (domain.Subscription, error) {
+	r.logger.Printf("restoring subscription id=%s", id)
+	out, err := atomically(ctx, r.pool, func(db querier) (domain.Subscription, error) {
+		q := fmt.Sprintf(`SELECT %s FROM %s.subscriptions WHERE id=$1 AND tenant_id=$2 AND deleted_at IS NOT NULL FOR UPDATE`,
+			subscriptionColumns, r.schema)
+		var prev domain.Subscription
+		if err := scanSub(db.QueryRow(ctx, q, id, domain.TenantOrDefault(ctx)), &prev); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.Subscription{}, domain.ErrNotFound
+			}
+			return domain.Subscription{}, err
+		}
+		q = fmt.Sprintf(`
+			UPDATE %s.subscriptions SET deleted_at=NULL, updated_at=now(), updated_by=NULLIF($3,''), version=version+1
+			WHERE id=$1 AND tenant_id=$2
+			RETURNING %s`, r.schema, subscriptionColumns)
+		var out domain.Subscription
+		if err := scanSub(db.QueryRow(ctx, q, id, prev.TenantID, domain.ActorFromCtx(ctx)), &out); err != nil {
+			return domain.Subscription{}, err
+		}
+		return out, r.writeAudit(ctx, db, domain.AuditRestore, &prev, &out)
+	})
+	if err != nil {
+		r.logger.Printf("restore id=%s not applied: %v", id, err)
+		return domain.Subscription{}, err
+	}
+	r.logger.Printf("subscription restored id=%s version=%d", id, out.Version)
+	return out, nil
+}
+
+// PurgeTrash удаляет строки окончательно; надгробия для SyncSubs оставляет триггер (миграция 000014)
+func (r *PGRepo) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	q := fmt.Sprintf(`DELETE FROM %s.subscriptions WHERE deleted_at < $1`, r.schema)
+	ct, err := r.pool.Exec(ctx, q, before)
+	if err != nil {
+		r.logger.Printf("purge trash failed: %v", err)
+		return 0, err
+	}
+	r.logger.Printf("purged %d subscriptions from trash", ct.RowsAffected())
+	return int(ct.RowsAffected()), nil
 }
 
 func (r *PGRepo) GetSub(ctx context.Context, id string) (domain.Subscription, error) {
+	return r.getSub(ctx, r.pool, id)
+}
+
+func (r *PGRepo) getSub(ctx context.Context, db querier, id string) (domain.Subscription, error) {
 	r.logger.Printf("getting subscription id=%s", id)
 	q := fmt.Sprintf(`
-        SELECT id, service_name, price, user_id, start_date, end_date
-        FROM %s.subscriptions WHERE id=$1`, r.schema)
+        SELECT %s
+        FROM %s.subscriptions WHERE id=$1 AND tenant_id=$2 AND deleted_at IS NULL`, subscriptionColumns, r.schema)
 	var s domain.Subscription
-	err := r.pool.QueryRow(ctx, q, id).Scan(&s.ID, &s.ServiceName, &s.Price, &s.UserID, &s.StartDate, &s.EndDate)
+	err := scanSub(db.QueryRow(ctx, q, id, domain.TenantOrDefault(ctx)), &s)
 	if errors.Is(err, pgx.ErrNoRows) {
 		r.logger.Printf("get: subscription not found id=%s", id)
 		return domain.Subscription{}, domain.ErrNotFound
@@ -182,33 +360,90 @@ func (r *PGRepo) GetSub(ctx context.Context, id string) (domain.Subscription, er
 	return s, nil
 }
 
-func (r *PGRepo) ListSubs(ctx context.Context) ([]domain.Subscription, error) {
-	r.logger.Println("listing subscriptions...")
+func (r *PGRepo) ListSubs(ctx context.Context, f domain.SubscriptionFilter) (domain.SubscriptionPage, error) {
+	r.logger.Printf("listing subscriptions user=%s service=%s limit=%d...", f.UserID, f.ServiceName, f.Limit)
+	order := f.OrderBy()
+
+	b := &queryBuilder{}
+	b.applySubscriptionFilter(domain.TenantOrDefault(ctx), f)
+	if f.After != nil {
+		b.applyKeyset(order, *f.After)
+	}
 	q := fmt.Sprintf(`
-        SELECT id, service_name, price, user_id, start_date, end_date
+        SELECT %s
         FROM %s.subscriptions
-        ORDER BY created_at NULLS LAST, id`, r.schema)
-	rows, err := r.pool.Query(ctx, q)
+        WHERE %s
+        ORDER BY %s`, subscriptionColumns, r.schema, b.whereSQL(), orderBySQL(order))
+	if f.Limit > 0 {
+		// берём на одну строку больше, чтобы понять, есть ли следующая страница
+		q += " LIMIT " + b.arg(f.Limit+1)
+	}
+
+	rows, err := r.pool.Query(ctx, q, b.args...)
 	if err != nil {
 		r.logger.Printf("list failed: %v", err)
-		return nil, err
+		return domain.SubscriptionPage{}, err
 	}
 	defer rows.Close()
-	var out []domain.Subscription
+	out := make([]domain.Subscription, 0)
 	for rows.Next() {
 		var s domain.Subscription
-		if err := rows.Scan(&s.ID, &s.ServiceName, &s.Price, &s.UserID, &s.StartDate, &s.EndDate); err != nil {
+		if err := scanSub(rows, &s); err != nil {
 			r.logger.Printf("scan row failed: %v", err)
-			return nil, err
+			return domain.SubscriptionPage{}, err
 		}
 		out = append(out, s)
 	}
 	if err := rows.Err(); err != nil {
 		r.logger.Printf("list rows error: %v", err)
-		return nil, err
+		return domain.SubscriptionPage{}, err
 	}
-	r.logger.Printf("list complete, count=%d", len(out))
-	return out, nil
+
+	page := domain.SubscriptionPage{Subs: out}
+	if f.Limit > 0 && len(out) > f.Limit {
+		page.Subs, page.HasMore = out[:f.Limit], true
+	}
+	r.logger.Printf("list complete, count=%d has_more=%t", len(page.Subs), page.HasMore)
+	return page, nil
+}
+
+// StreamSubs читает строки курсором pgx по мере вызова fn: результат не собирается в память,
+// а отмена ctx (например, клиент отключился) прерывает запрос на стороне базы
+func (r *PGRepo) StreamSubs(ctx context.Context, f domain.SubscriptionFilter, fn func(domain.Subscription) error) error {
+	r.logger.Printf("streaming subscriptions user=%s service=%s...", f.UserID, f.ServiceName)
+	b := &queryBuilder{}
+	b.applySubscriptionFilter(domain.TenantOrDefault(ctx), f)
+	q := fmt.Sprintf(`
+        SELECT %s
+        FROM %s.subscriptions
+        WHERE %s
+        ORDER BY %s`, subscriptionColumns, r.schema, b.whereSQL(), orderBySQL(f.OrderBy()))
+
+	rows, err := r.pool.Query(ctx, q, b.args...)
+	if err != nil {
+		r.logger.Printf("stream failed: %v", err)
+		return err
+	}
+	defer rows.Close()
+	n := 0
+	for rows.Next() {
+		var s domain.Subscription
+		if err := scanSub(rows, &s); err != nil {
+			r.logger.Printf("scan row failed: %v", err)
+			return err
+		}
+		if err := fn(s); err != nil {
+			r.logger.Printf("stream stopped after %d rows: %v", n, err)
+			return err
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Printf("stream rows error after %d rows: %v", n, err)
+		return err
+	}
+	r.logger.Printf("stream complete, count=%d", n)
+	return nil
 }
 
 // TotalCost суммирует поле Price для подписок, которые пересекают период [start,end] включительно.
@@ -222,9 +457,9 @@ func (r *PGRepo) TotalCost(ctx context.Context, serviceName, userID string, star
 	base := fmt.Sprintf(`
         SELECT COALESCE(SUM(price),0)
         FROM %s.subscriptions
-        WHERE start_date <= $1 AND end_date >= $2`, r.schema)
-	args := []any{end, start}
-	idx := 3
+        WHERE tenant_id = $1 AND deleted_at IS NULL AND start_date <= $2 AND end_date >= $3`, r.schema)
+	args := []any{domain.TenantOrDefault(ctx), end, start}
+	idx := 4
 	if serviceName != "" {
 		base += fmt.Sprintf(" AND service_name = $%d", idx)
 		args = append(args, serviceName)
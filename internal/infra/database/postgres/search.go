@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
+
+// SearchSubs ищет по индексу pg_trgm (idx_subscriptions_search_trgm): оператор <% отбирает строки,
+// где запрос похож на часть текста не меньше порога, word_similarity задаёт порядок
+func (r *PGRepo) SearchSubs(ctx context.Context, sq domain.SearchQuery) ([]domain.SearchHit, error) {
+	r.logger.Printf("searching subscriptions q=%q user=%s limit=%d", sq.Text, sq.UserID, sq.Limit)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// порог действует только до конца транзакции
+	threshold := strconv.FormatFloat(domain.SearchThreshold, 'f', -1, 64)
+	if _, err := tx.Exec(ctx, `SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)`, threshold); err != nil {
+		r.logger.Printf("search failed: %v", err)
+		return nil, err
+	}
+
+	doc := fmt.Sprintf("%s.subscription_search_text(service_name, notes, tags)", r.schema)
+	b := &queryBuilder{}
+	b.where("tenant_id = " + b.arg(domain.TenantOrDefault(ctx)))
+	b.where("deleted_at IS NULL")
+	text := b.arg(sq.Text)
+	b.where(text + " <% " + doc)
+	if sq.UserID != "" {
+		b.where("user_id = " + b.arg(sq.UserID))
+	}
+	q := fmt.Sprintf(`
+        SELECT %s, word_similarity(%s, %s) AS score
+        FROM %s.subscriptions
+        WHERE %s
+        ORDER BY score DESC, id`, subscriptionColumns, text, doc, r.schema, b.whereSQL())
+	if sq.Limit > 0 {
+		q += " LIMIT " + b.arg(sq.Limit)
+	}
+
+	rows, err := tx.Query(ctx, q, b.args...)
+	if err != nil {
+		r.logger.Printf("search failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]domain.SearchHit, 0)
+	for rows.Next() {
+		var h domain.SearchHit
+		if err := scanSub(rows, &h.Sub, &h.Score); err != nil {
+			r.logger.Printf("scan row failed: %v", err)
+			return nil, err
+		}
+		out = append(out, h)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Printf("search rows error: %v", err)
+		return nil, err
+	}
+	r.logger.Printf("search complete, count=%d", len(out))
+	return out, nil
+}
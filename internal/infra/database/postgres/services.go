@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
+
+// likeEscaper экранирует спецсимволы LIKE, чтобы префикс сравнивался буквально
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SuggestServices группирует подписки по ключу сервиса; условие LIKE 'prefix%' по lower(btrim(service_name))
+// совпадает с выражением индекса idx_subscriptions_tenant_service_prefix
+func (r *PGRepo) SuggestServices(ctx context.Context, prefix string, limit int) ([]domain.ServiceSuggestion, error) {
+	r.logger.Printf("suggesting services prefix=%q limit=%d", prefix, limit)
+
+	b := &queryBuilder{}
+	b.where("tenant_id = " + b.arg(domain.TenantOrDefault(ctx)))
+	b.where("deleted_at IS NULL")
+	b.where(`lower(btrim(service_name)) LIKE ` + b.arg(likeEscaper.Replace(domain.ServiceKey(prefix))+"%") + ` ESCAPE '\'`)
+	q := fmt.Sprintf(`
+        SELECT mode() WITHIN GROUP (ORDER BY service_name),
+               count(*),
+               percentile_disc(0.5) WITHIN GROUP (ORDER BY price)
+        FROM %s.subscriptions
+        WHERE %s
+        GROUP BY lower(btrim(service_name))
+        ORDER BY count(*) DESC, lower(btrim(service_name))`, r.schema, b.whereSQL())
+	if limit > 0 {
+		q += " LIMIT " + b.arg(limit)
+	}
+
+	rows, err := r.pool.Query(ctx, q, b.args...)
+	if err != nil {
+		r.logger.Printf("suggest services failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]domain.ServiceSuggestion, 0)
+	for rows.Next() {
+		var s domain.ServiceSuggestion
+		if err := rows.Scan(&s.Name, &s.Subscriptions, &s.TypicalPrice); err != nil {
+			r.logger.Printf("scan row failed: %v", err)
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Printf("rows error: %v", err)
+		return nil, err
+	}
+	r.logger.Printf("suggested %d services", len(out))
+	return out, nil
+}
@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+// SyncSubs выбирает изменения подписок и надгробия по (change_xid, id) — см. миграцию 000014.
+// Верхняя граница — xmin текущего снимка: все транзакции с меньшим номером уже завершены,
+// поэтому ни одна из них не появится позже позади выданного курсора.
+func (r *PGRepo) SyncSubs(ctx context.Context, q domain.SyncQuery) (domain.SyncPage, error) {
+	tenantID := domain.TenantOrDefault(ctx)
+	r.logger.Printf("syncing subscriptions after=%d/%s user=%s limit=%d...", q.After.Seq, q.After.ID, q.UserID, q.Limit)
+
+	var horizon string
+	if err := r.pool.QueryRow(ctx, `SELECT pg_snapshot_xmin(pg_current_snapshot())::text`).Scan(&horizon); err != nil {
+		r.logger.Printf("sync horizon failed: %v", err)
+		return domain.SyncPage{}, err
+	}
+	upto, err := strconv.ParseUint(horizon, 10, 64)
+	if err != nil {
+		return domain.SyncPage{}, fmt.Errorf("sync horizon %q: %w", horizon, err)
+	}
+
+	// обе выборки берут на одну строку больше лимита: после слияния станет ясно, есть ли ещё изменения
+	where := `tenant_id=$1 AND (change_xid, id) > ($2::text::xid8, $3) AND change_xid < $4::text::xid8 AND ($5 = '' OR user_id = $5)`
+	args := []any{tenantID, strconv.FormatUint(q.After.Seq, 10), q.After.ID, horizon, q.UserID}
+	limit := ""
+	if q.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", q.Limit+1)
+	}
+
+	// подписка в корзине — тоже надгробие; без q.Tombstones такие строки не выбираются
+	out := make([]domain.SyncChange, 0)
+	sql := fmt.Sprintf(`SELECT %s, change_xid::text FROM %s.subscriptions WHERE %s AND ($6 OR deleted_at IS NULL)
+		ORDER BY change_xid, id%s`, subscriptionColumns, r.schema, where, limit)
+	rows, err := r.pool.Query(ctx, sql, append(args, q.Tombstones)...)
+	if err != nil {
+		r.logger.Printf("sync failed: %v", err)
+		return domain.SyncPage{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c domain.SyncChange
+		var xid string
+		if err := scanSub(rows, &c.Sub, &xid); err != nil {
+			r.logger.Printf("scan row failed: %v", err)
+			return domain.SyncPage{}, err
+		}
+		if c.Seq, err = strconv.ParseUint(xid, 10, 64); err != nil {
+			return domain.SyncPage{}, err
+		}
+		if !c.Sub.DeletedAt.IsZero() {
+			c.Deleted = &domain.Tombstone{ID: c.Sub.ID, TenantID: c.Sub.TenantID, UserID: c.Sub.UserID, DeletedAt: c.Sub.DeletedAt}
+			c.Sub = domain.Subscription{}
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Printf("sync rows error: %v", err)
+		return domain.SyncPage{}, err
+	}
+
+	if q.Tombstones {
+		sql = fmt.Sprintf(`SELECT id, tenant_id, user_id, deleted_at, change_xid::text FROM %s.subscription_tombstones
+			WHERE %s ORDER BY change_xid, id%s`, r.schema, where, limit)
+		rows, err := r.pool.Query(ctx, sql, args...)
+		if err != nil {
+			r.logger.Printf("sync tombstones failed: %v", err)
+			return domain.SyncPage{}, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var t domain.Tombstone
+			var xid string
+			if err := rows.Scan(&t.ID, &t.TenantID, &t.UserID, &t.DeletedAt, &xid); err != nil {
+				r.logger.Printf("scan tombstone failed: %v", err)
+				return domain.SyncPage{}, err
+			}
+			seq, err := strconv.ParseUint(xid, 10, 64)
+			if err != nil {
+				return domain.SyncPage{}, err
+			}
+			out = append(out, domain.SyncChange{Seq: seq, Deleted: &t})
+		}
+		if err := rows.Err(); err != nil {
+			r.logger.Printf("sync tombstones rows error: %v", err)
+			return domain.SyncPage{}, err
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Cursor().Before(out[j].Cursor()) })
+
+	page := domain.SyncPage{Changes: out, Next: domain.SyncCursor{Seq: upto}}
+	if q.After.Seq >= upto {
+		page.Next = q.After
+	}
+	if q.Limit > 0 && len(out) > q.Limit {
+		page.Changes, page.HasMore = out[:q.Limit], true
+		page.Next = out[q.Limit-1].Cursor()
+	}
+	r.logger.Printf("sync complete, count=%d has_more=%t", len(page.Changes), page.HasMore)
+	return page, nil
+}
+
+// GetTombstone ищет и окончательно удалённые подписки, и лежащие в корзине
+func (r *PGRepo) GetTombstone(ctx context.Context, id string) (domain.Tombstone, error) {
+	return r.getTombstone(ctx, r.pool, id)
+}
+
+func (r *PGRepo) getTombstone(ctx context.Context, db querier, id string) (domain.Tombstone, error) {
+	q := fmt.Sprintf(`
+		SELECT id, tenant_id, user_id, deleted_at FROM %s.subscription_tombstones WHERE id=$1 AND tenant_id=$2
+		UNION ALL
+		SELECT id, tenant_id, user_id, deleted_at FROM %s.subscriptions WHERE id=$1 AND tenant_id=$2 AND deleted_at IS NOT NULL
+		LIMIT 1`, r.schema, r.schema)
+	var t domain.Tombstone
+	err := db.QueryRow(ctx, q, id, domain.TenantOrDefault(ctx)).Scan(&t.ID, &t.TenantID, &t.UserID, &t.DeletedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Tombstone{}, domain.ErrNotFound
+	}
+	if err != nil {
+		r.logger.Printf("get tombstone failed id=%s: %v", id, err)
+		return domain.Tombstone{}, err
+	}
+	return t, nil
+}
+
+func (r *PGRepo) PurgeTombstones(ctx context.Context, before time.Time) (int, error) {
+	q := fmt.Sprintf(`DELETE FROM %s.subscription_tombstones WHERE deleted_at < $1`, r.schema)
+	ct, err := r.pool.Exec(ctx, q, before)
+	if err != nil {
+		r.logger.Printf("purge tombstones failed: %v", err)
+		return 0, err
+	}
+	r.logger.Printf("purged %d tombstones", ct.RowsAffected())
+	return int(ct.RowsAffected()), nil
+}
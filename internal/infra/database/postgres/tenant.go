@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (r *PGRepo) AddTenant(ctx context.Context, t domain.Tenant) (domain.Tenant, error) {
+	r.logger.Printf("adding tenant name=%s", t.Name)
+	q := fmt.Sprintf(`
+		INSERT INTO %s.tenants (id, name)
+		VALUES ($1,$2)
+		RETURNING id, name, created_at`, r.schema)
+	var out domain.Tenant
+	err := r.pool.QueryRow(ctx, q, uuid.NewString(), t.Name).Scan(&out.ID, &out.Name, &out.CreatedAt)
+	if err != nil {
+		r.logger.Printf("add tenant failed: %v", err)
+		return out, err
+	}
+	r.logger.Printf("tenant added id=%s", out.ID)
+	return out, nil
+}
+
+func (r *PGRepo) GetTenant(ctx context.Context, id string) (domain.Tenant, error) {
+	q := fmt.Sprintf(`SELECT id, name, created_at FROM %s.tenants WHERE id=$1`, r.schema)
+	var t domain.Tenant
+	err := r.pool.QueryRow(ctx, q, id).Scan(&t.ID, &t.Name, &t.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Tenant{}, domain.ErrTenantNotFound
+	}
+	if err != nil {
+		r.logger.Printf("get tenant failed id=%s: %v", id, err)
+		return domain.Tenant{}, err
+	}
+	return t, nil
+}
+
+func (r *PGRepo) ListTenants(ctx context.Context) ([]domain.Tenant, error) {
+	q := fmt.Sprintf(`SELECT id, name, created_at FROM %s.tenants ORDER BY created_at, id`, r.schema)
+	rows, err := r.pool.Query(ctx, q)
+	if err != nil {
+		r.logger.Printf("list tenants failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	var out []domain.Tenant
+	for rows.Next() {
+		var t domain.Tenant
+		if err := rows.Scan(&t.ID, &t.Name, &t.CreatedAt); err != nil {
+			r.logger.Printf("scan tenant failed: %v", err)
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// isFKViolation — нарушение внешнего ключа (SQLSTATE 23503)
+func isFKViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
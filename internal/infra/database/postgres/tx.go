@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/jackc/pgx/v5"
+)
+
+// InTx открывает транзакцию и передаёт fn её представление. Каждая операция выполняется
+// в своей точке сохранения (SAVEPOINT), поэтому её ошибка не переводит транзакцию в состояние aborted.
+func (r *PGRepo) InTx(ctx context.Context, fn func(tx domain.SubscriptionTx) error) error {
+	r.logger.Println("begin transaction")
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		r.logger.Printf("begin failed: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&pgSubTx{r: r, tx: tx}); err != nil {
+		r.logger.Printf("transaction rolled back: %v", err)
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Printf("commit failed: %v", err)
+		return err
+	}
+	r.logger.Println("transaction committed")
+	return nil
+}
+
+type pgSubTx struct {
+	r  *PGRepo
+	tx pgx.Tx
+}
+
+func (t *pgSubTx) GetSub(ctx context.Context, id string) (domain.Subscription, error) {
+	return t.r.getSub(ctx, t.tx, id)
+}
+
+func (t *pgSubTx) GetTombstone(ctx context.Context, id string) (domain.Tombstone, error) {
+	return t.r.getTombstone(ctx, t.tx, id)
+}
+
+func (t *pgSubTx) AddSub(ctx context.Context, s domain.Subscription) (domain.Subscription, error) {
+	return atomically(ctx, t.tx, func(q querier) (domain.Subscription, error) {
+		return t.r.addSub(ctx, q, s)
+	})
+}
+
+func (t *pgSubTx) UpdateSub(ctx context.Context, s domain.Subscription, version int) (domain.Subscription, error) {
+	return atomically(ctx, t.tx, func(q querier) (domain.Subscription, error) {
+		return t.r.updateSub(ctx, q, s, version)
+	})
+}
+
+func (t *pgSubTx) DeleteSub(ctx context.Context, id string, version int) (domain.Subscription, error) {
+	return atomically(ctx, t.tx, func(q querier) (domain.Subscription, error) {
+		return t.r.deleteSub(ctx, q, id, version)
+	})
+}
+
+// atomically выполняет fn в транзакции: на пуле — в новой, внутри транзакции — во вложенной
+// (SAVEPOINT / RELEASE / ROLLBACK TO). Ошибка fn откатывает все её запросы.
+func atomically[T any](ctx context.Context, db querier, fn func(q querier) (T, error)) (T, error) {
+	sp, err := db.Begin(ctx)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	defer sp.Rollback(ctx)
+
+	out, err := fn(sp)
+	if err != nil {
+		return out, err
+	}
+	return out, sp.Commit(ctx)
+}
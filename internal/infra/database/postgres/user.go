@@ -0,0 +1,249 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const userColumns = `id, tenant_id, display_name, COALESCE(email, ''), currency, locale, timezone, created_at, updated_at`
+
+func scanUser(row pgx.Row, u *domain.User) error {
+	return row.Scan(&u.ID, &u.TenantID, &u.DisplayName, &u.Email, &u.Currency, &u.Locale, &u.Timezone, &u.CreatedAt, &u.UpdatedAt)
+}
+
+func (r *PGRepo) AddUser(ctx context.Context, u domain.User) (domain.User, error) {
+	tenantID := domain.TenantOrDefault(ctx)
+	r.logger.Printf("adding user tenant=%s name=%s", tenantID, u.DisplayName)
+	q := fmt.Sprintf(`
+		INSERT INTO %s.users (id, tenant_id, display_name, email, currency, locale, timezone)
+		VALUES ($1,$2,$3,NULLIF($4,''),$5,$6,$7)
+		RETURNING %s`, r.schema, userColumns)
+	var out domain.User
+	err := scanUser(r.pool.QueryRow(ctx, q, uuid.NewString(), tenantID, u.DisplayName, u.Email, u.Currency, u.Locale, u.Timezone), &out)
+	if err != nil {
+		r.logger.Printf("add user failed: %v", err)
+		if isUniqueViolation(err) {
+			return out, domain.ErrEmailTaken
+		}
+		if isFKViolation(err) {
+			return out, domain.ErrTenantNotFound
+		}
+		return out, err
+	}
+	r.logger.Printf("user added id=%s", out.ID)
+	return out, nil
+}
+
+func (r *PGRepo) UpdateUser(ctx context.Context, u domain.User) (domain.User, error) {
+	r.logger.Printf("updating user id=%s", u.ID)
+	q := fmt.Sprintf(`
+		UPDATE %s.users
+		SET display_name=$3, email=NULLIF($4,''), currency=$5, locale=$6, timezone=$7, updated_at=now()
+		WHERE id=$1 AND tenant_id=$2
+		RETURNING %s`, r.schema, userColumns)
+	var out domain.User
+	err := scanUser(r.pool.QueryRow(ctx, q, u.ID, domain.TenantOrDefault(ctx), u.DisplayName, u.Email, u.Currency, u.Locale, u.Timezone), &out)
+	if errors.Is(err, pgx.ErrNoRows) {
+		r.logger.Printf("update: user not found id=%s", u.ID)
+		return out, domain.ErrUserNotFound
+	}
+	if err != nil {
+		r.logger.Printf("update user failed id=%s: %v", u.ID, err)
+		if isUniqueViolation(err) {
+			return out, domain.ErrEmailTaken
+		}
+		return out, err
+	}
+	r.logger.Printf("user updated id=%s", u.ID)
+	return out, nil
+}
+
+func (r *PGRepo) DeleteUser(ctx context.Context, id string, cascade bool) ([]domain.Subscription, error) {
+	tenantID := domain.TenantOrDefault(ctx)
+	r.logger.Printf("deleting user id=%s cascade=%t", id, cascade)
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// блокируем пользователя, чтобы параллельно не появилась новая подписка
+	var exists bool
+	q := fmt.Sprintf(`SELECT TRUE FROM %s.users WHERE id=$1 AND tenant_id=$2 FOR UPDATE`, r.schema)
+	if err := tx.QueryRow(ctx, q, id, tenantID).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			r.logger.Printf("delete: user not found id=%s", id)
+			return nil, domain.ErrUserNotFound
+		}
+		r.logger.Printf("delete user failed id=%s: %v", id, err)
+		return nil, err
+	}
+
+	// при cascade живые подписки удаляются так же, как DeleteSub: с записью в журнале
+	var deleted []domain.Subscription
+	if cascade {
+		if deleted, err = r.deleteUserSubs(ctx, tx, id); err != nil {
+			r.logger.Printf("delete user subscriptions failed id=%s: %v", id, err)
+			return nil, err
+		}
+	}
+
+	// корзина не мешает удалить пользователя: восстановить подписку без владельца нельзя
+	q = fmt.Sprintf(`DELETE FROM %s.subscriptions WHERE user_id=$1 AND tenant_id=$2 AND deleted_at IS NOT NULL`, r.schema)
+	ct, err := tx.Exec(ctx, q, id, tenantID)
+	if err != nil {
+		r.logger.Printf("purge user trash failed id=%s: %v", id, err)
+		return nil, err
+	}
+	r.logger.Printf("purged %d trashed subscriptions of user id=%s", ct.RowsAffected(), id)
+
+	q = fmt.Sprintf(`DELETE FROM %s.users WHERE id=$1 AND tenant_id=$2`, r.schema)
+	if _, err := tx.Exec(ctx, q, id, tenantID); err != nil {
+		r.logger.Printf("delete user failed id=%s: %v", id, err)
+		if isFKViolation(err) {
+			return nil, domain.ErrUserHasSubscriptions
+		}
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	r.logger.Printf("user deleted id=%s subscriptions=%d", id, len(deleted))
+	return deleted, nil
+}
+
+// deleteUserSubs переносит в корзину все живые подписки пользователя через deleteSub
+func (r *PGRepo) deleteUserSubs(ctx context.Context, db querier, userID string) ([]domain.Subscription, error) {
+	q := fmt.Sprintf(`SELECT id FROM %s.subscriptions WHERE user_id=$1 AND tenant_id=$2 AND deleted_at IS NULL ORDER BY id`, r.schema)
+	rows, err := db.Query(ctx, q, userID, domain.TenantOrDefault(ctx))
+	if err != nil {
+		return nil, err
+	}
+	// id читаются целиком до удаления: пока строки открыты, соединение занято
+	var ids []string
+	for rows.Next() {
+		var subID string
+		if err := rows.Scan(&subID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, subID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	out := make([]domain.Subscription, 0, len(ids))
+	for _, subID := range ids {
+		sub, err := r.deleteSub(ctx, db, subID, domain.AnyVersion)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sub)
+	}
+	return out, nil
+}
+
+func (r *PGRepo) GetUser(ctx context.Context, id string) (domain.User, error) {
+	q := fmt.Sprintf(`SELECT %s FROM %s.users WHERE id=$1 AND tenant_id=$2`, userColumns, r.schema)
+	var u domain.User
+	err := scanUser(r.pool.QueryRow(ctx, q, id, domain.TenantOrDefault(ctx)), &u)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+	if err != nil {
+		r.logger.Printf("get user failed id=%s: %v", id, err)
+		return domain.User{}, err
+	}
+	return u, nil
+}
+
+func (r *PGRepo) ListUsers(ctx context.Context) ([]domain.User, error) {
+	q := fmt.Sprintf(`SELECT %s FROM %s.users WHERE tenant_id=$1 ORDER BY created_at, id`, userColumns, r.schema)
+	rows, err := r.pool.Query(ctx, q, domain.TenantOrDefault(ctx))
+	if err != nil {
+		r.logger.Printf("list users failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]domain.User, 0)
+	for rows.Next() {
+		var u domain.User
+		if err := scanUser(rows, &u); err != nil {
+			r.logger.Printf("scan user failed: %v", err)
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+func (r *PGRepo) UsersByIDs(ctx context.Context, ids []string) ([]domain.User, error) {
+	q := fmt.Sprintf(`SELECT %s FROM %s.users WHERE tenant_id=$1 AND id = ANY($2)`, userColumns, r.schema)
+	rows, err := r.pool.Query(ctx, q, domain.TenantOrDefault(ctx), ids)
+	if err != nil {
+		r.logger.Printf("users by ids failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]domain.User, 0, len(ids))
+	for rows.Next() {
+		var u domain.User
+		if err := scanUser(rows, &u); err != nil {
+			r.logger.Printf("scan user failed: %v", err)
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+func (r *PGRepo) SetCalendarToken(ctx context.Context, id, tokenHash string) error {
+	r.logger.Printf("setting calendar token user=%s revoke=%t", id, tokenHash == "")
+	q := fmt.Sprintf(`UPDATE %s.users SET calendar_token_hash=NULLIF($3,''), updated_at=now() WHERE id=$1 AND tenant_id=$2`, r.schema)
+	ct, err := r.pool.Exec(ctx, q, id, domain.TenantOrDefault(ctx), tokenHash)
+	if err != nil {
+		r.logger.Printf("set calendar token failed user=%s: %v", id, err)
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *PGRepo) UserByCalendarToken(ctx context.Context, tokenHash string) (domain.User, error) {
+	q := fmt.Sprintf(`SELECT %s FROM %s.users WHERE calendar_token_hash=$1`, userColumns, r.schema)
+	var u domain.User
+	err := scanUser(r.pool.QueryRow(ctx, q, tokenHash), &u)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.User{}, domain.ErrUserNotFound
+	}
+	if err != nil {
+		r.logger.Printf("get user by calendar token failed: %v", err)
+		return domain.User{}, err
+	}
+	return u, nil
+}
+
+// isUniqueViolation — нарушение уникальности (SQLSTATE 23505)
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// fkConstraint возвращает имя нарушенного внешнего ключа или пустую строку
+func fkConstraint(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return pgErr.ConstraintName
+	}
+	return ""
+}
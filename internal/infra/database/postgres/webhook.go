@@ -0,0 +1,224 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const webhookColumns = `id, tenant_id, url, secret, events, created_at`
+
+func scanWebhook(row pgx.Row, wh *domain.Webhook) error {
+	return row.Scan(&wh.ID, &wh.TenantID, &wh.URL, &wh.Secret, &wh.Events, &wh.CreatedAt)
+}
+
+const deliveryColumns = `id, tenant_id, webhook_id, event_id, event, payload, status, attempts, next_attempt_at,
+	COALESCE(last_status_code, 0), COALESCE(last_error, ''), created_at, updated_at`
+
+func scanDelivery(row pgx.Row, d *domain.WebhookDelivery) error {
+	return row.Scan(&d.ID, &d.TenantID, &d.WebhookID, &d.EventID, &d.Event, &d.Payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.UpdatedAt)
+}
+
+func collectDeliveries(rows pgx.Rows) ([]domain.WebhookDelivery, error) {
+	defer rows.Close()
+	out := make([]domain.WebhookDelivery, 0)
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		if err := scanDelivery(rows, &d); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (r *PGRepo) AddWebhook(ctx context.Context, wh domain.Webhook) (domain.Webhook, error) {
+	tenantID := domain.TenantOrDefault(ctx)
+	r.logger.Printf("adding webhook tenant=%s url=%s", tenantID, wh.URL)
+	q := fmt.Sprintf(`
+		INSERT INTO %s.webhooks (id, tenant_id, url, secret, events)
+		VALUES ($1,$2,$3,$4,$5)
+		RETURNING %s`, r.schema, webhookColumns)
+	var out domain.Webhook
+	err := scanWebhook(r.pool.QueryRow(ctx, q, uuid.NewString(), tenantID, wh.URL, wh.Secret, wh.Events), &out)
+	if err != nil {
+		r.logger.Printf("add webhook failed: %v", err)
+		if isFKViolation(err) {
+			return out, domain.ErrTenantNotFound
+		}
+		return out, err
+	}
+	r.logger.Printf("webhook added id=%s", out.ID)
+	return out, nil
+}
+
+func (r *PGRepo) GetWebhook(ctx context.Context, id string) (domain.Webhook, error) {
+	q := fmt.Sprintf(`SELECT %s FROM %s.webhooks WHERE id=$1 AND tenant_id=$2`, webhookColumns, r.schema)
+	var wh domain.Webhook
+	err := scanWebhook(r.pool.QueryRow(ctx, q, id, domain.TenantOrDefault(ctx)), &wh)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Webhook{}, domain.ErrWebhookNotFound
+	}
+	if err != nil {
+		r.logger.Printf("get webhook failed id=%s: %v", id, err)
+		return domain.Webhook{}, err
+	}
+	return wh, nil
+}
+
+func (r *PGRepo) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	q := fmt.Sprintf(`SELECT %s FROM %s.webhooks WHERE tenant_id=$1 ORDER BY created_at, id`, webhookColumns, r.schema)
+	rows, err := r.pool.Query(ctx, q, domain.TenantOrDefault(ctx))
+	if err != nil {
+		r.logger.Printf("list webhooks failed: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+	out := make([]domain.Webhook, 0)
+	for rows.Next() {
+		var wh domain.Webhook
+		if err := scanWebhook(rows, &wh); err != nil {
+			r.logger.Printf("scan webhook failed: %v", err)
+			return nil, err
+		}
+		out = append(out, wh)
+	}
+	return out, rows.Err()
+}
+
+func (r *PGRepo) DeleteWebhook(ctx context.Context, id string) error {
+	r.logger.Printf("deleting webhook id=%s", id)
+	// журнал доставок удаляется каскадом
+	q := fmt.Sprintf(`DELETE FROM %s.webhooks WHERE id=$1 AND tenant_id=$2`, r.schema)
+	ct, err := r.pool.Exec(ctx, q, id, domain.TenantOrDefault(ctx))
+	if err != nil {
+		r.logger.Printf("delete webhook failed id=%s: %v", id, err)
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return domain.ErrWebhookNotFound
+	}
+	r.logger.Printf("webhook deleted id=%s", id)
+	return nil
+}
+
+func (r *PGRepo) EnqueueDeliveries(ctx context.Context, event string, events []domain.WebhookEvent) (int, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+	ids, payloads := make([]string, len(events)), make([][]byte, len(events))
+	for i, e := range events {
+		ids[i], payloads[i] = e.ID, e.Payload
+	}
+	q := fmt.Sprintf(`
+		INSERT INTO %[1]s.webhook_deliveries (id, tenant_id, webhook_id, event_id, event, payload)
+		SELECT gen_random_uuid()::text, w.tenant_id, w.id, e.id, $2, e.payload
+		FROM %[1]s.webhooks w, unnest($3::text[], $4::bytea[]) AS e(id, payload)
+		WHERE w.tenant_id=$1 AND $2 = ANY(w.events)`, r.schema)
+	ct, err := r.pool.Exec(ctx, q, domain.TenantOrDefault(ctx), event, ids, payloads)
+	if err != nil {
+		r.logger.Printf("enqueue deliveries failed event=%s: %v", event, err)
+		return 0, err
+	}
+	return int(ct.RowsAffected()), nil
+}
+
+func (r *PGRepo) ListDeliveries(ctx context.Context, webhookID string, limit int) ([]domain.WebhookDelivery, error) {
+	if _, err := r.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	q := fmt.Sprintf(`
+		SELECT %s FROM %s.webhook_deliveries
+		WHERE webhook_id=$1 AND tenant_id=$2
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3`, deliveryColumns, r.schema)
+	rows, err := r.pool.Query(ctx, q, webhookID, domain.TenantOrDefault(ctx), limit)
+	if err != nil {
+		r.logger.Printf("list deliveries failed webhook=%s: %v", webhookID, err)
+		return nil, err
+	}
+	return collectDeliveries(rows)
+}
+
+func (r *PGRepo) GetDelivery(ctx context.Context, webhookID, deliveryID string) (domain.WebhookDelivery, error) {
+	if _, err := r.GetWebhook(ctx, webhookID); err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	q := fmt.Sprintf(`
+		SELECT %s FROM %s.webhook_deliveries
+		WHERE id=$1 AND webhook_id=$2 AND tenant_id=$3`, deliveryColumns, r.schema)
+	var d domain.WebhookDelivery
+	err := scanDelivery(r.pool.QueryRow(ctx, q, deliveryID, webhookID, domain.TenantOrDefault(ctx)), &d)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.WebhookDelivery{}, domain.ErrDeliveryNotFound
+	}
+	if err != nil {
+		r.logger.Printf("get delivery failed id=%s: %v", deliveryID, err)
+		return domain.WebhookDelivery{}, err
+	}
+	return d, nil
+}
+
+func (r *PGRepo) RedeliverWebhook(ctx context.Context, webhookID, deliveryID string) (domain.WebhookDelivery, error) {
+	src, err := r.GetDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	r.logger.Printf("redelivering webhook=%s delivery=%s", webhookID, deliveryID)
+	q := fmt.Sprintf(`
+		INSERT INTO %s.webhook_deliveries (id, tenant_id, webhook_id, event_id, event, payload)
+		VALUES ($1,$2,$3,$4,$5,$6)
+		RETURNING %s`, r.schema, deliveryColumns)
+	var d domain.WebhookDelivery
+	err = scanDelivery(r.pool.QueryRow(ctx, q, uuid.NewString(), src.TenantID, src.WebhookID, src.EventID, src.Event, src.Payload), &d)
+	if err != nil {
+		r.logger.Printf("redeliver failed delivery=%s: %v", deliveryID, err)
+		if isFKViolation(err) {
+			return domain.WebhookDelivery{}, domain.ErrWebhookNotFound
+		}
+		return domain.WebhookDelivery{}, err
+	}
+	return d, nil
+}
+
+func (r *PGRepo) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	// SKIP LOCKED: несколько экземпляров разбирают очередь, не отправляя одну доставку дважды
+	q := fmt.Sprintf(`
+		UPDATE %[1]s.webhook_deliveries d
+		SET next_attempt_at=$2
+		WHERE d.id IN (
+			SELECT id FROM %[1]s.webhook_deliveries
+			WHERE status='pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at, created_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED)
+		RETURNING %[2]s`, r.schema, deliveryColumns)
+	rows, err := r.pool.Query(ctx, q, now, now.Add(lease), limit)
+	if err != nil {
+		r.logger.Printf("claim deliveries failed: %v", err)
+		return nil, err
+	}
+	return collectDeliveries(rows)
+}
+
+func (r *PGRepo) UpdateDelivery(ctx context.Context, d domain.WebhookDelivery) error {
+	q := fmt.Sprintf(`
+		UPDATE %s.webhook_deliveries
+		SET status=$2, attempts=$3, next_attempt_at=$4, last_status_code=NULLIF($5, 0), last_error=NULLIF($6, ''), updated_at=now()
+		WHERE id=$1`, r.schema)
+	ct, err := r.pool.Exec(ctx, q, d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastStatusCode, d.LastError)
+	if err != nil {
+		r.logger.Printf("update delivery failed id=%s: %v", d.ID, err)
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return domain.ErrDeliveryNotFound
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package anomaly
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
+
+// Detector — фоновая задача: строит помесячные ряды по подпискам
+// и сравнивает каждый месяц со скользящим средним предыдущих Window месяцев.
+type Detector struct {
+	Log  *log.Logger
+	Subs interface {
+		ListSubs(ctx context.Context, f domain.SubscriptionFilter) (domain.SubscriptionPage, error)
+	}
+	Store   domain.AnomalyRepository
+	Tenants interface {
+		ListTenants(ctx context.Context) ([]domain.Tenant, error)
+	}
+	Window    int           // сколько предыдущих месяцев входит в среднее
+	Threshold float64       // во сколько раз месяц должен превысить среднее
+	Lookback  int           // сколько последних месяцев анализировать
+	Interval  time.Duration // период запуска
+	Now       func() time.Time
+}
+
+// Run запускает анализ сразу и затем раз в Interval, пока не отменён ctx
+func (d *Detector) Run(ctx context.Context) {
+	d.Log.Printf("started, interval=%s window=%d threshold=%.2f", d.Interval, d.Window, d.Threshold)
+
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.RunOnce(ctx); err != nil {
+			d.Log.Printf("run failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			d.Log.Println("stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce выполняет один проход анализа по всем тенантам и сохраняет найденные аномалии
+func (d *Detector) RunOnce(ctx context.Context) ([]domain.Anomaly, error) {
+	tenants, err := d.Tenants.ListTenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []domain.Anomaly
+	for _, t := range tenants {
+		found, err := d.runTenant(domain.WithTenant(ctx, t.ID))
+		if err != nil {
+			return all, fmt.Errorf("tenant %s: %w", t.ID, err)
+		}
+		all = append(all, found...)
+	}
+	return all, nil
+}
+
+// runTenant анализирует подписки тенанта из контекста
+func (d *Detector) runTenant(ctx context.Context) ([]domain.Anomaly, error) {
+	now := time.Now()
+	if d.Now != nil {
+		now = d.Now()
+	}
+
+	page, err := d.Subs.ListSubs(ctx, domain.SubscriptionFilter{})
+	if err != nil {
+		return nil, err
+	}
+	subs := page.Subs
+
+	to := monthOf(now)
+	from := to.AddDate(0, -(d.Lookback - 1), 0)
+
+	found := Detect(subs, from, to, d.Window, d.Threshold)
+	for i := range found {
+		found[i].DetectedAt = now
+	}
+
+	// пустой результат тоже записывается: аномалии, которые больше не подтверждаются, удаляются
+	if err := d.Store.ReplaceAnomalies(ctx, from, to, found); err != nil {
+		return nil, err
+	}
+	d.Log.Printf("analysis complete, tenant=%s subs=%d anomalies=%d", domain.TenantOrDefault(ctx), len(subs), len(found))
+	return found, nil
+}
+
+// Detect ищет аномалии в месяцах [from, to].
+// Подписка считается активной в месяце m, если start_date <= m и end_date >= m —
+// так же, как TotalCost считает период из одного месяца.
+// Для сервиса сравнивается средняя цена активных подписок, для пользователя — сумма его трат.
+func Detect(subs []domain.Subscription, from, to time.Time, window int, threshold float64) []domain.Anomaly {
+	if window <= 0 || threshold <= 0 {
+		return nil
+	}
+	from, to = monthOf(from), monthOf(to)
+	if to.Before(from) {
+		return nil
+	}
+
+	// ряд начинается раньше from, чтобы у первого месяца было полное окно
+	start := from.AddDate(0, -window, 0)
+	var months []time.Time
+	for m := start; !m.After(to); m = m.AddDate(0, 1, 0) {
+		months = append(months, m)
+	}
+
+	svcSum := map[string][]int{}
+	svcCnt := map[string][]int{}
+	userSum := map[string][]int{}
+	userCnt := map[string][]int{}
+
+	for _, s := range subs {
+		for i, m := range months {
+			if s.StartDate.After(m) || s.EndDate.Before(m) {
+				continue
+			}
+			add(svcSum, s.ServiceName, i, len(months), s.Price)
+			add(svcCnt, s.ServiceName, i, len(months), 1)
+			add(userSum, s.UserID, i, len(months), s.Price)
+			add(userCnt, s.UserID, i, len(months), 1)
+		}
+	}
+
+	var out []domain.Anomaly
+	for svc, sums := range svcSum {
+		avg := make([]int, len(sums))
+		for i := range sums {
+			if c := svcCnt[svc][i]; c > 0 {
+				avg[i] = int(math.Round(float64(sums[i]) / float64(c)))
+			}
+		}
+		out = append(out, scan(domain.AnomalyServicePrice, svc, months, avg, svcCnt[svc], window, threshold)...)
+	}
+	for user, sums := range userSum {
+		out = append(out, scan(domain.AnomalyUserSpend, user, months, sums, userCnt[user], window, threshold)...)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Month.Equal(out[j].Month) {
+			return out[i].Month.Before(out[j].Month)
+		}
+		if out[i].Kind != out[j].Kind {
+			return out[i].Kind < out[j].Kind
+		}
+		return out[i].Subject < out[j].Subject
+	})
+	return out
+}
+
+// scan проходит по ряду и сравнивает каждый месяц со средним предыдущих window месяцев.
+// Месяцы без активных подписок в среднее не входят; если таких месяцев в окне нет — сравнивать не с чем.
+func scan(kind domain.AnomalyKind, subject string, months []time.Time, values, counts []int, window int, threshold float64) []domain.Anomaly {
+	var out []domain.Anomaly
+	for i := window; i < len(months); i++ {
+		if counts[i] == 0 {
+			continue
+		}
+		sum, n := 0, 0
+		for j := i - window; j < i; j++ {
+			if counts[j] > 0 {
+				sum += values[j]
+				n++
+			}
+		}
+		if n < window || sum == 0 {
+			continue
+		}
+		baseline := float64(sum) / float64(n)
+		ratio := float64(values[i]) / baseline
+		sev, ok := severity(ratio, threshold)
+		if !ok {
+			continue
+		}
+		out = append(out, domain.Anomaly{
+			Kind:     kind,
+			Subject:  subject,
+			Month:    months[i],
+			Amount:   values[i],
+			Baseline: math.Round(baseline*100) / 100,
+			Ratio:    math.Round(ratio*100) / 100,
+			Severity: sev,
+		})
+	}
+	return out
+}
+
+// severity: low — от threshold, medium — от 1.5×threshold, high — от 2×threshold
+func severity(ratio, threshold float64) (domain.Severity, bool) {
+	switch {
+	case ratio >= 2*threshold:
+		return domain.SeverityHigh, true
+	case ratio >= 1.5*threshold:
+		return domain.SeverityMedium, true
+	case ratio >= threshold:
+		return domain.SeverityLow, true
+	}
+	return "", false
+}
+
+func add(m map[string][]int, key string, i, n, v int) {
+	row, ok := m[key]
+	if !ok {
+		row = make([]int, n)
+		m[key] = row
+	}
+	row[i] += v
+}
+
+func monthOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
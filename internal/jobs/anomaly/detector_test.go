@@ -0,0 +1,128 @@
+package anomaly
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	mockrepo "github.com/EgorLis/my-subs/internal/infra/database/mock"
+	"github.com/google/uuid"
+)
+
+func month(mm, yyyy int) time.Time {
+	return time.Date(yyyy, time.Month(mm), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func sub(service, user string, price int, from, to time.Time) domain.Subscription {
+	return domain.Subscription{ServiceName: service, UserID: user, Price: price, StartDate: from, EndDate: to}
+}
+
+func TestDetect_ServicePriceJump(t *testing.T) {
+	u1, u2 := uuid.NewString(), uuid.NewString()
+	subs := []domain.Subscription{
+		// цена держится 4 месяца, затем вырастает в 2 раза
+		sub("Netflix", u1, 500, month(1, 2025), month(4, 2025)),
+		sub("Netflix", u1, 1000, month(5, 2025), month(6, 2025)),
+		// второй подписчик не меняет среднюю цену до мая
+		sub("Netflix", u2, 500, month(1, 2025), month(6, 2025)),
+	}
+
+	got := Detect(subs, month(4, 2025), month(6, 2025), 3, 1.5)
+
+	var svc []domain.Anomaly
+	for _, a := range got {
+		if a.Kind == domain.AnomalyServicePrice {
+			svc = append(svc, a)
+		}
+	}
+	// май: средняя (1000+500)/2=750 против 500 → ratio 1.5 → low
+	if len(svc) != 1 {
+		t.Fatalf("want 1 service anomaly, got %d: %+v", len(svc), svc)
+	}
+	if !svc[0].Month.Equal(month(5, 2025)) || svc[0].Subject != "Netflix" || svc[0].Severity != domain.SeverityLow {
+		t.Fatalf("unexpected anomaly: %+v", svc[0])
+	}
+}
+
+func TestDetect_UserSpendSeverity(t *testing.T) {
+	u := uuid.NewString()
+	subs := []domain.Subscription{
+		sub("A", u, 100, month(1, 2025), month(12, 2025)),
+		sub("B", u, 200, month(4, 2025), month(4, 2025)), // 300 против 100 → ratio 3 → high
+	}
+
+	got := Detect(subs, month(4, 2025), month(4, 2025), 3, 1.5)
+
+	var user []domain.Anomaly
+	for _, a := range got {
+		if a.Kind == domain.AnomalyUserSpend {
+			user = append(user, a)
+		}
+	}
+	if len(user) != 1 {
+		t.Fatalf("want 1 user anomaly, got %d: %+v", len(user), user)
+	}
+	if user[0].Amount != 300 || user[0].Baseline != 100 || user[0].Severity != domain.SeverityHigh {
+		t.Fatalf("unexpected anomaly: %+v", user[0])
+	}
+}
+
+func TestDetect_NoHistoryNoAnomaly(t *testing.T) {
+	u := uuid.NewString()
+	subs := []domain.Subscription{
+		sub("A", u, 100, month(3, 2025), month(12, 2025)),
+		sub("B", u, 900, month(4, 2025), month(4, 2025)),
+	}
+
+	// до марта подписок нет — окно из 3 месяцев не заполнено
+	if got := Detect(subs, month(4, 2025), month(4, 2025), 3, 1.5); len(got) != 0 {
+		t.Fatalf("want no anomalies, got %+v", got)
+	}
+}
+
+func TestDetector_RunOnceStores(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	ctx := context.Background()
+	user, _ := repo.AddUser(ctx, domain.User{DisplayName: "Test"})
+	u := user.ID
+	_, _ = repo.AddSub(ctx, sub("A", u, 100, month(1, 2025), month(12, 2025)))
+	spike, _ := repo.AddSub(ctx, sub("B", u, 200, month(6, 2025), month(6, 2025)))
+
+	d := &Detector{
+		Log: log.New(io.Discard, "", 0), Subs: repo, Store: repo, Tenants: repo,
+		Window: 3, Threshold: 1.5, Lookback: 12,
+		Now: func() time.Time { return time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC) },
+	}
+
+	// повторный запуск не должен плодить дубликаты
+	for i := 0; i < 2; i++ {
+		if _, err := d.RunOnce(ctx); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	}
+
+	list, _ := repo.ListAnomalies(ctx, domain.AnomalyFilter{Kind: domain.AnomalyUserSpend})
+	if len(list) != 1 || !list[0].Month.Equal(month(6, 2025)) {
+		t.Fatalf("want one stored anomaly for 06-2025, got %+v", list)
+	}
+
+	// всплеск оказался ошибкой: после удаления подписки аномалия за 06-2025 исчезает,
+	// а записи вне окна анализа не трогаются
+	old := domain.Anomaly{Kind: domain.AnomalyUserSpend, Subject: u, Month: month(1, 2024), Amount: 900, Baseline: 100, Ratio: 9, Severity: domain.SeverityHigh}
+	if err := repo.ReplaceAnomalies(ctx, month(1, 2024), month(1, 2024), []domain.Anomaly{old}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.DeleteSub(ctx, spike.ID, domain.AnyVersion); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.RunOnce(ctx); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	list, _ = repo.ListAnomalies(ctx, domain.AnomalyFilter{Kind: domain.AnomalyUserSpend})
+	if len(list) != 1 || !list[0].Month.Equal(month(1, 2024)) {
+		t.Fatalf("want only the anomaly outside lookback kept, got %+v", list)
+	}
+}
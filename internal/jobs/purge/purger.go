@@ -0,0 +1,73 @@
+package purge
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Purger — фоновая задача: периодически удаляет устаревшие служебные данные
+type Purger struct {
+	Log  *log.Logger
+	Keys interface {
+		PurgeIdempotencyKeys(ctx context.Context, now time.Time) (int, error)
+	}
+	Tombstones interface {
+		PurgeTombstones(ctx context.Context, before time.Time) (int, error)
+	}
+	Trash interface {
+		PurgeTrash(ctx context.Context, before time.Time) (int, error)
+	}
+	TombstoneTTL   time.Duration // сколько хранятся надгробия удалённых подписок; 0 — не удаляются
+	TrashRetention time.Duration // сколько подписка лежит в корзине до окончательного удаления; 0 — не удаляется
+	Interval       time.Duration // период запуска
+	Now            func() time.Time
+}
+
+// Run запускает очистку сразу и затем раз в Interval, пока не отменён ctx
+func (p *Purger) Run(ctx context.Context) {
+	p.Log.Printf("started, interval=%s", p.Interval)
+
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.RunOnce(ctx); err != nil {
+			p.Log.Printf("run failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			p.Log.Println("stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce выполняет один проход очистки
+func (p *Purger) RunOnce(ctx context.Context) error {
+	now := time.Now()
+	if p.Now != nil {
+		now = p.Now()
+	}
+
+	keys, err := p.Keys.PurgeIdempotencyKeys(ctx, now)
+	if err != nil {
+		return err
+	}
+	// корзина чистится первой: окончательно удалённые подписки оставляют надгробия
+	trashed := 0
+	if p.Trash != nil && p.TrashRetention > 0 {
+		if trashed, err = p.Trash.PurgeTrash(ctx, now.Add(-p.TrashRetention)); err != nil {
+			return err
+		}
+	}
+	tombstones := 0
+	if p.Tombstones != nil && p.TombstoneTTL > 0 {
+		if tombstones, err = p.Tombstones.PurgeTombstones(ctx, now.Add(-p.TombstoneTTL)); err != nil {
+			return err
+		}
+	}
+	p.Log.Printf("purge complete, idempotency_keys=%d trash=%d tombstones=%d", keys, trashed, tombstones)
+	return nil
+}
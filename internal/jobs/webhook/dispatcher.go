@@ -0,0 +1,164 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
+
+const (
+	claimBatch = 50              // сколько доставок забирается за один запрос
+	claimLease = 2 * time.Minute // на сколько забранная доставка скрывается от других экземпляров
+	maxBackoff = time.Hour       // предел паузы между попытками
+	maxError   = 512             // сколько символов ошибки сохраняется в журнале
+)
+
+// Dispatcher — фоновая задача: отправляет доставки из очереди и повторяет неудачные
+// с экспоненциальной паузой Backoff, 2·Backoff, 4·Backoff… (не больше часа)
+type Dispatcher struct {
+	Log  *log.Logger
+	Repo interface {
+		GetWebhook(ctx context.Context, id string) (domain.Webhook, error)
+		ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error)
+		UpdateDelivery(ctx context.Context, d domain.WebhookDelivery) error
+	}
+	Client      *http.Client  // с таймаутом на одну попытку
+	Interval    time.Duration // период опроса очереди
+	MaxAttempts int           // после стольких неудач доставка помечается failed
+	Backoff     time.Duration // пауза перед второй попыткой
+	Now         func() time.Time
+}
+
+// Run разбирает очередь сразу и затем раз в Interval, пока не отменён ctx
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.Log.Printf("started, interval=%s max_attempts=%d backoff=%s", d.Interval, d.MaxAttempts, d.Backoff)
+
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.RunOnce(ctx); err != nil {
+			d.Log.Printf("run failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			d.Log.Println("stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce отправляет все доставки, у которых подошло время попытки; возвращает их число
+func (d *Dispatcher) RunOnce(ctx context.Context) (int, error) {
+	sent := 0
+	for {
+		batch, err := d.Repo.ClaimDeliveries(ctx, d.now(), claimLease, claimBatch)
+		if err != nil {
+			return sent, err
+		}
+		for _, dl := range batch {
+			if err := d.deliver(ctx, dl); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+		// неудачные попытки отложены в будущее, так что очередь конечна
+		if len(batch) < claimBatch || ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+	}
+}
+
+// deliver выполняет одну попытку и сохраняет её итог
+func (d *Dispatcher) deliver(ctx context.Context, dl domain.WebhookDelivery) error {
+	wh, err := d.Repo.GetWebhook(domain.WithTenant(ctx, dl.TenantID), dl.WebhookID)
+	if errors.Is(err, domain.ErrWebhookNotFound) {
+		return nil // вебхук удалён вместе с журналом, пока доставка была в работе
+	}
+	if err != nil {
+		return err
+	}
+
+	code, sendErr := d.send(ctx, wh, dl)
+	dl.Attempts++
+	dl.LastStatusCode, dl.LastError = code, ""
+	switch {
+	case sendErr == nil:
+		dl.Status = domain.DeliverySucceeded
+	case dl.Attempts >= d.MaxAttempts:
+		dl.Status, dl.LastError = domain.DeliveryFailed, truncate(sendErr.Error())
+	default:
+		dl.LastError = truncate(sendErr.Error())
+		dl.NextAttemptAt = d.now().Add(d.backoff(dl.Attempts))
+	}
+	if sendErr != nil {
+		d.Log.Printf("delivery failed id=%s webhook=%s event=%s attempt=%d status=%s: %v",
+			dl.ID, dl.WebhookID, dl.Event, dl.Attempts, dl.Status, sendErr)
+	} else {
+		d.Log.Printf("delivered id=%s webhook=%s event=%s attempt=%d", dl.ID, dl.WebhookID, dl.Event, dl.Attempts)
+	}
+	return d.Repo.UpdateDelivery(ctx, dl)
+}
+
+// send подписывает тело и отправляет его; ошибка — любой ответ кроме 2xx или сбой соединения
+func (d *Dispatcher) send(ctx context.Context, wh domain.Webhook, dl domain.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewReader(dl.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "my-subs-webhooks/1")
+	req.Header.Set(HeaderEvent, dl.Event)
+	req.Header.Set(HeaderDelivery, dl.ID)
+	req.Header.Set(HeaderSignature, Sign(wh.Secret, d.now(), dl.Payload))
+
+	resp, err := d.client().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // чтобы соединение вернулось в пул
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff — пауза после attempts неудачных попыток
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.Backoff
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
+
+func (d *Dispatcher) client() *http.Client {
+	if d.Client != nil {
+		return d.Client
+	}
+	return http.DefaultClient
+}
+
+func (d *Dispatcher) now() time.Time {
+	if d.Now != nil {
+		return d.Now()
+	}
+	return time.Now()
+}
+
+func truncate(s string) string {
+	if len(s) <= maxError {
+		return s
+	}
+	return s[:maxError]
+}
@@ -0,0 +1,273 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	mockrepo "github.com/EgorLis/my-subs/internal/infra/database/mock"
+)
+
+const secret = "test-secret-0123456789"
+
+// receiver — локальный получатель: проверяет подпись и отвечает кодами из statuses по очереди
+type receiver struct {
+	t        *testing.T
+	mu       sync.Mutex
+	statuses []int
+	got      []Event
+	headers  []http.Header
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	// часы диспетчера подменены, поэтому метку времени здесь не проверяем — см. TestVerify
+	if err := Verify(secret, r.Header.Get(HeaderSignature), body, 0, time.Time{}); err != nil {
+		rc.t.Errorf("signature: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var e Event
+	if err := json.Unmarshal(body, &e); err != nil {
+		rc.t.Errorf("payload: %v", err)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.got = append(rc.got, e)
+	rc.headers = append(rc.headers, r.Header.Clone())
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+type fixture struct {
+	repo *mockrepo.Repo
+	pub  *Publisher
+	disp *Dispatcher
+	rc   *receiver
+	hook domain.Webhook
+	now  time.Time
+}
+
+func newFixture(t *testing.T, events []string, statuses ...int) *fixture {
+	t.Helper()
+	f := &fixture{repo: mockrepo.NewMockRepo(), rc: &receiver{t: t, statuses: statuses}, now: time.Now()}
+	srv := httptest.NewServer(f.rc)
+	t.Cleanup(srv.Close)
+
+	quiet := log.New(io.Discard, "", 0)
+	f.pub = &Publisher{Log: quiet, Repo: f.repo}
+	f.disp = &Dispatcher{
+		Log: quiet, Repo: f.repo, Client: srv.Client(),
+		MaxAttempts: 3, Backoff: time.Second,
+		Now: func() time.Time { return f.now },
+	}
+
+	var err error
+	f.hook, err = f.repo.AddWebhook(context.Background(), domain.Webhook{URL: srv.URL, Secret: secret, Events: events})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// publish ставит событие в очередь и переводит часы диспетчера на текущее время,
+// чтобы новая доставка сразу была готова к отправке
+func (f *fixture) publish(t *testing.T, ctx context.Context, event string, data any) {
+	t.Helper()
+	if err := f.pub.Publish(ctx, event, data); err != nil {
+		t.Fatal(err)
+	}
+	f.now = time.Now()
+}
+
+func (f *fixture) deliveries(t *testing.T) []domain.WebhookDelivery {
+	t.Helper()
+	list, err := f.repo.ListDeliveries(context.Background(), f.hook.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+func TestDispatch_SignedDelivery(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t, domain.WebhookEvents)
+
+	f.publish(t, ctx, domain.EventSubscriptionCreated, map[string]string{"id": "s1"})
+	n, err := f.disp.RunOnce(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("want 1 delivery, got %d, err=%v", n, err)
+	}
+
+	if len(f.rc.got) != 1 {
+		t.Fatalf("receiver got %d requests", len(f.rc.got))
+	}
+	e, hdr := f.rc.got[0], f.rc.headers[0]
+	if e.Type != domain.EventSubscriptionCreated || e.TenantID != domain.DefaultTenantID || e.ID == "" {
+		t.Fatalf("unexpected event: %+v", e)
+	}
+	list := f.deliveries(t)
+	if hdr.Get(HeaderEvent) != domain.EventSubscriptionCreated || hdr.Get(HeaderDelivery) != list[0].ID {
+		t.Fatalf("unexpected headers: %v", hdr)
+	}
+	if list[0].Status != domain.DeliverySucceeded || list[0].Attempts != 1 || list[0].LastStatusCode != 200 {
+		t.Fatalf("unexpected delivery: %+v", list[0])
+	}
+
+	// повторный проход ничего не отправляет
+	if n, _ := f.disp.RunOnce(ctx); n != 0 {
+		t.Fatalf("want nothing to send, got %d", n)
+	}
+}
+
+func TestDispatch_OnlySubscribedEvents(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t, []string{domain.EventSubscriptionDeleted})
+
+	f.publish(t, ctx, domain.EventSubscriptionCreated, nil)
+	// другой тенант не видит чужих вебхуков
+	f.publish(t, domain.WithTenant(ctx, "other"), domain.EventSubscriptionDeleted, nil)
+	f.publish(t, ctx, domain.EventSubscriptionDeleted, nil)
+
+	if n, _ := f.disp.RunOnce(ctx); n != 1 {
+		t.Fatalf("want 1 delivery, got %d", n)
+	}
+	if f.rc.got[0].Type != domain.EventSubscriptionDeleted {
+		t.Fatalf("unexpected event: %+v", f.rc.got[0])
+	}
+}
+
+func TestDispatch_Batch(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t, domain.WebhookEvents)
+
+	// пачка ставится в очередь одним вызовом, но каждое событие доставляется отдельно и со своим ID
+	data := []any{map[string]string{"id": "s1"}, map[string]string{"id": "s2"}, map[string]string{"id": "s3"}}
+	if err := f.pub.PublishBatch(ctx, domain.EventSubscriptionCreated, data); err != nil {
+		t.Fatal(err)
+	}
+	f.now = time.Now()
+	if n, err := f.disp.RunOnce(ctx); err != nil || n != 3 {
+		t.Fatalf("want 3 deliveries, got %d, err=%v", n, err)
+	}
+	ids := map[string]bool{}
+	for _, e := range f.rc.got {
+		ids[e.ID] = true
+	}
+	if len(ids) != 3 {
+		t.Fatalf("want 3 distinct event ids, got %+v", f.rc.got)
+	}
+}
+
+func TestDispatch_RetryWithBackoff(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t, domain.WebhookEvents, http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK)
+	f.publish(t, ctx, domain.EventSubscriptionUpdated, nil)
+
+	steps := []struct {
+		advance  time.Duration
+		sent     int
+		attempts int
+		status   domain.DeliveryStatus
+		code     int
+	}{
+		{0, 1, 1, domain.DeliveryPending, 500},
+		{time.Second - time.Millisecond, 0, 1, domain.DeliveryPending, 500}, // пауза 1s ещё не прошла
+		{time.Millisecond, 1, 2, domain.DeliveryPending, 502},
+		{time.Second, 0, 2, domain.DeliveryPending, 502}, // вторая пауза вдвое длиннее
+		{time.Second, 1, 3, domain.DeliverySucceeded, 200},
+	}
+	for i, s := range steps {
+		f.now = f.now.Add(s.advance)
+		n, err := f.disp.RunOnce(ctx)
+		if err != nil || n != s.sent {
+			t.Fatalf("step %d: want %d sent, got %d, err=%v", i, s.sent, n, err)
+		}
+		d := f.deliveries(t)[0]
+		if d.Attempts != s.attempts || d.Status != s.status || d.LastStatusCode != s.code {
+			t.Fatalf("step %d: unexpected delivery: %+v", i, d)
+		}
+	}
+	if len(f.rc.got) != 3 || f.rc.got[0].ID != f.rc.got[2].ID {
+		t.Fatalf("retries must carry the same event: %+v", f.rc.got)
+	}
+}
+
+func TestDispatch_FailedAfterMaxAttemptsThenRedeliver(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t, domain.WebhookEvents, 500, 500, 500)
+	f.publish(t, ctx, domain.EventSubscriptionDeleted, nil)
+
+	for i := 0; i < 3; i++ {
+		if _, err := f.disp.RunOnce(ctx); err != nil {
+			t.Fatal(err)
+		}
+		f.now = f.now.Add(time.Hour)
+	}
+	failed := f.deliveries(t)[0]
+	if failed.Status != domain.DeliveryFailed || failed.Attempts != 3 || failed.LastError == "" {
+		t.Fatalf("want failed after 3 attempts: %+v", failed)
+	}
+	if n, _ := f.disp.RunOnce(ctx); n != 0 {
+		t.Fatalf("failed delivery must not be retried, sent %d", n)
+	}
+
+	again, err := f.repo.RedeliverWebhook(ctx, f.hook.ID, failed.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.now = time.Now()
+	if again.ID == failed.ID || again.EventID != failed.EventID || again.Status != domain.DeliveryPending {
+		t.Fatalf("unexpected redelivery: %+v", again)
+	}
+	if n, _ := f.disp.RunOnce(ctx); n != 1 {
+		t.Fatalf("want redelivery sent, got %d", n)
+	}
+	got, _ := f.repo.GetDelivery(ctx, f.hook.ID, again.ID)
+	if got.Status != domain.DeliverySucceeded {
+		t.Fatalf("redelivery: %+v", got)
+	}
+}
+
+func TestBackoffCapped(t *testing.T) {
+	d := &Dispatcher{Backoff: 30 * time.Second}
+	cases := map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 4: 4 * time.Minute, 20: maxBackoff}
+	for attempts, want := range cases {
+		if got := d.backoff(attempts); got != want {
+			t.Fatalf("backoff(%d): want %s, got %s", attempts, want, got)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"a":1}`)
+	now := time.Unix(1700000000, 0)
+	sig := Sign(secret, now, body)
+
+	if err := Verify(secret, sig, body, time.Minute, now); err != nil {
+		t.Fatalf("valid signature: %v", err)
+	}
+	if err := Verify("other", sig, body, time.Minute, now); err != ErrBadSignature {
+		t.Fatalf("wrong secret: %v", err)
+	}
+	if err := Verify(secret, sig, []byte(`{"a":2}`), time.Minute, now); err != ErrBadSignature {
+		t.Fatalf("tampered body: %v", err)
+	}
+	if err := Verify(secret, sig, body, time.Minute, now.Add(2*time.Minute)); err != ErrSignatureExpired {
+		t.Fatalf("stale timestamp: %v", err)
+	}
+	if err := Verify(secret, "garbage", body, 0, now); err != ErrBadSignature {
+		t.Fatalf("malformed header: %v", err)
+	}
+}
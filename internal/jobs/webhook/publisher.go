@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Event — тело доставки; одно и то же событие уходит всем подписанным вебхукам тенанта
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	TenantID  string    `json:"tenant_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Publisher ставит события в очередь доставок; отправляет их Dispatcher
+type Publisher struct {
+	Log  *log.Logger
+	Repo interface {
+		EnqueueDeliveries(ctx context.Context, event string, events []domain.WebhookEvent) (int, error)
+	}
+	Now func() time.Time
+}
+
+// Publish сохраняет событие для всех вебхуков тенанта из контекста, подписанных на него
+func (p *Publisher) Publish(ctx context.Context, event string, data any) error {
+	return p.PublishBatch(ctx, event, []any{data})
+}
+
+// PublishBatch сохраняет пачку событий одного типа одним запросом, например строки импорта
+func (p *Publisher) PublishBatch(ctx context.Context, event string, data []any) error {
+	now := time.Now()
+	if p.Now != nil {
+		now = p.Now()
+	}
+	events := make([]domain.WebhookEvent, 0, len(data))
+	for _, d := range data {
+		e := Event{
+			ID:        uuid.NewString(),
+			Type:      event,
+			TenantID:  domain.TenantOrDefault(ctx),
+			CreatedAt: now.UTC(),
+			Data:      d,
+		}
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		events = append(events, domain.WebhookEvent{ID: e.ID, Payload: payload})
+	}
+	n, err := p.Repo.EnqueueDeliveries(ctx, event, events)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		p.Log.Printf("enqueued event=%s events=%d deliveries=%d", event, len(events), n)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Заголовки доставки
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+var (
+	ErrBadSignature     = errors.New("webhook signature mismatch")
+	ErrSignatureExpired = errors.New("webhook signature timestamp out of tolerance")
+)
+
+// Sign возвращает значение X-Webhook-Signature: t=<unix>,v1=<hex HMAC-SHA256(secret, "<unix>.<body>")>.
+// Метка времени входит в подпись, чтобы перехваченный запрос нельзя было повторить позже.
+func Sign(secret string, ts time.Time, body []byte) string {
+	t := strconv.FormatInt(ts.Unix(), 10)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac(secret, t, body))
+}
+
+// Verify проверяет X-Webhook-Signature на стороне получателя. tolerance — допустимое расхождение
+// метки времени с now; 0 отключает проверку времени.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var t, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			t = v
+		case "v1":
+			sig = v
+		}
+	}
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || sig == "" {
+		return ErrBadSignature
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, mac(secret, t, body)) {
+		return ErrBadSignature
+	}
+	if tolerance > 0 {
+		if d := now.Sub(time.Unix(unix, 0)); d > tolerance || d < -tolerance {
+			return ErrSignatureExpired
+		}
+	}
+	return nil
+}
+
+func mac(secret, t string, body []byte) []byte {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(t))
+	m.Write([]byte("."))
+	m.Write(body)
+	return m.Sum(nil)
+}
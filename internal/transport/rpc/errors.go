@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func invalidArgument(err error) error {
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+// repoError переводит ошибки репозитория в статусы gRPC; неожиданные логируются и скрываются от клиента
+func repoError(ctx context.Context, logger *log.Logger, op string, err error) error {
+	reqID := mw.RequestIDFromCtx(ctx)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return status.Error(codes.NotFound, "subscription not found")
+	case errors.Is(err, domain.ErrTenantNotFound):
+		return status.Error(codes.NotFound, "tenant not found")
+	case errors.Is(err, domain.ErrUserNotFound):
+		return status.Error(codes.InvalidArgument, "user_id: unknown user")
+	case errors.Is(err, domain.ErrVersionMismatch):
+		// конфликт параллельных изменений: клиенту нужно перечитать подписку и повторить
+		return status.Error(codes.Aborted, "subscription was modified")
+	case errors.Is(err, context.DeadlineExceeded):
+		logx.Error(logger, reqID, op, "repo timeout", err)
+		return status.Error(codes.DeadlineExceeded, "request timed out")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "request canceled")
+	}
+	logx.Error(logger, reqID, op, "repo failed", err)
+	return status.Error(codes.Internal, "internal error")
+}
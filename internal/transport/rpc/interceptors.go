@@ -0,0 +1,124 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ключи метаданных — те же, что заголовки REST, в нижнем регистре
+const (
+	MetaRequestID = "x-request-id"
+	MetaTenantID  = "x-tenant-id"
+)
+
+// служебные сервисы (health, reflection) не привязаны к тенанту
+const subscriptionServicePrefix = "/mysubs.subscription.v1."
+
+func metaValue(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// requestContext кладёт в контекст id запроса и, для методов подписок, тенант — как WithRequestID и WithTenant в REST
+func requestContext(ctx context.Context, method string, tenants mw.TenantGetter) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	reqID := metaValue(md, MetaRequestID)
+	if reqID == "" {
+		reqID = uuid.NewString()
+	}
+	ctx = domain.WithRequestID(ctx, reqID)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(MetaRequestID, reqID))
+
+	if !strings.HasPrefix(method, subscriptionServicePrefix) {
+		return ctx, nil
+	}
+
+	header := metaValue(md, MetaTenantID)
+	tenantID, fromAuth := domain.TenantFromCtx(ctx)
+	switch {
+	case fromAuth && header != "" && header != tenantID:
+		return ctx, status.Error(codes.PermissionDenied, "tenant: does not match credentials")
+	case fromAuth:
+	case header != "":
+		if _, err := uuid.Parse(header); err != nil {
+			return ctx, status.Error(codes.InvalidArgument, "tenant: must be a valid GUID")
+		}
+		tenantID = header
+	default:
+		tenantID = domain.DefaultTenantID
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	_, err := tenants.GetTenant(tctx, tenantID)
+	cancel()
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrTenantNotFound):
+			return ctx, status.Error(codes.NotFound, "tenant not found")
+		case errors.Is(err, context.DeadlineExceeded):
+			return ctx, status.Error(codes.DeadlineExceeded, "request timed out")
+		case errors.Is(err, context.Canceled):
+			return ctx, status.Error(codes.Canceled, "request canceled")
+		default:
+			return ctx, status.Error(codes.Internal, "internal error")
+		}
+	}
+	return domain.WithTenant(ctx, tenantID), nil
+}
+
+func logFinish(l *log.Logger, ctx context.Context, method string, start time.Time, err error) {
+	l.Printf("lvl=info event=finish req_id=%s method=%s code=%s duration_ms=%d",
+		mw.RequestIDFromCtx(ctx), method, status.Code(err), time.Since(start).Milliseconds())
+}
+
+// unaryInterceptor — id запроса, тенант и лог вызова для unary-методов
+func unaryInterceptor(l *log.Logger, tenants mw.TenantGetter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx, err := requestContext(ctx, info.FullMethod, tenants)
+		if err != nil {
+			logFinish(l, ctx, info.FullMethod, start, err)
+			return nil, err
+		}
+		resp, err := handler(ctx, req)
+		logFinish(l, ctx, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// ctxStream подменяет контекст серверного потока
+type ctxStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *ctxStream) Context() context.Context { return s.ctx }
+
+// streamInterceptor — то же для потоковых методов
+func streamInterceptor(l *log.Logger, tenants mw.TenantGetter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx, err := requestContext(ss.Context(), info.FullMethod, tenants)
+		if err != nil {
+			logFinish(l, ctx, info.FullMethod, start, err)
+			return err
+		}
+		err = handler(srv, &ctxStream{ServerStream: ss, ctx: ctx})
+		logFinish(l, ctx, info.FullMethod, start, err)
+		return err
+	}
+}
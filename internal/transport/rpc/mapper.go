@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	pb "github.com/EgorLis/my-subs/internal/transport/rpc/subscriptionv1"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// --- proto -> запросы REST (для общих валидаторов) ---
+
+// yearMonth переводит месяц из proto; nil — не задан. Некорректный месяц возвращает ok=false.
+func yearMonth(ym *pb.YearMonth) (subscription.YearMonth, bool) {
+	if ym == nil {
+		return subscription.YearMonth{}, true
+	}
+	if ym.GetMonth() < 1 || ym.GetMonth() > 12 || ym.GetYear() < 1 || ym.GetYear() > 9999 {
+		return subscription.YearMonth{}, false
+	}
+	return subscription.YearMonth(time.Date(int(ym.GetYear()), time.Month(ym.GetMonth()), 1, 0, 0, 0, 0, time.UTC)), true
+}
+
+func mapCreateRequest(req *pb.CreateSubscriptionRequest) (subscription.CreateRequest, []string) {
+	var errs []string
+	start, ok := yearMonth(req.GetStartDate())
+	if !ok {
+		errs = append(errs, "start_date: invalid month")
+	}
+	end, ok := yearMonth(req.GetEndDate())
+	if !ok {
+		errs = append(errs, "end_date: invalid month")
+	}
+	return subscription.CreateRequest{
+		ServiceName: req.GetServiceName(),
+		Price:       int(req.GetPrice()),
+		UserID:      req.GetUserId(),
+		StartDate:   start,
+		EndDate:     end,
+		Notes:       req.GetNotes(),
+		Tags:        req.GetTags(),
+	}, errs
+}
+
+func mapUpdateRequest(req *pb.UpdateSubscriptionRequest) (subscription.UpdateRequest, []string) {
+	var errs []string
+	start, ok := yearMonth(req.GetStartDate())
+	if !ok {
+		errs = append(errs, "start_date: invalid month")
+	}
+	end, ok := yearMonth(req.GetEndDate())
+	if !ok {
+		errs = append(errs, "end_date: invalid month")
+	}
+	return subscription.UpdateRequest{
+		ID:          req.GetId(),
+		ServiceName: req.GetServiceName(),
+		Price:       int(req.GetPrice()),
+		UserID:      req.GetUserId(),
+		StartDate:   start,
+		EndDate:     end,
+		Notes:       req.GetNotes(),
+		Tags:        req.GetTags(),
+	}, errs
+}
+
+// --- домен -> proto ---
+
+func mapYearMonth(t time.Time) *pb.YearMonth {
+	return &pb.YearMonth{Year: int32(t.Year()), Month: int32(t.Month())}
+}
+
+func mapDomainToProto(s domain.Subscription) *pb.Subscription {
+	return &pb.Subscription{
+		Id:          s.ID,
+		ServiceName: s.ServiceName,
+		Price:       int64(s.Price),
+		UserId:      s.UserID,
+		StartDate:   mapYearMonth(s.StartDate),
+		EndDate:     mapYearMonth(s.EndDate),
+		Notes:       s.Notes,
+		Tags:        append([]string{}, s.Tags...),
+		Version:     int64(s.Version),
+		CreatedAt:   timestamppb.New(s.CreatedAt),
+		UpdatedAt:   timestamppb.New(s.UpdatedAt),
+		CreatedBy:   s.CreatedBy,
+		UpdatedBy:   s.UpdatedBy,
+	}
+}
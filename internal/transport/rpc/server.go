@@ -0,0 +1,109 @@
+package rpc
+
+import (
+	"context"
+	"log"
+	"net"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/config"
+	"github.com/EgorLis/my-subs/internal/domain"
+	pb "github.com/EgorLis/my-subs/internal/transport/rpc/subscriptionv1"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// healthInterval — как часто проверяется БД для статуса health-сервиса
+const healthInterval = 10 * time.Second
+
+type Server struct {
+	log    *log.Logger
+	server *grpc.Server
+	health *health.Server
+	repo   domain.Repository
+	cfg    *config.Config
+	stop   chan struct{}
+}
+
+// New собирает сервер; events получает события об изменениях подписок (обычно web.Server.Events), nil — не публикуются
+func New(logger *log.Logger, cfg *config.Config, repo domain.Repository, events subscription.EventPublisher) *Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryInterceptor(logger, repo)),
+		grpc.ChainStreamInterceptor(streamInterceptor(logger, repo)),
+	)
+
+	pb.RegisterSubscriptionServiceServer(srv, &subscriptionService{log: logger, repo: repo, events: events})
+
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	reflection.Register(srv)
+
+	return &Server{log: logger, server: srv, health: hs, repo: repo, cfg: cfg, stop: make(chan struct{})}
+}
+
+func (s *Server) Run() {
+	lis, err := net.Listen("tcp", s.cfg.GRPCPort)
+	if err != nil {
+		s.log.Fatalf("error: %v", err)
+	}
+	s.Serve(lis)
+}
+
+// Serve обслуживает уже открытый listener (в тестах — bufconn)
+func (s *Server) Serve(lis net.Listener) {
+	s.checkHealth()
+	go s.watchHealth()
+
+	s.log.Printf("started on %s", lis.Addr())
+	if err := s.server.Serve(lis); err != nil && err != grpc.ErrServerStopped {
+		s.log.Fatalf("error: %v", err)
+	}
+}
+
+func (s *Server) Close(ctx context.Context) {
+	close(s.stop)
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.server.Stop()
+		s.log.Printf("forced to shutdown: %v", ctx.Err())
+	}
+	s.log.Println("exited gracefully")
+}
+
+// watchHealth периодически пингует БД: без неё сервис отвечает NOT_SERVING
+func (s *Server) watchHealth() {
+	t := time.NewTicker(healthInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-t.C:
+			s.checkHealth()
+		}
+	}
+}
+
+func (s *Server) checkHealth() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	st := healthpb.HealthCheckResponse_SERVING
+	if err := s.repo.Ping(ctx); err != nil {
+		s.log.Printf("lvl=error op=rpc.health msg=%q err=%q", "db ping failed", err)
+		st = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	s.health.SetServingStatus("", st)
+	s.health.SetServingStatus(pb.SubscriptionService_ServiceDesc.ServiceName, st)
+}
@@ -0,0 +1,227 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	pb "github.com/EgorLis/my-subs/internal/transport/rpc/subscriptionv1"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+	"google.golang.org/grpc"
+)
+
+// subscriptionService реализует SubscriptionService поверх репозитория;
+// проверки входных данных общие с REST (пакет subscription)
+type subscriptionService struct {
+	pb.UnimplementedSubscriptionServiceServer
+	log    *log.Logger
+	repo   domain.SubscriptionRepository
+	events subscription.EventPublisher // те же события, что и у REST; nil — не публикуются
+}
+
+// publish отправляет событие об изменении; оно уже сохранено, поэтому сбой только логируется
+func (s *subscriptionService) publish(ctx context.Context, op, event string, data any) {
+	if s.events == nil {
+		return
+	}
+	if err := s.events.Publish(ctx, event, data); err != nil {
+		logx.Error(s.log, mw.RequestIDFromCtx(ctx), op, "publish event failed", err, "event", event)
+	}
+}
+
+func (s *subscriptionService) CreateSubscription(ctx context.Context, req *pb.CreateSubscriptionRequest) (*pb.Subscription, error) {
+	const op = "rpc.subscription.create"
+
+	cr, errs := mapCreateRequest(req)
+	if err := joinValidation(errs, subscription.ValidateCreateRequest(cr)); err != nil {
+		logx.Error(s.log, mw.RequestIDFromCtx(ctx), op, "validation failed", err)
+		return nil, invalidArgument(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	sub, err := s.repo.AddSub(ctx, subscription.MapCreateReqToDomain(cr))
+	if err != nil {
+		return nil, repoError(ctx, s.log, op, err)
+	}
+	logx.Info(s.log, mw.RequestIDFromCtx(ctx), op, "created", "id", sub.ID)
+	s.publish(ctx, op, domain.EventSubscriptionCreated, subscription.MapDomainToDTO(sub))
+	return mapDomainToProto(sub), nil
+}
+
+func (s *subscriptionService) GetSubscription(ctx context.Context, req *pb.GetSubscriptionRequest) (*pb.Subscription, error) {
+	const op = "rpc.subscription.get"
+
+	if err := subscription.ValidateGUID(req.GetId()); err != nil {
+		return nil, invalidArgument(fmt.Errorf("id: %w", err))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	sub, err := s.repo.GetSub(ctx, req.GetId())
+	if err != nil {
+		return nil, repoError(ctx, s.log, op, err)
+	}
+	return mapDomainToProto(sub), nil
+}
+
+func (s *subscriptionService) UpdateSubscription(ctx context.Context, req *pb.UpdateSubscriptionRequest) (*pb.Subscription, error) {
+	const op = "rpc.subscription.update"
+
+	ur, errs := mapUpdateRequest(req)
+	if req.GetVersion() < 0 {
+		errs = append(errs, "version: must be >= 0")
+	}
+	if err := joinValidation(errs, subscription.ValidateUpdateRequest(ur)); err != nil {
+		logx.Error(s.log, mw.RequestIDFromCtx(ctx), op, "validation failed", err)
+		return nil, invalidArgument(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	sub, err := s.repo.UpdateSub(ctx, subscription.MapUpdateReqToDomain(ur), int(req.GetVersion()))
+	if err != nil {
+		return nil, repoError(ctx, s.log, op, err)
+	}
+	logx.Info(s.log, mw.RequestIDFromCtx(ctx), op, "updated", "id", sub.ID)
+	s.publish(ctx, op, domain.EventSubscriptionUpdated, subscription.MapDomainToDTO(sub))
+	return mapDomainToProto(sub), nil
+}
+
+func (s *subscriptionService) DeleteSubscription(ctx context.Context, req *pb.DeleteSubscriptionRequest) (*pb.DeleteSubscriptionResponse, error) {
+	const op = "rpc.subscription.delete"
+
+	var errs []string
+	if err := subscription.ValidateGUID(req.GetId()); err != nil {
+		errs = append(errs, "id: "+err.Error())
+	}
+	if req.GetVersion() < 0 {
+		errs = append(errs, "version: must be >= 0")
+	}
+	if err := joinValidation(errs, nil); err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	deleted, err := s.repo.DeleteSub(ctx, req.GetId(), int(req.GetVersion()))
+	if err != nil {
+		return nil, repoError(ctx, s.log, op, err)
+	}
+	logx.Info(s.log, mw.RequestIDFromCtx(ctx), op, "deleted", "id", req.GetId())
+	s.publish(ctx, op, domain.EventSubscriptionDeleted, subscription.MapDomainToDeletedEvent(deleted))
+	return &pb.DeleteSubscriptionResponse{}, nil
+}
+
+// ListSubscriptions не ограничен по времени, как и экспорт: поток живёт, пока клиент читает
+func (s *subscriptionService) ListSubscriptions(req *pb.ListSubscriptionsRequest, stream grpc.ServerStreamingServer[pb.Subscription]) error {
+	const op = "rpc.subscription.list"
+	ctx := stream.Context()
+
+	q, errs := listQuery(req)
+	f, err := subscription.ParseListQuery(q)
+	if err := joinValidation(errs, err); err != nil {
+		logx.Error(s.log, mw.RequestIDFromCtx(ctx), op, "validation failed", err)
+		return invalidArgument(err)
+	}
+
+	sent := 0
+	err = s.repo.StreamSubs(ctx, f, func(sub domain.Subscription) error {
+		if err := stream.Send(mapDomainToProto(sub)); err != nil {
+			return err
+		}
+		sent++
+		return nil
+	})
+	if err != nil {
+		return repoError(ctx, s.log, op, err)
+	}
+	logx.Info(s.log, mw.RequestIDFromCtx(ctx), op, "streamed", "count", sent)
+	return nil
+}
+
+func (s *subscriptionService) TotalCost(ctx context.Context, req *pb.TotalCostRequest) (*pb.TotalCostResponse, error) {
+	const op = "rpc.subscription.total_cost"
+
+	var errs []string
+	from, ok := yearMonth(req.GetFrom())
+	if !ok {
+		errs = append(errs, "from: invalid month")
+	}
+	to, ok := yearMonth(req.GetTo())
+	if !ok {
+		errs = append(errs, "to: invalid month")
+	}
+	err := subscription.ValidateTotalCostQuery(req.GetUserId(), req.GetServiceName(), from, to)
+	if err := joinValidation(errs, err); err != nil {
+		logx.Error(s.log, mw.RequestIDFromCtx(ctx), op, "validation failed", err)
+		return nil, invalidArgument(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	total, err := s.repo.TotalCost(ctx, req.GetServiceName(), req.GetUserId(), from.ToTime(), to.ToTime())
+	if err != nil {
+		return nil, repoError(ctx, s.log, op, err)
+	}
+	return &pb.TotalCostResponse{TotalCost: int64(total)}, nil
+}
+
+// listQuery переводит фильтры в параметры GET /v1/subscriptions, чтобы разбирать их тем же ParseListQuery
+func listQuery(req *pb.ListSubscriptionsRequest) (url.Values, []string) {
+	var errs []string
+	q := url.Values{}
+	set := func(key, v string) {
+		if v != "" {
+			q.Set(key, v)
+		}
+	}
+	setYM := func(key string, ym *pb.YearMonth) {
+		if ym == nil {
+			return
+		}
+		if _, ok := yearMonth(ym); !ok {
+			errs = append(errs, key+": invalid month")
+			return
+		}
+		q.Set(key, fmt.Sprintf("%02d-%04d", ym.GetMonth(), ym.GetYear()))
+	}
+
+	set("user_id", req.GetUserId())
+	set("service_name", req.GetServiceName())
+	set("sort", req.GetSort())
+	setYM("active_at", req.GetActiveAt())
+	setYM("from", req.GetFrom())
+	setYM("to", req.GetTo())
+	if req.PriceMin != nil {
+		q.Set("price_min", strconv.FormatInt(req.GetPriceMin(), 10))
+	}
+	if req.PriceMax != nil {
+		q.Set("price_max", strconv.FormatInt(req.GetPriceMax(), 10))
+	}
+	return q, errs
+}
+
+// joinValidation объединяет ошибки разбора proto с ошибкой общего валидатора через "; "
+func joinValidation(errs []string, err error) error {
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(errs, "; "))
+}
@@ -0,0 +1,290 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/EgorLis/my-subs/internal/config"
+	"github.com/EgorLis/my-subs/internal/domain"
+	mockrepo "github.com/EgorLis/my-subs/internal/infra/database/mock"
+	pb "github.com/EgorLis/my-subs/internal/transport/rpc/subscriptionv1"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type timeoutRepo struct{ domain.Repository }
+
+func (timeoutRepo) GetSub(ctx context.Context, id string) (domain.Subscription, error) {
+	return domain.Subscription{}, context.DeadlineExceeded
+}
+
+// recordingPublisher запоминает опубликованные события; сервер вызывает его из своих горутин
+type recordingPublisher struct {
+	mu  sync.Mutex
+	got []string
+	ids []string
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event string, data any) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.got = append(p.got, event)
+	switch d := data.(type) {
+	case subscription.SubscriptionDTO:
+		p.ids = append(p.ids, d.ID)
+	case subscription.DeletedEvent:
+		p.ids = append(p.ids, d.ID)
+	}
+	return nil
+}
+
+// startServer поднимает сервер на bufconn и возвращает подключённое соединение
+func startServer(t *testing.T, repo domain.Repository, events subscription.EventPublisher) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := New(log.New(io.Discard, "", 0), &config.Config{}, repo, events)
+	go s.Serve(lis)
+	t.Cleanup(func() { s.Close(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func ym(year, month int32) *pb.YearMonth { return &pb.YearMonth{Year: year, Month: month} }
+
+func wantCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Fatalf("want %s, got %v", code, err)
+	}
+}
+
+func TestSubscriptionService_CRUD(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	ctx := context.Background()
+	u, _ := repo.AddUser(ctx, domain.User{DisplayName: "Ivan"})
+	events := &recordingPublisher{}
+	client := pb.NewSubscriptionServiceClient(startServer(t, repo, events))
+
+	var header metadata.MD
+	created, err := client.CreateSubscription(metadata.AppendToOutgoingContext(ctx, MetaRequestID, "req-1"),
+		&pb.CreateSubscriptionRequest{
+			ServiceName: "Netflix", Price: 500, UserId: u.ID,
+			StartDate: ym(2025, 1), EndDate: ym(2025, 12), Tags: []string{"Video"},
+		}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if got := header.Get(MetaRequestID); len(got) != 1 || got[0] != "req-1" {
+		t.Fatalf("want request id echoed, got %v", got)
+	}
+	if created.GetVersion() != 1 || created.GetTags()[0] != "video" || created.GetEndDate().GetMonth() != 12 {
+		t.Fatalf("unexpected created: %v", created)
+	}
+
+	got, err := client.GetSubscription(ctx, &pb.GetSubscriptionRequest{Id: created.GetId()})
+	if err != nil || got.GetServiceName() != "Netflix" {
+		t.Fatalf("get: %v %v", got, err)
+	}
+
+	upd := &pb.UpdateSubscriptionRequest{
+		Id: created.GetId(), Version: 1, ServiceName: "Netflix", Price: 700, UserId: u.ID,
+		StartDate: ym(2025, 1), EndDate: ym(2025, 12),
+	}
+	updated, err := client.UpdateSubscription(ctx, upd)
+	if err != nil || updated.GetPrice() != 700 || updated.GetVersion() != 2 {
+		t.Fatalf("update: %v %v", updated, err)
+	}
+	// повтор с устаревшей версией
+	_, err = client.UpdateSubscription(ctx, upd)
+	wantCode(t, err, codes.Aborted)
+
+	_, err = client.DeleteSubscription(ctx, &pb.DeleteSubscriptionRequest{Id: created.GetId(), Version: 2})
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_, err = client.GetSubscription(ctx, &pb.GetSubscriptionRequest{Id: created.GetId()})
+	wantCode(t, err, codes.NotFound)
+
+	// события — как у REST; отклонённое обновление их не порождает
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	want := []string{domain.EventSubscriptionCreated, domain.EventSubscriptionUpdated, domain.EventSubscriptionDeleted}
+	if fmt.Sprint(events.got) != fmt.Sprint(want) {
+		t.Fatalf("events: want %v, got %v", want, events.got)
+	}
+	for _, id := range events.ids {
+		if id != created.GetId() {
+			t.Fatalf("event for %s, want %s", id, created.GetId())
+		}
+	}
+}
+
+func TestSubscriptionService_Errors(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	ctx := context.Background()
+	u, _ := repo.AddUser(ctx, domain.User{DisplayName: "Ivan"})
+	client := pb.NewSubscriptionServiceClient(startServer(t, repo, nil))
+	slow := pb.NewSubscriptionServiceClient(startServer(t, timeoutRepo{repo}, nil))
+
+	cases := []struct {
+		name    string
+		call    func() error
+		want    codes.Code
+		wantMsg string
+	}{
+		{name: "Create_Invalid", want: codes.InvalidArgument, wantMsg: "price: must be > 0",
+			call: func() error {
+				_, err := client.CreateSubscription(ctx, &pb.CreateSubscriptionRequest{
+					ServiceName: "Netflix", UserId: u.ID, StartDate: ym(2025, 1), EndDate: ym(2025, 2)})
+				return err
+			}},
+		{name: "Create_BadMonth", want: codes.InvalidArgument, wantMsg: "start_date: invalid month",
+			call: func() error {
+				_, err := client.CreateSubscription(ctx, &pb.CreateSubscriptionRequest{
+					ServiceName: "Netflix", Price: 1, UserId: u.ID, StartDate: ym(2025, 13), EndDate: ym(2025, 2)})
+				return err
+			}},
+		{name: "Create_UnknownUser", want: codes.InvalidArgument, wantMsg: "user_id: unknown user",
+			call: func() error {
+				_, err := client.CreateSubscription(ctx, &pb.CreateSubscriptionRequest{
+					ServiceName: "Netflix", Price: 1, UserId: uuid.NewString(), StartDate: ym(2025, 1), EndDate: ym(2025, 2)})
+				return err
+			}},
+		{name: "Get_BadID", want: codes.InvalidArgument,
+			call: func() error {
+				_, err := client.GetSubscription(ctx, &pb.GetSubscriptionRequest{Id: "nope"})
+				return err
+			}},
+		{name: "Get_Timeout", want: codes.DeadlineExceeded,
+			call: func() error {
+				_, err := slow.GetSubscription(ctx, &pb.GetSubscriptionRequest{Id: uuid.NewString()})
+				return err
+			}},
+		{name: "Delete_NotFound", want: codes.NotFound,
+			call: func() error {
+				_, err := client.DeleteSubscription(ctx, &pb.DeleteSubscriptionRequest{Id: uuid.NewString()})
+				return err
+			}},
+		{name: "TotalCost_Missing", want: codes.InvalidArgument, wantMsg: "from: required",
+			call: func() error {
+				_, err := client.TotalCost(ctx, &pb.TotalCostRequest{UserId: u.ID, ServiceName: "Netflix", To: ym(2025, 1)})
+				return err
+			}},
+		{name: "Tenant_BadGUID", want: codes.InvalidArgument,
+			call: func() error {
+				_, err := client.GetSubscription(metadata.AppendToOutgoingContext(ctx, MetaTenantID, "nope"),
+					&pb.GetSubscriptionRequest{Id: uuid.NewString()})
+				return err
+			}},
+		{name: "Tenant_Unknown", want: codes.NotFound, wantMsg: "tenant not found",
+			call: func() error {
+				_, err := client.GetSubscription(metadata.AppendToOutgoingContext(ctx, MetaTenantID, uuid.NewString()),
+					&pb.GetSubscriptionRequest{Id: uuid.NewString()})
+				return err
+			}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.call()
+			wantCode(t, err, tc.want)
+			if tc.wantMsg != "" && !strings.Contains(status.Convert(err).Message(), tc.wantMsg) {
+				t.Fatalf("want message with %q, got %q", tc.wantMsg, status.Convert(err).Message())
+			}
+		})
+	}
+}
+
+func TestSubscriptionService_ListAndTotalCost(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	ctx := context.Background()
+	acme, _ := repo.AddTenant(ctx, domain.Tenant{Name: "acme"})
+	u, _ := repo.AddUser(ctx, domain.User{DisplayName: "Ivan"})
+	client := pb.NewSubscriptionServiceClient(startServer(t, repo, nil))
+
+	for _, price := range []int64{300, 100, 200} {
+		_, err := client.CreateSubscription(ctx, &pb.CreateSubscriptionRequest{
+			ServiceName: "Netflix", Price: price, UserId: u.ID, StartDate: ym(2025, 1), EndDate: ym(2025, 3)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := func(ctx context.Context, req *pb.ListSubscriptionsRequest) ([]int64, error) {
+		stream, err := client.ListSubscriptions(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		var prices []int64
+		for {
+			s, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return prices, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			prices = append(prices, s.GetPrice())
+		}
+	}
+
+	minPrice := int64(150)
+	prices, err := list(ctx, &pb.ListSubscriptionsRequest{Sort: "-price", PriceMin: &minPrice})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(prices) != 2 || prices[0] != 300 || prices[1] != 200 {
+		t.Fatalf("want [300 200], got %v", prices)
+	}
+
+	_, err = list(ctx, &pb.ListSubscriptionsRequest{Sort: "nope"})
+	wantCode(t, err, codes.InvalidArgument)
+
+	// в другом тенанте подписок нет
+	prices, err = list(metadata.AppendToOutgoingContext(ctx, MetaTenantID, acme.ID), &pb.ListSubscriptionsRequest{})
+	if err != nil || len(prices) != 0 {
+		t.Fatalf("want empty list in other tenant, got %v %v", prices, err)
+	}
+
+	total, err := client.TotalCost(ctx, &pb.TotalCostRequest{UserId: u.ID, ServiceName: "Netflix", From: ym(2025, 1), To: ym(2025, 3)})
+	if err != nil {
+		t.Fatalf("total cost: %v", err)
+	}
+	if total.GetTotalCost() != 600 {
+		t.Fatalf("want 600, got %d", total.GetTotalCost())
+	}
+}
+
+func TestHealth(t *testing.T) {
+	conn := startServer(t, mockrepo.NewMockRepo(), nil)
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(),
+		&healthpb.HealthCheckRequest{Service: pb.SubscriptionService_ServiceDesc.ServiceName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("want SERVING, got %s", resp.GetStatus())
+	}
+}
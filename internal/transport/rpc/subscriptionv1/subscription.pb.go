@@ -0,0 +1,931 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: mysubs/subscription/v1/subscription.proto
+
+package subscriptionv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// YearMonth — месяц подписки; день не используется
+type YearMonth struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Year          int32                  `protobuf:"varint,1,opt,name=year,proto3" json:"year,omitempty"`
+	Month         int32                  `protobuf:"varint,2,opt,name=month,proto3" json:"month,omitempty"` // 1..12
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *YearMonth) Reset() {
+	*x = YearMonth{}
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *YearMonth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*YearMonth) ProtoMessage() {}
+
+func (x *YearMonth) ProtoReflect() protoreflect.Message {
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use YearMonth.ProtoReflect.Descriptor instead.
+func (*YearMonth) Descriptor() ([]byte, []int) {
+	return file_mysubs_subscription_v1_subscription_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *YearMonth) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+func (x *YearMonth) GetMonth() int32 {
+	if x != nil {
+		return x.Month
+	}
+	return 0
+}
+
+type Subscription struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ServiceName   string                 `protobuf:"bytes,2,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	Price         int64                  `protobuf:"varint,3,opt,name=price,proto3" json:"price,omitempty"`
+	UserId        string                 `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	StartDate     *YearMonth             `protobuf:"bytes,5,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	EndDate       *YearMonth             `protobuf:"bytes,6,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	Notes         string                 `protobuf:"bytes,7,opt,name=notes,proto3" json:"notes,omitempty"`
+	Tags          []string               `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"`
+	Version       int64                  `protobuf:"varint,9,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	CreatedBy     string                 `protobuf:"bytes,12,opt,name=created_by,json=createdBy,proto3" json:"created_by,omitempty"`
+	UpdatedBy     string                 `protobuf:"bytes,13,opt,name=updated_by,json=updatedBy,proto3" json:"updated_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Subscription) Reset() {
+	*x = Subscription{}
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Subscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Subscription) ProtoMessage() {}
+
+func (x *Subscription) ProtoReflect() protoreflect.Message {
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Subscription.ProtoReflect.Descriptor instead.
+func (*Subscription) Descriptor() ([]byte, []int) {
+	return file_mysubs_subscription_v1_subscription_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Subscription) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Subscription) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
+	}
+	return ""
+}
+
+func (x *Subscription) GetPrice() int64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Subscription) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *Subscription) GetStartDate() *YearMonth {
+	if x != nil {
+		return x.StartDate
+	}
+	return nil
+}
+
+func (x *Subscription) GetEndDate() *YearMonth {
+	if x != nil {
+		return x.EndDate
+	}
+	return nil
+}
+
+func (x *Subscription) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+func (x *Subscription) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Subscription) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Subscription) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Subscription) GetCreatedBy() string {
+	if x != nil {
+		return x.CreatedBy
+	}
+	return ""
+}
+
+func (x *Subscription) GetUpdatedBy() string {
+	if x != nil {
+		return x.UpdatedBy
+	}
+	return ""
+}
+
+type CreateSubscriptionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ServiceName   string                 `protobuf:"bytes,1,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	Price         int64                  `protobuf:"varint,2,opt,name=price,proto3" json:"price,omitempty"`
+	UserId        string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	StartDate     *YearMonth             `protobuf:"bytes,4,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	EndDate       *YearMonth             `protobuf:"bytes,5,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	Notes         string                 `protobuf:"bytes,6,opt,name=notes,proto3" json:"notes,omitempty"`
+	Tags          []string               `protobuf:"bytes,7,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSubscriptionRequest) Reset() {
+	*x = CreateSubscriptionRequest{}
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSubscriptionRequest) ProtoMessage() {}
+
+func (x *CreateSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*CreateSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_mysubs_subscription_v1_subscription_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateSubscriptionRequest) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
+	}
+	return ""
+}
+
+func (x *CreateSubscriptionRequest) GetPrice() int64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *CreateSubscriptionRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateSubscriptionRequest) GetStartDate() *YearMonth {
+	if x != nil {
+		return x.StartDate
+	}
+	return nil
+}
+
+func (x *CreateSubscriptionRequest) GetEndDate() *YearMonth {
+	if x != nil {
+		return x.EndDate
+	}
+	return nil
+}
+
+func (x *CreateSubscriptionRequest) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+func (x *CreateSubscriptionRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type GetSubscriptionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSubscriptionRequest) Reset() {
+	*x = GetSubscriptionRequest{}
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSubscriptionRequest) ProtoMessage() {}
+
+func (x *GetSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*GetSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_mysubs_subscription_v1_subscription_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetSubscriptionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type UpdateSubscriptionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// ожидаемая версия подписки; 0 — без проверки
+	Version       int64      `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	ServiceName   string     `protobuf:"bytes,3,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	Price         int64      `protobuf:"varint,4,opt,name=price,proto3" json:"price,omitempty"`
+	UserId        string     `protobuf:"bytes,5,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	StartDate     *YearMonth `protobuf:"bytes,6,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	EndDate       *YearMonth `protobuf:"bytes,7,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	Notes         string     `protobuf:"bytes,8,opt,name=notes,proto3" json:"notes,omitempty"`
+	Tags          []string   `protobuf:"bytes,9,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateSubscriptionRequest) Reset() {
+	*x = UpdateSubscriptionRequest{}
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateSubscriptionRequest) ProtoMessage() {}
+
+func (x *UpdateSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*UpdateSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_mysubs_subscription_v1_subscription_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UpdateSubscriptionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateSubscriptionRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *UpdateSubscriptionRequest) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
+	}
+	return ""
+}
+
+func (x *UpdateSubscriptionRequest) GetPrice() int64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *UpdateSubscriptionRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *UpdateSubscriptionRequest) GetStartDate() *YearMonth {
+	if x != nil {
+		return x.StartDate
+	}
+	return nil
+}
+
+func (x *UpdateSubscriptionRequest) GetEndDate() *YearMonth {
+	if x != nil {
+		return x.EndDate
+	}
+	return nil
+}
+
+func (x *UpdateSubscriptionRequest) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+func (x *UpdateSubscriptionRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type DeleteSubscriptionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// ожидаемая версия подписки; 0 — без проверки
+	Version       int64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSubscriptionRequest) Reset() {
+	*x = DeleteSubscriptionRequest{}
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSubscriptionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSubscriptionRequest) ProtoMessage() {}
+
+func (x *DeleteSubscriptionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSubscriptionRequest.ProtoReflect.Descriptor instead.
+func (*DeleteSubscriptionRequest) Descriptor() ([]byte, []int) {
+	return file_mysubs_subscription_v1_subscription_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DeleteSubscriptionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeleteSubscriptionRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type DeleteSubscriptionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSubscriptionResponse) Reset() {
+	*x = DeleteSubscriptionResponse{}
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSubscriptionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSubscriptionResponse) ProtoMessage() {}
+
+func (x *DeleteSubscriptionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSubscriptionResponse.ProtoReflect.Descriptor instead.
+func (*DeleteSubscriptionResponse) Descriptor() ([]byte, []int) {
+	return file_mysubs_subscription_v1_subscription_proto_rawDescGZIP(), []int{6}
+}
+
+// ListSubscriptionsRequest — фильтры как у GET /v1/subscriptions; пустые поля не применяются
+type ListSubscriptionsRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	UserId      string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ServiceName string                 `protobuf:"bytes,2,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	ActiveAt    *YearMonth             `protobuf:"bytes,3,opt,name=active_at,json=activeAt,proto3" json:"active_at,omitempty"`
+	PriceMin    *int64                 `protobuf:"varint,4,opt,name=price_min,json=priceMin,proto3,oneof" json:"price_min,omitempty"`
+	PriceMax    *int64                 `protobuf:"varint,5,opt,name=price_max,json=priceMax,proto3,oneof" json:"price_max,omitempty"`
+	From        *YearMonth             `protobuf:"bytes,6,opt,name=from,proto3" json:"from,omitempty"`
+	To          *YearMonth             `protobuf:"bytes,7,opt,name=to,proto3" json:"to,omitempty"`
+	// "price,-start_date": поля через запятую, «-» — по убыванию
+	Sort          string `protobuf:"bytes,8,opt,name=sort,proto3" json:"sort,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSubscriptionsRequest) Reset() {
+	*x = ListSubscriptionsRequest{}
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSubscriptionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSubscriptionsRequest) ProtoMessage() {}
+
+func (x *ListSubscriptionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSubscriptionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSubscriptionsRequest) Descriptor() ([]byte, []int) {
+	return file_mysubs_subscription_v1_subscription_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListSubscriptionsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListSubscriptionsRequest) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
+	}
+	return ""
+}
+
+func (x *ListSubscriptionsRequest) GetActiveAt() *YearMonth {
+	if x != nil {
+		return x.ActiveAt
+	}
+	return nil
+}
+
+func (x *ListSubscriptionsRequest) GetPriceMin() int64 {
+	if x != nil && x.PriceMin != nil {
+		return *x.PriceMin
+	}
+	return 0
+}
+
+func (x *ListSubscriptionsRequest) GetPriceMax() int64 {
+	if x != nil && x.PriceMax != nil {
+		return *x.PriceMax
+	}
+	return 0
+}
+
+func (x *ListSubscriptionsRequest) GetFrom() *YearMonth {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *ListSubscriptionsRequest) GetTo() *YearMonth {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *ListSubscriptionsRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+type TotalCostRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ServiceName   string                 `protobuf:"bytes,2,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	From          *YearMonth             `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	To            *YearMonth             `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TotalCostRequest) Reset() {
+	*x = TotalCostRequest{}
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TotalCostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TotalCostRequest) ProtoMessage() {}
+
+func (x *TotalCostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TotalCostRequest.ProtoReflect.Descriptor instead.
+func (*TotalCostRequest) Descriptor() ([]byte, []int) {
+	return file_mysubs_subscription_v1_subscription_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TotalCostRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *TotalCostRequest) GetServiceName() string {
+	if x != nil {
+		return x.ServiceName
+	}
+	return ""
+}
+
+func (x *TotalCostRequest) GetFrom() *YearMonth {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *TotalCostRequest) GetTo() *YearMonth {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+type TotalCostResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TotalCost     int64                  `protobuf:"varint,1,opt,name=total_cost,json=totalCost,proto3" json:"total_cost,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TotalCostResponse) Reset() {
+	*x = TotalCostResponse{}
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TotalCostResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TotalCostResponse) ProtoMessage() {}
+
+func (x *TotalCostResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mysubs_subscription_v1_subscription_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TotalCostResponse.ProtoReflect.Descriptor instead.
+func (*TotalCostResponse) Descriptor() ([]byte, []int) {
+	return file_mysubs_subscription_v1_subscription_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *TotalCostResponse) GetTotalCost() int64 {
+	if x != nil {
+		return x.TotalCost
+	}
+	return 0
+}
+
+var File_mysubs_subscription_v1_subscription_proto protoreflect.FileDescriptor
+
+const file_mysubs_subscription_v1_subscription_proto_rawDesc = "" +
+	"\n" +
+	")mysubs/subscription/v1/subscription.proto\x12\x16mysubs.subscription.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"5\n" +
+	"\tYearMonth\x12\x12\n" +
+	"\x04year\x18\x01 \x01(\x05R\x04year\x12\x14\n" +
+	"\x05month\x18\x02 \x01(\x05R\x05month\"\xe8\x03\n" +
+	"\fSubscription\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12!\n" +
+	"\fservice_name\x18\x02 \x01(\tR\vserviceName\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\x03R\x05price\x12\x17\n" +
+	"\auser_id\x18\x04 \x01(\tR\x06userId\x12@\n" +
+	"\n" +
+	"start_date\x18\x05 \x01(\v2!.mysubs.subscription.v1.YearMonthR\tstartDate\x12<\n" +
+	"\bend_date\x18\x06 \x01(\v2!.mysubs.subscription.v1.YearMonthR\aendDate\x12\x14\n" +
+	"\x05notes\x18\a \x01(\tR\x05notes\x12\x12\n" +
+	"\x04tags\x18\b \x03(\tR\x04tags\x12\x18\n" +
+	"\aversion\x18\t \x01(\x03R\aversion\x129\n" +
+	"\n" +
+	"created_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1d\n" +
+	"\n" +
+	"created_by\x18\f \x01(\tR\tcreatedBy\x12\x1d\n" +
+	"\n" +
+	"updated_by\x18\r \x01(\tR\tupdatedBy\"\x97\x02\n" +
+	"\x19CreateSubscriptionRequest\x12!\n" +
+	"\fservice_name\x18\x01 \x01(\tR\vserviceName\x12\x14\n" +
+	"\x05price\x18\x02 \x01(\x03R\x05price\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12@\n" +
+	"\n" +
+	"start_date\x18\x04 \x01(\v2!.mysubs.subscription.v1.YearMonthR\tstartDate\x12<\n" +
+	"\bend_date\x18\x05 \x01(\v2!.mysubs.subscription.v1.YearMonthR\aendDate\x12\x14\n" +
+	"\x05notes\x18\x06 \x01(\tR\x05notes\x12\x12\n" +
+	"\x04tags\x18\a \x03(\tR\x04tags\"(\n" +
+	"\x16GetSubscriptionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xc1\x02\n" +
+	"\x19UpdateSubscriptionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12!\n" +
+	"\fservice_name\x18\x03 \x01(\tR\vserviceName\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\x03R\x05price\x12\x17\n" +
+	"\auser_id\x18\x05 \x01(\tR\x06userId\x12@\n" +
+	"\n" +
+	"start_date\x18\x06 \x01(\v2!.mysubs.subscription.v1.YearMonthR\tstartDate\x12<\n" +
+	"\bend_date\x18\a \x01(\v2!.mysubs.subscription.v1.YearMonthR\aendDate\x12\x14\n" +
+	"\x05notes\x18\b \x01(\tR\x05notes\x12\x12\n" +
+	"\x04tags\x18\t \x03(\tR\x04tags\"E\n" +
+	"\x19DeleteSubscriptionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"\x1c\n" +
+	"\x1aDeleteSubscriptionResponse\"\xf4\x02\n" +
+	"\x18ListSubscriptionsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12!\n" +
+	"\fservice_name\x18\x02 \x01(\tR\vserviceName\x12>\n" +
+	"\tactive_at\x18\x03 \x01(\v2!.mysubs.subscription.v1.YearMonthR\bactiveAt\x12 \n" +
+	"\tprice_min\x18\x04 \x01(\x03H\x00R\bpriceMin\x88\x01\x01\x12 \n" +
+	"\tprice_max\x18\x05 \x01(\x03H\x01R\bpriceMax\x88\x01\x01\x125\n" +
+	"\x04from\x18\x06 \x01(\v2!.mysubs.subscription.v1.YearMonthR\x04from\x121\n" +
+	"\x02to\x18\a \x01(\v2!.mysubs.subscription.v1.YearMonthR\x02to\x12\x12\n" +
+	"\x04sort\x18\b \x01(\tR\x04sortB\f\n" +
+	"\n" +
+	"_price_minB\f\n" +
+	"\n" +
+	"_price_max\"\xb8\x01\n" +
+	"\x10TotalCostRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12!\n" +
+	"\fservice_name\x18\x02 \x01(\tR\vserviceName\x125\n" +
+	"\x04from\x18\x03 \x01(\v2!.mysubs.subscription.v1.YearMonthR\x04from\x121\n" +
+	"\x02to\x18\x04 \x01(\v2!.mysubs.subscription.v1.YearMonthR\x02to\"2\n" +
+	"\x11TotalCostResponse\x12\x1d\n" +
+	"\n" +
+	"total_cost\x18\x01 \x01(\x03R\ttotalCost2\xaa\x05\n" +
+	"\x13SubscriptionService\x12m\n" +
+	"\x12CreateSubscription\x121.mysubs.subscription.v1.CreateSubscriptionRequest\x1a$.mysubs.subscription.v1.Subscription\x12g\n" +
+	"\x0fGetSubscription\x12..mysubs.subscription.v1.GetSubscriptionRequest\x1a$.mysubs.subscription.v1.Subscription\x12m\n" +
+	"\x12UpdateSubscription\x121.mysubs.subscription.v1.UpdateSubscriptionRequest\x1a$.mysubs.subscription.v1.Subscription\x12{\n" +
+	"\x12DeleteSubscription\x121.mysubs.subscription.v1.DeleteSubscriptionRequest\x1a2.mysubs.subscription.v1.DeleteSubscriptionResponse\x12m\n" +
+	"\x11ListSubscriptions\x120.mysubs.subscription.v1.ListSubscriptionsRequest\x1a$.mysubs.subscription.v1.Subscription0\x01\x12`\n" +
+	"\tTotalCost\x12(.mysubs.subscription.v1.TotalCostRequest\x1a).mysubs.subscription.v1.TotalCostResponseBQZOgithub.com/EgorLis/my-subs/internal/transport/rpc/subscriptionv1;subscriptionv1b\x06proto3"
+
+var (
+	file_mysubs_subscription_v1_subscription_proto_rawDescOnce sync.Once
+	file_mysubs_subscription_v1_subscription_proto_rawDescData []byte
+)
+
+func file_mysubs_subscription_v1_subscription_proto_rawDescGZIP() []byte {
+	file_mysubs_subscription_v1_subscription_proto_rawDescOnce.Do(func() {
+		file_mysubs_subscription_v1_subscription_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_mysubs_subscription_v1_subscription_proto_rawDesc), len(file_mysubs_subscription_v1_subscription_proto_rawDesc)))
+	})
+	return file_mysubs_subscription_v1_subscription_proto_rawDescData
+}
+
+var file_mysubs_subscription_v1_subscription_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_mysubs_subscription_v1_subscription_proto_goTypes = []any{
+	(*YearMonth)(nil),                  // 0: mysubs.subscription.v1.YearMonth
+	(*Subscription)(nil),               // 1: mysubs.subscription.v1.Subscription
+	(*CreateSubscriptionRequest)(nil),  // 2: mysubs.subscription.v1.CreateSubscriptionRequest
+	(*GetSubscriptionRequest)(nil),     // 3: mysubs.subscription.v1.GetSubscriptionRequest
+	(*UpdateSubscriptionRequest)(nil),  // 4: mysubs.subscription.v1.UpdateSubscriptionRequest
+	(*DeleteSubscriptionRequest)(nil),  // 5: mysubs.subscription.v1.DeleteSubscriptionRequest
+	(*DeleteSubscriptionResponse)(nil), // 6: mysubs.subscription.v1.DeleteSubscriptionResponse
+	(*ListSubscriptionsRequest)(nil),   // 7: mysubs.subscription.v1.ListSubscriptionsRequest
+	(*TotalCostRequest)(nil),           // 8: mysubs.subscription.v1.TotalCostRequest
+	(*TotalCostResponse)(nil),          // 9: mysubs.subscription.v1.TotalCostResponse
+	(*timestamppb.Timestamp)(nil),      // 10: google.protobuf.Timestamp
+}
+var file_mysubs_subscription_v1_subscription_proto_depIdxs = []int32{
+	0,  // 0: mysubs.subscription.v1.Subscription.start_date:type_name -> mysubs.subscription.v1.YearMonth
+	0,  // 1: mysubs.subscription.v1.Subscription.end_date:type_name -> mysubs.subscription.v1.YearMonth
+	10, // 2: mysubs.subscription.v1.Subscription.created_at:type_name -> google.protobuf.Timestamp
+	10, // 3: mysubs.subscription.v1.Subscription.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 4: mysubs.subscription.v1.CreateSubscriptionRequest.start_date:type_name -> mysubs.subscription.v1.YearMonth
+	0,  // 5: mysubs.subscription.v1.CreateSubscriptionRequest.end_date:type_name -> mysubs.subscription.v1.YearMonth
+	0,  // 6: mysubs.subscription.v1.UpdateSubscriptionRequest.start_date:type_name -> mysubs.subscription.v1.YearMonth
+	0,  // 7: mysubs.subscription.v1.UpdateSubscriptionRequest.end_date:type_name -> mysubs.subscription.v1.YearMonth
+	0,  // 8: mysubs.subscription.v1.ListSubscriptionsRequest.active_at:type_name -> mysubs.subscription.v1.YearMonth
+	0,  // 9: mysubs.subscription.v1.ListSubscriptionsRequest.from:type_name -> mysubs.subscription.v1.YearMonth
+	0,  // 10: mysubs.subscription.v1.ListSubscriptionsRequest.to:type_name -> mysubs.subscription.v1.YearMonth
+	0,  // 11: mysubs.subscription.v1.TotalCostRequest.from:type_name -> mysubs.subscription.v1.YearMonth
+	0,  // 12: mysubs.subscription.v1.TotalCostRequest.to:type_name -> mysubs.subscription.v1.YearMonth
+	2,  // 13: mysubs.subscription.v1.SubscriptionService.CreateSubscription:input_type -> mysubs.subscription.v1.CreateSubscriptionRequest
+	3,  // 14: mysubs.subscription.v1.SubscriptionService.GetSubscription:input_type -> mysubs.subscription.v1.GetSubscriptionRequest
+	4,  // 15: mysubs.subscription.v1.SubscriptionService.UpdateSubscription:input_type -> mysubs.subscription.v1.UpdateSubscriptionRequest
+	5,  // 16: mysubs.subscription.v1.SubscriptionService.DeleteSubscription:input_type -> mysubs.subscription.v1.DeleteSubscriptionRequest
+	7,  // 17: mysubs.subscription.v1.SubscriptionService.ListSubscriptions:input_type -> mysubs.subscription.v1.ListSubscriptionsRequest
+	8,  // 18: mysubs.subscription.v1.SubscriptionService.TotalCost:input_type -> mysubs.subscription.v1.TotalCostRequest
+	1,  // 19: mysubs.subscription.v1.SubscriptionService.CreateSubscription:output_type -> mysubs.subscription.v1.Subscription
+	1,  // 20: mysubs.subscription.v1.SubscriptionService.GetSubscription:output_type -> mysubs.subscription.v1.Subscription
+	1,  // 21: mysubs.subscription.v1.SubscriptionService.UpdateSubscription:output_type -> mysubs.subscription.v1.Subscription
+	6,  // 22: mysubs.subscription.v1.SubscriptionService.DeleteSubscription:output_type -> mysubs.subscription.v1.DeleteSubscriptionResponse
+	1,  // 23: mysubs.subscription.v1.SubscriptionService.ListSubscriptions:output_type -> mysubs.subscription.v1.Subscription
+	9,  // 24: mysubs.subscription.v1.SubscriptionService.TotalCost:output_type -> mysubs.subscription.v1.TotalCostResponse
+	19, // [19:25] is the sub-list for method output_type
+	13, // [13:19] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_mysubs_subscription_v1_subscription_proto_init() }
+func file_mysubs_subscription_v1_subscription_proto_init() {
+	if File_mysubs_subscription_v1_subscription_proto != nil {
+		return
+	}
+	file_mysubs_subscription_v1_subscription_proto_msgTypes[7].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_mysubs_subscription_v1_subscription_proto_rawDesc), len(file_mysubs_subscription_v1_subscription_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_mysubs_subscription_v1_subscription_proto_goTypes,
+		DependencyIndexes: file_mysubs_subscription_v1_subscription_proto_depIdxs,
+		MessageInfos:      file_mysubs_subscription_v1_subscription_proto_msgTypes,
+	}.Build()
+	File_mysubs_subscription_v1_subscription_proto = out.File
+	file_mysubs_subscription_v1_subscription_proto_goTypes = nil
+	file_mysubs_subscription_v1_subscription_proto_depIdxs = nil
+}
@@ -0,0 +1,104 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// MaxBreakdownMonths ограничивает период costBreakdown
+const MaxBreakdownMonths = 120
+
+type costBreakdown struct {
+	Total   int32
+	Buckets []costBucket
+}
+
+type costBucket struct {
+	Key     string
+	Amount  int32
+	Charges int32
+}
+
+// breakdownKey — ключ группы списания для BreakdownGroup
+func breakdownKey(groupBy string, c domain.Charge) string {
+	switch groupBy {
+	case "SERVICE":
+		return c.ServiceName
+	case "USER":
+		return c.UserID
+	}
+	return c.Month.Format("01-2006")
+}
+
+// CostBreakdown раскладывает подписки на ежемесячные списания (domain.Subscription.Charges) и суммирует их по группам.
+// Месяцы идут по порядку, сервисы и пользователи — по убыванию суммы.
+func (r *queryResolver) CostBreakdown(ctx context.Context, args struct {
+	UserID      *graphql.ID
+	ServiceName *string
+	From        string
+	To          string
+	GroupBy     string
+}) (*costBreakdown, error) {
+	from, to, errs := parsePeriod(args.From, args.To)
+	f := domain.SubscriptionFilter{From: from.ToTime(), To: to.ToTime()}
+	if args.UserID != nil {
+		if err := subscription.ValidateGUID(string(*args.UserID)); err != nil {
+			errs = append(errs, "user_id: "+err.Error())
+		}
+		f.UserID = string(*args.UserID)
+	}
+	if args.ServiceName != nil {
+		f.ServiceName = strings.TrimSpace(*args.ServiceName)
+	}
+	if len(errs) == 0 {
+		if f.To.Before(f.From) {
+			errs = append(errs, "date range: from must be <= to")
+		} else if f.From.AddDate(0, MaxBreakdownMonths, 0).Before(f.To) {
+			errs = append(errs, "date range: must be at most 120 months")
+		}
+	}
+	if len(errs) > 0 {
+		return nil, badInput(errors.New(strings.Join(errs, "; ")))
+	}
+
+	res := &costBreakdown{Buckets: make([]costBucket, 0)}
+	idx := make(map[string]int)
+	err := r.repo.StreamSubs(ctx, f, func(s domain.Subscription) error {
+		for _, c := range s.Charges(f.From, f.To) {
+			key := breakdownKey(args.GroupBy, c)
+			i, ok := idx[key]
+			if !ok {
+				i = len(res.Buckets)
+				idx[key] = i
+				res.Buckets = append(res.Buckets, costBucket{Key: key})
+			}
+			res.Buckets[i].Amount += int32(c.Amount)
+			res.Buckets[i].Charges++
+			res.Total += int32(c.Amount)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, r.repoError(ctx, "graphql.cost_breakdown", err)
+	}
+
+	sort.Slice(res.Buckets, func(i, j int) bool {
+		a, b := res.Buckets[i], res.Buckets[j]
+		if args.GroupBy == "MONTH" {
+			am, _ := subscription.YMFromStr(a.Key)
+			bm, _ := subscription.YMFromStr(b.Key)
+			return am.ToTime().Before(bm.ToTime())
+		}
+		if a.Amount != b.Amount {
+			return a.Amount > b.Amount
+		}
+		return a.Key < b.Key
+	})
+	return res, nil
+}
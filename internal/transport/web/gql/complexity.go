@@ -0,0 +1,127 @@
+package gql
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// listSizes — сколько элементов вернут списковые поля без аргумента first.
+// У subscriptions это first по умолчанию из схемы; у users пагинации нет, берём оценку.
+var listSizes = map[string]int{
+	"subscriptions": 50,
+	"users":         100,
+}
+
+// complexity оценивает стоимость операции: каждое поле стоит 1, а стоимость вложенных полей списка
+// умножается на его размер (first или listSizes). Так subscriptions(first: 500) { nodes { user { ... } } }
+// стоит во много раз дороже одной подписки, хотя глубина у них одинаковая.
+// Подсчёт останавливается, как только стоимость превысила limit: тогда возвращается limit+1.
+// Стоимость фрагмента считается один раз, поэтому вложенные фрагменты не раскрываются заново при каждом использовании.
+// Ошибки синтаксиса не считаются здесь: их вернёт graphql-go с указанием места.
+func complexity(query, operationName string, vars map[string]any, limit int) (int, bool) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return 0, false
+	}
+	op := doc.Operations.ForName(operationName)
+	if op == nil {
+		return 0, false
+	}
+	c := &costCounter{doc: doc, op: op, vars: vars, limit: limit, fragments: map[string]int{}, visiting: map[string]bool{}}
+	return c.selectionSet(op.SelectionSet), true
+}
+
+type costCounter struct {
+	doc       *ast.QueryDocument
+	op        *ast.OperationDefinition
+	vars      map[string]any
+	limit     int             // при превышении счёт прекращается
+	fragments map[string]int  // посчитанная стоимость фрагментов по имени
+	visiting  map[string]bool // фрагменты на текущем пути: циклы отклонит валидация, здесь их просто не раскрываем
+}
+
+// over — стоимость, которая заведомо больше limit
+func (c *costCounter) over() int {
+	return c.limit + 1
+}
+
+func (c *costCounter) selectionSet(set ast.SelectionSet) int {
+	total := 0
+	for _, sel := range set {
+		switch s := sel.(type) {
+		case *ast.Field:
+			inner, n := c.selectionSet(s.SelectionSet), c.listSize(s)
+			// произведение сравнивается с limit делением, чтобы не переполнить int
+			if inner > 0 && n > c.limit/inner {
+				return c.over()
+			}
+			total += 1 + n*inner
+		case *ast.InlineFragment:
+			total += c.selectionSet(s.SelectionSet)
+		case *ast.FragmentSpread:
+			total += c.fragment(s.Name)
+		}
+		if total > c.limit {
+			return c.over()
+		}
+	}
+	return total
+}
+
+func (c *costCounter) fragment(name string) int {
+	if cost, ok := c.fragments[name]; ok {
+		return cost
+	}
+	frag := c.doc.Fragments.ForName(name)
+	if frag == nil || c.visiting[name] {
+		return 0
+	}
+	c.visiting[name] = true
+	cost := c.selectionSet(frag.SelectionSet)
+	delete(c.visiting, name)
+	c.fragments[name] = cost
+	return cost
+}
+
+func (c *costCounter) listSize(f *ast.Field) int {
+	if arg := f.Arguments.ForName("first"); arg != nil {
+		if n, ok := c.intValue(arg.Value); ok && n > 0 {
+			return n
+		}
+	}
+	if n, ok := listSizes[f.Name]; ok {
+		return n
+	}
+	return 1
+}
+
+// intValue — значение целочисленного аргумента: литерал или переменная (с её значением по умолчанию)
+func (c *costCounter) intValue(v *ast.Value) (int, bool) {
+	if v.Kind == ast.Variable {
+		raw, ok := c.vars[v.Raw]
+		if !ok {
+			if def := c.op.VariableDefinitions.ForName(v.Raw); def != nil && def.DefaultValue != nil {
+				return c.intValue(def.DefaultValue)
+			}
+			return 0, false
+		}
+		switch n := raw.(type) {
+		case float64:
+			return int(n), true
+		case json.Number:
+			i, err := n.Int64()
+			return int(i), err == nil
+		case int:
+			return n, true
+		}
+		return 0, false
+	}
+	if v.Kind != ast.IntValue {
+		return 0, false
+	}
+	n, err := strconv.Atoi(v.Raw)
+	return n, err == nil
+}
@@ -0,0 +1,53 @@
+package gql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+// Коды ошибок в extensions.code; соответствуют статусам REST
+const (
+	CodeBadInput        = "BAD_USER_INPUT"   // 400
+	CodeNotFound        = "NOT_FOUND"        // 404
+	CodeVersionMismatch = "VERSION_MISMATCH" // 412
+	CodeTimeout         = "TIMEOUT"          // 504
+	CodeInternal        = "INTERNAL"         // 500
+	CodeComplexity      = "COMPLEXITY_LIMIT"
+)
+
+// Error — ошибка резолвера; graphql-go переносит Extensions в ответ
+type Error struct {
+	Code string
+	Msg  string
+}
+
+func (e *Error) Error() string { return e.Msg }
+
+func (e *Error) Extensions() map[string]any { return map[string]any{"code": e.Code} }
+
+func badInput(err error) error { return &Error{Code: CodeBadInput, Msg: err.Error()} }
+
+// repoError переводит ошибки репозитория в ошибки GraphQL; неожиданные логируются и скрываются от клиента
+func (r *resolver) repoError(ctx context.Context, op string, err error) error {
+	reqID := mw.RequestIDFromCtx(ctx)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return &Error{Code: CodeNotFound, Msg: "subscription not found"}
+	case errors.Is(err, domain.ErrTenantNotFound):
+		return &Error{Code: CodeNotFound, Msg: "tenant not found"}
+	case errors.Is(err, domain.ErrUserNotFound):
+		return &Error{Code: CodeBadInput, Msg: "user_id: unknown user"}
+	case errors.Is(err, domain.ErrVersionMismatch):
+		return &Error{Code: CodeVersionMismatch, Msg: "version mismatch"}
+	case v1.IsTimeout(err):
+		logx.Error(r.log, reqID, op, "repo timeout", err)
+		return &Error{Code: CodeTimeout, Msg: "request timed out"}
+	}
+	logx.Error(r.log, reqID, op, "repo failed", err)
+	return &Error{Code: CodeInternal, Msg: "internal error"}
+}
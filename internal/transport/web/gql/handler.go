@@ -0,0 +1,117 @@
+package gql
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+//go:embed schema.graphql
+var Schema string
+
+// MaxQueryLength — ограничение на размер текста запроса, байт
+const MaxQueryLength = 16 << 10
+
+type Handler struct {
+	Log           *log.Logger
+	Repo          Repository
+	MaxComplexity int // см. complexity; 0 — без ограничения
+
+	schema *graphql.Schema
+}
+
+// NewHandler разбирает схему; maxDepth ограничивает вложенность полей, maxComplexity — оценку стоимости запроса.
+// Мутации публикуют в events те же события, что и REST; nil — события не публикуются
+func NewHandler(logger *log.Logger, repo Repository, events subscription.EventPublisher, maxDepth, maxComplexity int) *Handler {
+	res := &resolver{repo: repo, log: logger, events: events}
+	return &Handler{
+		Log:           logger,
+		Repo:          repo,
+		MaxComplexity: maxComplexity,
+		schema: graphql.MustParseSchema(Schema, res,
+			graphql.UseStringDescriptions(),
+			graphql.UseFieldResolvers(),
+			graphql.MaxDepth(maxDepth),
+			graphql.MaxQueryLength(MaxQueryLength),
+		),
+	}
+}
+
+// Request — тело POST /graphql
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+	Extensions    map[string]any `json:"extensions,omitempty"` // допускается по GraphQL over HTTP, не используется
+}
+
+// ServeHTTP godoc
+// @Summary      GraphQL endpoint
+// @Description  Запросы и мутации GraphQL: подписки с фильтрами и пагинацией, пользователи, totalCost и costBreakdown.
+// @Description  Схема — GET /graphql/schema. Ошибки возвращаются в errors со статусом 200, код — в extensions.code.
+// @Tags         graphql
+// @Accept       json
+// @Produce      json
+// @Param        request  body  gql.Request  true  "Запрос GraphQL"
+// @Success      200  {object}  map[string]any
+// @Failure      400  {object}  v1.Problem
+// @Router       /graphql [post]
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const op = "graphql.exec"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	var req Request
+	if err := v1.DecodeJSON(r.Body, &req); err != nil {
+		logx.Error(h.Log, reqID, op, "invalid JSON", err)
+		v1.WriteDecodeError(w, err)
+		return
+	}
+	if req.Query == "" {
+		logx.Info(h.Log, reqID, op, "empty query")
+		v1.WriteValidationError(w, v1.NewFieldError("query", "required"))
+		return
+	}
+	defer r.Body.Close()
+
+	if h.MaxComplexity > 0 {
+		if cost, ok := complexity(req.Query, req.OperationName, req.Variables, h.MaxComplexity); ok && cost > h.MaxComplexity {
+			logx.Info(h.Log, reqID, op, "complexity limit", "limit", h.MaxComplexity)
+			v1.WriteJSON(w, http.StatusOK, &graphql.Response{Errors: []*gqlerrors.QueryError{{
+				Message:    fmt.Sprintf("query complexity exceeds limit %d", h.MaxComplexity),
+				Extensions: map[string]any{"code": CodeComplexity},
+			}}})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	ctx = withUserLoader(ctx, h.Repo)
+
+	resp := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+	logx.Info(h.Log, reqID, op, "executed", "operation", req.OperationName, "errors", len(resp.Errors))
+	v1.WriteJSON(w, http.StatusOK, resp)
+}
+
+// SchemaSDL godoc
+// @Summary      GraphQL schema
+// @Description  Схема GraphQL в SDL
+// @Tags         graphql
+// @Produce      plain
+// @Success      200  {string}  string
+// @Router       /graphql/schema [get]
+func (h *Handler) SchemaSDL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(Schema))
+}
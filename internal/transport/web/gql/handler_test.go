@@ -0,0 +1,363 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	mockrepo "github.com/EgorLis/my-subs/internal/infra/database/mock"
+)
+
+// countingRepo считает обращения к UsersByIDs, чтобы проверить пакетную загрузку
+type countingRepo struct {
+	*mockrepo.Repo
+	batches int
+}
+
+func (r *countingRepo) UsersByIDs(ctx context.Context, ids []string) ([]domain.User, error) {
+	r.batches++
+	return r.Repo.UsersByIDs(ctx, ids)
+}
+
+type timeoutRepo struct{ Repository }
+
+func (timeoutRepo) GetSub(ctx context.Context, id string) (domain.Subscription, error) {
+	return domain.Subscription{}, context.DeadlineExceeded
+}
+
+type gqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func exec(t *testing.T, h *Handler, query string, vars map[string]any) gqlResponse {
+	t.Helper()
+	body, _ := json.Marshal(Request{Query: query, Variables: vars})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d. body=%s", w.Code, w.Body.String())
+	}
+	var resp gqlResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v; body=%s", err, w.Body.String())
+	}
+	return resp
+}
+
+func month(mm, yyyy int) time.Time {
+	return time.Date(yyyy, time.Month(mm), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func seed(t *testing.T) (*countingRepo, []domain.User) {
+	t.Helper()
+	repo := &countingRepo{Repo: mockrepo.NewMockRepo()}
+	ctx := context.Background()
+	var users []domain.User
+	for _, name := range []string{"Ivan", "Petr", "Anna"} {
+		u, _ := repo.AddUser(ctx, domain.User{DisplayName: name, Currency: "RUB"})
+		users = append(users, u)
+	}
+	for i, s := range []domain.Subscription{
+		{ServiceName: "Netflix", Price: 500, StartDate: month(1, 2025), EndDate: month(3, 2025)},
+		{ServiceName: "Spotify", Price: 300, StartDate: month(2, 2025), EndDate: month(3, 2025)},
+		{ServiceName: "Netflix", Price: 700, StartDate: month(3, 2025), EndDate: month(12, 2025)},
+	} {
+		s.UserID = users[i].ID
+		if _, err := repo.AddSub(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo, users
+}
+
+func newHandler(repo Repository) *Handler {
+	return NewHandler(log.New(io.Discard, "", 0), repo, nil, 8, 2000)
+}
+
+// recordingPublisher запоминает типы опубликованных событий
+type recordingPublisher struct{ got []string }
+
+func (p *recordingPublisher) Publish(ctx context.Context, event string, data any) error {
+	p.got = append(p.got, event)
+	return nil
+}
+
+func TestQuery_SubscriptionsWithUsers(t *testing.T) {
+	repo, _ := seed(t)
+	h := newHandler(repo)
+
+	resp := exec(t, h, `query($first: Int) {
+		subscriptions(filter: {serviceName: "Netflix"}, sort: "price", first: $first) {
+			nodes { serviceName price startDate user { displayName } }
+			pageInfo { hasNextPage endCursor }
+		}
+	}`, map[string]any{"first": 1})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("unexpected errors: %+v", resp.Errors)
+	}
+	var data struct {
+		Subscriptions struct {
+			Nodes []struct {
+				ServiceName string
+				Price       int
+				StartDate   string
+				User        struct{ DisplayName string }
+			}
+			PageInfo struct {
+				HasNextPage bool
+				EndCursor   string
+			}
+		}
+	}
+	_ = json.Unmarshal(resp.Data, &data)
+	page := data.Subscriptions
+	if len(page.Nodes) != 1 || page.Nodes[0].Price != 500 || page.Nodes[0].StartDate != "01-2025" || page.Nodes[0].User.DisplayName != "Ivan" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+	if !page.PageInfo.HasNextPage || page.PageInfo.EndCursor == "" {
+		t.Fatalf("want next page, got %+v", page.PageInfo)
+	}
+
+	resp = exec(t, h, `query($after: String) {
+		subscriptions(filter: {serviceName: "Netflix"}, sort: "price", after: $after) { nodes { price } pageInfo { hasNextPage } }
+	}`, map[string]any{"after": page.PageInfo.EndCursor})
+	if !strings.Contains(string(resp.Data), `"nodes":[{"price":700}],"pageInfo":{"hasNextPage":false}`) {
+		t.Fatalf("unexpected second page: %s %+v", resp.Data, resp.Errors)
+	}
+}
+
+func TestQuery_UsersAreBatched(t *testing.T) {
+	repo, _ := seed(t)
+	h := newHandler(repo)
+
+	resp := exec(t, h, `{ subscriptions { nodes { user { id displayName } } } }`, nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("unexpected errors: %+v", resp.Errors)
+	}
+	if n := strings.Count(string(resp.Data), "displayName"); n != 3 {
+		t.Fatalf("want 3 users, got %d: %s", n, resp.Data)
+	}
+	if repo.batches != 1 {
+		t.Fatalf("want users loaded in 1 batch, got %d", repo.batches)
+	}
+}
+
+func TestQuery_Totals(t *testing.T) {
+	repo, users := seed(t)
+	h := newHandler(repo)
+
+	cases := []struct {
+		name     string
+		query    string
+		wantData string
+		wantCode string
+	}{
+		{
+			name:     "TotalCost",
+			query:    `{ totalCost(userId: "` + users[0].ID + `", serviceName: "Netflix", from: "01-2025", to: "12-2025") }`,
+			wantData: `{"totalCost":500}`,
+		},
+		{
+			name:     "BreakdownByMonth",
+			query:    `{ costBreakdown(from: "01-2025", to: "04-2025", groupBy: MONTH) { total buckets { key amount charges } } }`,
+			wantData: `{"costBreakdown":{"total":3500,"buckets":[{"key":"01-2025","amount":500,"charges":1},{"key":"02-2025","amount":800,"charges":2},{"key":"03-2025","amount":1500,"charges":3},{"key":"04-2025","amount":700,"charges":1}]}}`,
+		},
+		{
+			name:     "BreakdownByService",
+			query:    `{ costBreakdown(from: "01-2025", to: "04-2025", groupBy: SERVICE) { buckets { key amount } } }`,
+			wantData: `{"costBreakdown":{"buckets":[{"key":"Netflix","amount":2900},{"key":"Spotify","amount":600}]}}`,
+		},
+		{
+			name:     "BreakdownBadPeriod",
+			query:    `{ costBreakdown(from: "05-2025", to: "01-2025", groupBy: USER) { total } }`,
+			wantCode: CodeBadInput,
+		},
+		{
+			name:     "TotalCostBadMonth",
+			query:    `{ totalCost(userId: "` + users[0].ID + `", serviceName: "Netflix", from: "2025-01", to: "12-2025") }`,
+			wantCode: CodeBadInput,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := exec(t, h, tc.query, nil)
+			if tc.wantCode != "" {
+				if len(resp.Errors) == 0 || resp.Errors[0].Extensions["code"] != tc.wantCode {
+					t.Fatalf("want error %s, got %+v", tc.wantCode, resp.Errors)
+				}
+				return
+			}
+			if len(resp.Errors) > 0 {
+				t.Fatalf("unexpected errors: %+v", resp.Errors)
+			}
+			if string(resp.Data) != tc.wantData {
+				t.Fatalf("want %s, got %s", tc.wantData, resp.Data)
+			}
+		})
+	}
+}
+
+func TestMutations(t *testing.T) {
+	repo, users := seed(t)
+	events := &recordingPublisher{}
+	h := NewHandler(log.New(io.Discard, "", 0), repo, events, 8, 2000)
+
+	resp := exec(t, h, `mutation($in: SubscriptionInput!) { createSubscription(input: $in) { id version tags } }`,
+		map[string]any{"in": map[string]any{
+			"serviceName": "Yandex Plus", "price": 400, "userId": users[1].ID,
+			"startDate": "07-2025", "endDate": "12-2025", "tags": []string{"Music"},
+		}})
+	if len(resp.Errors) > 0 {
+		t.Fatalf("create: %+v", resp.Errors)
+	}
+	var created struct {
+		CreateSubscription struct {
+			ID      string
+			Version int
+			Tags    []string
+		}
+	}
+	_ = json.Unmarshal(resp.Data, &created)
+	sub := created.CreateSubscription
+	if sub.ID == "" || sub.Version != 1 || len(sub.Tags) != 1 || sub.Tags[0] != "music" {
+		t.Fatalf("unexpected created subscription: %+v", sub)
+	}
+
+	update := `mutation($id: ID!, $v: Int) {
+		updateSubscription(id: $id, version: $v, input: {serviceName: "Yandex Plus", price: 450, userId: "` + users[1].ID + `", startDate: "07-2025", endDate: "12-2025"}) { price version }
+	}`
+	resp = exec(t, h, update, map[string]any{"id": sub.ID, "v": 1})
+	if string(resp.Data) != `{"updateSubscription":{"price":450,"version":2}}` {
+		t.Fatalf("update: %s %+v", resp.Data, resp.Errors)
+	}
+	resp = exec(t, h, update, map[string]any{"id": sub.ID, "v": 1})
+	if len(resp.Errors) == 0 || resp.Errors[0].Extensions["code"] != CodeVersionMismatch {
+		t.Fatalf("stale update: want %s, got %+v", CodeVersionMismatch, resp.Errors)
+	}
+
+	resp = exec(t, h, `mutation { createSubscription(input: {serviceName: "", price: 0, userId: "x", startDate: "07-2025", endDate: "01-2025"}) { id } }`, nil)
+	if len(resp.Errors) == 0 || resp.Errors[0].Extensions["code"] != CodeBadInput || !strings.Contains(resp.Errors[0].Message, "service_name: required") {
+		t.Fatalf("invalid create: %+v", resp.Errors)
+	}
+
+	resp = exec(t, h, `mutation($id: ID!) { deleteSubscription(id: $id) }`, map[string]any{"id": sub.ID})
+	if string(resp.Data) != `{"deleteSubscription":true}` {
+		t.Fatalf("delete: %s %+v", resp.Data, resp.Errors)
+	}
+	resp = exec(t, h, `query($id: ID!) { subscription(id: $id) { id } }`, map[string]any{"id": sub.ID})
+	if string(resp.Data) != `{"subscription":null}` {
+		t.Fatalf("get deleted: %s %+v", resp.Data, resp.Errors)
+	}
+
+	// события — как у REST; отклонённые мутации их не порождают
+	want := []string{domain.EventSubscriptionCreated, domain.EventSubscriptionUpdated, domain.EventSubscriptionDeleted}
+	if fmt.Sprint(events.got) != fmt.Sprint(want) {
+		t.Fatalf("events: want %v, got %v", want, events.got)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	repo, _ := seed(t)
+	h := newHandler(repo)
+
+	cases := []struct {
+		name     string
+		h        *Handler
+		query    string
+		wantCode string
+		wantMsg  string
+	}{
+		{
+			name:     "Complexity",
+			h:        h,
+			query:    `{ subscriptions(first: 500) { nodes { id serviceName price user { id displayName } } } }`,
+			wantCode: CodeComplexity,
+		},
+		{
+			name: "ComplexityThroughFragment",
+			h:    h,
+			query: `query { users { ...U } }
+				fragment U on User { subscriptions(first: 100) { nodes { id price } } }`,
+			wantCode: CodeComplexity,
+		},
+		{
+			name:    "Depth",
+			h:       h,
+			query:   `{ user(id: "3f1c2b9e-8a4d-4c7e-9f10-2b5a6c7d8e9f") { subscriptions(first: 1) { nodes { user { subscriptions(first: 1) { nodes { user { subscriptions(first: 1) { nodes { id } } } } } } } } } }`,
+			wantMsg: "exceeds max depth",
+		},
+		{
+			name:     "Timeout",
+			h:        newHandler(timeoutRepo{repo}),
+			query:    `{ subscription(id: "3f1c2b9e-8a4d-4c7e-9f10-2b5a6c7d8e9f") { id } }`,
+			wantCode: CodeTimeout,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := exec(t, tc.h, tc.query, nil)
+			if len(resp.Errors) == 0 {
+				t.Fatalf("want error, got %s", resp.Data)
+			}
+			if tc.wantCode != "" && resp.Errors[0].Extensions["code"] != tc.wantCode {
+				t.Fatalf("want %s, got %+v", tc.wantCode, resp.Errors)
+			}
+			if tc.wantMsg != "" && !strings.Contains(resp.Errors[0].Message, tc.wantMsg) {
+				t.Fatalf("want %q, got %+v", tc.wantMsg, resp.Errors)
+			}
+		})
+	}
+
+	t.Run("InvalidBody", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader("{")))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("want 400, got %d", w.Code)
+		}
+	})
+}
+
+// nestedFragments — fragment Fi on Query { ...Fi+1 ...Fi+1 }: без запоминания стоимости фрагментов
+// подсчёт удваивается на каждом уровне
+func nestedFragments(levels int) string {
+	var b strings.Builder
+	b.WriteString("query { ...F0 }\n")
+	for i := range levels {
+		fmt.Fprintf(&b, "fragment F%d on Query { ...F%d ...F%d }\n", i, i+1, i+1)
+	}
+	fmt.Fprintf(&b, "fragment F%d on Query { users { id } }\n", levels)
+	return b.String()
+}
+
+func TestComplexity_NestedFragments(t *testing.T) {
+	// 2^10 копий users { id }: 1024 * (1 + 100*1)
+	if cost, ok := complexity(nestedFragments(10), "", nil, 1<<30); !ok || cost != 1024*101 {
+		t.Fatalf("want %d, got %d %v", 1024*101, cost, ok)
+	}
+
+	start := time.Now()
+	cost, ok := complexity(nestedFragments(1000), "", nil, 5000)
+	if !ok || cost <= 5000 {
+		t.Fatalf("want cost over limit, got %d %v", cost, ok)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("complexity took %s", d)
+	}
+
+	resp := exec(t, newHandler(mockrepo.NewMockRepo()), nestedFragments(1000), nil)
+	if len(resp.Errors) == 0 || resp.Errors[0].Extensions["code"] != CodeComplexity {
+		t.Fatalf("want %s, got %+v", CodeComplexity, resp.Errors)
+	}
+}
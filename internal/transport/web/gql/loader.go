@@ -0,0 +1,70 @@
+package gql
+
+import (
+	"context"
+	"sync"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
+
+type loaderCtxKey struct{}
+
+// userLoader — загрузчик пользователей на время одного запроса. Списки подписок заранее сообщают (prime)
+// id владельцев, и первый же Subscription.user загружает их всех одним UsersByIDs вместо запроса на каждую подписку.
+type userLoader struct {
+	repo    domain.UserRepository
+	mu      sync.Mutex
+	pending map[string]struct{}
+	users   map[string]*domain.User // nil — пользователя нет
+}
+
+func withUserLoader(ctx context.Context, repo domain.UserRepository) context.Context {
+	return context.WithValue(ctx, loaderCtxKey{}, &userLoader{
+		repo:    repo,
+		pending: make(map[string]struct{}),
+		users:   make(map[string]*domain.User),
+	})
+}
+
+func userLoaderFrom(ctx context.Context) *userLoader {
+	l, _ := ctx.Value(loaderCtxKey{}).(*userLoader)
+	return l
+}
+
+// prime откладывает id до ближайшей загрузки
+func (l *userLoader) prime(ids ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, id := range ids {
+		if _, ok := l.users[id]; !ok {
+			l.pending[id] = struct{}{}
+		}
+	}
+}
+
+// load возвращает пользователя, загружая вместе с ним все отложенные id; nil — пользователя нет
+func (l *userLoader) load(ctx context.Context, id string) (*domain.User, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if u, ok := l.users[id]; ok {
+		return u, nil
+	}
+	l.pending[id] = struct{}{}
+	ids := make([]string, 0, len(l.pending))
+	for k := range l.pending {
+		ids = append(ids, k)
+	}
+	users, err := l.repo.UsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range ids {
+		l.users[k] = nil
+	}
+	for i := range users {
+		l.users[users[i].ID] = &users[i]
+	}
+	clear(l.pending)
+	return l.users[id], nil
+}
@@ -0,0 +1,389 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// Repository — GraphQL работает с подписками и их владельцами
+type Repository interface {
+	domain.SubscriptionRepository
+	domain.UserRepository
+}
+
+// resolver — корневой резолвер: поля Query и Mutation — методы queryResolver и mutationResolver,
+// поля остальных типов берутся из структур *Node
+type resolver struct {
+	repo   Repository
+	log    *log.Logger
+	events subscription.EventPublisher
+}
+
+type queryResolver struct{ *resolver }
+
+type mutationResolver struct{ *resolver }
+
+func (r *resolver) Query() *queryResolver { return &queryResolver{r} }
+
+func (r *resolver) Mutation() *mutationResolver { return &mutationResolver{r} }
+
+// publish отправляет событие об изменении; оно уже сохранено, поэтому сбой только логируется
+func (r *resolver) publish(ctx context.Context, op, event string, data any) {
+	if r.events == nil {
+		return
+	}
+	if err := r.events.Publish(ctx, event, data); err != nil {
+		logx.Error(r.log, mw.RequestIDFromCtx(ctx), op, "publish event failed", err, "event", event)
+	}
+}
+
+// ---- типы схемы ----
+
+type subscriptionNode struct {
+	ID          graphql.ID
+	ServiceName string
+	Price       int32
+	UserID      graphql.ID
+	StartDate   string
+	EndDate     string
+	Notes       string
+	Tags        []string
+	Version     int32
+	CreatedAt   string
+	UpdatedAt   string
+	CreatedBy   string
+	UpdatedBy   string
+
+	root *resolver
+}
+
+type userNode struct {
+	ID          graphql.ID
+	DisplayName string
+	Email       string
+	Currency    string
+	Locale      string
+	Timezone    string
+	CreatedAt   string
+	UpdatedAt   string
+
+	root *resolver
+}
+
+type connection struct {
+	Nodes    []*subscriptionNode
+	PageInfo pageInfo
+}
+
+type pageInfo struct {
+	HasNextPage bool
+	EndCursor   *string
+}
+
+func (r *resolver) subscriptionNode(s domain.Subscription) *subscriptionNode {
+	return &subscriptionNode{
+		ID:          graphql.ID(s.ID),
+		ServiceName: s.ServiceName,
+		Price:       int32(s.Price),
+		UserID:      graphql.ID(s.UserID),
+		StartDate:   s.StartDate.Format("01-2006"),
+		EndDate:     s.EndDate.Format("01-2006"),
+		Notes:       s.Notes,
+		Tags:        append([]string{}, s.Tags...),
+		Version:     int32(s.Version),
+		CreatedAt:   s.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:   s.UpdatedAt.UTC().Format(time.RFC3339),
+		CreatedBy:   s.CreatedBy,
+		UpdatedBy:   s.UpdatedBy,
+		root:        r,
+	}
+}
+
+func (r *resolver) userNode(u domain.User) *userNode {
+	return &userNode{
+		ID:          graphql.ID(u.ID),
+		DisplayName: u.DisplayName,
+		Email:       u.Email,
+		Currency:    u.Currency,
+		Locale:      u.Locale,
+		Timezone:    u.Timezone,
+		CreatedAt:   u.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:   u.UpdatedAt.UTC().Format(time.RFC3339),
+		root:        r,
+	}
+}
+
+// User загружает владельца через userLoader запроса
+func (n *subscriptionNode) User(ctx context.Context) (*userNode, error) {
+	u, err := userLoaderFrom(ctx).load(ctx, string(n.UserID))
+	if err != nil {
+		return nil, n.root.repoError(ctx, "graphql.subscription.user", err)
+	}
+	if u == nil {
+		return nil, nil
+	}
+	return n.root.userNode(*u), nil
+}
+
+// pageArgs — first по умолчанию задан в схеме, поэтому он всегда есть
+type pageArgs struct {
+	First int32
+	After *string
+}
+
+func (n *userNode) Subscriptions(ctx context.Context, args pageArgs) (*connection, error) {
+	q := url.Values{"user_id": {string(n.ID)}}
+	return n.root.listSubs(ctx, q, args)
+}
+
+// ---- Query ----
+
+func (r *queryResolver) Subscription(ctx context.Context, args struct{ ID graphql.ID }) (*subscriptionNode, error) {
+	if err := subscription.ValidateGUID(string(args.ID)); err != nil {
+		return nil, badInput(errors.New("id: " + err.Error()))
+	}
+	sub, err := r.repo.GetSub(ctx, string(args.ID))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.repoError(ctx, "graphql.subscription", err)
+	}
+	return r.subscriptionNode(sub), nil
+}
+
+type filterInput struct {
+	UserID      *graphql.ID
+	ServiceName *string
+	ActiveAt    *string
+	PriceMin    *int32
+	PriceMax    *int32
+	From        *string
+	To          *string
+}
+
+func (r *queryResolver) Subscriptions(ctx context.Context, args struct {
+	Filter *filterInput
+	Sort   *string
+	First  int32
+	After  *string
+}) (*connection, error) {
+	q := url.Values{}
+	set := func(key string, v *string) {
+		if v != nil {
+			q.Set(key, *v)
+		}
+	}
+	setInt := func(key string, v *int32) {
+		if v != nil {
+			q.Set(key, strconv.Itoa(int(*v)))
+		}
+	}
+	if f := args.Filter; f != nil {
+		if f.UserID != nil {
+			q.Set("user_id", string(*f.UserID))
+		}
+		set("service_name", f.ServiceName)
+		set("active_at", f.ActiveAt)
+		setInt("price_min", f.PriceMin)
+		setInt("price_max", f.PriceMax)
+		set("from", f.From)
+		set("to", f.To)
+	}
+	set("sort", args.Sort)
+	return r.listSubs(ctx, q, pageArgs{First: args.First, After: args.After})
+}
+
+// listSubs разбирает фильтры так же, как GET /v1/subscriptions, и сообщает загрузчику владельцев страницы
+func (r *resolver) listSubs(ctx context.Context, q url.Values, page pageArgs) (*connection, error) {
+	q.Set("limit", strconv.Itoa(int(page.First)))
+	if page.After != nil {
+		q.Set("cursor", *page.After)
+	}
+	f, err := subscription.ParseListQuery(q)
+	if err != nil {
+		return nil, badInput(err)
+	}
+
+	res, err := r.repo.ListSubs(ctx, f)
+	if err != nil {
+		return nil, r.repoError(ctx, "graphql.subscriptions", err)
+	}
+	conn := &connection{Nodes: make([]*subscriptionNode, 0, len(res.Subs)), PageInfo: pageInfo{HasNextPage: res.HasMore}}
+	ids := make([]string, 0, len(res.Subs))
+	for _, s := range res.Subs {
+		conn.Nodes = append(conn.Nodes, r.subscriptionNode(s))
+		ids = append(ids, s.UserID)
+	}
+	userLoaderFrom(ctx).prime(ids...)
+	if res.HasMore && len(res.Subs) > 0 {
+		cursor := subscription.EncodeCursor(res.Subs[len(res.Subs)-1], f.OrderBy())
+		conn.PageInfo.EndCursor = &cursor
+	}
+	return conn, nil
+}
+
+func (r *queryResolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userNode, error) {
+	if err := subscription.ValidateGUID(string(args.ID)); err != nil {
+		return nil, badInput(errors.New("id: " + err.Error()))
+	}
+	u, err := r.repo.GetUser(ctx, string(args.ID))
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.repoError(ctx, "graphql.user", err)
+	}
+	return r.userNode(u), nil
+}
+
+func (r *queryResolver) Users(ctx context.Context) ([]*userNode, error) {
+	list, err := r.repo.ListUsers(ctx)
+	if err != nil {
+		return nil, r.repoError(ctx, "graphql.users", err)
+	}
+	out := make([]*userNode, 0, len(list))
+	for _, u := range list {
+		out = append(out, r.userNode(u))
+	}
+	return out, nil
+}
+
+func (r *queryResolver) TotalCost(ctx context.Context, args struct {
+	UserID      graphql.ID
+	ServiceName string
+	From        string
+	To          string
+}) (int32, error) {
+	from, to, errs := parsePeriod(args.From, args.To)
+	if len(errs) > 0 {
+		return 0, badInput(errors.New(strings.Join(errs, "; ")))
+	}
+	if err := subscription.ValidateTotalCostQuery(string(args.UserID), args.ServiceName, from, to); err != nil {
+		return 0, badInput(err)
+	}
+	total, err := r.repo.TotalCost(ctx, args.ServiceName, string(args.UserID), from.ToTime(), to.ToTime())
+	if err != nil {
+		return 0, r.repoError(ctx, "graphql.total_cost", err)
+	}
+	return int32(total), nil
+}
+
+// parsePeriod разбирает from и to в формате MM-YYYY
+func parsePeriod(fromStr, toStr string) (from, to subscription.YearMonth, errs []string) {
+	from, err := subscription.YMFromStr(fromStr)
+	if err != nil {
+		errs = append(errs, "from: invalid format, expected MM-YYYY")
+	}
+	to, err = subscription.YMFromStr(toStr)
+	if err != nil {
+		errs = append(errs, "to: invalid format, expected MM-YYYY")
+	}
+	return from, to, errs
+}
+
+// ---- Mutation ----
+
+type subscriptionInput struct {
+	ServiceName string
+	Price       int32
+	UserID      graphql.ID
+	StartDate   string
+	EndDate     string
+	Notes       *string
+	Tags        *[]string
+}
+
+// toCreateRequest приводит ввод к запросу REST, чтобы проверять его теми же валидаторами
+func (in subscriptionInput) toCreateRequest() (subscription.CreateRequest, []string) {
+	req := subscription.CreateRequest{ServiceName: in.ServiceName, Price: int(in.Price), UserID: string(in.UserID)}
+	var errs []string
+	var err error
+	if req.StartDate, err = subscription.YMFromStr(in.StartDate); err != nil {
+		errs = append(errs, "start_date: invalid format, expected MM-YYYY")
+	}
+	if req.EndDate, err = subscription.YMFromStr(in.EndDate); err != nil {
+		errs = append(errs, "end_date: invalid format, expected MM-YYYY")
+	}
+	if in.Notes != nil {
+		req.Notes = *in.Notes
+	}
+	if in.Tags != nil {
+		req.Tags = *in.Tags
+	}
+	return req, errs
+}
+
+func (r *mutationResolver) CreateSubscription(ctx context.Context, args struct{ Input subscriptionInput }) (*subscriptionNode, error) {
+	req, errs := args.Input.toCreateRequest()
+	if len(errs) > 0 {
+		return nil, badInput(errors.New(strings.Join(errs, "; ")))
+	}
+	if err := subscription.ValidateCreateRequest(req); err != nil {
+		return nil, badInput(err)
+	}
+	const op = "graphql.create_subscription"
+	sub, err := r.repo.AddSub(ctx, subscription.MapCreateReqToDomain(req))
+	if err != nil {
+		return nil, r.repoError(ctx, op, err)
+	}
+	r.publish(ctx, op, domain.EventSubscriptionCreated, subscription.MapDomainToDTO(sub))
+	return r.subscriptionNode(sub), nil
+}
+
+func (r *mutationResolver) UpdateSubscription(ctx context.Context, args struct {
+	ID      graphql.ID
+	Input   subscriptionInput
+	Version *int32
+}) (*subscriptionNode, error) {
+	c, errs := args.Input.toCreateRequest()
+	if len(errs) > 0 {
+		return nil, badInput(errors.New(strings.Join(errs, "; ")))
+	}
+	req := subscription.UpdateRequest{ID: string(args.ID), ServiceName: c.ServiceName, Price: c.Price, UserID: c.UserID,
+		StartDate: c.StartDate, EndDate: c.EndDate, Notes: c.Notes, Tags: c.Tags}
+	if err := subscription.ValidateUpdateRequest(req); err != nil {
+		return nil, badInput(err)
+	}
+	const op = "graphql.update_subscription"
+	sub, err := r.repo.UpdateSub(ctx, subscription.MapUpdateReqToDomain(req), version(args.Version))
+	if err != nil {
+		return nil, r.repoError(ctx, op, err)
+	}
+	r.publish(ctx, op, domain.EventSubscriptionUpdated, subscription.MapDomainToDTO(sub))
+	return r.subscriptionNode(sub), nil
+}
+
+func (r *mutationResolver) DeleteSubscription(ctx context.Context, args struct {
+	ID      graphql.ID
+	Version *int32
+}) (bool, error) {
+	if err := subscription.ValidateGUID(string(args.ID)); err != nil {
+		return false, badInput(errors.New("id: " + err.Error()))
+	}
+	const op = "graphql.delete_subscription"
+	deleted, err := r.repo.DeleteSub(ctx, string(args.ID), version(args.Version))
+	if err != nil {
+		return false, r.repoError(ctx, op, err)
+	}
+	r.publish(ctx, op, domain.EventSubscriptionDeleted, subscription.MapDomainToDeletedEvent(deleted))
+	return true, nil
+}
+
+func version(v *int32) int {
+	if v == nil {
+		return domain.AnyVersion
+	}
+	return int(*v)
+}
@@ -0,0 +1,166 @@
+package mw
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	HeaderReplayed       = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLen = 255
+)
+
+// заголовки исходного ответа, которые повторяются при воспроизведении
+var replayHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// Idempotency — middleware для небезопасных методов: запрос с заголовком Idempotency-Key выполняется один раз,
+// повтор с тем же ключом и телом получает сохранённый ответ, с тем же ключом и другим телом — 422.
+// Ответы 5xx не сохраняются: ключ освобождается, и запрос можно повторить.
+// Без заголовка запрос проходит как обычно.
+func Idempotency(store domain.IdempotencyRepository, ttl time.Duration, l *log.Logger) func(http.Handler) http.Handler {
+	const op = "idempotency"
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderIdempotencyKey)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			reqID := RequestIDFromCtx(r.Context())
+			if len(key) > maxIdempotencyKeyLen {
+				v1.WriteValidationError(w, v1.NewFieldError("Idempotency-Key", "max_length", "max", 255))
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				v1.WriteMessage(w, http.StatusBadRequest, "invalid_body")
+				return
+			}
+			r.Body.Close()
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			fp := fingerprint(r, body)
+
+			ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+			existing, reserved, err := store.ReserveIdempotencyKey(ctx, domain.IdempotencyRecord{
+				Key:         key,
+				Fingerprint: fp,
+				ExpiresAt:   time.Now().Add(ttl),
+			})
+			cancel()
+			if err != nil {
+				logx.Error(l, reqID, op, "reserve failed", err, "key", key)
+				if v1.IsTimeout(err) {
+					v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+					return
+				}
+				v1.WriteError(w, http.StatusInternalServerError, "")
+				return
+			}
+
+			if !reserved {
+				switch {
+				case existing.Fingerprint != fp:
+					v1.WriteMessage(w, http.StatusUnprocessableEntity, "idempotency_key_reused")
+				case existing.Status == 0:
+					v1.WriteMessage(w, http.StatusConflict, "idempotency_in_progress")
+				default:
+					logx.Info(l, reqID, op, "replayed", "key", key, "status", existing.Status)
+					for k, v := range existing.Header {
+						w.Header().Set(k, v)
+					}
+					w.Header().Set(HeaderReplayed, "true")
+					w.WriteHeader(existing.Status)
+					_, _ = w.Write(existing.Body)
+				}
+				return
+			}
+
+			rec := &recordingWriter{ResponseWriter: w}
+			completed := false
+			// ключ нельзя оставлять занятым, если ответ не сохранён (5xx, паника, обрыв)
+			defer func() {
+				if completed {
+					return
+				}
+				ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 2*time.Second)
+				defer cancel()
+				if err := store.ReleaseIdempotencyKey(ctx, key); err != nil {
+					logx.Error(l, reqID, op, "release failed", err, "key", key)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if rec.status == 0 {
+				rec.status = http.StatusOK
+			}
+			if rec.status >= http.StatusInternalServerError {
+				return
+			}
+			header := make(map[string]string, len(replayHeaders))
+			for _, k := range replayHeaders {
+				if v := w.Header().Get(k); v != "" {
+					header[k] = v
+				}
+			}
+			ctx, cancel = context.WithTimeout(context.WithoutCancel(r.Context()), 2*time.Second)
+			defer cancel()
+			err = store.CompleteIdempotencyKey(ctx, domain.IdempotencyRecord{
+				Key: key, Status: rec.status, Header: header, Body: rec.body.Bytes(),
+			})
+			if err != nil {
+				logx.Error(l, reqID, op, "save response failed", err, "key", key)
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// fingerprint — отпечаток запроса: один ключ нельзя использовать для разных запросов.
+// Строка запроса входит в отпечаток: import?dry_run=true и настоящий импорт с тем же телом — разные запросы.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter пропускает ответ клиенту и одновременно запоминает статус и тело
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	if rw.status == 0 {
+		rw.status = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *recordingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,119 @@
+package mw
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	mockrepo "github.com/EgorLis/my-subs/internal/infra/database/mock"
+)
+
+func TestIdempotency(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	acme, _ := repo.AddTenant(context.Background(), domain.Tenant{Name: "acme"})
+
+	calls := 0
+	status := http.StatusCreated
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"1"`)
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"call":%d,"echo":%q}`, calls, body)
+	})
+	h := Idempotency(repo, time.Hour, log.New(io.Discard, "", 0))(next)
+
+	send := func(target, key, body, tenant string) *httptest.ResponseRecorder {
+		if target == "" {
+			target = "/v1/subscriptions"
+		}
+		r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		if key != "" {
+			r.Header.Set(HeaderIdempotencyKey, key)
+		}
+		if tenant != "" {
+			r = r.WithContext(domain.WithTenant(r.Context(), tenant))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	steps := []struct {
+		name         string
+		target       string // по умолчанию /v1/subscriptions
+		key, body    string
+		tenant       string
+		status       int // что вернёт обработчик
+		wantCode     int
+		wantCalls    int
+		wantReplayed bool
+		wantBody     string
+	}{
+		{name: "NoKey", body: `a`, status: 201, wantCode: 201, wantCalls: 1},
+		{name: "First", key: "k1", body: `a`, status: 201, wantCode: 201, wantCalls: 2, wantBody: `"call":2`},
+		{name: "Replay", key: "k1", body: `a`, status: 201, wantCode: 201, wantCalls: 2, wantReplayed: true, wantBody: `"call":2`},
+		{name: "DifferentBody", key: "k1", body: `b`, status: 201, wantCode: 422, wantCalls: 2},
+		{name: "OtherTenantSameKey", key: "k1", body: `a`, tenant: acme.ID, status: 201, wantCode: 201, wantCalls: 3, wantBody: `"call":3`},
+		{name: "ClientErrorIsStored", key: "k2", body: `x`, status: 400, wantCode: 400, wantCalls: 4},
+		{name: "ClientErrorReplayed", key: "k2", body: `x`, status: 201, wantCode: 400, wantCalls: 4, wantReplayed: true},
+		{name: "ServerErrorNotStored", key: "k3", body: `y`, status: 500, wantCode: 500, wantCalls: 5},
+		{name: "RetryAfterServerError", key: "k3", body: `y`, status: 201, wantCode: 201, wantCalls: 6, wantBody: `"call":6`},
+		// dry_run и настоящий импорт — разные запросы: ответ пробного прогона не должен подменить импорт
+		{name: "DryRun", target: "/v1/subscriptions/import?dry_run=true", key: "k4", body: `csv`, status: 200, wantCode: 200, wantCalls: 7},
+		{name: "SameKeyWithoutDryRun", target: "/v1/subscriptions/import", key: "k4", body: `csv`, status: 200, wantCode: 422, wantCalls: 7},
+		{name: "DryRunReplay", target: "/v1/subscriptions/import?dry_run=true", key: "k4", body: `csv`, status: 201, wantCode: 200, wantCalls: 7, wantReplayed: true},
+		{name: "KeyTooLong", key: strings.Repeat("k", 256), body: `a`, status: 201, wantCode: 400, wantCalls: 7},
+	}
+	for _, st := range steps {
+		status = st.status
+		w := send(st.target, st.key, st.body, st.tenant)
+		if w.Code != st.wantCode {
+			t.Fatalf("%s: want %d, got %d. body=%s", st.name, st.wantCode, w.Code, w.Body.String())
+		}
+		if calls != st.wantCalls {
+			t.Fatalf("%s: handler calls want %d, got %d", st.name, st.wantCalls, calls)
+		}
+		if replayed := w.Header().Get(HeaderReplayed) == "true"; replayed != st.wantReplayed {
+			t.Fatalf("%s: replayed want %t, got %t", st.name, st.wantReplayed, replayed)
+		}
+		if st.wantBody != "" && !strings.Contains(w.Body.String(), st.wantBody) {
+			t.Fatalf("%s: body should contain %s, got %s", st.name, st.wantBody, w.Body.String())
+		}
+		if st.wantReplayed && (w.Header().Get("ETag") != `"1"` || w.Header().Get("Content-Type") != "application/json") {
+			t.Fatalf("%s: replay must restore headers, got %v", st.name, w.Header())
+		}
+	}
+
+	t.Run("InProgress", func(t *testing.T) {
+		// исходный запрос занял ключ, но ещё не сохранил ответ
+		r := httptest.NewRequest(http.MethodPost, "/v1/subscriptions", nil)
+		_, reserved, _ := repo.ReserveIdempotencyKey(context.Background(), domain.IdempotencyRecord{
+			Key: "busy", Fingerprint: fingerprint(r, []byte(`z`)), ExpiresAt: time.Now().Add(time.Hour),
+		})
+		if !reserved {
+			t.Fatal("key should be reserved")
+		}
+		if w := send("", "busy", `z`, ""); w.Code != http.StatusConflict {
+			t.Fatalf("want 409, got %d. body=%s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("ExpiredKeyIsReused", func(t *testing.T) {
+		if n, _ := repo.PurgeIdempotencyKeys(context.Background(), time.Now().Add(2*time.Hour)); n == 0 {
+			t.Fatal("expired keys should be purged")
+		}
+		before := calls
+		if w := send("", "k1", `b`, ""); w.Code != http.StatusCreated || calls != before+1 {
+			t.Fatalf("want fresh execution after expiry, got %d calls=%d", w.Code, calls)
+		}
+	})
+}
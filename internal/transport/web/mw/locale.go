@@ -0,0 +1,20 @@
+package mw
+
+import (
+	"net/http"
+
+	"github.com/EgorLis/my-subs/internal/i18n"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+// WithLocale выбирает язык сообщений по Accept-Language и ставит его в Content-Language ответа:
+// по нему v1.WriteProblem переводит ошибки
+func WithLocale(cat *i18n.Catalog) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(v1.HeaderContentLanguage, cat.Match(r.Header.Get("Accept-Language")))
+			w.Header().Add("Vary", "Accept-Language")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package mw
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockrepo "github.com/EgorLis/my-subs/internal/infra/database/mock"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+func TestWithLocale(t *testing.T) {
+	h := WithLocale(v1.Messages)(WithTenant(mockrepo.NewMockRepo())(http.NotFoundHandler()))
+
+	cases := []struct {
+		name      string
+		accept    string
+		wantLang  string
+		wantTitle string
+		wantMsg   string
+	}{
+		{name: "Default", wantLang: "en", wantTitle: "Validation Failed", wantMsg: `must be a valid GUID: "nope"`},
+		{name: "Russian", accept: "ru-RU,ru;q=0.9,en;q=0.8", wantLang: "ru", wantTitle: "Ошибка проверки данных", wantMsg: `должен быть корректным GUID: "nope"`},
+		{name: "Unknown", accept: "de", wantLang: "en", wantTitle: "Validation Failed", wantMsg: `must be a valid GUID: "nope"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/subscriptions", nil)
+			r.Header.Set(HeaderTenantID, "nope")
+			if tc.accept != "" {
+				r.Header.Set("Accept-Language", tc.accept)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if got := w.Header().Get(v1.HeaderContentLanguage); got != tc.wantLang {
+				t.Fatalf("Content-Language = %q, want %q", got, tc.wantLang)
+			}
+			var p v1.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Code != v1.CodeValidation || p.Title != tc.wantTitle {
+				t.Fatalf("unexpected problem: %+v", p)
+			}
+			if len(p.Errors) != 1 || p.Errors[0].Field != "tenant" || p.Errors[0].Code != "invalid_guid" || p.Errors[0].Message != tc.wantMsg {
+				t.Fatalf("errors = %+v", p.Errors)
+			}
+			if p.Detail != "tenant: "+tc.wantMsg {
+				t.Fatalf("detail = %q", p.Detail)
+			}
+		})
+	}
+}
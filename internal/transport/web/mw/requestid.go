@@ -4,16 +4,14 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/EgorLis/my-subs/internal/domain"
 	"github.com/google/uuid"
 )
 
-type ctxKey string
-
-const (
-	CtxRequestID ctxKey = "req_id"
-	HeaderReqID  string = "X-Request-ID"
-)
+const HeaderReqID string = "X-Request-ID"
 
+// WithRequestID берёт id запроса из X-Request-ID или создаёт новый; id кладётся в контекст
+// (domain.WithRequestID), поэтому его видят и логи, и журнал изменений репозитория
 func WithRequestID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		reqID := r.Header.Get(HeaderReqID)
@@ -21,14 +19,11 @@ func WithRequestID(next http.Handler) http.Handler {
 			reqID = uuid.NewString()
 		}
 		w.Header().Set(HeaderReqID, reqID)
-		ctx := context.WithValue(r.Context(), CtxRequestID, reqID)
+		ctx := domain.WithRequestID(r.Context(), reqID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
 func RequestIDFromCtx(ctx context.Context) string {
-	if v, ok := ctx.Value(CtxRequestID).(string); ok {
-		return v
-	}
-	return ""
+	return domain.RequestIDFromCtx(ctx)
 }
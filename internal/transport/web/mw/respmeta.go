@@ -22,3 +22,8 @@ func (m *metaWriter) Write(b []byte) (int, error) {
 	m.size += n
 	return n, err
 }
+
+// Unwrap даёт http.ResponseController доступ к Flush и дедлайнам исходного writer
+func (m *metaWriter) Unwrap() http.ResponseWriter {
+	return m.ResponseWriter
+}
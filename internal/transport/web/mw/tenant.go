@@ -0,0 +1,63 @@
+package mw
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+	"github.com/google/uuid"
+)
+
+const HeaderTenantID = "X-Tenant-ID"
+
+type TenantGetter interface {
+	GetTenant(ctx context.Context, id string) (domain.Tenant, error)
+}
+
+// WithTenant — middleware: определяет тенант запроса и кладёт его в контекст.
+// Тенант, уже заданный слоем аутентификации, имеет приоритет; иначе берётся X-Tenant-ID,
+// а без заголовка — тенант по умолчанию.
+func WithTenant(tenants TenantGetter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get(HeaderTenantID)
+			tenantID, fromAuth := domain.TenantFromCtx(r.Context())
+
+			switch {
+			case fromAuth && header != "" && header != tenantID:
+				v1.WriteMessage(w, http.StatusForbidden, "tenant_mismatch")
+				return
+			case fromAuth:
+			case header != "":
+				if _, err := uuid.Parse(header); err != nil {
+					v1.WriteValidationError(w, v1.NewFieldError("tenant", "invalid_guid", "value", strconv.Quote(header)))
+					return
+				}
+				tenantID = header
+			default:
+				tenantID = domain.DefaultTenantID
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+			_, err := tenants.GetTenant(ctx, tenantID)
+			cancel()
+			if err != nil {
+				switch {
+				case errors.Is(err, domain.ErrTenantNotFound):
+					v1.WriteMessage(w, http.StatusNotFound, "tenant_not_found")
+				case v1.IsTimeout(err):
+					v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+				default:
+					v1.WriteError(w, http.StatusInternalServerError, "")
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(domain.WithTenant(r.Context(), tenantID)))
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package mw
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	mockrepo "github.com/EgorLis/my-subs/internal/infra/database/mock"
+	"github.com/google/uuid"
+)
+
+func TestWithTenant(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	acme, _ := repo.AddTenant(context.Background(), domain.Tenant{Name: "acme"})
+
+	cases := []struct {
+		name       string
+		header     string
+		authTenant string
+		wantCode   int
+		wantTenant string
+	}{
+		{name: "NoHeader_Default", wantCode: http.StatusOK, wantTenant: domain.DefaultTenantID},
+		{name: "Header", header: acme.ID, wantCode: http.StatusOK, wantTenant: acme.ID},
+		{name: "BadGUID", header: "nope", wantCode: http.StatusBadRequest},
+		{name: "Unknown", header: uuid.NewString(), wantCode: http.StatusNotFound},
+		{name: "FromAuth", authTenant: acme.ID, wantCode: http.StatusOK, wantTenant: acme.ID},
+		{name: "AuthMismatch", authTenant: acme.ID, header: domain.DefaultTenantID, wantCode: http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = domain.TenantFromCtx(r.Context())
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/subscriptions", nil)
+			if tc.header != "" {
+				r.Header.Set(HeaderTenantID, tc.header)
+			}
+			if tc.authTenant != "" {
+				r = r.WithContext(domain.WithTenant(r.Context(), tc.authTenant))
+			}
+			w := httptest.NewRecorder()
+
+			WithTenant(repo)(next).ServeHTTP(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
+			}
+			if got != tc.wantTenant {
+				t.Fatalf("want tenant %q, got %q", tc.wantTenant, got)
+			}
+		})
+	}
+}
@@ -9,9 +9,20 @@ import (
 	"github.com/EgorLis/my-subs/internal/config"
 	_ "github.com/EgorLis/my-subs/internal/docs" // docs generated by Swag CLI
 	"github.com/EgorLis/my-subs/internal/domain"
+	hooks "github.com/EgorLis/my-subs/internal/jobs/webhook"
+	"github.com/EgorLis/my-subs/internal/transport/web/gql"
 	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	"github.com/EgorLis/my-subs/internal/transport/web/sse"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/anomaly"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/audit"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/calendar"
 	"github.com/EgorLis/my-subs/internal/transport/web/v1/health"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/service"
 	"github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/tenant"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/user"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/webhook"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
@@ -19,25 +30,61 @@ type Server struct {
 	log    *log.Logger
 	server *http.Server
 	cfg    *config.Config
+	events subscription.EventPublisher
 }
 
-func New(logger *log.Logger, cfg *config.Config, repo domain.SubscriptionRepository) *Server {
+func New(logger *log.Logger, cfg *config.Config, repo domain.Repository) *Server {
 	healthLog := log.New(logger.Writer(), logger.Prefix()+"[health] ", logger.Flags())
 	subLog := log.New(logger.Writer(), logger.Prefix()+"[subscriptions] ", logger.Flags())
+	anomalyLog := log.New(logger.Writer(), logger.Prefix()+"[anomalies] ", logger.Flags())
+	tenantLog := log.New(logger.Writer(), logger.Prefix()+"[tenants] ", logger.Flags())
+	userLog := log.New(logger.Writer(), logger.Prefix()+"[users] ", logger.Flags())
+	calendarLog := log.New(logger.Writer(), logger.Prefix()+"[calendar] ", logger.Flags())
+	serviceLog := log.New(logger.Writer(), logger.Prefix()+"[services] ", logger.Flags())
+	graphqlLog := log.New(logger.Writer(), logger.Prefix()+"[graphql] ", logger.Flags())
+	webhookLog := log.New(logger.Writer(), logger.Prefix()+"[webhooks] ", logger.Flags())
+	auditLog := log.New(logger.Writer(), logger.Prefix()+"[audit] ", logger.Flags())
 
 	healthHandler := &health.Handler{DBPinger: repo, Log: healthLog}
-	subHandler := &subscription.Handler{Repo: repo, Log: subLog}
+	// события об изменениях подписок ставятся в очередь доставок вебхуков (отправляет их webhook.Dispatcher)
+	// и уходят в поток SSE
+	changes := sse.NewBroker(cfg.StreamReplaySize)
+	events := subscription.Publishers{&hooks.Publisher{Repo: repo, Log: webhookLog}, subscription.Feed{Broker: changes}}
+	subHandler := &subscription.Handler{Repo: repo, Users: repo, Log: subLog, Events: events, Changes: changes, Heartbeat: cfg.StreamHeartbeat,
+		ExportGrace:  cfg.ExportWriteGrace,
+		SyncTokenTTL: cfg.SyncTokenTTL}
+	anomalyHandler := &anomaly.Handler{Repo: repo, Log: anomalyLog}
+	tenantHandler := &tenant.Handler{Repo: repo, Log: tenantLog}
+	userHandler := &user.Handler{Repo: repo, Log: userLog, DeletePolicy: domain.UserDeletePolicy(cfg.UserDeletePolicy), Events: events}
+	calendarHandler := &calendar.Handler{Repo: repo, Log: calendarLog,
+		AlarmDays: cfg.CalendarAlarmDays, HorizonMonths: cfg.CalendarHorizonMonths}
+	serviceHandler := &service.Handler{Repo: repo, Log: serviceLog}
+	webhookHandler := &webhook.Handler{Repo: repo, Log: webhookLog}
+	auditHandler := &audit.Handler{Repo: repo, Log: auditLog}
+	graphqlHandler := gql.NewHandler(graphqlLog, repo, events, cfg.GraphQLMaxDepth, cfg.GraphQLMaxComplexity)
+
+	idempotency := mw.Idempotency(repo, cfg.IdempotencyTTL, logger)
+	router := newRouter(healthHandler, subHandler, anomalyHandler, tenantHandler, userHandler, calendarHandler, serviceHandler,
+		webhookHandler, auditHandler, graphqlHandler, repo, idempotency, logger)
 
 	srv := &http.Server{
 		Addr:              cfg.AppPort,
-		Handler:           newRouter(healthHandler, subHandler, logger),
+		Handler:           router,
 		ReadTimeout:       10 * time.Second,
 		WriteTimeout:      10 * time.Second,
 		MaxHeaderBytes:    1 << 20,
 		ReadHeaderTimeout: 2 * time.Second,
 		IdleTimeout:       60 * time.Second,
 	}
-	return &Server{server: srv, cfg: cfg, log: logger}
+	// потоки SSE бесконечны: при остановке закрываем их, иначе Shutdown ждал бы до таймаута
+	srv.RegisterOnShutdown(changes.Close)
+	return &Server{server: srv, cfg: cfg, log: logger, events: events}
+}
+
+// Events — получатели событий об изменениях подписок (вебхуки и поток SSE);
+// через них публикуют и другие транспорты, например gRPC
+func (s *Server) Events() subscription.EventPublisher {
+	return s.events
 }
 
 func (ws *Server) Run() {
@@ -47,6 +94,11 @@ func (ws *Server) Run() {
 	}
 }
 
+// Handler — роутер со всеми middleware, например для httptest
+func (ws *Server) Handler() http.Handler {
+	return ws.server.Handler
+}
+
 func (ws *Server) Close(ctx context.Context) {
 	if err := ws.server.Shutdown(ctx); err != nil {
 		ws.log.Printf("forced to shutdown: %v", err)
@@ -54,7 +106,8 @@ func (ws *Server) Close(ctx context.Context) {
 	ws.log.Println("exited gracefully")
 }
 
-func newRouter(hh *health.Handler, sh *subscription.Handler, logger *log.Logger) http.Handler {
+func newRouter(hh *health.Handler, sh *subscription.Handler, ah *anomaly.Handler, th *tenant.Handler,
+	uh *user.Handler, ch *calendar.Handler, svh *service.Handler, wh *webhook.Handler, adh *audit.Handler, gh *gql.Handler, tenants mw.TenantGetter, idem func(http.Handler) http.Handler, logger *log.Logger) http.Handler {
 	mux := http.NewServeMux()
 
 	// health
@@ -62,20 +115,73 @@ func newRouter(hh *health.Handler, sh *subscription.Handler, logger *log.Logger)
 	mux.HandleFunc("GET /v1/readyz", hh.Readiness)
 
 	// subscriptions CRUDL
-	mux.HandleFunc("POST /v1/subscriptions", limitBody(16<<10, sh.Create))
+	mux.HandleFunc("POST /v1/subscriptions", limitBody(16<<10, idem(http.HandlerFunc(sh.Create)).ServeHTTP))
+	mux.HandleFunc("POST /v1/subscriptions:batch", limitBody(1<<20, idem(http.HandlerFunc(sh.Batch)).ServeHTTP))
+	mux.HandleFunc("POST /v1/subscriptions/import", limitBody(5<<20, idem(http.HandlerFunc(sh.Import)).ServeHTTP))
 	mux.HandleFunc("GET /v1/subscriptions", sh.List)
+	mux.HandleFunc("GET /v1/subscriptions/export", sh.Export)
+	mux.HandleFunc("GET /v1/subscriptions/search", sh.Search)
+	mux.HandleFunc("GET /v1/subscriptions/events", sh.Stream) // SSE: дедлайн записи продлевается в самом обработчике
 	mux.HandleFunc("PUT /v1/subscriptions/{id}", limitBody(16<<10, sh.Update))
+	mux.HandleFunc("PATCH /v1/subscriptions/{id}", limitBody(16<<10, sh.Patch))
 	mux.HandleFunc("DELETE /v1/subscriptions/{id}", sh.Delete)
 	mux.HandleFunc("GET /v1/subscriptions/{id}", sh.Get)
+	mux.HandleFunc("POST /v1/subscriptions/{id}/restore", sh.Restore)
+	mux.HandleFunc("GET /v1/subscriptions/{id}/history", adh.History)
+
+	// корзина: удалённые подписки до окончательной очистки
+	mux.HandleFunc("GET /v1/trash", sh.Trash)
 
 	// total cost
 	mux.HandleFunc("GET /v1/subscriptions/totalcost", sh.TotalCost)
 
+	// sync: локальная копия для офлайн-клиентов
+	mux.HandleFunc("GET /v1/sync", sh.Sync)
+	mux.HandleFunc("POST /v1/sync", limitBody(1<<20, idem(http.HandlerFunc(sh.Push)).ServeHTTP))
+
+	// services: подсказки названий
+	mux.HandleFunc("GET /v1/services/suggest", svh.Suggest)
+
+	// audit: журнал изменений подписок
+	mux.HandleFunc("GET /v1/audit", adh.List)
+
+	// anomalies
+	mux.HandleFunc("GET /v1/anomalies", ah.List)
+
+	// tenants
+	mux.HandleFunc("POST /v1/tenants", limitBody(16<<10, th.Create))
+	mux.HandleFunc("GET /v1/tenants/{id}", th.Get)
+
+	// users CRUDL
+	mux.HandleFunc("POST /v1/users", limitBody(16<<10, idem(http.HandlerFunc(uh.Create)).ServeHTTP))
+	mux.HandleFunc("GET /v1/users", uh.List)
+	mux.HandleFunc("GET /v1/users/{id}", uh.Get)
+	mux.HandleFunc("PUT /v1/users/{id}", limitBody(16<<10, uh.Update))
+	mux.HandleFunc("DELETE /v1/users/{id}", uh.Delete)
+
+	// calendar feed: клиенты календаря приходят без заголовков, доступ по токену
+	mux.HandleFunc("POST /v1/users/{id}/calendar-token", ch.IssueToken)
+	mux.HandleFunc("DELETE /v1/users/{id}/calendar-token", ch.RevokeToken)
+	mux.HandleFunc("GET /v1/users/{id}/calendar.ics", ch.Feed)
+
+	// webhooks: исходящие события об изменениях подписок
+	mux.HandleFunc("POST /v1/webhooks", limitBody(16<<10, wh.Create))
+	mux.HandleFunc("GET /v1/webhooks", wh.List)
+	mux.HandleFunc("GET /v1/webhooks/{id}", wh.Get)
+	mux.HandleFunc("DELETE /v1/webhooks/{id}", wh.Delete)
+	mux.HandleFunc("GET /v1/webhooks/{id}/deliveries", wh.Deliveries)
+	mux.HandleFunc("GET /v1/webhooks/{id}/deliveries/{delivery_id}", wh.Delivery)
+	mux.HandleFunc("POST /v1/webhooks/{id}/deliveries/{delivery_id}/redeliver", wh.Redeliver)
+
+	// graphql: те же данные одним запросом
+	mux.Handle("POST /graphql", limitBody(64<<10, gh.ServeHTTP))
+	mux.HandleFunc("GET /graphql/schema", gh.SchemaSDL)
+
 	// swagger
 	mux.Handle("GET /swagger/", httpSwagger.WrapHandler)
 
-	// 🔗 цепочка middleware: RequestID → Logging
-	return mw.WithRequestID(mw.Logging(logger)(mux))
+	// 🔗 цепочка middleware: RequestID → Locale → Logging → Tenant
+	return mw.WithRequestID(mw.WithLocale(v1.Messages)(mw.Logging(logger)(mw.WithTenant(tenants)(mux))))
 }
 
 func limitBody(n int64, h http.HandlerFunc) http.HandlerFunc {
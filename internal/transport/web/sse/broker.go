@@ -0,0 +1,170 @@
+package sse
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer — сколько событий может ждать отправки одному клиенту. Клиент, который
+// отстал сильнее, отключается: EventSource переподключится с Last-Event-ID и дочитает из буфера.
+const subscriberBuffer = 64
+
+// Event — событие потока. ID назначает Broker: "<эпоха>-<номер>", эпоха меняется при перезапуске,
+// поэтому Last-Event-ID из прошлого запуска не спутать с текущим.
+type Event struct {
+	ID     string
+	Type   string
+	Tenant string // событие получают только клиенты этого тенанта
+	Key    string // ключ фильтра, например user_id; пустой Key у подписчика — все события
+	Data   []byte // JSON
+
+	seq uint64
+}
+
+// Broker раздаёт события подключённым клиентам и хранит последние size событий для Last-Event-ID.
+// Буфер в памяти процесса: каждый экземпляр сервиса видит только изменения, прошедшие через него.
+type Broker struct {
+	mu     sync.Mutex
+	epoch  string
+	seq    uint64
+	ring   []Event
+	next   int // куда писать следующее событие
+	full   bool
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewBroker создаёт брокер с буфером повтора на size событий
+func NewBroker(size int) *Broker {
+	return &Broker{
+		epoch: strconv.FormatInt(time.Now().UnixNano(), 36),
+		ring:  make([]Event, max(size, 1)),
+		subs:  make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscription — подключённый клиент; события приходят в C, канал закрывается при отключении
+type Subscription struct {
+	C <-chan Event
+
+	b      *Broker
+	ch     chan Event
+	tenant string
+	key    string
+}
+
+// Close отключает клиента
+func (s *Subscription) Close() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	s.b.drop(s)
+}
+
+func (s *Subscription) match(e Event) bool {
+	return e.Tenant == s.tenant && (s.key == "" || e.Key == s.key)
+}
+
+// Publish назначает событию ID, сохраняет его в буфере и отправляет подходящим клиентам
+func (b *Broker) Publish(e Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	e.seq = b.seq
+	e.ID = b.epoch + "-" + strconv.FormatUint(e.seq, 10)
+	b.ring[b.next] = e
+	if b.next = (b.next + 1) % len(b.ring); b.next == 0 {
+		b.full = true
+	}
+
+	for s := range b.subs {
+		if !s.match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			b.drop(s) // отстал: пусть переподключится и дочитает из буфера
+		}
+	}
+	return e
+}
+
+// Subscribe подключает клиента тенанта с фильтром key. Если lastID задан и ещё есть в буфере,
+// replay — пропущенные с него события; если он устарел или из прошлого запуска, resync=true:
+// клиенту нужно перечитать данные целиком. Повтор и подписка выполняются под одной блокировкой,
+// поэтому событие не теряется и не приходит дважды.
+func (b *Broker) Subscribe(tenant, key, lastID string) (replay []Event, sub *Subscription, resync bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	sub = &Subscription{C: ch, b: b, ch: ch, tenant: tenant, key: key}
+	if b.closed {
+		close(ch)
+		return nil, sub, false
+	}
+	b.subs[sub] = struct{}{}
+
+	if lastID == "" {
+		return nil, sub, false
+	}
+	last, ok := b.parseID(lastID)
+	if !ok || last > b.seq || last+1 < b.oldest() {
+		return nil, sub, true
+	}
+	for _, e := range b.since(last) {
+		if sub.match(e) {
+			replay = append(replay, e)
+		}
+	}
+	return replay, sub, false
+}
+
+// Close отключает всех клиентов, например при остановке сервера
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for s := range b.subs {
+		b.drop(s)
+	}
+}
+
+// drop отключает клиента; вызывается под b.mu
+func (b *Broker) drop(s *Subscription) {
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.ch)
+	}
+}
+
+// oldest — номер самого старого события в буфере; вызывается под b.mu
+func (b *Broker) oldest() uint64 {
+	if !b.full {
+		return 1
+	}
+	return b.seq - uint64(len(b.ring)) + 1
+}
+
+// since — события буфера с номером больше last, по порядку; вызывается под b.mu
+func (b *Broker) since(last uint64) []Event {
+	n := int(b.seq - last)
+	out := make([]Event, 0, n)
+	for i := n; i > 0; i-- {
+		out = append(out, b.ring[(b.next-i+len(b.ring))%len(b.ring)])
+	}
+	return out
+}
+
+func (b *Broker) parseID(id string) (uint64, bool) {
+	epoch, num, ok := strings.Cut(id, "-")
+	if !ok || epoch != b.epoch {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(num, 10, 64)
+	return seq, err == nil
+}
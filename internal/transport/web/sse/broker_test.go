@@ -0,0 +1,129 @@
+package sse
+
+import (
+	"strings"
+	"testing"
+)
+
+func publish(b *Broker, tenant, key, data string) Event {
+	return b.Publish(Event{Type: "test", Tenant: tenant, Key: key, Data: []byte(data)})
+}
+
+func recv(t *testing.T, s *Subscription) Event {
+	t.Helper()
+	select {
+	case e := <-s.C:
+		return e
+	default:
+		t.Fatal("no event")
+		return Event{}
+	}
+}
+
+func TestBroker_FilterByTenantAndKey(t *testing.T) {
+	b := NewBroker(10)
+	_, all, _ := b.Subscribe("t1", "", "")
+	_, u1, _ := b.Subscribe("t1", "u1", "")
+
+	publish(b, "t1", "u1", "a")
+	publish(b, "t1", "u2", "b")
+	publish(b, "t2", "u1", "c")
+
+	if len(all.C) != 2 {
+		t.Fatalf("tenant subscriber: want 2 events, got %d", len(all.C))
+	}
+	_, late, _ := b.Subscribe("t1", "", "")
+	if len(late.C) != 0 {
+		t.Fatalf("new subscriber must not get old events without Last-Event-ID")
+	}
+	if got := recv(t, u1); string(got.Data) != "a" {
+		t.Fatalf("u1 got %q", got.Data)
+	}
+	if len(u1.C) != 0 {
+		t.Fatalf("u1 must get only its events, %d left", len(u1.C))
+	}
+}
+
+func TestBroker_ReplayFromLastEventID(t *testing.T) {
+	b := NewBroker(3)
+	first := publish(b, "t", "", "1")
+	publish(b, "t", "", "2")
+	publish(b, "t", "", "3")
+
+	replay, sub, resync := b.Subscribe("t", "", first.ID)
+	defer sub.Close()
+	if resync || len(replay) != 2 || string(replay[0].Data) != "2" || string(replay[1].Data) != "3" {
+		t.Fatalf("replay=%+v resync=%v", replay, resync)
+	}
+
+	// буфер на 3 события: после "1" нужны "2".."4" — все ещё в буфере
+	publish(b, "t", "", "4")
+	if _, _, resync := b.Subscribe("t", "", first.ID); resync {
+		t.Fatal("Last-Event-ID right before the buffer must not require resync")
+	}
+	// "2" вытеснено — после "1" уже не дочитать, после "2" ещё можно
+	publish(b, "t", "", "5")
+	if _, _, resync := b.Subscribe("t", "", first.ID); !resync {
+		t.Fatal("evicted Last-Event-ID must require resync")
+	}
+	replay, _, resync = b.Subscribe("t", "", replay[0].ID)
+	if resync || len(replay) != 3 || string(replay[0].Data) != "3" || string(replay[2].Data) != "5" {
+		t.Fatalf("replay=%+v resync=%v", replay, resync)
+	}
+
+	// последний ID — повторять нечего
+	replay, _, resync = b.Subscribe("t", "", replay[2].ID)
+	if resync || len(replay) != 0 {
+		t.Fatalf("replay=%+v resync=%v", replay, resync)
+	}
+}
+
+func TestBroker_ForeignIDRequiresResync(t *testing.T) {
+	old := NewBroker(10)
+	e := publish(old, "t", "", "x")
+
+	b := NewBroker(10)
+	b.epoch = old.epoch + "z" // другой запуск
+	for _, id := range []string{e.ID, "garbage", b.epoch + "-99"} {
+		if _, _, resync := b.Subscribe("t", "", id); !resync {
+			t.Fatalf("%q: want resync", id)
+		}
+	}
+}
+
+func TestBroker_SlowSubscriberDropped(t *testing.T) {
+	b := NewBroker(10)
+	_, sub, _ := b.Subscribe("t", "", "")
+	for i := 0; i < subscriberBuffer+1; i++ {
+		publish(b, "t", "", "x")
+	}
+	n := 0
+	for range sub.C {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Fatalf("want %d buffered events before close, got %d", subscriberBuffer, n)
+	}
+	sub.Close() // повторное закрытие безопасно
+}
+
+func TestBroker_Close(t *testing.T) {
+	b := NewBroker(10)
+	_, sub, _ := b.Subscribe("t", "", "")
+	b.Close()
+	if _, ok := <-sub.C; ok {
+		t.Fatal("channel must be closed")
+	}
+	_, late, _ := b.Subscribe("t", "", "")
+	if _, ok := <-late.C; ok {
+		t.Fatal("subscribe after Close must return closed channel")
+	}
+}
+
+func TestEventIDFormat(t *testing.T) {
+	b := NewBroker(1)
+	e := publish(b, "t", "", "x")
+	if !strings.HasPrefix(e.ID, b.epoch+"-") || !strings.HasSuffix(e.ID, "-1") {
+		t.Fatalf("unexpected id %q", e.ID)
+	}
+}
@@ -0,0 +1,78 @@
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ContentType — тип ответа потока
+const ContentType = "text/event-stream"
+
+// Writer пишет события в формате text/event-stream. Поток живёт дольше WriteTimeout сервера,
+// поэтому перед каждой записью дедлайн продлевается на Grace: пока клиент читает, соединение не рвётся,
+// а зависший клиент отваливается через Grace после последней попытки записи.
+type Writer struct {
+	w     http.ResponseWriter
+	rc    *http.ResponseController
+	grace time.Duration
+}
+
+// NewWriter отправляет заголовки потока и возвращает Writer; retry — пауза переподключения для клиента
+func NewWriter(w http.ResponseWriter, grace, retry time.Duration) (*Writer, error) {
+	sw := &Writer{w: w, rc: http.NewResponseController(w), grace: grace}
+
+	h := w.Header()
+	h.Set("Content-Type", ContentType)
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no") // nginx не должен копить ответ
+	w.WriteHeader(http.StatusOK)
+
+	return sw, sw.write(func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "retry: %d\n\n", retry.Milliseconds())
+		return err
+	})
+}
+
+// Event отправляет событие; многострочные данные разбиваются на несколько строк data:
+func (sw *Writer) Event(e Event) error {
+	return sw.write(func(w io.Writer) error {
+		var b strings.Builder
+		if e.ID != "" {
+			fmt.Fprintf(&b, "id: %s\n", e.ID)
+		}
+		if e.Type != "" {
+			fmt.Fprintf(&b, "event: %s\n", e.Type)
+		}
+		for _, line := range strings.Split(string(e.Data), "\n") {
+			fmt.Fprintf(&b, "data: %s\n", line)
+		}
+		b.WriteString("\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	})
+}
+
+// Comment отправляет комментарий — клиент его игнорирует, прокси видят живое соединение
+func (sw *Writer) Comment(text string) error {
+	return sw.write(func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, ": %s\n\n", text)
+		return err
+	})
+}
+
+func (sw *Writer) write(fn func(w io.Writer) error) error {
+	if err := sw.rc.SetWriteDeadline(time.Now().Add(sw.grace)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if err := fn(sw.w); err != nil {
+		return err
+	}
+	if err := sw.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
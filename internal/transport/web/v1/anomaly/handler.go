@@ -0,0 +1,65 @@
+package anomaly
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+type Handler struct {
+	Log  *log.Logger
+	Repo domain.AnomalyRepository
+}
+
+// List godoc
+// @Summary      List anomalies
+// @Description  Получить найденные аномалии: скачки цен сервисов и всплески трат пользователей
+// @Tags         anomalies
+// @Produce      json
+// @Param        kind      query  string  false  "Тип аномалии (service_price | user_spend)"
+// @Param        subject   query  string  false  "Название сервиса или ID пользователя"
+// @Param        severity  query  string  false  "Серьёзность (low | medium | high)"
+// @Param        from      query  string  false  "Начало периода (MM-YYYY)"
+// @Param        to        query  string  false  "Конец периода (MM-YYYY)"
+// @Success      200  {object}  anomaly.ListResponse
+// @Failure      400  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/anomalies [get]
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	const op = "anomaly.list"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	q := r.URL.Query()
+	f, err := ParseListQuery(q.Get("kind"), q.Get("subject"), q.Get("severity"), q.Get("from"), q.Get("to"))
+	if err != nil {
+		logx.Error(h.Log, reqID, op, "validation failed", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	list, err := h.Repo.ListAnomalies(ctx, f)
+	if err != nil {
+		if v1.IsTimeout(err) {
+			logx.Error(h.Log, reqID, op, "timeout", err)
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+			return
+		}
+		logx.Error(h.Log, reqID, op, "repo list failed", err)
+		v1.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	resp := &ListResponse{Anomalies: MapDomainListToDTO(list)}
+	logx.Info(h.Log, reqID, op, "returned", "count", len(resp.Anomalies))
+	v1.WriteJSON(w, http.StatusOK, resp)
+}
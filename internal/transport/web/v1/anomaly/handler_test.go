@@ -0,0 +1,71 @@
+package anomaly
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	mockrepo "github.com/EgorLis/my-subs/internal/infra/database/mock"
+)
+
+type timeoutRepo struct{ domain.AnomalyRepository }
+
+func (timeoutRepo) ListAnomalies(ctx context.Context, f domain.AnomalyFilter) ([]domain.Anomaly, error) {
+	return nil, context.DeadlineExceeded
+}
+
+func TestList_Various(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	_ = repo.ReplaceAnomalies(context.Background(), time.Time{}, time.Time{}, []domain.Anomaly{
+		{Kind: domain.AnomalyServicePrice, Subject: "Netflix", Month: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC), Amount: 750, Baseline: 500, Ratio: 1.5, Severity: domain.SeverityLow},
+		{Kind: domain.AnomalyUserSpend, Subject: "u1", Month: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), Amount: 300, Baseline: 100, Ratio: 3, Severity: domain.SeverityHigh},
+	})
+
+	cases := []struct {
+		name       string
+		repo       domain.AnomalyRepository
+		query      string
+		wantCode   int
+		wantLen    int
+		wantInBody string
+	}{
+		{name: "All", repo: repo, wantCode: http.StatusOK, wantLen: 2},
+		{name: "ByKind", repo: repo, query: "kind=user_spend", wantCode: http.StatusOK, wantLen: 1},
+		{name: "BySeverity", repo: repo, query: "severity=low", wantCode: http.StatusOK, wantLen: 1},
+		{name: "ByPeriod", repo: repo, query: "from=06-2025&to=12-2025", wantCode: http.StatusOK, wantLen: 1},
+		{name: "BadKind", repo: repo, query: "kind=weird", wantCode: http.StatusBadRequest, wantInBody: "kind"},
+		{name: "BadFrom", repo: repo, query: "from=2025-06", wantCode: http.StatusBadRequest, wantInBody: "from"},
+		{name: "Timeout", repo: timeoutRepo{}, wantCode: http.StatusGatewayTimeout},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &Handler{Log: log.New(io.Discard, "", 0), Repo: tc.repo}
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/v1/anomalies?"+tc.query, nil)
+
+			h.List(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
+			}
+			if tc.wantInBody != "" && !strings.Contains(w.Body.String(), tc.wantInBody) {
+				t.Fatalf("body should contain %q, got %s", tc.wantInBody, w.Body.String())
+			}
+			if tc.wantCode == http.StatusOK {
+				var resp ListResponse
+				_ = json.Unmarshal(w.Body.Bytes(), &resp)
+				if len(resp.Anomalies) != tc.wantLen {
+					t.Fatalf("want %d items, got %d", tc.wantLen, len(resp.Anomalies))
+				}
+			}
+		})
+	}
+}
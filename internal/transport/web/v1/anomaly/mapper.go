@@ -0,0 +1,30 @@
+package anomaly
+
+import (
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+)
+
+func MapDomainToDTO(a domain.Anomaly) AnomalyDTO {
+	return AnomalyDTO{
+		ID:         a.ID,
+		Kind:       string(a.Kind),
+		Subject:    a.Subject,
+		Month:      subscription.YearMonth(a.Month),
+		Amount:     a.Amount,
+		Baseline:   a.Baseline,
+		Ratio:      a.Ratio,
+		Severity:   string(a.Severity),
+		DetectedAt: a.DetectedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func MapDomainListToDTO(list []domain.Anomaly) []AnomalyDTO {
+	out := make([]AnomalyDTO, 0, len(list))
+	for _, a := range list {
+		out = append(out, MapDomainToDTO(a))
+	}
+	return out
+}
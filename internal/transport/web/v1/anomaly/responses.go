@@ -0,0 +1,19 @@
+package anomaly
+
+import "github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+
+type AnomalyDTO struct {
+	ID         string                 `json:"id"`
+	Kind       string                 `json:"kind"`
+	Subject    string                 `json:"subject"`
+	Month      subscription.YearMonth `json:"month"`
+	Amount     int                    `json:"amount"`
+	Baseline   float64                `json:"baseline"`
+	Ratio      float64                `json:"ratio"`
+	Severity   string                 `json:"severity"`
+	DetectedAt string                 `json:"detected_at"`
+}
+
+type ListResponse struct {
+	Anomalies []AnomalyDTO `json:"anomalies"`
+}
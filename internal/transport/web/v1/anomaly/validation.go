@@ -0,0 +1,52 @@
+package anomaly
+
+import (
+	"strings"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+)
+
+// ParseListQuery разбирает необязательные фильтры kind, subject, severity, from, to (MM-YYYY)
+func ParseListQuery(kind, subject, severity, from, to string) (domain.AnomalyFilter, error) {
+	var errs []v1.FieldError
+	f := domain.AnomalyFilter{Subject: strings.TrimSpace(subject)}
+
+	switch k := domain.AnomalyKind(kind); k {
+	case "", domain.AnomalyServicePrice, domain.AnomalyUserSpend:
+		f.Kind = k
+	default:
+		errs = append(errs, v1.Field("kind", "one_of", "values", "service_price, user_spend"))
+	}
+
+	switch s := domain.Severity(severity); s {
+	case "", domain.SeverityLow, domain.SeverityMedium, domain.SeverityHigh:
+		f.Severity = s
+	default:
+		errs = append(errs, v1.Field("severity", "one_of", "values", "low, medium, high"))
+	}
+
+	if from != "" {
+		ym, err := subscription.YMFromStr(from)
+		if err != nil {
+			errs = append(errs, v1.Field("from", "invalid_format", "format", "MM-YYYY"))
+		}
+		f.From = ym.ToTime()
+	}
+	if to != "" {
+		ym, err := subscription.YMFromStr(to)
+		if err != nil {
+			errs = append(errs, v1.Field("to", "invalid_format", "format", "MM-YYYY"))
+		}
+		f.To = ym.ToTime()
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		errs = append(errs, v1.Field("date range", "from_after_to"))
+	}
+
+	if len(errs) > 0 {
+		return f, v1.Fields(errs)
+	}
+	return f, nil
+}
@@ -0,0 +1,117 @@
+package audit
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+)
+
+type Handler struct {
+	Log  *log.Logger
+	Repo domain.AuditRepository
+}
+
+// List godoc
+// @Summary      Audit log
+// @Description  Журнал изменений подписок тенанта, новые записи первыми. Каждая запись — одно создание, изменение,
+// @Description  удаление в корзину или восстановление: кто (actor), в каком запросе (request_id, как X-Request-ID)
+// @Description  и состояние подписки до (before) и после (after). changed — поля, значения которых различаются.
+// @Tags         audit
+// @Produce      json
+// @Param        subscription_id  query  string  false  "ID подписки"
+// @Param        user_id          query  string  false  "ID владельца подписки"
+// @Param        actor            query  string  false  "Автор изменения"
+// @Param        action           query  string  false  "Действие (create | update | delete | restore)"
+// @Param        request_id       query  string  false  "ID запроса"
+// @Param        field            query  string  false  "Только изменения поля (service_name, price, user_id, start_date, end_date, notes, tags, deleted_at)"
+// @Param        from             query  string  false  "Не раньше (RFC3339)"
+// @Param        to               query  string  false  "Раньше (RFC3339)"
+// @Param        limit            query  int     false  "Размер страницы (1..500, по умолчанию 50)"
+// @Param        cursor           query  string  false  "Курсор из next_cursor предыдущей страницы"
+// @Success      200  {object}  audit.ListResponse
+// @Failure      400  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/audit [get]
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	const op = "audit.list"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	q, err := ParseQuery(r.URL.Query())
+	if err != nil {
+		logx.Error(h.Log, reqID, op, "validation failed", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+	h.list(w, r, op, q)
+}
+
+// History godoc
+// @Summary      Subscription history
+// @Description  Все изменения подписки, новые первыми; фильтры и пагинация — как у GET /v1/audit.
+// @Description  История сохраняется и после окончательного удаления подписки из корзины.
+// @Tags         audit
+// @Produce      json
+// @Param        id      path   string  true   "Subscription ID (GUID)"
+// @Param        action  query  string  false  "Действие (create | update | delete | restore)"
+// @Param        field   query  string  false  "Только изменения поля, например price"
+// @Param        limit   query  int     false  "Размер страницы (1..500, по умолчанию 50)"
+// @Param        cursor  query  string  false  "Курсор из next_cursor предыдущей страницы"
+// @Success      200  {object}  audit.ListResponse
+// @Failure      400  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/subscriptions/{id}/history [get]
+func (h *Handler) History(w http.ResponseWriter, r *http.Request) {
+	const op = "audit.history"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	id := r.PathValue("id")
+	if err := subscription.ValidateGUID(id); err != nil {
+		logx.Error(h.Log, reqID, op, "bad id", err, "id", id)
+		v1.WriteValidationError(w, v1.NewFieldError("id", "invalid_guid", "value", strconv.Quote(id)))
+		return
+	}
+	q, err := ParseQuery(r.URL.Query())
+	if err != nil {
+		logx.Error(h.Log, reqID, op, "validation failed", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+	q.SubscriptionID = id
+	h.list(w, r, op, q)
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request, op string, q domain.AuditQuery) {
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	page, err := h.Repo.ListAudit(ctx, q)
+	if err != nil {
+		if v1.IsTimeout(err) {
+			logx.Error(h.Log, reqID, op, "timeout", err)
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+			return
+		}
+		logx.Error(h.Log, reqID, op, "repo list failed", err)
+		v1.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	resp := &ListResponse{Entries: MapDomainListToDTO(page.Entries)}
+	if page.HasMore && len(page.Entries) > 0 {
+		resp.NextCursor = EncodeCursor(page.Entries[len(page.Entries)-1])
+	}
+	logx.Info(h.Log, reqID, op, "returned", "count", len(resp.Entries), "has_more", page.HasMore)
+	v1.WriteJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,264 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	mockrepo "github.com/EgorLis/my-subs/internal/infra/database/mock"
+	"github.com/google/uuid"
+)
+
+// ---------- helpers ----------
+
+func newHandler(repo domain.AuditRepository) *Handler {
+	return &Handler{Log: log.New(io.Discard, "", 0), Repo: repo}
+}
+
+type timeoutRepo struct{ domain.AuditRepository }
+
+func (timeoutRepo) ListAudit(ctx context.Context, q domain.AuditQuery) (domain.AuditPage, error) {
+	return domain.AuditPage{}, context.DeadlineExceeded
+}
+
+// fixture: подписка создана, дважды изменена (цена, затем заметки), удалена и восстановлена;
+// вторая подписка только создана
+type fixture struct {
+	repo       *mockrepo.Repo
+	userID     string
+	subID      string
+	otherID    string
+	priceReqID string
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	repo := mockrepo.NewMockRepo()
+	u, _ := repo.AddUser(context.Background(), domain.User{DisplayName: "Test User", Currency: "RUB", Locale: "ru", Timezone: "UTC"})
+	f := fixture{repo: repo, userID: u.ID, priceReqID: uuid.NewString()}
+
+	as := func(actor, reqID string) context.Context {
+		return domain.WithRequestID(domain.WithActor(context.Background(), actor), reqID)
+	}
+	sub := domain.Subscription{
+		ServiceName: "Netflix", Price: 500, UserID: u.ID,
+		StartDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+	created, err := repo.AddSub(as("alice", uuid.NewString()), sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.subID = created.ID
+	sub.ID, sub.Price = created.ID, 700
+	if _, err := repo.UpdateSub(as("bob", f.priceReqID), sub, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.PatchSub(as("alice", uuid.NewString()), sub.ID, 2, func(cur domain.Subscription) (domain.Subscription, error) {
+		cur.Notes = "семейный тариф"
+		return cur, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.DeleteSub(as("bob", uuid.NewString()), sub.ID, 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.RestoreSub(as("alice", uuid.NewString()), sub.ID); err != nil {
+		t.Fatal(err)
+	}
+	other, _ := repo.AddSub(as("carol", uuid.NewString()), domain.Subscription{
+		ServiceName: "Spotify", Price: 200, UserID: u.ID, StartDate: sub.StartDate, EndDate: sub.EndDate,
+	})
+	f.otherID = other.ID
+	return f
+}
+
+func get(t *testing.T, h *Handler, target, id string) (*httptest.ResponseRecorder, ListResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	var resp ListResponse
+	if id != "" {
+		r.SetPathValue("id", id)
+		h.History(w, r)
+	} else {
+		h.List(w, r)
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func actions(resp ListResponse) []string {
+	out := make([]string, len(resp.Entries))
+	for i, e := range resp.Entries {
+		out[i] = e.Action
+	}
+	return out
+}
+
+// ---------- HISTORY ----------
+
+func TestHistory(t *testing.T) {
+	f := newFixture(t)
+	h := newHandler(f.repo)
+
+	w, resp := get(t, h, "/v1/subscriptions/"+f.subID+"/history", f.subID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d. body=%s", w.Code, w.Body.String())
+	}
+	want := []string{domain.AuditRestore, domain.AuditDelete, domain.AuditUpdate, domain.AuditUpdate, domain.AuditCreate}
+	if got := actions(resp); !slices.Equal(got, want) {
+		t.Fatalf("want %v newest first, got %v", want, got)
+	}
+
+	created := resp.Entries[4]
+	if created.Before != nil || created.After == nil || created.After.Price != 500 || created.Actor != "alice" {
+		t.Fatalf("create entry: %+v", created)
+	}
+	price := resp.Entries[3]
+	if price.Actor != "bob" || price.RequestID != f.priceReqID {
+		t.Fatalf("price entry: want actor bob and request %s, got %+v", f.priceReqID, price)
+	}
+	if price.Before.Price != 500 || price.After.Price != 700 || !slices.Equal(price.Changed, []string{domain.AuditFieldPrice}) {
+		t.Fatalf("price entry diff: before=%+v after=%+v changed=%v", price.Before, price.After, price.Changed)
+	}
+	if notes := resp.Entries[2]; !slices.Equal(notes.Changed, []string{domain.AuditFieldNotes}) {
+		t.Fatalf("patch entry: want notes changed, got %v", notes.Changed)
+	}
+	deleted := resp.Entries[1]
+	if deleted.After.DeletedAt == "" || deleted.Before.DeletedAt != "" || !slices.Equal(deleted.Changed, []string{domain.AuditFieldDeletedAt}) {
+		t.Fatalf("delete entry: %+v", deleted)
+	}
+	if restored := resp.Entries[0]; restored.After.DeletedAt != "" || restored.After.Version != 4 {
+		t.Fatalf("restore entry: %+v", restored.After)
+	}
+
+	t.Run("WhoChangedPrice", func(t *testing.T) {
+		_, resp := get(t, h, "/v1/subscriptions/"+f.subID+"/history?field=price", f.subID)
+		if got := actions(resp); !slices.Equal(got, []string{domain.AuditUpdate, domain.AuditCreate}) {
+			t.Fatalf("want update and create, got %v", got)
+		}
+		if resp.Entries[0].Actor != "bob" {
+			t.Fatalf("want bob, got %q", resp.Entries[0].Actor)
+		}
+	})
+	t.Run("Pagination", func(t *testing.T) {
+		var seen []int64
+		target := "/v1/subscriptions/" + f.subID + "/history?limit=2"
+		for range 3 {
+			_, resp := get(t, h, target, f.subID)
+			for _, e := range resp.Entries {
+				seen = append(seen, e.ID)
+			}
+			if resp.NextCursor == "" {
+				break
+			}
+			target = "/v1/subscriptions/" + f.subID + "/history?limit=2&cursor=" + resp.NextCursor
+		}
+		if len(seen) != 5 || !slices.IsSortedFunc(seen, func(a, b int64) int { return int(b - a) }) {
+			t.Fatalf("want 5 entries newest first across pages, got %v", seen)
+		}
+	})
+	t.Run("Unknown", func(t *testing.T) {
+		id := uuid.NewString()
+		w, resp := get(t, h, "/v1/subscriptions/"+id+"/history", id)
+		if w.Code != http.StatusOK || len(resp.Entries) != 0 {
+			t.Fatalf("want empty 200, got %d %s", w.Code, w.Body.String())
+		}
+	})
+	t.Run("BadID", func(t *testing.T) {
+		w, _ := get(t, h, "/v1/subscriptions/bad/history", "bad")
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("want 400, got %d", w.Code)
+		}
+	})
+}
+
+// ---------- GLOBAL QUERY ----------
+
+func TestList_Various(t *testing.T) {
+	f := newFixture(t)
+
+	cases := []struct {
+		name       string
+		repo       domain.AuditRepository
+		query      string
+		wantCode   int
+		wantLen    int
+		wantInBody string
+	}{
+		{name: "All", repo: f.repo, wantCode: http.StatusOK, wantLen: 6},
+		{name: "ByActor", repo: f.repo, query: "actor=bob", wantCode: http.StatusOK, wantLen: 2},
+		{name: "ByAction", repo: f.repo, query: "action=create", wantCode: http.StatusOK, wantLen: 2},
+		{name: "ByRequest", repo: f.repo, query: "request_id=" + f.priceReqID, wantCode: http.StatusOK, wantLen: 1},
+		{name: "BySubscription", repo: f.repo, query: "subscription_id=" + f.otherID, wantCode: http.StatusOK, wantLen: 1},
+		{name: "ByUser", repo: f.repo, query: "user_id=" + f.userID + "&field=deleted_at", wantCode: http.StatusOK, wantLen: 2},
+		{name: "Future", repo: f.repo, query: "from=" + time.Now().Add(time.Hour).UTC().Format(time.RFC3339), wantCode: http.StatusOK, wantLen: 0},
+		{name: "BadAction", repo: f.repo, query: "action=purge", wantCode: http.StatusBadRequest, wantInBody: "action"},
+		{name: "BadField", repo: f.repo, query: "field=version", wantCode: http.StatusBadRequest, wantInBody: "field"},
+		{name: "BadFrom", repo: f.repo, query: "from=07-2025", wantCode: http.StatusBadRequest, wantInBody: "RFC3339"},
+		{name: "BadUser", repo: f.repo, query: "user_id=nope", wantCode: http.StatusBadRequest, wantInBody: "user_id"},
+		{name: "BadLimit", repo: f.repo, query: "limit=501", wantCode: http.StatusBadRequest, wantInBody: "limit"},
+		{name: "BadCursor", repo: f.repo, query: "cursor=!!", wantCode: http.StatusBadRequest, wantInBody: "cursor"},
+		{name: "Timeout", repo: timeoutRepo{}, wantCode: http.StatusGatewayTimeout},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, resp := get(t, newHandler(tc.repo), "/v1/audit?"+tc.query, "")
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
+			}
+			if tc.wantInBody != "" && !strings.Contains(w.Body.String(), tc.wantInBody) {
+				t.Fatalf("body should contain %q, got %s", tc.wantInBody, w.Body.String())
+			}
+			if tc.wantCode == http.StatusOK && len(resp.Entries) != tc.wantLen {
+				t.Fatalf("want %d entries, got %d", tc.wantLen, len(resp.Entries))
+			}
+		})
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	f := newFixture(t)
+	other, _ := f.repo.AddTenant(context.Background(), domain.Tenant{Name: "other"})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/audit", nil)
+	newHandler(f.repo).List(w, r.WithContext(domain.WithTenant(r.Context(), other.ID)))
+	var resp ListResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || len(resp.Entries) != 0 {
+		t.Fatalf("want no entries of other tenant, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// записи журнала откатываются вместе с транзакцией
+func TestRolledBackChangesAreNotLogged(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+	err := f.repo.InTx(ctx, func(tx domain.SubscriptionTx) error {
+		if _, err := tx.DeleteSub(ctx, f.otherID, domain.AnyVersion); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("want abort, got %v", err)
+	}
+	page, _ := f.repo.ListAudit(ctx, domain.AuditQuery{SubscriptionID: f.otherID})
+	if len(page.Entries) != 1 || page.Entries[0].Action != domain.AuditCreate {
+		t.Fatalf("want only create entry, got %+v", page.Entries)
+	}
+}
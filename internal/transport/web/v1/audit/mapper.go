@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+)
+
+func MapDomainToDTO(e domain.AuditEntry) EntryDTO {
+	return EntryDTO{
+		ID:             e.ID,
+		SubscriptionID: e.SubscriptionID,
+		UserID:         e.UserID,
+		Action:         e.Action,
+		Actor:          e.Actor,
+		RequestID:      e.RequestID,
+		At:             e.CreatedAt.UTC().Format(time.RFC3339),
+		Changed:        e.Changed(),
+		Before:         mapSnapshot(e.Before),
+		After:          mapSnapshot(e.After),
+	}
+}
+
+func mapSnapshot(s *domain.Subscription) *subscription.SubscriptionDTO {
+	if s == nil {
+		return nil
+	}
+	dto := subscription.MapDomainToDTO(*s)
+	return &dto
+}
+
+func MapDomainListToDTO(list []domain.AuditEntry) []EntryDTO {
+	out := make([]EntryDTO, 0, len(list))
+	for _, e := range list {
+		out = append(out, MapDomainToDTO(e))
+	}
+	return out
+}
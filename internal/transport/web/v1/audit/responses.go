@@ -0,0 +1,21 @@
+package audit
+
+import "github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+
+type EntryDTO struct {
+	ID             int64                         `json:"id"`
+	SubscriptionID string                        `json:"subscription_id"`
+	UserID         string                        `json:"user_id"`
+	Action         string                        `json:"action"`
+	Actor          string                        `json:"actor,omitempty"`
+	RequestID      string                        `json:"request_id,omitempty"`
+	At             string                        `json:"at"`
+	Changed        []string                      `json:"changed"` // поля, значения которых отличаются в before и after
+	Before         *subscription.SubscriptionDTO `json:"before"`  // null при создании
+	After          *subscription.SubscriptionDTO `json:"after"`
+}
+
+type ListResponse struct {
+	Entries    []EntryDTO `json:"entries"`
+	NextCursor string     `json:"next_cursor,omitempty"` // передать в cursor, чтобы получить более старые записи
+}
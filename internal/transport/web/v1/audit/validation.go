@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"encoding/base64"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// ParseQuery разбирает фильтры журнала: subscription_id, user_id, actor, action, request_id, field,
+// from и to (RFC3339), limit и cursor. Для истории одной подписки subscription_id берётся из пути.
+func ParseQuery(q url.Values) (domain.AuditQuery, error) {
+	var errs []v1.FieldError
+	aq := domain.AuditQuery{
+		Actor:     strings.TrimSpace(q.Get("actor")),
+		RequestID: strings.TrimSpace(q.Get("request_id")),
+		Limit:     DefaultLimit,
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  *string
+	}{{"subscription_id", &aq.SubscriptionID}, {"user_id", &aq.UserID}} {
+		if v := q.Get(p.name); v != "" {
+			if subscription.ValidateGUID(v) != nil {
+				errs = append(errs, v1.Field(p.name, "invalid_guid", "value", strconv.Quote(v)))
+			}
+			*p.dst = v
+		}
+	}
+	if v := q.Get("action"); v != "" {
+		if !slices.Contains(domain.AuditActions, v) {
+			errs = append(errs, v1.Field("action", "one_of", "values", strings.Join(domain.AuditActions, ", ")))
+		}
+		aq.Action = v
+	}
+	if v := q.Get("field"); v != "" {
+		if !slices.Contains(domain.AuditFields, v) {
+			errs = append(errs, v1.Field("field", "one_of", "values", strings.Join(domain.AuditFields, ", ")))
+		}
+		aq.Field = v
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &aq.From}, {"to", &aq.To}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				errs = append(errs, v1.Field(p.name, "invalid_format", "format", "RFC3339"))
+			}
+			*p.dst = t
+		}
+	}
+	if !aq.From.IsZero() && !aq.To.IsZero() && aq.To.Before(aq.From) {
+		errs = append(errs, v1.Field("date range", "from_after_to"))
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxLimit {
+			errs = append(errs, v1.Field("limit", "between", "min", 1, "max", MaxLimit))
+		} else {
+			aq.Limit = n
+		}
+	}
+	if v := q.Get("cursor"); v != "" {
+		id, err := DecodeCursor(v)
+		if err != nil {
+			errs = append(errs, v1.Field("cursor", "malformed"))
+		}
+		aq.BeforeID = id
+	}
+
+	if len(errs) > 0 {
+		return aq, v1.Fields(errs)
+	}
+	return aq, nil
+}
+
+// EncodeCursor — курсор следующей страницы: записи старше последней выданной
+func EncodeCursor(last domain.AuditEntry) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(last.ID, 10)))
+}
+
+func DecodeCursor(s string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err == nil && id < 1 {
+		err = strconv.ErrRange
+	}
+	return id, err
+}
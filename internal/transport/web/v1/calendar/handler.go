@@ -0,0 +1,237 @@
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+const MaxAlarmDays = 30
+
+// Repository — ленте нужны пользователь (по токену) и его подписки
+type Repository interface {
+	domain.UserRepository
+	domain.SubscriptionRepository
+}
+
+type Handler struct {
+	Log           *log.Logger
+	Repo          Repository
+	AlarmDays     int // напоминание по умолчанию, дней до события; 0 — без напоминаний
+	HorizonMonths int // на сколько месяцев вперёд строить ленту
+	Now           func() time.Time
+}
+
+type TokenResponse struct {
+	Token string `json:"token"`
+	URL   string `json:"url"` // адрес для подписки в приложении календаря
+}
+
+// HashToken — в базе хранится только sha256 токена
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (h *Handler) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now()
+}
+
+// IssueToken godoc
+// @Summary      Issue calendar token
+// @Description  Выпустить секретный токен ленты календаря пользователя. Предыдущий токен перестаёт действовать.
+// @Tags         calendar
+// @Produce      json
+// @Param        id   path      string  true  "ID пользователя"
+// @Success      200  {object}  calendar.TokenResponse
+// @Failure      400  {object}  v1.Problem
+// @Failure      404  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/users/{id}/calendar-token [post]
+func (h *Handler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	const op = "calendar.issue_token"
+	reqID := mw.RequestIDFromCtx(r.Context())
+	id := r.PathValue("id")
+
+	token, err := newToken()
+	if err != nil {
+		logx.Error(h.Log, reqID, op, "token generation failed", err)
+		v1.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+	if !h.setToken(w, r, op, id, HashToken(token)) {
+		return
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	feed := url.URL{Scheme: scheme, Host: r.Host, Path: "/v1/users/" + id + "/calendar.ics", RawQuery: "token=" + token}
+	logx.Info(h.Log, reqID, op, "issued", "user_id", id)
+	v1.WriteJSON(w, http.StatusOK, TokenResponse{Token: token, URL: feed.String()})
+}
+
+// RevokeToken godoc
+// @Summary      Revoke calendar token
+// @Description  Закрыть ленту календаря пользователя: текущий токен перестаёт действовать
+// @Tags         calendar
+// @Param        id   path      string  true  "ID пользователя"
+// @Success      204
+// @Failure      400  {object}  v1.Problem
+// @Failure      404  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/users/{id}/calendar-token [delete]
+func (h *Handler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	const op = "calendar.revoke_token"
+	id := r.PathValue("id")
+	if h.setToken(w, r, op, id, "") {
+		logx.Info(h.Log, mw.RequestIDFromCtx(r.Context()), op, "revoked", "user_id", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *Handler) setToken(w http.ResponseWriter, r *http.Request, op, id, hash string) bool {
+	reqID := mw.RequestIDFromCtx(r.Context())
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	err := h.Repo.SetCalendarToken(ctx, id, hash)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, domain.ErrUserNotFound):
+		logx.Info(h.Log, reqID, op, "user not found", "user_id", id)
+		v1.WriteMessage(w, http.StatusNotFound, "user_not_found")
+	case v1.IsTimeout(err):
+		logx.Error(h.Log, reqID, op, "repo timeout", err)
+		v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+	default:
+		logx.Error(h.Log, reqID, op, "repo set token failed", err)
+		v1.WriteError(w, http.StatusInternalServerError, "")
+	}
+	return false
+}
+
+// Feed godoc
+// @Summary      Calendar feed of upcoming charges
+// @Description  Лента iCalendar (RFC 5545) для подписки в приложении календаря: списания и окончания подписок
+// @Description  на CALENDAR_HORIZON_MONTHS месяцев вперёд с напоминанием. Доступ — по токену из POST /v1/users/{id}/calendar-token,
+// @Description  заголовки не нужны: тенант определяется по токену.
+// @Tags         calendar
+// @Produce      text/calendar
+// @Param        id          path   string  true   "ID пользователя"
+// @Param        token       query  string  true   "Секретный токен ленты"
+// @Param        alarm_days  query  int     false  "За сколько дней напоминать (0..30, 0 — без напоминаний)"
+// @Success      200  {string}  string
+// @Failure      400  {object}  v1.Problem
+// @Failure      404  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/users/{id}/calendar.ics [get]
+func (h *Handler) Feed(w http.ResponseWriter, r *http.Request) {
+	const op = "calendar.feed"
+	reqID := mw.RequestIDFromCtx(r.Context())
+	id := r.PathValue("id")
+	q := r.URL.Query()
+
+	token := q.Get("token")
+	if token == "" {
+		v1.WriteValidationError(w, v1.NewFieldError("token", "required"))
+		return
+	}
+	alarmDays := h.AlarmDays
+	if v := q.Get("alarm_days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > MaxAlarmDays {
+			v1.WriteValidationError(w, v1.NewFieldError("alarm_days", "between", "min", 0, "max", 30))
+			return
+		}
+		alarmDays = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	u, err := h.Repo.UserByCalendarToken(ctx, HashToken(token))
+	if err == nil && u.ID != id {
+		err = domain.ErrUserNotFound
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			// неверный токен и чужой пользователь неразличимы
+			logx.Info(h.Log, reqID, op, "calendar not found", "user_id", id)
+			v1.WriteMessage(w, http.StatusNotFound, "calendar_not_found")
+			return
+		}
+		h.repoError(w, reqID, op, err)
+		return
+	}
+
+	// тенант берётся из владельца токена, а не из заголовков запроса
+	ctx = domain.WithTenant(ctx, u.TenantID)
+	now := h.now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	var subs []domain.Subscription
+	err = h.Repo.StreamSubs(ctx, domain.SubscriptionFilter{UserID: u.ID, From: from, To: from.AddDate(0, h.HorizonMonths, -1)},
+		func(s domain.Subscription) error {
+			subs = append(subs, s)
+			return nil
+		})
+	if err != nil {
+		h.repoError(w, reqID, op, err)
+		return
+	}
+
+	events := BuildEvents(u, subs, now, h.HorizonMonths)
+	name := "Подписки"
+	if u.DisplayName != "" {
+		name += " — " + u.DisplayName
+	}
+	var sb strings.Builder
+	WriteICS(&sb, name, events, alarmDays, now)
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="subscriptions.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(sb.String()))
+	logx.Info(h.Log, reqID, op, "served", "user_id", u.ID, "events", len(events))
+}
+
+func (h *Handler) repoError(w http.ResponseWriter, reqID, op string, err error) {
+	if v1.IsTimeout(err) {
+		logx.Error(h.Log, reqID, op, "repo timeout", err)
+		v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+		return
+	}
+	logx.Error(h.Log, reqID, op, "repo failed", err)
+	v1.WriteError(w, http.StatusInternalServerError, "")
+}
@@ -0,0 +1,212 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	mockrepo "github.com/EgorLis/my-subs/internal/infra/database/mock"
+	"github.com/google/uuid"
+)
+
+// ---------- helpers ----------
+
+var now = time.Date(2025, 7, 15, 9, 30, 0, 0, time.UTC)
+
+func newHandler(repo Repository) *Handler {
+	return &Handler{Log: log.New(io.Discard, "", 0), Repo: repo, AlarmDays: 3, HorizonMonths: 12,
+		Now: func() time.Time { return now }}
+}
+
+func month(mm, yyyy int) time.Time {
+	return time.Date(yyyy, time.Month(mm), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func issue(t *testing.T, h *Handler, tenantID, userID string) TokenResponse {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v1/users/"+userID+"/calendar-token", nil)
+	r = r.WithContext(domain.WithTenant(r.Context(), tenantID))
+	r.SetPathValue("id", userID)
+	h.IssueToken(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("issue token: want 200, got %d. body=%s", w.Code, w.Body.String())
+	}
+	var resp TokenResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp
+}
+
+func feed(h *Handler, userID, query string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	// без X-Tenant-ID: middleware кладёт тенант по умолчанию
+	r := httptest.NewRequest(http.MethodGet, "/v1/users/"+userID+"/calendar.ics"+query, nil)
+	r.SetPathValue("id", userID)
+	h.Feed(w, r)
+	return w
+}
+
+// ---------- FEED ----------
+
+func TestFeed(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	acme, _ := repo.AddTenant(context.Background(), domain.Tenant{Name: "acme"})
+	ctx := domain.WithTenant(context.Background(), acme.ID)
+	u, _ := repo.AddUser(ctx, domain.User{DisplayName: "Ivan", Currency: "RUB"})
+	other, _ := repo.AddUser(ctx, domain.User{DisplayName: "Petr"})
+	for _, s := range []domain.Subscription{
+		{ServiceName: "Netflix", Price: 500, StartDate: month(1, 2025), EndDate: month(9, 2025)},
+		{ServiceName: "Spotify", Price: 300, StartDate: month(1, 2025), EndDate: month(6, 2025)}, // уже закончилась
+	} {
+		s.UserID = u.ID
+		if _, err := repo.AddSub(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := newHandler(repo)
+	token := issue(t, h, acme.ID, u.ID)
+	if !strings.HasSuffix(token.URL, "/v1/users/"+u.ID+"/calendar.ics?token="+token.Token) {
+		t.Fatalf("unexpected feed url %q", token.URL)
+	}
+
+	cases := []struct {
+		name       string
+		userID     string
+		query      string
+		wantCode   int
+		wantEvents int
+		wantIn     []string
+		wantNotIn  []string
+	}{
+		{
+			name: "OK", userID: u.ID, query: "?token=" + token.Token, wantCode: 200, wantEvents: 3,
+			wantIn: []string{
+				"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+				"X-WR-CALNAME:Подписки — Ivan",
+				"DTSTART;VALUE=DATE:20250801", "DTSTART;VALUE=DATE:20250901",
+				"SUMMARY:Netflix: списание 500 RUB",
+				"DTSTART;VALUE=DATE:20250930\r\nDTEND;VALUE=DATE:20251001\r\nSUMMARY:Netflix: подписка заканчивается",
+				"TRIGGER:-P3D", "END:VCALENDAR\r\n",
+			},
+			// июльское списание уже прошло, Spotify закончилась
+			wantNotIn: []string{"DTSTART;VALUE=DATE:20250701", "Spotify"},
+		},
+		{name: "CustomAlarm", userID: u.ID, query: "?token=" + token.Token + "&alarm_days=7", wantCode: 200, wantEvents: 3, wantIn: []string{"TRIGGER:-P7D"}},
+		{name: "NoAlarm", userID: u.ID, query: "?token=" + token.Token + "&alarm_days=0", wantCode: 200, wantEvents: 3, wantNotIn: []string{"VALARM"}},
+		{name: "BadAlarm", userID: u.ID, query: "?token=" + token.Token + "&alarm_days=99", wantCode: 400},
+		{name: "NoToken", userID: u.ID, wantCode: 400},
+		{name: "WrongToken", userID: u.ID, query: "?token=nope", wantCode: 404},
+		{name: "TokenOfAnotherUser", userID: other.ID, query: "?token=" + token.Token, wantCode: 404},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := feed(h, tc.userID, tc.query)
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
+			}
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "text/calendar; charset=utf-8" {
+				t.Fatalf("Content-Type: got %q", ct)
+			}
+			body := w.Body.String()
+			if n := strings.Count(body, "BEGIN:VEVENT"); n != tc.wantEvents {
+				t.Fatalf("want %d events, got %d:\n%s", tc.wantEvents, n, body)
+			}
+			for _, s := range tc.wantIn {
+				if !strings.Contains(body, s) {
+					t.Fatalf("want %q in:\n%s", s, body)
+				}
+			}
+			for _, s := range tc.wantNotIn {
+				if strings.Contains(body, s) {
+					t.Fatalf("unexpected %q in:\n%s", s, body)
+				}
+			}
+		})
+	}
+
+	t.Run("ReissueInvalidatesOldToken", func(t *testing.T) {
+		fresh := issue(t, h, acme.ID, u.ID)
+		if w := feed(h, u.ID, "?token="+token.Token); w.Code != http.StatusNotFound {
+			t.Fatalf("old token: want 404, got %d", w.Code)
+		}
+		if w := feed(h, u.ID, "?token="+fresh.Token); w.Code != http.StatusOK {
+			t.Fatalf("new token: want 200, got %d", w.Code)
+		}
+		token = fresh
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "/v1/users/"+u.ID+"/calendar-token", nil)
+		r = r.WithContext(domain.WithTenant(r.Context(), acme.ID))
+		r.SetPathValue("id", u.ID)
+		h.RevokeToken(w, r)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("revoke: want 204, got %d", w.Code)
+		}
+		if w := feed(h, u.ID, "?token="+token.Token); w.Code != http.StatusNotFound {
+			t.Fatalf("revoked token: want 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("IssueForUnknownUser", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		id := uuid.NewString()
+		r := httptest.NewRequest(http.MethodPost, "/v1/users/"+id+"/calendar-token", nil)
+		r.SetPathValue("id", id)
+		h.IssueToken(w, r)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("want 404, got %d", w.Code)
+		}
+	})
+}
+
+// ---------- ICS ----------
+
+func TestWriteFolded(t *testing.T) {
+	long := "DESCRIPTION:" + strings.Repeat("Подписка на сервис, ", 10)
+	var sb strings.Builder
+	writeFolded(&sb, long)
+
+	lines := strings.Split(strings.TrimSuffix(sb.String(), "\r\n"), "\r\n")
+	if len(lines) < 2 {
+		t.Fatalf("expected folding, got %q", sb.String())
+	}
+	var unfolded strings.Builder
+	for i, l := range lines {
+		if len(l) > 75 {
+			t.Fatalf("line %d is %d octets: %q", i, len(l), l)
+		}
+		if i > 0 {
+			if l[0] != ' ' {
+				t.Fatalf("continuation line %d must start with a space: %q", i, l)
+			}
+			l = l[1:]
+		}
+		unfolded.WriteString(l)
+	}
+	if unfolded.String() != long {
+		t.Fatalf("unfolded text differs:\n%q\n%q", unfolded.String(), long)
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	got := escapeText("a,b;c\\d\ne")
+	if want := `a\,b\;c\\d\ne`; got != want {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,117 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
+
+// Event — событие ленты: списание или окончание подписки (весь день)
+type Event struct {
+	UID         string
+	Date        time.Time
+	Summary     string
+	Description string
+}
+
+// BuildEvents собирает события подписок пользователя с сегодняшнего дня на horizon месяцев вперёд:
+// по событию на каждое списание (первое число месяца) и на окончание подписки (последний день end_date)
+func BuildEvents(u domain.User, subs []domain.Subscription, now time.Time, horizon int) []Event {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, horizon, -1) // последний день горизонта
+
+	var out []Event
+	for _, s := range subs {
+		for _, c := range s.Charges(from, to) {
+			if c.Month.Before(today) {
+				continue
+			}
+			out = append(out, Event{
+				UID:         fmt.Sprintf("%s-%s@my-subs", s.ID, c.Month.Format("200601")),
+				Date:        c.Month,
+				Summary:     fmt.Sprintf("%s: списание %d %s", s.ServiceName, c.Amount, u.Currency),
+				Description: fmt.Sprintf("Ежемесячное списание по подписке %s.", s.ServiceName),
+			})
+		}
+
+		end := time.Date(s.EndDate.Year(), s.EndDate.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, -1)
+		if !end.Before(today) && !end.After(to) {
+			out = append(out, Event{
+				UID:         s.ID + "-end@my-subs",
+				Date:        end,
+				Summary:     fmt.Sprintf("%s: подписка заканчивается", s.ServiceName),
+				Description: fmt.Sprintf("Последний оплаченный месяц подписки %s — %s. Продлите или отмените её.", s.ServiceName, s.EndDate.Format("01-2006")),
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].Date.Equal(out[j].Date) {
+			return out[i].Date.Before(out[j].Date)
+		}
+		return out[i].UID < out[j].UID
+	})
+	return out
+}
+
+// WriteICS формирует календарь RFC 5545. alarmDays > 0 добавляет к событиям напоминание за столько дней.
+func WriteICS(sb *strings.Builder, name string, events []Event, alarmDays int, now time.Time) {
+	stamp := now.UTC().Format("20060102T150405Z")
+	line := func(s string) { writeFolded(sb, s) }
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//my-subs//subscriptions calendar//RU")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escapeText(name))
+	// подсказка клиентам, как часто перечитывать ленту
+	line("REFRESH-INTERVAL;VALUE=DURATION:PT12H")
+	line("X-PUBLISHED-TTL:PT12H")
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + e.UID)
+		line("DTSTAMP:" + stamp)
+		line("DTSTART;VALUE=DATE:" + e.Date.Format("20060102"))
+		line("DTEND;VALUE=DATE:" + e.Date.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:" + escapeText(e.Summary))
+		line("DESCRIPTION:" + escapeText(e.Description))
+		line("TRANSP:TRANSPARENT")
+		if alarmDays > 0 {
+			line("BEGIN:VALARM")
+			line("ACTION:DISPLAY")
+			line("DESCRIPTION:" + escapeText(e.Summary))
+			line(fmt.Sprintf("TRIGGER:-P%dD", alarmDays))
+			line("END:VALARM")
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+}
+
+// escapeText экранирует значение типа TEXT (RFC 5545, 3.3.11)
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeFolded пишет строку содержимого, перенося её по 75 октетов (RFC 5545, 3.1) без разрыва символов UTF-8
+func writeFolded(sb *strings.Builder, s string) {
+	const limit = 75
+	width := limit
+	for len(s) > width {
+		cut := width
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		sb.WriteString(s[:cut])
+		sb.WriteString("\r\n ")
+		s = s[cut:]
+		width = limit - 1 // продолжение начинается с пробела
+	}
+	sb.WriteString(s)
+	sb.WriteString("\r\n")
+}
@@ -33,7 +33,7 @@ func (h *Handler) Liveness(w http.ResponseWriter, r *http.Request) {
 // @Tags         health
 // @Produce      json
 // @Success      200  {string}  string  "ready"
-// @Failure      503  {object}  map[string]string
+// @Failure      503  {object}  v1.Problem
 // @Router       /v1/readyz [get]
 func (h *Handler) Readiness(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5000*time.Millisecond)
@@ -12,8 +12,10 @@ func WriteJSON(w http.ResponseWriter, code int, v any) {
 	w.WriteHeader(code)
 	_ = json.NewEncoder(w).Encode(v)
 }
+
+// WriteError — ошибка с кодом по статусу и текстом msg (для 5xx текст обычно пустой)
 func WriteError(w http.ResponseWriter, code int, msg string) {
-	WriteJSON(w, code, map[string]string{"error": msg})
+	WriteProblem(w, Problem{Status: code, Detail: msg})
 }
 
 func IsTimeout(err error) bool {
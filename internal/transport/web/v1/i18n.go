@@ -0,0 +1,56 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/EgorLis/my-subs/internal/i18n"
+)
+
+// HeaderContentLanguage — язык ответа; mw.WithLocale выбирает его по Accept-Language
+const HeaderContentLanguage = "Content-Language"
+
+// Messages — каталог сообщений об ошибках; при старте заменяется каталогом с LOCALES_DIR
+var Messages = i18n.Builtin()
+
+// Language — язык ответа, выбранный mw.WithLocale, или язык по умолчанию
+func Language(w http.ResponseWriter) string {
+	if lang := w.Header().Get(HeaderContentLanguage); lang != "" {
+		return lang
+	}
+	return Messages.Default()
+}
+
+// Message — текст detail.<key> из каталога на языке ответа, например для ошибок внутри 200-ответа
+func Message(w http.ResponseWriter, key string, kv ...any) string {
+	return Messages.Text(Language(w), "detail."+key, argsOf(kv))
+}
+
+// Localize — текст ошибки на языке ответа: ValidationError переводится по кодам полей,
+// остальные ошибки возвращаются как есть
+func Localize(w http.ResponseWriter, err error) string {
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		return err.Error()
+	}
+	lang := Language(w)
+	fields := make([]FieldError, len(ve.Fields))
+	for i, f := range ve.Fields {
+		fields[i] = f.localize(lang)
+	}
+	return joinFields(fields)
+}
+
+// argsOf собирает параметры сообщения из пар имя, значение
+func argsOf(kv []any) i18n.Args {
+	if len(kv) == 0 {
+		return nil
+	}
+	args := make(i18n.Args, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		if name, ok := kv[i].(string); ok {
+			args[name] = kv[i+1]
+		}
+	}
+	return args
+}
@@ -0,0 +1,230 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/EgorLis/my-subs/internal/i18n"
+)
+
+// ProblemContentType — ошибки отдаются в формате RFC 7807 (problem details)
+const ProblemContentType = "application/problem+json"
+
+// HeaderRequestID дублирует mw.HeaderReqID: mw сам пишет ошибки через этот пакет
+const HeaderRequestID = "X-Request-ID"
+
+// Коды ошибок — стабильная часть ответа, на них можно опираться в клиентах.
+// type ошибки — "urn:my-subs:problem:" + код.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeValidation           = "validation_failed"
+	CodeInvalidJSON          = "invalid_json"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeConflict             = "conflict"
+	CodePreconditionFailed   = "precondition_failed"
+	CodeGone                 = "gone"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeUnprocessable        = "unprocessable_entity"
+	CodeInternal             = "internal_error"
+	CodeUnavailable          = "service_unavailable"
+	CodeTimeout              = "timeout"
+)
+
+const problemTypePrefix = "urn:my-subs:problem:"
+
+// Problem — тело ошибки (RFC 7807) с кодом, id запроса и ошибками по полям
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+
+	// Key и Args — сообщение detail.<Key> из каталога; если Key задан, Detail подставляется на языке ответа
+	Key  string    `json:"-"`
+	Args i18n.Args `json:"-"`
+}
+
+// FieldError — ошибка одного поля запроса; Field — имя поля JSON или query-параметра, например tags[2].
+// Code — стабильный код сообщения (ключ field.<code> в каталоге), Message — текст на языке ответа.
+type FieldError struct {
+	Field   string    `json:"field"`
+	Code    string    `json:"code,omitempty"`
+	Message string    `json:"message"`
+	Args    i18n.Args `json:"-"`
+}
+
+// Field — ошибка поля с сообщением code из каталога; kv — параметры сообщения парами имя, значение
+func Field(field, code string, kv ...any) FieldError {
+	args := argsOf(kv)
+	return FieldError{Field: field, Code: code, Args: args, Message: Messages.Text(Messages.Default(), "field."+code, args)}
+}
+
+// localize переводит сообщение на язык lang; ошибки без кода остаются как есть
+func (f FieldError) localize(lang string) FieldError {
+	if f.Code != "" {
+		f.Message = Messages.Text(lang, "field."+f.Code, f.Args)
+	}
+	return f
+}
+
+// ValidationError — ошибки проверки запроса по полям; Error() склеивает их в "поле: текст; ..."
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return joinFields(e.Fields)
+}
+
+func joinFields(fields []FieldError) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f.Field + ": " + f.Message
+		if f.Field == "" {
+			parts[i] = f.Message
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Fields собирает ValidationError из ошибок полей; nil, если ошибок нет
+func Fields(errs []FieldError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: errs}
+}
+
+// NewFieldError — ValidationError с одним полем
+func NewFieldError(field, code string, kv ...any) error {
+	return &ValidationError{Fields: []FieldError{Field(field, code, kv...)}}
+}
+
+// JoinValidation объединяет ошибки проверки нескольких частей запроса, nil пропускаются.
+// Ошибки другого типа становятся сообщениями без поля.
+func JoinValidation(errs ...error) error {
+	var fields []FieldError
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		var ve *ValidationError
+		if errors.As(err, &ve) {
+			fields = append(fields, ve.Fields...)
+		} else {
+			fields = append(fields, FieldError{Message: err.Error()})
+		}
+	}
+	return Fields(fields)
+}
+
+// codeByStatus — код по умолчанию для статуса ответа
+func codeByStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusPreconditionFailed:
+		return CodePreconditionFailed
+	case http.StatusGone:
+		return CodeGone
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMediaType
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// WriteProblem пишет ошибку на языке ответа (Content-Language, его ставит mw.WithLocale).
+// Title берётся из каталога по Code, Detail — по Key, если он задан; сообщения полей переводятся.
+// Пустой Code заполняется по статусу, RequestID — из заголовка ответа X-Request-ID (его ставит mw.WithRequestID).
+func WriteProblem(w http.ResponseWriter, p Problem) {
+	lang := Language(w)
+	if p.Code == "" {
+		p.Code = codeByStatus(p.Status)
+	}
+	if p.Type == "" {
+		p.Type = problemTypePrefix + p.Code
+	}
+	if p.Title == "" {
+		p.Title = Messages.Text(lang, "title."+p.Code, nil)
+	}
+	if p.Key != "" {
+		p.Detail = Messages.Text(lang, "detail."+p.Key, p.Args)
+	}
+	if len(p.Errors) > 0 {
+		fields := make([]FieldError, len(p.Errors))
+		for i, f := range p.Errors {
+			fields[i] = f.localize(lang)
+		}
+		p.Errors, p.Detail = fields, joinFields(fields)
+	}
+	if p.RequestID == "" {
+		p.RequestID = w.Header().Get(HeaderRequestID)
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// WriteMessage — ошибка с текстом detail.<key> из каталога; kv — параметры сообщения
+func WriteMessage(w http.ResponseWriter, status int, key string, kv ...any) {
+	WriteProblem(w, Problem{Status: status, Key: key, Args: argsOf(kv)})
+}
+
+// WriteValidationError — 400 с ошибками по полям, если err — ValidationError
+func WriteValidationError(w http.ResponseWriter, err error) {
+	p := Problem{Status: http.StatusBadRequest, Code: CodeValidation, Detail: err.Error()}
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		p.Errors = ve.Fields
+	}
+	WriteProblem(w, p)
+}
+
+// WriteDecodeError — ответ на тело, которое не удалось разобрать DecodeJSON
+func WriteDecodeError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		WriteMessage(w, http.StatusRequestEntityTooLarge, "payload_too_large", "limit", tooLarge.Limit)
+		return
+	}
+	WriteProblem(w, Problem{Status: http.StatusBadRequest, Code: CodeInvalidJSON,
+		Key: "invalid_json", Args: i18n.Args{"error": err.Error()}})
+}
+
+// DecodeJSON строго разбирает тело запроса: неизвестные поля и данные после JSON-значения — ошибка
+func DecodeJSON(r io.Reader, v any) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("unexpected data after JSON value")
+	}
+	return nil
+}
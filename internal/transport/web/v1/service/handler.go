@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+type Handler struct {
+	Log  *log.Logger
+	Repo domain.SubscriptionRepository
+}
+
+// Suggest godoc
+// @Summary      Suggest service names
+// @Description  Подсказки для поля service_name: названия сервисов из подписок тенанта, начинающиеся с prefix
+// @Description  (без учёта регистра), по убыванию числа подписок. typical_price — медиана цены.
+// @Tags         services
+// @Produce      json
+// @Param        prefix  query  string  false  "Начало названия; пусто — самые популярные сервисы"
+// @Param        limit   query  int     false  "Сколько подсказок вернуть (1..50, по умолчанию 10)"
+// @Success      200  {object}  service.SuggestResponse
+// @Failure      400  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/services/suggest [get]
+func (h *Handler) Suggest(w http.ResponseWriter, r *http.Request) {
+	const op = "service.suggest"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	sq, err := ParseSuggestQuery(r.URL.Query())
+	if err != nil {
+		logx.Error(h.Log, reqID, op, "validation failed", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	list, err := h.Repo.SuggestServices(ctx, sq.Prefix, sq.Limit)
+	if err != nil {
+		if v1.IsTimeout(err) {
+			logx.Error(h.Log, reqID, op, "timeout", err)
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+			return
+		}
+		logx.Error(h.Log, reqID, op, "repo suggest failed", err)
+		v1.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	resp := &SuggestResponse{Suggestions: MapDomainListToDTO(list)}
+	logx.Info(h.Log, reqID, op, "returned", "prefix", sq.Prefix, "count", len(resp.Suggestions))
+	v1.WriteJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	mockrepo "github.com/EgorLis/my-subs/internal/infra/database/mock"
+)
+
+type timeoutRepo struct{ domain.SubscriptionRepository }
+
+func (timeoutRepo) SuggestServices(ctx context.Context, prefix string, limit int) ([]domain.ServiceSuggestion, error) {
+	return nil, context.DeadlineExceeded
+}
+
+func suggest(h *Handler, ctx context.Context, query string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/services/suggest?"+query, nil).WithContext(ctx)
+	h.Suggest(w, r)
+	return w
+}
+
+func suggestions(t *testing.T, w *httptest.ResponseRecorder) []SuggestionDTO {
+	t.Helper()
+	var resp SuggestResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp.Suggestions
+}
+
+func TestSuggest(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	ctx := context.Background()
+	u, _ := repo.AddUser(ctx, domain.User{DisplayName: "Ivan"})
+	month := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	var ids []string
+	for _, s := range []struct {
+		name  string
+		price int
+	}{
+		{"Netflix", 500}, {"Netflix", 700}, {"netflix", 600}, {"Netflix", 900},
+		{"Nebula", 250},
+		{"Spotify", 300}, {"Spotify", 300},
+		{"100% Music", 100},
+	} {
+		sub, err := repo.AddSub(ctx, domain.Subscription{ServiceName: s.name, Price: s.price, UserID: u.ID, StartDate: month, EndDate: month})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, sub.ID)
+	}
+	h := &Handler{Log: log.New(io.Discard, "", 0), Repo: repo}
+
+	cases := []struct {
+		name       string
+		query      string
+		wantCode   int
+		want       []SuggestionDTO
+		wantInBody string
+	}{
+		{name: "Prefix", query: "prefix=ne", wantCode: 200, want: []SuggestionDTO{
+			// четыре подписки: медиана 600/700 нижняя, самое частое написание — Netflix
+			{Name: "Netflix", Subscriptions: 4, TypicalPrice: 600},
+			{Name: "Nebula", Subscriptions: 1, TypicalPrice: 250},
+		}},
+		{name: "CaseInsensitive", query: "prefix=+NETF", wantCode: 200, want: []SuggestionDTO{{Name: "Netflix", Subscriptions: 4, TypicalPrice: 600}}},
+		{name: "Popular", query: "limit=2", wantCode: 200, want: []SuggestionDTO{
+			{Name: "Netflix", Subscriptions: 4, TypicalPrice: 600},
+			{Name: "Spotify", Subscriptions: 2, TypicalPrice: 300},
+		}},
+		{name: "LiteralPercent", query: "prefix=100%25", wantCode: 200, want: []SuggestionDTO{{Name: "100% Music", Subscriptions: 1, TypicalPrice: 100}}},
+		{name: "NoMatch", query: "prefix=zzz", wantCode: 200, want: []SuggestionDTO{}},
+		{name: "BadLimit", query: "limit=0", wantCode: 400, wantInBody: "limit"},
+		{name: "LongPrefix", query: "prefix=" + strings.Repeat("a", MaxPrefixLen+1), wantCode: 400, wantInBody: "prefix"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := suggest(h, ctx, tc.query)
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
+			}
+			if tc.wantInBody != "" && !strings.Contains(w.Body.String(), tc.wantInBody) {
+				t.Fatalf("body should contain %q, got %s", tc.wantInBody, w.Body.String())
+			}
+			if tc.wantCode == http.StatusOK {
+				if got := suggestions(t, w); !reflect.DeepEqual(got, tc.want) {
+					t.Fatalf("want %+v, got %+v", tc.want, got)
+				}
+			}
+		})
+	}
+
+	t.Run("FollowsChanges", func(t *testing.T) {
+		// переименование и удаление переносят подписки между сервисами
+		if _, err := repo.PatchSub(ctx, ids[5], domain.AnyVersion, func(cur domain.Subscription) (domain.Subscription, error) {
+			cur.ServiceName = "Nebula"
+			return cur, nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.DeleteSub(ctx, ids[0], domain.AnyVersion); err != nil {
+			t.Fatal(err)
+		}
+		// откат транзакции возвращает подсказки к прежнему состоянию
+		_ = repo.InTx(ctx, func(tx domain.SubscriptionTx) error {
+			_, _ = tx.DeleteSub(ctx, ids[4], domain.AnyVersion)
+			return errors.New("rollback")
+		})
+
+		want := []SuggestionDTO{
+			{Name: "Netflix", Subscriptions: 3, TypicalPrice: 700},
+			{Name: "Nebula", Subscriptions: 2, TypicalPrice: 250},
+		}
+		if got := suggestions(t, suggest(h, ctx, "prefix=ne")); !reflect.DeepEqual(got, want) {
+			t.Fatalf("want %+v, got %+v", want, got)
+		}
+	})
+
+	t.Run("OtherTenant", func(t *testing.T) {
+		other, _ := repo.AddTenant(ctx, domain.Tenant{Name: "other"})
+		w := suggest(h, domain.WithTenant(ctx, other.ID), "prefix=ne")
+		if got := suggestions(t, w); len(got) != 0 {
+			t.Fatalf("want no suggestions from another tenant, got %+v", got)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		w := suggest(&Handler{Log: log.New(io.Discard, "", 0), Repo: timeoutRepo{}}, ctx, "prefix=ne")
+		if w.Code != http.StatusGatewayTimeout {
+			t.Fatalf("want 504, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,15 @@
+package service
+
+import "github.com/EgorLis/my-subs/internal/domain"
+
+func MapDomainToDTO(s domain.ServiceSuggestion) SuggestionDTO {
+	return SuggestionDTO{Name: s.Name, Subscriptions: s.Subscriptions, TypicalPrice: s.TypicalPrice}
+}
+
+func MapDomainListToDTO(list []domain.ServiceSuggestion) []SuggestionDTO {
+	out := make([]SuggestionDTO, 0, len(list))
+	for _, s := range list {
+		out = append(out, MapDomainToDTO(s))
+	}
+	return out
+}
@@ -0,0 +1,11 @@
+package service
+
+type SuggestionDTO struct {
+	Name          string `json:"name"`
+	Subscriptions int    `json:"subscriptions"`
+	TypicalPrice  int    `json:"typical_price"`
+}
+
+type SuggestResponse struct {
+	Suggestions []SuggestionDTO `json:"suggestions"`
+}
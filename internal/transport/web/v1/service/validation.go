@@ -0,0 +1,45 @@
+package service
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+const (
+	DefaultSuggestLimit = 10
+	MaxSuggestLimit     = 50
+	MaxPrefixLen        = 100
+)
+
+// SuggestQuery — параметры GET /v1/services/suggest; пустой префикс — самые популярные сервисы
+type SuggestQuery struct {
+	Prefix string
+	Limit  int
+}
+
+// ParseSuggestQuery разбирает prefix и limit
+func ParseSuggestQuery(q url.Values) (SuggestQuery, error) {
+	var errs []v1.FieldError
+	sq := SuggestQuery{Prefix: strings.TrimSpace(q.Get("prefix")), Limit: DefaultSuggestLimit}
+
+	if utf8.RuneCountInString(sq.Prefix) > MaxPrefixLen {
+		errs = append(errs, v1.Field("prefix", "max_length", "max", MaxPrefixLen))
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxSuggestLimit {
+			errs = append(errs, v1.Field("limit", "between", "min", 1, "max", MaxSuggestLimit))
+		} else {
+			sq.Limit = n
+		}
+	}
+
+	if len(errs) > 0 {
+		return sq, v1.Fields(errs)
+	}
+	return sq, nil
+}
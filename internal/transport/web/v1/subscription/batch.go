@@ -0,0 +1,176 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+// errBatchRolledBack — операция atomic-пакета не удалась, транзакция откатывается
+var errBatchRolledBack = errors.New("batch rolled back")
+
+// Batch godoc
+// @Summary      Batch create/update/delete
+// @Description  Выполнить набор операций над подписками в одной транзакции.
+// @Description  atomic — всё или ничего: при первой ошибке изменения откатываются, ответ получает её код.
+// @Description  best_effort — неудачные операции пропускаются, успешные фиксируются; ответ 200.
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        request  body      subscription.BatchRequest  true  "Операции"
+// @Success      200      {object}  subscription.BatchResponse
+// @Failure      400      {object}  subscription.BatchResponse
+// @Failure      404      {object}  subscription.BatchResponse
+// @Failure      412      {object}  subscription.BatchResponse
+// @Failure      504      {object}  v1.Problem
+// @Failure      500      {object}  v1.Problem
+// @Router       /v1/subscriptions:batch [post]
+func (h *Handler) Batch(w http.ResponseWriter, r *http.Request) {
+	const op = "subscription.batch"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	req := BatchRequest{Mode: BatchAtomic}
+	if err := v1.DecodeJSON(r.Body, &req); err != nil {
+		logx.Error(h.Log, reqID, op, "invalid JSON", err)
+		v1.WriteDecodeError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := ValidateBatchRequest(req); err != nil {
+		logx.Error(h.Log, reqID, op, "validation failed", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+	atomic := req.Mode == BatchAtomic
+
+	resp := BatchResponse{Mode: req.Mode, Results: make([]BatchItemResult, len(req.Operations))}
+	failed := -1
+	for i, o := range req.Operations {
+		resp.Results[i] = BatchItemResult{Index: i, Op: o.Op}
+		if err := ValidateBatchOperation(o); err != nil {
+			resp.Results[i].Status, resp.Results[i].Error = http.StatusBadRequest, v1.Localize(w, err)
+			if atomic && failed < 0 {
+				failed = i
+			}
+		}
+	}
+	// в atomic-режиме невалидный пакет отклоняется целиком, до обращения к базе
+	if failed >= 0 {
+		markNotApplied(resp.Results, failed, v1.Message(w, "batch_not_applied"))
+		logx.Info(h.Log, reqID, op, "rejected", "failed_index", failed)
+		v1.WriteJSON(w, resp.Results[failed].Status, resp)
+		return
+	}
+
+	// события публикуются только после фиксации транзакции
+	var events []batchEvent
+	err := h.Repo.InTx(ctx, func(tx domain.SubscriptionTx) error {
+		events = events[:0]
+		for i, o := range req.Operations {
+			res := &resp.Results[i]
+			if res.Status != 0 {
+				continue // не прошла валидацию в best_effort
+			}
+			sub, err := applyBatchOperation(ctx, tx, o)
+			if err != nil {
+				status, key, ok := batchItemError(err)
+				if !ok {
+					return err
+				}
+				res.Status, res.Error = status, v1.Message(w, key)
+				if atomic {
+					failed = i
+					return errBatchRolledBack
+				}
+				continue
+			}
+			res.Status, res.SubID, res.Version = http.StatusOK, sub.ID, sub.Version
+			events = append(events, newBatchEvent(o.Op, sub))
+		}
+		return nil
+	})
+
+	switch {
+	case err == nil:
+		resp.Applied = true
+		logx.Info(h.Log, reqID, op, "applied", "mode", req.Mode, "operations", len(req.Operations))
+		for _, e := range events {
+			h.publish(ctx, reqID, op, e.event, e.data)
+		}
+		v1.WriteJSON(w, http.StatusOK, resp)
+	case errors.Is(err, errBatchRolledBack):
+		markNotApplied(resp.Results, failed, v1.Message(w, "batch_not_applied"))
+		logx.Info(h.Log, reqID, op, "rolled back", "failed_index", failed, "reason", resp.Results[failed].Error)
+		v1.WriteJSON(w, resp.Results[failed].Status, resp)
+	case v1.IsTimeout(err):
+		logx.Error(h.Log, reqID, op, "repo timeout", err)
+		v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+	default:
+		logx.Error(h.Log, reqID, op, "batch failed", err)
+		v1.WriteError(w, http.StatusInternalServerError, "")
+	}
+}
+
+func applyBatchOperation(ctx context.Context, tx domain.SubscriptionTx, o BatchOperation) (domain.Subscription, error) {
+	switch o.Op {
+	case "create":
+		return tx.AddSub(ctx, MapCreateReqToDomain(o.Data))
+	case "update":
+		return tx.UpdateSub(ctx, MapUpdateReqToDomain(batchUpdateRequest(o)), o.Version)
+	default:
+		return tx.DeleteSub(ctx, o.ID, o.Version)
+	}
+}
+
+// batchEvent — событие успешной операции пакета
+type batchEvent struct {
+	event string
+	data  any
+}
+
+func newBatchEvent(op string, sub domain.Subscription) batchEvent {
+	switch op {
+	case "create":
+		return batchEvent{domain.EventSubscriptionCreated, MapDomainToDTO(sub)}
+	case "update":
+		return batchEvent{domain.EventSubscriptionUpdated, MapDomainToDTO(sub)}
+	default:
+		return batchEvent{domain.EventSubscriptionDeleted, MapDomainToDeletedEvent(sub)}
+	}
+}
+
+// batchItemError переводит ошибку операции в код и ключ сообщения, как у одиночных запросов.
+// ok=false — ошибка не относится к операции (таймаут, сбой базы) и прерывает весь пакет.
+func batchItemError(err error) (status int, key string, ok bool) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, "not_found", true
+	case errors.Is(err, domain.ErrUserNotFound):
+		return http.StatusBadRequest, "unknown_user", true
+	case errors.Is(err, domain.ErrVersionMismatch):
+		return http.StatusPreconditionFailed, "version_mismatch", true
+	case errors.Is(err, domain.ErrTenantNotFound):
+		return http.StatusNotFound, "tenant_not_found", true
+	}
+	return 0, "", false
+}
+
+// markNotApplied помечает выполненные и невыполненные операции как откатанные; ошибки валидации остаются
+func markNotApplied(results []BatchItemResult, failed int, msg string) {
+	for i := range results {
+		if i == failed || (results[i].Status != 0 && results[i].Status != http.StatusOK) {
+			continue
+		}
+		results[i] = BatchItemResult{Index: i, Op: results[i].Op, Status: http.StatusFailedDependency, Error: msg}
+	}
+}
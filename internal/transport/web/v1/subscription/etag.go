@@ -0,0 +1,84 @@
+package subscription
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
+
+// ETag — сильный валидатор подписки: номер версии в кавычках
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseETags разбирает список из If-Match/If-None-Match.
+// any — в заголовке "*"; weak — теги с префиксом W/ (сравниваются только в If-None-Match).
+// Версии начинаются с 1: теги "0" и отрицательные пропускаются как некорректные, иначе If-Match: "0"
+// совпал бы с domain.AnyVersion и изменение стало бы безусловным.
+func parseETags(header string) (versions []int, weak []int, any bool) {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			any = true
+			continue
+		}
+		isWeak := strings.HasPrefix(tag, "W/")
+		tag = strings.TrimPrefix(tag, "W/")
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		v, err := strconv.Atoi(tag[1 : len(tag)-1])
+		if err != nil || v < 1 {
+			continue
+		}
+		if isWeak {
+			weak = append(weak, v)
+		} else {
+			versions = append(versions, v)
+		}
+	}
+	return versions, weak, any
+}
+
+// notModified — If-None-Match совпадает с текущей версией (слабое сравнение, RFC 9110 13.1.2)
+func notModified(r *http.Request, version int) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	strong, weak, any := parseETags(header)
+	return any || slices.Contains(strong, version) || slices.Contains(weak, version)
+}
+
+// expectedVersion переводит If-Match в версию для условного изменения в репозитории.
+// Без заголовка или с "*" — domain.AnyVersion. Если тегов несколько, сверяемся с текущей версией:
+// само изменение всё равно выполняется условно, поэтому гонки между чтением и записью нет.
+func (h *Handler) expectedVersion(ctx context.Context, r *http.Request, id string) (int, error) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return domain.AnyVersion, nil
+	}
+	strong, _, any := parseETags(header)
+	switch {
+	case any:
+		return domain.AnyVersion, nil
+	case len(strong) == 1:
+		return strong[0], nil
+	case len(strong) == 0:
+		// слабые и некорректные теги в If-Match никогда не совпадают
+		return 0, domain.ErrVersionMismatch
+	}
+
+	cur, err := h.Repo.GetSub(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if !slices.Contains(strong, cur.Version) {
+		return 0, domain.ErrVersionMismatch
+	}
+	return cur.Version, nil
+}
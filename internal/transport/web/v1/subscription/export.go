@@ -0,0 +1,235 @@
+package subscription
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+const (
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+
+	ExportSubscriptions = "subscriptions"
+	ExportCharges       = "charges"
+
+	// exportFlushEvery — через сколько записей отдавать накопленное клиенту
+	exportFlushEvery = 100
+)
+
+// DefaultExportWriteGrace — сколько выгрузка может ждать следующую подписку из базы или чтения клиентом.
+// Дедлайн продлевается с каждой прочитанной подпиской, поэтому WriteTimeout сервера выгрузку не ограничивает.
+const DefaultExportWriteGrace = time.Minute
+
+// колонки CSV; у подписок они совпадают с колонками импорта, поэтому выгрузку можно загрузить обратно
+var (
+	subscriptionCSVHeader = []string{"id", "service_name", "price", "user_id", "start_date", "end_date",
+		"created_at", "updated_at", "created_by", "updated_by", "version"}
+	chargeCSVHeader = []string{"subscription_id", "user_id", "service_name", "month", "amount"}
+)
+
+func subscriptionCSVRecord(d SubscriptionDTO) []string {
+	return []string{d.ID, d.ServiceName, strconv.Itoa(d.Price), d.UserID,
+		time.Time(d.StartDate).Format("01-2006"), time.Time(d.EndDate).Format("01-2006"),
+		d.CreatedAt, d.UpdatedAt, d.CreatedBy, d.UpdatedBy, strconv.Itoa(d.Version)}
+}
+
+func chargeCSVRecord(d ChargeDTO) []string {
+	return []string{d.SubscriptionID, d.UserID, d.ServiceName, time.Time(d.Month).Format("01-2006"), strconv.Itoa(d.Amount)}
+}
+
+// exportWriter пишет записи в выбранном формате. Заголовки ответа отправляются с первой записью,
+// поэтому ошибка до неё ещё может стать обычным ответом с кодом 4xx/5xx.
+type exportWriter struct {
+	w        http.ResponseWriter
+	rc       *http.ResponseController
+	format   string
+	filename string
+	header   []string
+	grace    time.Duration
+
+	csv     *csv.Writer
+	json    *json.Encoder
+	started bool
+	n       int
+}
+
+func (ew *exportWriter) start() {
+	ew.started = true
+	contentType := "text/csv; charset=utf-8"
+	if ew.format == ExportNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	ew.w.Header().Set("Content-Type", contentType)
+	ew.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, ew.filename, ew.format))
+	ew.w.Header().Set("X-Content-Type-Options", "nosniff")
+	ew.w.WriteHeader(http.StatusOK)
+
+	if ew.format == ExportCSV {
+		ew.csv = csv.NewWriter(ew.w)
+		_ = ew.csv.Write(ew.header)
+	} else {
+		ew.json = json.NewEncoder(ew.w)
+	}
+}
+
+// write добавляет запись: record — строка CSV, v — объект NDJSON
+func (ew *exportWriter) write(record []string, v any) error {
+	if !ew.started {
+		ew.start()
+	}
+	var err error
+	if ew.csv != nil {
+		err = ew.csv.Write(record)
+	} else {
+		err = ew.json.Encode(v)
+	}
+	if err != nil {
+		return err
+	}
+	if ew.n++; ew.n%exportFlushEvery == 0 {
+		return ew.flush()
+	}
+	return nil
+}
+
+func (ew *exportWriter) flush() error {
+	if ew.csv != nil {
+		ew.csv.Flush()
+		if err := ew.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if err := ew.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// extendDeadline продлевает WriteTimeout сервера: выгрузка идёт дольше обычного запроса, пока клиент читает
+func (ew *exportWriter) extendDeadline() {
+	_ = ew.rc.SetWriteDeadline(time.Now().Add(ew.grace))
+}
+
+// Export godoc
+// @Summary      Export subscriptions or charges
+// @Description  Выгрузить подписки (kind=subscriptions) или ежемесячные списания по ним (kind=charges) в CSV или NDJSON.
+// @Description  Фильтры и сортировка — как у списка; limit и cursor не применяются. Строки передаются по мере чтения из базы.
+// @Description  Списания ограничиваются периодом from..to, если он задан.
+// @Tags         subscriptions
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        format        query  string  false  "Формат: csv (по умолчанию) или ndjson"
+// @Param        kind          query  string  false  "Что выгружать: subscriptions (по умолчанию) или charges"
+// @Param        user_id       query  string  false  "ID пользователя"
+// @Param        service_name  query  string  false  "Название подписки"
+// @Param        active_at     query  string  false  "Активна в месяце (MM-YYYY)"
+// @Param        price_min     query  int     false  "Минимальная цена"
+// @Param        price_max     query  int     false  "Максимальная цена"
+// @Param        from          query  string  false  "Пересекает период с (MM-YYYY)"
+// @Param        to            query  string  false  "Пересекает период по (MM-YYYY)"
+// @Param        sort          query  string  false  "Сортировка, например price,-start_date"
+// @Success      200  {file}    file
+// @Failure      400  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/subscriptions/export [get]
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	const op = "subscription.export"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	q := r.URL.Query()
+	var errs []v1.FieldError
+	format := q.Get("format")
+	switch format {
+	case "":
+		format = ExportCSV
+	case ExportCSV, ExportNDJSON:
+	default:
+		errs = append(errs, v1.Field("format", "one_of", "values", ExportCSV+", "+ExportNDJSON))
+	}
+	kind := q.Get("kind")
+	switch kind {
+	case "":
+		kind = ExportSubscriptions
+	case ExportSubscriptions, ExportCharges:
+	default:
+		errs = append(errs, v1.Field("kind", "one_of", "values", ExportSubscriptions+", "+ExportCharges))
+	}
+	q.Del("limit")
+	q.Del("cursor")
+	f, err := ParseListQuery(q)
+	if err := v1.JoinValidation(v1.Fields(errs), err); err != nil {
+		logx.Error(h.Log, reqID, op, "validation failed", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+
+	ew := &exportWriter{
+		w:        w,
+		rc:       http.NewResponseController(w),
+		format:   format,
+		filename: kind + "-" + time.Now().UTC().Format("20060102"),
+		header:   subscriptionCSVHeader,
+		grace:    h.ExportGrace,
+	}
+	if ew.grace <= 0 {
+		ew.grace = DefaultExportWriteGrace
+	}
+	emit := func(s domain.Subscription) error {
+		d := MapDomainToDTO(s)
+		return ew.write(subscriptionCSVRecord(d), d)
+	}
+	if kind == ExportCharges {
+		ew.header = chargeCSVHeader
+		emit = func(s domain.Subscription) error {
+			for _, c := range s.Charges(f.From, f.To) {
+				d := MapChargeToDTO(c)
+				if err := ew.write(chargeCSVRecord(d), d); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	// без таймаута: выгрузка длится, пока клиент читает; отключение клиента отменяет r.Context() и запрос к базе.
+	// Дедлайн записи продлеваем заранее и на каждой подписке: первая строка может прийти не сразу,
+	// а при kind=charges подписки вне периода не дают ни одной записи.
+	ew.extendDeadline()
+	err = h.Repo.StreamSubs(r.Context(), f, func(s domain.Subscription) error {
+		ew.extendDeadline()
+		return emit(s)
+	})
+	if err == nil {
+		if !ew.started {
+			ew.start() // пустая выгрузка: только заголовок CSV
+		}
+		err = ew.flush()
+	}
+	if err == nil {
+		logx.Info(h.Log, reqID, op, "exported", "format", format, "kind", kind, "rows", ew.n)
+		return
+	}
+
+	if !ew.started {
+		logx.Error(h.Log, reqID, op, "repo stream failed", err)
+		if v1.IsTimeout(err) {
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+			return
+		}
+		v1.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+	// статус 200 уже отправлен: обрываем соединение, чтобы клиент не принял обрезанный файл за полный
+	logx.Error(h.Log, reqID, op, "export interrupted", err, "rows", ew.n)
+	panic(http.ErrAbortHandler)
+}
@@ -1,28 +1,81 @@
 package subscription
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
+	"mime"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/EgorLis/my-subs/internal/domain"
 	"github.com/EgorLis/my-subs/internal/transport/web/logx"
 	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	"github.com/EgorLis/my-subs/internal/transport/web/sse"
 	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
 )
 
 const (
-	CREATED = "subscription created"
-	UPDATED = "subscription updated"
-	DELETED = "subscription deleted"
+	CREATED  = "subscription created"
+	UPDATED  = "subscription updated"
+	DELETED  = "subscription deleted"
+	RESTORED = "subscription restored"
 )
 
 type Handler struct {
-	Log  *log.Logger
-	Repo domain.SubscriptionRepository
+	Log       *log.Logger
+	Repo      domain.SubscriptionRepository
+	Events    EventPublisher // nil — события об изменениях не публикуются
+	Changes   *sse.Broker    // источник потока Stream; события в него публикует Feed
+	Heartbeat time.Duration  // пинг пустого потока; 0 — DefaultStreamHeartbeat
+
+	SyncTokenTTL time.Duration // срок жизни токена GET /v1/sync; 0 — DefaultSyncTokenTTL
+	ExportGrace  time.Duration // сколько выгрузка ждёт следующую подписку или клиента; 0 — DefaultExportWriteGrace
+
+	// Users проверяет user_id строк импорта одним запросом; nil — неизвестный пользователь обнаружится только при записи
+	Users interface {
+		UsersByIDs(ctx context.Context, ids []string) ([]domain.User, error)
+	}
+}
+
+// EventPublisher получает события subscription.created/updated/deleted после успешного изменения
+type EventPublisher interface {
+	Publish(ctx context.Context, event string, data any) error
+}
+
+// BatchPublisher — получатель, которому выгоднее принять пачку событий одного типа разом, например вебхуки
+type BatchPublisher interface {
+	PublishBatch(ctx context.Context, event string, data []any) error
+}
+
+// DeletedEvent — данные события subscription.deleted
+type DeletedEvent struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+}
+
+// publish отправляет событие; изменение уже сохранено, поэтому сбой только логируется
+func (h *Handler) publish(ctx context.Context, reqID, op, event string, data any) {
+	if h.Events == nil {
+		return
+	}
+	if err := h.Events.Publish(ctx, event, data); err != nil {
+		logx.Error(h.Log, reqID, op, "publish event failed", err, "event", event)
+	}
+}
+
+// publishBatch — как publish, но для пачки событий одного типа
+func (h *Handler) publishBatch(ctx context.Context, reqID, op, event string, data []any) {
+	if h.Events == nil || len(data) == 0 {
+		return
+	}
+	if err := PublishBatch(ctx, h.Events, event, data); err != nil {
+		logx.Error(h.Log, reqID, op, "publish events failed", err, "event", event, "count", len(data))
+	}
 }
 
 // Create godoc
@@ -31,11 +84,15 @@ type Handler struct {
 // @Tags         subscriptions
 // @Accept       json
 // @Produce      json
-// @Param        request  body      subscription.CreateRequest  true  "Subscription payload"
+// @Param        request          body      subscription.CreateRequest  true   "Subscription payload"
+// @Param        Idempotency-Key  header    string                      false  "Ключ идемпотентности: повтор возвращает сохранённый ответ"
 // @Success      200      {object}  subscription.CUDResponse
-// @Failure      400      {object}  map[string]string
-// @Failure      504      {object}  map[string]string
-// @Failure      500      {object}  map[string]string
+// @Failure      400      {object}  v1.Problem
+// @Failure      404      {object}  v1.Problem
+// @Failure      409      {object}  v1.Problem
+// @Failure      422      {object}  v1.Problem
+// @Failure      504      {object}  v1.Problem
+// @Failure      500      {object}  v1.Problem
 // @Router       /v1/subscriptions [post]
 func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	const op = "subscription.create"
@@ -45,16 +102,16 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	var req CreateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := v1.DecodeJSON(r.Body, &req); err != nil {
 		logx.Error(h.Log, reqID, op, "invalid JSON", err)
-		v1.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		v1.WriteDecodeError(w, err)
 		return
 	}
 	defer r.Body.Close()
 
 	if err := ValidateCreateRequest(req); err != nil {
 		logx.Error(h.Log, reqID, op, "validation failed", err)
-		v1.WriteError(w, http.StatusBadRequest, err.Error())
+		v1.WriteValidationError(w, err)
 		return
 	}
 
@@ -63,7 +120,17 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		if v1.IsTimeout(err) {
 			logx.Error(h.Log, reqID, op, "repo timeout", err)
-			v1.WriteError(w, http.StatusGatewayTimeout, "request timed out")
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+			return
+		}
+		if errors.Is(err, domain.ErrTenantNotFound) {
+			logx.Info(h.Log, reqID, op, "tenant not found")
+			v1.WriteMessage(w, http.StatusNotFound, "tenant_not_found")
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			logx.Info(h.Log, reqID, op, "unknown user", "user_id", req.UserID)
+			v1.WriteValidationError(w, v1.NewFieldError("user_id", "unknown_user"))
 			return
 		}
 		logx.Error(h.Log, reqID, op, "repo add failed", err)
@@ -72,12 +139,14 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := &CUDResponse{SubID: subWithID.ID, Status: CREATED}
+	w.Header().Set("ETag", ETag(subWithID.Version))
 	logx.Info(h.Log, reqID, op, "created",
 		"sub_id", subWithID.ID,
 		"user_id", req.UserID,
 		"service_name", req.ServiceName,
 		"price", req.Price,
 	)
+	h.publish(ctx, reqID, op, domain.EventSubscriptionCreated, MapDomainToDTO(subWithID))
 	v1.WriteJSON(w, http.StatusOK, resp)
 }
 
@@ -86,12 +155,15 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 // @Description  Получить подписку по её идентификатору
 // @Tags         subscriptions
 // @Produce      json
-// @Param        id   path      string  true  "Subscription ID (GUID)"
+// @Param        id             path      string  true   "Subscription ID (GUID)"
+// @Param        If-None-Match  header    string  false  "ETag, полученный ранее"
 // @Success      200  {object}  subscription.SubscriptionDTO
-// @Failure      400  {object}  map[string]string
-// @Failure      404  {object}  map[string]string
-// @Failure      504  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Header       200  {string}  ETag  "Версия подписки"
+// @Success      304  "Не изменилась с указанного ETag"
+// @Failure      400  {object}  v1.Problem
+// @Failure      404  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
 // @Router       /v1/subscriptions/{id} [get]
 func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 	const op = "subscription.get"
@@ -100,7 +172,7 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if err := ValidateGUID(id); err != nil {
 		logx.Error(h.Log, reqID, op, "bad id", err, "id", id)
-		v1.WriteError(w, http.StatusBadRequest, err.Error())
+		v1.WriteValidationError(w, v1.NewFieldError("id", "invalid_guid", "value", strconv.Quote(id)))
 		return
 	}
 
@@ -111,12 +183,12 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		if v1.IsTimeout(err) {
 			logx.Error(h.Log, reqID, op, "timeout", err, "id", id)
-			v1.WriteError(w, http.StatusGatewayTimeout, "request timed out")
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
 			return
 		}
 		if errors.Is(err, domain.ErrNotFound) {
 			logx.Info(h.Log, reqID, op, "not found", "id", id)
-			v1.WriteError(w, http.StatusNotFound, "not found")
+			v1.WriteMessage(w, http.StatusNotFound, "not_found")
 			return
 		}
 		logx.Error(h.Log, reqID, op, "repo get failed", err, "id", id)
@@ -124,6 +196,13 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", ETag(sub.Version))
+	if notModified(r, sub.Version) {
+		logx.Info(h.Log, reqID, op, "not modified", "id", id)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	resp := MapDomainToDTO(sub)
 	logx.Info(h.Log, reqID, op, "returned", "id", id)
 	v1.WriteJSON(w, http.StatusOK, resp)
@@ -135,12 +214,16 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 // @Tags         subscriptions
 // @Accept       json
 // @Produce      json
-// @Param        request  body      subscription.UpdateRequest  true  "Subscription payload"
+// @Param        id        path      string                      true   "Subscription ID (GUID)"
+// @Param        request   body      subscription.UpdateRequest  true   "Subscription payload"
+// @Param        If-Match  header    string                      false  "ETag версии, которую изменяем"
 // @Success      200      {object}  subscription.CUDResponse
-// @Failure      400      {object}  map[string]string
-// @Failure      404      {object}  map[string]string
-// @Failure      504      {object}  map[string]string
-// @Failure      500      {object}  map[string]string
+// @Header       200      {string}  ETag  "Новая версия подписки"
+// @Failure      400      {object}  v1.Problem
+// @Failure      404      {object}  v1.Problem
+// @Failure      412      {object}  v1.Problem
+// @Failure      504      {object}  v1.Problem
+// @Failure      500      {object}  v1.Problem
 // @Router       /v1/subscriptions/{id} [put]
 func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	const op = "subscription.update"
@@ -150,29 +233,53 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	var req UpdateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := v1.DecodeJSON(r.Body, &req); err != nil {
 		logx.Error(h.Log, reqID, op, "invalid JSON", err)
-		v1.WriteError(w, http.StatusBadRequest, "invalid JSON")
+		v1.WriteDecodeError(w, err)
 		return
 	}
 	defer r.Body.Close()
 
+	// подписку выбирает путь; id в теле необязателен, но если указан — должен с ним совпадать
+	id := r.PathValue("id")
+	if req.ID == "" {
+		req.ID = id
+	} else if req.ID != id {
+		logx.Info(h.Log, reqID, op, "id mismatch", "id", id, "body_id", req.ID)
+		v1.WriteValidationError(w, v1.NewFieldError("id", "path_mismatch"))
+		return
+	}
+
 	if err := ValidateUpdateRequest(req); err != nil {
 		logx.Error(h.Log, reqID, op, "validation failed", err)
-		v1.WriteError(w, http.StatusBadRequest, err.Error())
+		v1.WriteValidationError(w, err)
 		return
 	}
 
 	sub := MapUpdateReqToDomain(req)
-	if err := h.Repo.UpdateSub(ctx, sub); err != nil {
+	version, err := h.expectedVersion(ctx, r, req.ID)
+	if err == nil {
+		sub, err = h.Repo.UpdateSub(ctx, sub, version)
+	}
+	if err != nil {
 		if v1.IsTimeout(err) {
 			logx.Error(h.Log, reqID, op, "repo timeout", err, "id", req.ID)
-			v1.WriteError(w, http.StatusGatewayTimeout, "request timed out")
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
 			return
 		}
 		if errors.Is(err, domain.ErrNotFound) {
 			logx.Info(h.Log, reqID, op, "not found", "id", req.ID)
-			v1.WriteError(w, http.StatusNotFound, "not found")
+			v1.WriteMessage(w, http.StatusNotFound, "not_found")
+			return
+		}
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			logx.Info(h.Log, reqID, op, "version mismatch", "id", req.ID)
+			v1.WriteMessage(w, http.StatusPreconditionFailed, "version_mismatch")
+			return
+		}
+		if errors.Is(err, domain.ErrUserNotFound) {
+			logx.Info(h.Log, reqID, op, "unknown user", "user_id", req.UserID)
+			v1.WriteValidationError(w, v1.NewFieldError("user_id", "unknown_user"))
 			return
 		}
 		logx.Error(h.Log, reqID, op, "repo update failed", err, "id", req.ID)
@@ -181,21 +288,165 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp := &CUDResponse{SubID: req.ID, Status: UPDATED}
+	w.Header().Set("ETag", ETag(sub.Version))
 	logx.Info(h.Log, reqID, op, "updated", "id", req.ID)
+	h.publish(ctx, reqID, op, domain.EventSubscriptionUpdated, MapDomainToDTO(sub))
+	v1.WriteJSON(w, http.StatusOK, resp)
+}
+
+// Patch godoc
+// @Summary      Patch subscription
+// @Description  Частично обновить подписку: application/merge-patch+json (RFC 7396) или application/json-patch+json (RFC 6902).
+// @Description  Патч применяется к текущему состоянию, результат проверяется как при PUT и сохраняется атомарно.
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string  true  "Subscription ID (GUID)"
+// @Param        request   body      object  true   "Merge patch или массив операций JSON Patch"
+// @Param        If-Match  header    string  false  "ETag версии, которую изменяем"
+// @Success      200      {object}  subscription.CUDResponse
+// @Header       200      {string}  ETag  "Новая версия подписки"
+// @Failure      400      {object}  v1.Problem
+// @Failure      404      {object}  v1.Problem
+// @Failure      409      {object}  v1.Problem
+// @Failure      412      {object}  v1.Problem
+// @Failure      415      {object}  v1.Problem
+// @Failure      504      {object}  v1.Problem
+// @Failure      500      {object}  v1.Problem
+// @Router       /v1/subscriptions/{id} [patch]
+func (h *Handler) Patch(w http.ResponseWriter, r *http.Request) {
+	const op = "subscription.patch"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	id := r.PathValue("id")
+	if err := ValidateGUID(id); err != nil {
+		logx.Error(h.Log, reqID, op, "bad id", err, "id", id)
+		v1.WriteValidationError(w, v1.NewFieldError("id", "invalid_guid", "value", strconv.Quote(id)))
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != MergePatchContentType && contentType != JSONPatchContentType {
+		logx.Info(h.Log, reqID, op, "unsupported content type", "content_type", contentType)
+		w.Header().Set("Accept-Patch", MergePatchContentType+", "+JSONPatchContentType)
+		v1.WriteMessage(w, http.StatusUnsupportedMediaType, "unsupported_patch_type", "types", MergePatchContentType+", "+JSONPatchContentType)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		logx.Error(h.Log, reqID, op, "read body failed", err)
+		v1.WriteMessage(w, http.StatusBadRequest, "invalid_body")
+		return
+	}
+	defer r.Body.Close()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var sub domain.Subscription
+	version, err := h.expectedVersion(ctx, r, id)
+	if err == nil {
+		sub, err = h.Repo.PatchSub(ctx, id, version, func(cur domain.Subscription) (domain.Subscription, error) {
+			req, err := patchSubscription(cur, contentType, patch)
+			if err != nil {
+				return domain.Subscription{}, err
+			}
+			if err := ValidateUpdateRequest(req); err != nil {
+				return domain.Subscription{}, invalidPatchResult{err}
+			}
+			return MapUpdateReqToDomain(req), nil
+		})
+	}
+	if err != nil {
+		var pe *PatchError
+		var ve invalidPatchResult
+		switch {
+		case v1.IsTimeout(err):
+			logx.Error(h.Log, reqID, op, "repo timeout", err, "id", id)
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+		case errors.Is(err, domain.ErrNotFound):
+			logx.Info(h.Log, reqID, op, "not found", "id", id)
+			v1.WriteMessage(w, http.StatusNotFound, "not_found")
+		case errors.Is(err, domain.ErrVersionMismatch):
+			logx.Info(h.Log, reqID, op, "version mismatch", "id", id)
+			v1.WriteMessage(w, http.StatusPreconditionFailed, "version_mismatch")
+		case errors.As(err, &pe) && pe.Conflict:
+			logx.Info(h.Log, reqID, op, "patch conflict", "id", id, "reason", pe.Error())
+			pe.Write(w, http.StatusConflict)
+		case errors.As(err, &ve):
+			logx.Error(h.Log, reqID, op, "patch rejected", err, "id", id)
+			v1.WriteValidationError(w, ve.error)
+		case errors.As(err, &pe):
+			logx.Error(h.Log, reqID, op, "patch rejected", err, "id", id)
+			pe.Write(w, http.StatusBadRequest)
+		case errors.Is(err, domain.ErrUserNotFound):
+			logx.Info(h.Log, reqID, op, "unknown user", "id", id)
+			v1.WriteValidationError(w, v1.NewFieldError("user_id", "unknown_user"))
+		default:
+			logx.Error(h.Log, reqID, op, "repo patch failed", err, "id", id)
+			v1.WriteError(w, http.StatusInternalServerError, "")
+		}
+		return
+	}
+
+	resp := &CUDResponse{SubID: id, Status: UPDATED}
+	w.Header().Set("ETag", ETag(sub.Version))
+	logx.Info(h.Log, reqID, op, "patched", "id", id, "content_type", contentType)
+	h.publish(ctx, reqID, op, domain.EventSubscriptionUpdated, MapDomainToDTO(sub))
 	v1.WriteJSON(w, http.StatusOK, resp)
 }
 
+// invalidPatchResult — патч применился, но результат не прошёл валидацию
+type invalidPatchResult struct{ error }
+
+// patchSubscription применяет патч к представлению подписки в формате PUT-запроса.
+// Неизвестные поля в результате и изменение id отклоняются.
+func patchSubscription(cur domain.Subscription, contentType string, patch []byte) (UpdateRequest, error) {
+	doc, err := json.Marshal(UpdateRequest{
+		ID:          cur.ID,
+		ServiceName: cur.ServiceName,
+		Price:       cur.Price,
+		UserID:      cur.UserID,
+		StartDate:   YearMonth(cur.StartDate),
+		EndDate:     YearMonth(cur.EndDate),
+		Notes:       cur.Notes,
+		Tags:        append([]string{}, cur.Tags...), // [] вместо null, чтобы работал add /tags/-
+	})
+	if err != nil {
+		return UpdateRequest{}, err
+	}
+	merged, err := ApplyPatch(doc, contentType, patch)
+	if err != nil {
+		return UpdateRequest{}, err
+	}
+
+	var req UpdateRequest
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return UpdateRequest{}, patchError("patch_invalid_result", "error", err)
+	}
+	if req.ID != cur.ID {
+		return UpdateRequest{}, patchError("patch_id_changed")
+	}
+	return req, nil
+}
+
 // Delete godoc
 // @Summary      Delete subscription
-// @Description  Удалить подписку по её идентификатору
+// @Description  Перенести подписку в корзину: она пропадает из всех запросов, но её можно вернуть
+// @Description  через POST /v1/subscriptions/{id}/restore, пока не истёк TRASH_RETENTION
 // @Tags         subscriptions
 // @Produce      json
-// @Param        id   path      string  true  "Subscription ID (GUID)"
+// @Param        id        path      string  true   "Subscription ID (GUID)"
+// @Param        If-Match  header    string  false  "ETag версии, которую удаляем"
 // @Success      200  {object}  subscription.CUDResponse
-// @Failure      400  {object}  map[string]string
-// @Failure      404  {object}  map[string]string
-// @Failure      504  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      400  {object}  v1.Problem
+// @Failure      404  {object}  v1.Problem
+// @Failure      412  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
 // @Router       /v1/subscriptions/{id} [delete]
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	const op = "subscription.delete"
@@ -204,22 +455,32 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if err := ValidateGUID(id); err != nil {
 		logx.Error(h.Log, reqID, op, "bad id", err, "id", id)
-		v1.WriteError(w, http.StatusBadRequest, err.Error())
+		v1.WriteValidationError(w, v1.NewFieldError("id", "invalid_guid", "value", strconv.Quote(id)))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	if err := h.Repo.DeleteSub(ctx, id); err != nil {
+	var deleted domain.Subscription
+	version, err := h.expectedVersion(ctx, r, id)
+	if err == nil {
+		deleted, err = h.Repo.DeleteSub(ctx, id, version)
+	}
+	if err != nil {
 		if v1.IsTimeout(err) {
 			logx.Error(h.Log, reqID, op, "repo timeout", err, "id", id)
-			v1.WriteError(w, http.StatusGatewayTimeout, "request timed out")
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
 			return
 		}
 		if errors.Is(err, domain.ErrNotFound) {
 			logx.Info(h.Log, reqID, op, "not found", "id", id)
-			v1.WriteError(w, http.StatusNotFound, "not found")
+			v1.WriteMessage(w, http.StatusNotFound, "not_found")
+			return
+		}
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			logx.Info(h.Log, reqID, op, "version mismatch", "id", id)
+			v1.WriteMessage(w, http.StatusPreconditionFailed, "version_mismatch")
 			return
 		}
 		logx.Error(h.Log, reqID, op, "repo delete failed", err, "id", id)
@@ -229,30 +490,49 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	resp := &CUDResponse{SubID: id, Status: DELETED}
 	logx.Info(h.Log, reqID, op, "deleted", "id", id)
+	h.publish(ctx, reqID, op, domain.EventSubscriptionDeleted, MapDomainToDeletedEvent(deleted))
 	v1.WriteJSON(w, http.StatusOK, resp)
 }
 
 // List godoc
 // @Summary      List subscriptions
-// @Description  Получить список всех подписок
+// @Description  Получить список подписок с фильтрами, сортировкой и keyset-пагинацией
 // @Tags         subscriptions
 // @Produce      json
+// @Param        user_id       query  string  false  "ID пользователя"
+// @Param        service_name  query  string  false  "Название подписки"
+// @Param        active_at     query  string  false  "Активна в месяце (MM-YYYY)"
+// @Param        price_min     query  int     false  "Минимальная цена"
+// @Param        price_max     query  int     false  "Максимальная цена"
+// @Param        from          query  string  false  "Пересекает период с (MM-YYYY)"
+// @Param        to            query  string  false  "Пересекает период по (MM-YYYY)"
+// @Param        sort          query  string  false  "Сортировка, например price,-start_date"
+// @Param        limit         query  int     false  "Размер страницы (1..500); без limit и cursor — все записи, с cursor — по умолчанию 50"
+// @Param        cursor        query  string  false  "Курсор из next_cursor предыдущей страницы"
 // @Success      200  {object}  subscription.ListResponse
-// @Failure      504  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      400  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
 // @Router       /v1/subscriptions [get]
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	const op = "subscription.list"
 	reqID := mw.RequestIDFromCtx(r.Context())
 
+	f, err := ParseListQuery(r.URL.Query())
+	if err != nil {
+		logx.Error(h.Log, reqID, op, "validation failed", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	subs, err := h.Repo.ListSubs(ctx)
+	page, err := h.Repo.ListSubs(ctx, f)
 	if err != nil {
 		if v1.IsTimeout(err) {
 			logx.Error(h.Log, reqID, op, "timeout", err)
-			v1.WriteError(w, http.StatusGatewayTimeout, "request timed out")
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
 			return
 		}
 		logx.Error(h.Log, reqID, op, "repo list failed", err)
@@ -260,8 +540,11 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp := &ListResponse{Subs: MapDomainListToDTO(subs)}
-	logx.Info(h.Log, reqID, op, "returned", "count", len(resp.Subs))
+	resp := &ListResponse{Subs: MapDomainListToDTO(page.Subs)}
+	if page.HasMore && len(page.Subs) > 0 {
+		resp.NextCursor = EncodeCursor(page.Subs[len(page.Subs)-1], f.OrderBy())
+	}
+	logx.Info(h.Log, reqID, op, "returned", "count", len(resp.Subs), "has_more", page.HasMore)
 	v1.WriteJSON(w, http.StatusOK, resp)
 }
 
@@ -276,9 +559,9 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 // @Param        from        query  string  true   "Начало периода (MM-YYYY)"
 // @Param        to          query  string  true   "Конец периода (MM-YYYY)"
 // @Success      200  {object}  subscription.TotalCostResponse
-// @Failure      400  {object}  map[string]string
-// @Failure      504  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Failure      400  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
 // @Router       /v1/subscriptions/totalcost [get]
 func (h *Handler) TotalCost(w http.ResponseWriter, r *http.Request) {
 	const op = "subscription.total_cost"
@@ -293,19 +576,19 @@ func (h *Handler) TotalCost(w http.ResponseWriter, r *http.Request) {
 	fromYM, err := YMFromStr(fromStr)
 	if err != nil {
 		logx.Error(h.Log, reqID, op, "bad from format", err, "from", fromStr)
-		v1.WriteError(w, http.StatusBadRequest, "from: invalid format, expected MM-YYYY")
+		v1.WriteValidationError(w, v1.NewFieldError("from", "invalid_format", "format", "MM-YYYY"))
 		return
 	}
 	toYM, err := YMFromStr(toStr)
 	if err != nil {
 		logx.Error(h.Log, reqID, op, "bad to format", err, "to", toStr)
-		v1.WriteError(w, http.StatusBadRequest, "to: invalid format, expected MM-YYYY")
+		v1.WriteValidationError(w, v1.NewFieldError("to", "invalid_format", "format", "MM-YYYY"))
 		return
 	}
 
 	if err := ValidateTotalCostQuery(userIDStr, serviceName, fromYM, toYM); err != nil {
 		logx.Error(h.Log, reqID, op, "validation failed", err)
-		v1.WriteError(w, http.StatusBadRequest, err.Error())
+		v1.WriteValidationError(w, err)
 		return
 	}
 
@@ -316,7 +599,7 @@ func (h *Handler) TotalCost(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		if v1.IsTimeout(err) {
 			logx.Error(h.Log, reqID, op, "repo timeout", err)
-			v1.WriteError(w, http.StatusGatewayTimeout, "request timed out")
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
 			return
 		}
 		logx.Error(h.Log, reqID, op, "repo total cost failed", err)
@@ -1,30 +1,42 @@
 package subscription
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/EgorLis/my-subs/internal/domain"
 	mockrepo "github.com/EgorLis/my-subs/internal/infra/database/mock"
+	"github.com/EgorLis/my-subs/internal/transport/web/sse"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
 	"github.com/google/uuid"
 )
 
 // ---------- helpers ----------
 
 func newHandler(repo domain.SubscriptionRepository) *Handler {
-	return &Handler{
+	h := &Handler{
 		Log:  log.New(io.Discard, "", 0),
 		Repo: repo,
 	}
+	// пользователей знает только мок целиком; заглушки ошибок их не проверяют
+	if users, ok := repo.(domain.UserRepository); ok {
+		h.Users = users
+	}
+	return h
 }
 
 func mustJSON(v any) *bytes.Reader {
@@ -36,11 +48,19 @@ func ym(mm, yyyy int) YearMonth {
 	return YearMonth(time.Date(yyyy, time.Month(mm), 1, 0, 0, 0, 0, time.UTC))
 }
 
+// addUser заводит пользователя: подписку можно создать только для существующего пользователя
+func addUser(repo *mockrepo.Repo) string {
+	u, _ := repo.AddUser(context.Background(), domain.User{
+		DisplayName: "Test User", Currency: "RUB", Locale: "ru", Timezone: "UTC",
+	})
+	return u.ID
+}
+
 func readErrorStr(t *testing.T, body []byte) string {
 	t.Helper()
-	var m map[string]string
-	_ = json.Unmarshal(body, &m)
-	return m["error"]
+	var p v1.Problem
+	_ = json.Unmarshal(body, &p)
+	return p.Detail
 }
 
 // ---------- repos for failure simulation ----------
@@ -53,15 +73,33 @@ func (timeoutRepo) AddSub(ctx context.Context, sub domain.Subscription) (domain.
 func (timeoutRepo) GetSub(ctx context.Context, id string) (domain.Subscription, error) {
 	return domain.Subscription{}, context.DeadlineExceeded
 }
-func (timeoutRepo) UpdateSub(ctx context.Context, sub domain.Subscription) error {
-	return context.DeadlineExceeded
+func (timeoutRepo) UpdateSub(ctx context.Context, sub domain.Subscription, version int) (domain.Subscription, error) {
+	return domain.Subscription{}, context.DeadlineExceeded
+}
+func (timeoutRepo) PatchSub(ctx context.Context, id string, version int, apply func(domain.Subscription) (domain.Subscription, error)) (domain.Subscription, error) {
+	return domain.Subscription{}, context.DeadlineExceeded
 }
-func (timeoutRepo) DeleteSub(ctx context.Context, id string) error {
+func (timeoutRepo) DeleteSub(ctx context.Context, id string, version int) (domain.Subscription, error) {
+	return domain.Subscription{}, context.DeadlineExceeded
+}
+func (timeoutRepo) RestoreSub(ctx context.Context, id string) (domain.Subscription, error) {
+	return domain.Subscription{}, context.DeadlineExceeded
+}
+func (timeoutRepo) ListSubs(ctx context.Context, f domain.SubscriptionFilter) (domain.SubscriptionPage, error) {
+	return domain.SubscriptionPage{}, context.DeadlineExceeded
+}
+func (timeoutRepo) ImportSubs(ctx context.Context, subs []domain.Subscription) ([]domain.Subscription, error) {
+	return nil, context.DeadlineExceeded
+}
+func (timeoutRepo) StreamSubs(ctx context.Context, f domain.SubscriptionFilter, fn func(domain.Subscription) error) error {
 	return context.DeadlineExceeded
 }
-func (timeoutRepo) ListSubs(ctx context.Context) ([]domain.Subscription, error) {
+func (timeoutRepo) SearchSubs(ctx context.Context, q domain.SearchQuery) ([]domain.SearchHit, error) {
 	return nil, context.DeadlineExceeded
 }
+func (timeoutRepo) InTx(ctx context.Context, fn func(domain.SubscriptionTx) error) error {
+	return context.DeadlineExceeded
+}
 func (timeoutRepo) TotalCost(ctx context.Context, _ string, _ string, _, _ time.Time) (int, error) {
 	return 0, context.DeadlineExceeded
 }
@@ -76,15 +114,33 @@ func (internalErrRepo) AddSub(ctx context.Context, sub domain.Subscription) (dom
 func (internalErrRepo) GetSub(ctx context.Context, id string) (domain.Subscription, error) {
 	return domain.Subscription{}, errInternal
 }
-func (internalErrRepo) UpdateSub(ctx context.Context, sub domain.Subscription) error {
-	return errInternal
+func (internalErrRepo) UpdateSub(ctx context.Context, sub domain.Subscription, version int) (domain.Subscription, error) {
+	return domain.Subscription{}, errInternal
+}
+func (internalErrRepo) PatchSub(ctx context.Context, id string, version int, apply func(domain.Subscription) (domain.Subscription, error)) (domain.Subscription, error) {
+	return domain.Subscription{}, errInternal
+}
+func (internalErrRepo) DeleteSub(ctx context.Context, id string, version int) (domain.Subscription, error) {
+	return domain.Subscription{}, errInternal
+}
+func (internalErrRepo) RestoreSub(ctx context.Context, id string) (domain.Subscription, error) {
+	return domain.Subscription{}, errInternal
+}
+func (internalErrRepo) ListSubs(ctx context.Context, f domain.SubscriptionFilter) (domain.SubscriptionPage, error) {
+	return domain.SubscriptionPage{}, errInternal
+}
+func (internalErrRepo) ImportSubs(ctx context.Context, subs []domain.Subscription) ([]domain.Subscription, error) {
+	return nil, errInternal
 }
-func (internalErrRepo) DeleteSub(ctx context.Context, id string) error {
+func (internalErrRepo) StreamSubs(ctx context.Context, f domain.SubscriptionFilter, fn func(domain.Subscription) error) error {
 	return errInternal
 }
-func (internalErrRepo) ListSubs(ctx context.Context) ([]domain.Subscription, error) {
+func (internalErrRepo) SearchSubs(ctx context.Context, q domain.SearchQuery) ([]domain.SearchHit, error) {
 	return nil, errInternal
 }
+func (internalErrRepo) InTx(ctx context.Context, fn func(domain.SubscriptionTx) error) error {
+	return errInternal
+}
 func (internalErrRepo) TotalCost(ctx context.Context, _ string, _ string, _, _ time.Time) (int, error) {
 	return 0, errInternal
 }
@@ -92,7 +148,8 @@ func (internalErrRepo) TotalCost(ctx context.Context, _ string, _ string, _, _ t
 // ---------- CREATE ----------
 
 func TestCreate_Various(t *testing.T) {
-	okUser := uuid.NewString()
+	repo := mockrepo.NewMockRepo()
+	okUser := addUser(repo)
 
 	cases := []struct {
 		name       string
@@ -103,10 +160,17 @@ func TestCreate_Various(t *testing.T) {
 	}{
 		{
 			name:     "OK",
-			repo:     mockrepo.NewMockRepo(),
+			repo:     repo,
 			body:     CreateRequest{ServiceName: "Yandex Plus", Price: 400, UserID: okUser, StartDate: ym(7, 2025), EndDate: ym(7, 2026)},
 			wantCode: http.StatusOK,
 		},
+		{
+			name:       "UnknownUser",
+			repo:       repo,
+			body:       CreateRequest{ServiceName: "Yandex Plus", Price: 400, UserID: uuid.NewString(), StartDate: ym(7, 2025), EndDate: ym(7, 2026)},
+			wantCode:   http.StatusBadRequest,
+			wantInBody: "unknown user",
+		},
 		{
 			name:       "BadJSON",
 			repo:       mockrepo.NewMockRepo(),
@@ -142,6 +206,13 @@ func TestCreate_Various(t *testing.T) {
 			wantCode:   http.StatusBadRequest,
 			wantInBody: "date range",
 		},
+		{
+			name:       "Validation_NotesAndTags",
+			repo:       mockrepo.NewMockRepo(),
+			body:       CreateRequest{ServiceName: "A", Price: 1, UserID: okUser, StartDate: ym(7, 2025), EndDate: ym(8, 2025), Notes: strings.Repeat("x", MaxNotesLen+1), Tags: []string{"ok", " "}},
+			wantCode:   http.StatusBadRequest,
+			wantInBody: "notes: must be at most 1000 characters; tags[1]: must be 1..32 characters",
+		},
 		{
 			name:       "Timeout",
 			repo:       timeoutRepo{},
@@ -189,7 +260,7 @@ func TestGet_Various(t *testing.T) {
 
 	// prepare one
 	sub, _ := repo.AddSub(context.Background(), domain.Subscription{
-		ServiceName: "Netflix", Price: 500, UserID: uuid.NewString(),
+		ServiceName: "Netflix", Price: 500, UserID: addUser(repo),
 		StartDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
 		EndDate:   time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
 	})
@@ -229,7 +300,7 @@ func TestGet_Various(t *testing.T) {
 func TestUpdate_Various(t *testing.T) {
 	baseRepo := mockrepo.NewMockRepo()
 	base, _ := baseRepo.AddSub(context.Background(), domain.Subscription{
-		ServiceName: "Spotify", Price: 300, UserID: uuid.NewString(),
+		ServiceName: "Spotify", Price: 300, UserID: addUser(baseRepo),
 		StartDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
 		EndDate:   time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
 	})
@@ -253,6 +324,7 @@ func TestUpdate_Various(t *testing.T) {
 		{"Validation_NegativePrice", baseRepo, func() UpdateRequest { x := okReq; x.Price = -1; return x }(), http.StatusBadRequest, "price"},
 		{"Validation_StartAfterEnd", baseRepo, func() UpdateRequest { x := okReq; x.StartDate = ym(9, 2025); x.EndDate = ym(8, 2025); return x }(), http.StatusBadRequest, "date range"},
 		{"NotFound", baseRepo, func() UpdateRequest { x := okReq; x.ID = uuid.NewString(); return x }(), http.StatusNotFound, ""},
+		{"UnknownUser", baseRepo, func() UpdateRequest { x := okReq; x.UserID = uuid.NewString(); return x }(), http.StatusBadRequest, "unknown user"},
 		{"Timeout", timeoutRepo{}, okReq, http.StatusGatewayTimeout, ""},
 		{"Internal", internalErrRepo{}, okReq, http.StatusInternalServerError, ""},
 	}
@@ -261,14 +333,17 @@ func TestUpdate_Various(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			h := newHandler(tc.repo)
 
+			// путь совпадает с id в теле; расхождение проверяет IDMismatch ниже
+			id := base.ID
 			var r *http.Request
 			switch b := tc.body.(type) {
 			case rawJSON:
-				r = httptest.NewRequest(http.MethodPut, "/v1/subscriptions/"+base.ID, bytes.NewBufferString(string(b)))
+				r = httptest.NewRequest(http.MethodPut, "/v1/subscriptions/"+id, bytes.NewBufferString(string(b)))
 			default:
-				r = httptest.NewRequest(http.MethodPut, "/v1/subscriptions/"+base.ID, mustJSON(tc.body))
+				id = b.(UpdateRequest).ID
+				r = httptest.NewRequest(http.MethodPut, "/v1/subscriptions/"+id, mustJSON(tc.body))
 			}
-			r.SetPathValue("id", base.ID)
+			r.SetPathValue("id", id)
 
 			w := httptest.NewRecorder()
 			h.Update(w, r)
@@ -281,184 +356,1992 @@ func TestUpdate_Various(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("IDFromPath", func(t *testing.T) {
+		req := okReq
+		req.ID = ""
+		r := httptest.NewRequest(http.MethodPut, "/v1/subscriptions/"+base.ID, mustJSON(req))
+		r.SetPathValue("id", base.ID)
+		w := httptest.NewRecorder()
+		newHandler(baseRepo).Update(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("want 200, got %d. body=%s", w.Code, w.Body.String())
+		}
+	})
+	t.Run("IDMismatch", func(t *testing.T) {
+		other, _ := baseRepo.AddSub(context.Background(), domain.Subscription{
+			ServiceName: "Okko", Price: 100, UserID: base.UserID, StartDate: base.StartDate, EndDate: base.EndDate,
+		})
+		// If-Match и изменение относятся к подписке из пути, а не из тела
+		r := httptest.NewRequest(http.MethodPut, "/v1/subscriptions/"+base.ID, mustJSON(UpdateRequest{
+			ID: other.ID, ServiceName: "Okko", Price: 999, UserID: base.UserID, StartDate: YearMonth(base.StartDate), EndDate: YearMonth(base.EndDate),
+		}))
+		r.SetPathValue("id", base.ID)
+		r.Header.Set("If-Match", ETag(other.Version))
+		w := httptest.NewRecorder()
+		newHandler(baseRepo).Update(w, r)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "must match the id in the path") {
+			t.Fatalf("want 400 id mismatch, got %d. body=%s", w.Code, w.Body.String())
+		}
+		if s, _ := baseRepo.GetSub(context.Background(), other.ID); s.Price != 100 {
+			t.Fatalf("subscription from body must stay unchanged, got price %d", s.Price)
+		}
+	})
 }
 
-// ---------- DELETE ----------
+// ---------- PATCH ----------
 
-func TestDelete_Various(t *testing.T) {
+func TestPatch_Various(t *testing.T) {
 	repo := mockrepo.NewMockRepo()
-	sub, _ := repo.AddSub(context.Background(), domain.Subscription{
-		ServiceName: "YouTube", Price: 199, UserID: uuid.NewString(),
-		StartDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
-		EndDate:   time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
-	})
+	userID := addUser(repo)
+	seed := func() domain.Subscription {
+		sub, _ := repo.AddSub(context.Background(), domain.Subscription{
+			ServiceName: "Spotify", Price: 300, UserID: userID,
+			StartDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+		})
+		return sub
+	}
 
 	cases := []struct {
-		name     string
-		repo     domain.SubscriptionRepository
-		id       string
-		wantCode int
+		name        string
+		repo        domain.SubscriptionRepository
+		contentType string
+		body        string
+		wantCode    int
+		wantInBody  string
+		check       func(t *testing.T, got domain.Subscription)
 	}{
-		{"OK", repo, sub.ID, http.StatusOK},
-		{"BadGUID", repo, "bad-guid", http.StatusBadRequest},
-		{"NotFound", repo, uuid.NewString(), http.StatusNotFound},
-		{"Timeout", timeoutRepo{}, uuid.NewString(), http.StatusGatewayTimeout},
-		{"Internal", internalErrRepo{}, uuid.NewString(), http.StatusInternalServerError},
+		{"Merge_Price", repo, MergePatchContentType, `{"price": 450}`, http.StatusOK, "", func(t *testing.T, got domain.Subscription) {
+			if got.Price != 450 || got.ServiceName != "Spotify" || !got.EndDate.Equal(time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Fatalf("only price should change, got %+v", got)
+			}
+		}},
+		{"Merge_WithCharset", repo, MergePatchContentType + "; charset=utf-8", `{"service_name": "Spotify Family"}`, http.StatusOK, "", func(t *testing.T, got domain.Subscription) {
+			if got.ServiceName != "Spotify Family" || got.Price != 300 {
+				t.Fatalf("only service_name should change, got %+v", got)
+			}
+		}},
+		{"Merge_NullRemovesRequired", repo, MergePatchContentType, `{"service_name": null}`, http.StatusBadRequest, "service_name: required", nil},
+		{"Merge_MergedRangeInvalid", repo, MergePatchContentType, `{"end_date": "01-2025"}`, http.StatusBadRequest, "date range", nil},
+		{"Merge_UnknownField", repo, MergePatchContentType, `{"pricee": 1}`, http.StatusBadRequest, "unknown field", nil},
+		{"Merge_ChangeID", repo, MergePatchContentType, `{"id": "` + uuid.NewString() + `"}`, http.StatusBadRequest, "id: cannot be changed", nil},
+		{"Merge_NotObject", repo, MergePatchContentType, `[1]`, http.StatusBadRequest, "JSON object", nil},
+		{"Merge_UnknownUser", repo, MergePatchContentType, `{"user_id": "` + uuid.NewString() + `"}`, http.StatusBadRequest, "unknown user", nil},
+		{"JSONPatch_TestAndReplace", repo, JSONPatchContentType,
+			`[{"op":"test","path":"/price","value":300},{"op":"replace","path":"/price","value":999},{"op":"add","path":"/end_date","value":"12-2026"}]`,
+			http.StatusOK, "", func(t *testing.T, got domain.Subscription) {
+				if got.Price != 999 || !got.EndDate.Equal(time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)) {
+					t.Fatalf("want price=999 and end_date=12-2026, got %+v", got)
+				}
+			}},
+		{"JSONPatch_TestFailed", repo, JSONPatchContentType, `[{"op":"test","path":"/price","value":1},{"op":"replace","path":"/price","value":2}]`, http.StatusConflict, "test failed", func(t *testing.T, got domain.Subscription) {
+			if got.Price != 300 {
+				t.Fatalf("failed patch must not change anything, got price=%d", got.Price)
+			}
+		}},
+		{"JSONPatch_RemoveRequired", repo, JSONPatchContentType, `[{"op":"remove","path":"/user_id"}]`, http.StatusBadRequest, "user_id", nil},
+		{"JSONPatch_PathNotFound", repo, JSONPatchContentType, `[{"op":"replace","path":"/nope","value":1}]`, http.StatusBadRequest, "path not found", nil},
+		{"JSONPatch_UnknownOp", repo, JSONPatchContentType, `[{"op":"frobnicate","path":"/price"}]`, http.StatusBadRequest, "unknown op", nil},
+		{"JSONPatch_NotArray", repo, JSONPatchContentType, `{"price": 1}`, http.StatusBadRequest, "array of operations", nil},
+		{"UnsupportedType", repo, "application/json", `{"price": 1}`, http.StatusUnsupportedMediaType, "merge-patch", nil},
+		{"Timeout", timeoutRepo{}, MergePatchContentType, `{"price": 1}`, http.StatusGatewayTimeout, "", nil},
+		{"Internal", internalErrRepo{}, MergePatchContentType, `{"price": 1}`, http.StatusInternalServerError, "", nil},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
+			sub := seed()
 			h := newHandler(tc.repo)
-			w := httptest.NewRecorder()
-			r := httptest.NewRequest(http.MethodDelete, "/v1/subscriptions/"+tc.id, nil)
-			r.SetPathValue("id", tc.id)
 
-			h.Delete(w, r)
+			r := httptest.NewRequest(http.MethodPatch, "/v1/subscriptions/"+sub.ID, strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", tc.contentType)
+			r.SetPathValue("id", sub.ID)
+			w := httptest.NewRecorder()
+			h.Patch(w, r)
 
 			if w.Code != tc.wantCode {
 				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
 			}
+			if tc.wantInBody != "" && !strings.Contains(w.Body.String(), tc.wantInBody) {
+				t.Fatalf("body should contain %q, got %s", tc.wantInBody, w.Body.String())
+			}
+			if tc.check != nil {
+				got, _ := repo.GetSub(context.Background(), sub.ID)
+				tc.check(t, got)
+			}
 		})
 	}
-}
-
-// ---------- LIST ----------
 
-func TestList_Various(t *testing.T) {
-	okRepo := mockrepo.NewMockRepo()
-	_, _ = okRepo.AddSub(context.Background(), domain.Subscription{
-		ServiceName: "A", Price: 1, UserID: uuid.NewString(),
-		StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
-		EndDate:   time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+	t.Run("NotFound", func(t *testing.T) {
+		id := uuid.NewString()
+		r := httptest.NewRequest(http.MethodPatch, "/v1/subscriptions/"+id, strings.NewReader(`{"price": 1}`))
+		r.Header.Set("Content-Type", MergePatchContentType)
+		r.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		newHandler(repo).Patch(w, r)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("want 404, got %d. body=%s", w.Code, w.Body.String())
+		}
 	})
-	_, _ = okRepo.AddSub(context.Background(), domain.Subscription{
-		ServiceName: "B", Price: 2, UserID: uuid.NewString(),
-		StartDate: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
-		EndDate:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+
+	// ошибки патча берутся из каталога на языке ответа, включая описание операции
+	t.Run("Localized", func(t *testing.T) {
+		sub := seed()
+		for _, tc := range []struct {
+			body       string
+			wantCode   int
+			wantDetail string
+		}{
+			{`[{"op":"test","path":"/price","value":1}]`, http.StatusConflict, "операция 0 (test /price): проверка test не прошла"},
+			{`[{"op":"add","path":"/price"}]`, http.StatusBadRequest, "операция 0 (add /price): value: обязательное поле"},
+		} {
+			r := httptest.NewRequest(http.MethodPatch, "/v1/subscriptions/"+sub.ID, strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", JSONPatchContentType)
+			r.SetPathValue("id", sub.ID)
+			w := httptest.NewRecorder()
+			w.Header().Set(v1.HeaderContentLanguage, "ru")
+			newHandler(repo).Patch(w, r)
+
+			var p v1.Problem
+			_ = json.Unmarshal(w.Body.Bytes(), &p)
+			if w.Code != tc.wantCode || p.Detail != tc.wantDetail {
+				t.Fatalf("want %d %q, got %d %q", tc.wantCode, tc.wantDetail, w.Code, p.Detail)
+			}
+		}
 	})
+}
+
+// ---------- IMPORT ----------
+
+func TestImport(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	userID := addUser(repo)
+	count := func() int {
+		page, _ := repo.ListSubs(context.Background(), domain.SubscriptionFilter{})
+		return len(page.Subs)
+	}
+
+	valid := "service_name,price,user_id,start_date,end_date\n" +
+		"Netflix,500," + userID + ",01-2025,12-2025\n" +
+		"Spotify,300," + userID + ",03-2025,06-2025\n"
 
 	cases := []struct {
-		name     string
-		repo     domain.SubscriptionRepository
-		wantCode int
-		wantLen  int // expected number of items (if 200)
+		name      string
+		repo      domain.SubscriptionRepository
+		query     string
+		body      string
+		wantCode  int
+		wantValid int
+		wantLines []int // строки с ошибками
+		wantErr   string
+		wantCount int // подписок после запроса
 	}{
-		{"OK", okRepo, http.StatusOK, 2},
-		{"Timeout", timeoutRepo{}, http.StatusGatewayTimeout, 0},
-		{"Internal", internalErrRepo{}, http.StatusInternalServerError, 0},
+		{name: "DryRun_NothingWritten", repo: repo, query: "?dry_run=true", body: valid, wantCode: 200, wantValid: 2, wantLines: []int{}, wantCount: 0},
+		{
+			name:      "DryRun_ReportsRowErrors",
+			repo:      repo,
+			query:     "?dry_run=true",
+			body:      valid + "Okko,abc," + userID + ",13-2025,12-2025\n,0,nope,05-2025,01-2025\n",
+			wantCode:  200,
+			wantValid: 2,
+			wantLines: []int{4, 5},
+			wantErr:   "price: must be an integer; start_date: invalid format, expected MM-YYYY",
+			wantCount: 0,
+		},
+		{
+			name:      "InvalidRows_RejectWholeFile",
+			repo:      repo,
+			body:      valid + "Okko,100," + userID + ",05-2025,\n",
+			wantCode:  400,
+			wantValid: 2,
+			wantLines: []int{4},
+			wantErr:   "end_date: required (MM-YYYY)",
+			wantCount: 0,
+		},
+		{name: "Imported", repo: repo, body: valid, wantCode: 200, wantValid: 2, wantLines: []int{}, wantCount: 2},
+		{
+			name:      "ColumnMapping_DateFormat_Delimiter",
+			repo:      repo,
+			query:     "?columns=service_name:Сервис,price:Цена,user_id:Пользователь,start_date:С,end_date:По&date_format=DD.MM.YYYY&delimiter=%3B",
+			body:      "\ufeffСервис;Цена;Пользователь;С;По;Комментарий\n\"Yandex; Plus\";400;" + userID + ";15.07.2025;01.12.2025;семейная\n",
+			wantCode:  200,
+			wantValid: 1,
+			wantLines: []int{},
+			wantCount: 3,
+		},
+		// неизвестные пользователи — ошибки строк и в пробном прогоне, и при импорте
+		{
+			name:      "DryRun_UnknownUser",
+			repo:      repo,
+			query:     "?dry_run=true",
+			body:      valid + "Okko,100," + uuid.NewString() + ",05-2025,12-2025\nOkko,abc," + userID + ",05-2025,12-2025\n",
+			wantCode:  200,
+			wantValid: 2,
+			wantLines: []int{4, 5},
+			wantErr:   "user_id: unknown user",
+			wantCount: 3,
+		},
+		{
+			name:      "UnknownUser",
+			repo:      repo,
+			body:      "service_name,price,user_id,start_date,end_date\nOkko,abc," + userID + ",05-2025,12-2025\nNetflix,500," + uuid.NewString() + ",01-2025,12-2025\n" + valid[strings.Index(valid, "\n")+1:],
+			wantCode:  400,
+			wantValid: 2,
+			wantLines: []int{2, 3},
+			wantErr:   "price: must be an integer",
+			wantCount: 3,
+		},
+		{name: "MissingColumn", repo: repo, body: "service_name,price,user_id,start_date\nNetflix,500," + userID + ",01-2025\n", wantCode: 400, wantErr: `columns: not found in header: end_date ("end_date")`, wantCount: 3},
+		{name: "HeaderOnly", repo: repo, body: "service_name,price,user_id,start_date,end_date\n", wantCode: 400, wantErr: "empty CSV: no data rows", wantCount: 3},
+		{name: "BadQuery", repo: repo, query: "?dry_run=maybe&columns=cost:Цена&date_format=DD.MM", body: valid, wantCode: 400,
+			wantErr: `dry_run: must be true or false; columns: unknown field "cost", allowed: service_name, price, user_id, start_date, end_date; date_format: must contain YYYY and MM, e.g. MM-YYYY or DD.MM.YYYY`, wantCount: 3},
+		{name: "Timeout", repo: timeoutRepo{}, body: valid, wantCode: http.StatusGatewayTimeout, wantCount: 3},
+		{name: "Internal", repo: internalErrRepo{}, body: valid, wantCode: http.StatusInternalServerError, wantCount: 3},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			h := newHandler(tc.repo)
 			w := httptest.NewRecorder()
-			r := httptest.NewRequest(http.MethodGet, "/v1/subscriptions", nil)
-
-			h.List(w, r)
+			r := httptest.NewRequest(http.MethodPost, "/v1/subscriptions/import"+tc.query, strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", "text/csv")
+			newHandler(tc.repo).Import(w, r)
 
 			if w.Code != tc.wantCode {
 				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
 			}
-			if tc.wantCode == http.StatusOK {
-				var resp ListResponse
-				_ = json.Unmarshal(w.Body.Bytes(), &resp)
-				if len(resp.Subs) != tc.wantLen {
-					t.Fatalf("want %d items, got %d", tc.wantLen, len(resp.Subs))
+			if got := count(); got != tc.wantCount {
+				t.Fatalf("want %d subscriptions after import, got %d", tc.wantCount, got)
+			}
+			if tc.wantLines == nil {
+				if tc.wantErr != "" {
+					if got := readErrorStr(t, w.Body.Bytes()); got != tc.wantErr {
+						t.Fatalf("error: want %q, got %q", tc.wantErr, got)
+					}
 				}
+				return
+			}
+
+			var resp ImportResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.Valid != tc.wantValid || resp.Total != tc.wantValid+len(tc.wantLines) {
+				t.Fatalf("want valid=%d total=%d, got %+v", tc.wantValid, tc.wantValid+len(tc.wantLines), resp)
+			}
+			lines := make([]int, len(resp.Errors))
+			for i, e := range resp.Errors {
+				lines[i] = e.Line
+			}
+			if fmt.Sprint(lines) != fmt.Sprint(tc.wantLines) {
+				t.Fatalf("error lines: want %v, got %v (%+v)", tc.wantLines, lines, resp.Errors)
+			}
+			if tc.wantErr != "" && !strings.HasPrefix(resp.Errors[0].Error, tc.wantErr) {
+				t.Fatalf("row error: want prefix %q, got %q", tc.wantErr, resp.Errors[0].Error)
+			}
+			if wantImported := tc.wantValid; tc.wantCode == 200 && !resp.DryRun && resp.Imported != wantImported {
+				t.Fatalf("imported: want %d, got %d", wantImported, resp.Imported)
 			}
 		})
 	}
 }
 
-// ---------- TOTALCOST ----------
-
-func TestTotalCost_Various(t *testing.T) {
-	userID := uuid.NewString()
+// ---------- SEARCH ----------
 
-	okRepo := mockrepo.NewMockRepo()
-	// подходящие
-	_, _ = okRepo.AddSub(context.Background(), domain.Subscription{
-		ServiceName: "Yandex Plus", Price: 400, UserID: userID,
-		StartDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
-		EndDate:   time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC),
-	})
-	_, _ = okRepo.AddSub(context.Background(), domain.Subscription{
-		ServiceName: "Yandex Plus", Price: 300, UserID: userID,
-		StartDate: time.Date(2025, 7, 15, 0, 0, 0, 0, time.UTC),
-		EndDate:   time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC),
-	})
+func TestSearch(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	userID, otherID := addUser(repo), addUser(repo)
+	add := func(user, name, notes string, tags ...string) string {
+		req := CreateRequest{ServiceName: name, Price: 100, UserID: user, StartDate: ym(1, 2025), EndDate: ym(12, 2025), Notes: notes, Tags: tags}
+		sub, err := repo.AddSub(context.Background(), MapCreateReqToDomain(req))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sub.ID
+	}
+	netflix := add(userID, "Netflix", "Семейная подписка, <b>делим</b> с родителями", "Video", "family")
+	spotify := add(userID, "Spotify", "", "music")
+	yandex := add(otherID, "Yandex Music", "", "music")
+	add(userID, "Okko", "кино по выходным")
+	// у обеих метка music совпадает полностью: при равной оценке порядок по id
+	musicIDs := []string{spotify, yandex}
+	slices.Sort(musicIDs)
 
 	cases := []struct {
-		name       string
-		repo       domain.SubscriptionRepository
-		query      string
-		wantCode   int
-		wantInBody string
+		name          string
+		repo          domain.SubscriptionRepository
+		query         string
+		wantCode      int
+		wantIDs       []string
+		wantHighlight SearchHighlights // первого результата
 	}{
 		{
-			name:     "OK",
-			repo:     okRepo,
-			query:    "user_id=" + userID + "&service_name=Yandex%20Plus&from=07-2025&to=08-2025",
-			wantCode: http.StatusOK,
+			name: "TypoInServiceName", repo: repo, query: "?q=netflx",
+			wantCode: 200, wantIDs: []string{netflix},
+			wantHighlight: SearchHighlights{ServiceName: "<mark>Netflix</mark>"},
 		},
 		{
-			name:       "BadUserID",
-			repo:       okRepo,
-			query:      "user_id=bad-guid&service_name=Yandex%20Plus&from=07-2025&to=08-2025",
-			wantCode:   http.StatusBadRequest,
-			wantInBody: "user_id",
+			name: "NotesWithTypo_Escaped", repo: repo, query: "?q=семеная",
+			wantCode: 200, wantIDs: []string{netflix},
+			wantHighlight: SearchHighlights{Notes: "<mark>Семейная</mark> подписка, &lt;b&gt;делим&lt;/b&gt; с родителями"},
 		},
 		{
-			name:       "BadFromFormat",
-			repo:       okRepo,
-			query:      "user_id=" + userID + "&service_name=Yandex%20Plus&from=bad&to=08-2025",
-			wantCode:   http.StatusBadRequest,
-			wantInBody: "from",
+			name: "Tag_RankedByScore", repo: repo, query: "?q=music",
+			wantCode: 200, wantIDs: musicIDs,
+			wantHighlight: SearchHighlights{Tags: []string{"<mark>music</mark>"}},
 		},
+		{name: "UserFilter", repo: repo, query: "?q=music&user_id=" + otherID, wantCode: 200, wantIDs: []string{yandex}},
+		{name: "AllTermsMustMatch", repo: repo, query: "?q=netflix+music", wantCode: 200, wantIDs: []string{}},
+		{name: "Prefix", repo: repo, query: "?q=spot&limit=1", wantCode: 200, wantIDs: []string{spotify}, wantHighlight: SearchHighlights{ServiceName: "<mark>Spotify</mark>"}},
+		{name: "NoMatch", repo: repo, query: "?q=zzzz", wantCode: 200, wantIDs: []string{}},
+		{name: "MissingQ", repo: repo, query: "", wantCode: 400},
+		{name: "OnlyPunctuation", repo: repo, query: "?q=%2B%2B", wantCode: 400},
+		{name: "BadLimit", repo: repo, query: "?q=a&limit=1000", wantCode: 400},
+		{name: "Timeout", repo: timeoutRepo{}, query: "?q=netflix", wantCode: http.StatusGatewayTimeout},
+		{name: "Internal", repo: internalErrRepo{}, query: "?q=netflix", wantCode: http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/v1/subscriptions/search"+tc.query, nil)
+			newHandler(tc.repo).Search(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
+			}
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+			var resp SearchResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			ids := make([]string, len(resp.Results))
+			for i, res := range resp.Results {
+				ids[i] = res.Subscription.ID
+				if res.Score <= 0 || res.Score > 1 || (i > 0 && res.Score > resp.Results[i-1].Score) {
+					t.Fatalf("scores must be in (0,1] and non-increasing: %+v", resp.Results)
+				}
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tc.wantIDs) {
+				t.Fatalf("ids: want %v, got %v", tc.wantIDs, ids)
+			}
+			if len(resp.Results) == 0 {
+				return
+			}
+			got := resp.Results[0].Highlights
+			want := tc.wantHighlight
+			if want.ServiceName != "" && got.ServiceName != want.ServiceName {
+				t.Fatalf("service_name highlight: want %q, got %q", want.ServiceName, got.ServiceName)
+			}
+			if want.Notes != "" && got.Notes != want.Notes {
+				t.Fatalf("notes highlight: want %q, got %q", want.Notes, got.Notes)
+			}
+			if want.Tags != nil && fmt.Sprint(got.Tags) != fmt.Sprint(want.Tags) {
+				t.Fatalf("tags highlight: want %v, got %v", want.Tags, got.Tags)
+			}
+		})
+	}
+
+	t.Run("TagsNormalized", func(t *testing.T) {
+		sub, _ := repo.GetSub(context.Background(), netflix)
+		if fmt.Sprint(sub.Tags) != "[video family]" {
+			t.Fatalf("tags must be lowercased, got %v", sub.Tags)
+		}
+	})
+}
+
+func TestNotesSnippet(t *testing.T) {
+	notes := strings.Repeat("бла ", 100) + "netflix " + strings.Repeat("бла ", 100)
+	got := notesSnippet(notes, []string{"netflix"})
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "netflix") {
+		t.Fatalf("snippet must be cut around the match, got %q", got)
+	}
+	if n := utf8.RuneCountInString(strings.Trim(got, "…")); n != notesSnippetLen {
+		t.Fatalf("snippet length: want %d, got %d", notesSnippetLen, n)
+	}
+}
+
+// ---------- EXPORT ----------
+
+// slowStreamRepo отдаёт подписки с паузой перед каждой, как медленный запрос к базе
+type slowStreamRepo struct {
+	*mockrepo.Repo
+	delay time.Duration
+}
+
+func (r slowStreamRepo) StreamSubs(ctx context.Context, f domain.SubscriptionFilter, fn func(domain.Subscription) error) error {
+	return r.Repo.StreamSubs(ctx, f, func(s domain.Subscription) error {
+		time.Sleep(r.delay)
+		return fn(s)
+	})
+}
+
+// brokenStreamRepo отдаёт одну подписку и падает — ответ уже начат
+type brokenStreamRepo struct{ *mockrepo.Repo }
+
+func (r brokenStreamRepo) StreamSubs(ctx context.Context, f domain.SubscriptionFilter, fn func(domain.Subscription) error) error {
+	if err := fn(domain.Subscription{ID: uuid.NewString()}); err != nil {
+		return err
+	}
+	return errInternal
+}
+
+func TestExport(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	userID := addUser(repo)
+	for _, s := range []domain.Subscription{
+		{ServiceName: "Netflix", Price: 500, StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{ServiceName: "Spotify", Price: 300, StartDate: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		s.UserID = userID
+		if _, err := repo.AddSub(context.Background(), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name      string
+		repo      domain.SubscriptionRepository
+		query     string
+		wantCode  int
+		wantType  string
+		wantFile  string
+		wantLines []string // ожидаемые строки; для CSV первая — заголовок
+	}{
 		{
-			name:       "BadToFormat",
-			repo:       okRepo,
-			query:      "user_id=" + userID + "&service_name=Yandex%20Plus&from=07-2025&to=bad",
-			wantCode:   http.StatusBadRequest,
-			wantInBody: "to",
+			name: "CSV_Default", repo: repo, query: "?sort=price",
+			wantCode: 200, wantType: "text/csv; charset=utf-8", wantFile: "subscriptions-",
+			wantLines: []string{
+				"id,service_name,price,user_id,start_date,end_date,created_at,updated_at,created_by,updated_by,version",
+				",Spotify,300," + userID + ",02-2025,12-2025,",
+				",Netflix,500," + userID + ",01-2025,03-2025,",
+			},
 		},
 		{
-			name:       "StartAfterEnd",
-			repo:       okRepo,
-			query:      "user_id=" + userID + "&service_name=Yandex%20Plus&from=09-2025&to=08-2025",
-			wantCode:   http.StatusBadRequest,
-			wantInBody: "date range",
+			name: "NDJSON_Filtered", repo: repo, query: "?format=ndjson&service_name=Netflix",
+			wantCode: 200, wantType: "application/x-ndjson", wantFile: ".ndjson",
+			wantLines: []string{`"service_name":"Netflix","price":500`},
 		},
 		{
-			name:       "Timeout",
-			repo:       timeoutRepo{},
-			query:      "user_id=" + userID + "&service_name=Yandex%20Plus&from=07-2025&to=08-2025",
-			wantCode:   http.StatusGatewayTimeout,
-			wantInBody: "timed out",
+			name: "Charges_InPeriod", repo: repo, query: "?kind=charges&from=03-2025&to=04-2025&sort=price",
+			wantCode: 200, wantType: "text/csv; charset=utf-8", wantFile: "charges-",
+			wantLines: []string{
+				"subscription_id,user_id,service_name,month,amount",
+				"Spotify,03-2025,300", "Spotify,04-2025,300", "Netflix,03-2025,500",
+			},
 		},
 		{
-			name:     "Internal",
-			repo:     internalErrRepo{},
-			query:    "user_id=" + userID + "&service_name=Yandex%20Plus&from=07-2025&to=08-2025",
-			wantCode: http.StatusInternalServerError,
+			name: "Empty_HeaderOnly", repo: repo, query: "?service_name=Okko",
+			wantCode: 200, wantType: "text/csv; charset=utf-8",
+			wantLines: []string{"id,service_name,price,user_id,start_date,end_date,created_at,updated_at,created_by,updated_by,version"},
 		},
+		{name: "BadParams", repo: repo, query: "?format=xml&kind=users&price_min=-1", wantCode: 400},
+		{name: "Timeout", repo: timeoutRepo{}, wantCode: http.StatusGatewayTimeout},
+		{name: "Internal", repo: internalErrRepo{}, wantCode: http.StatusInternalServerError},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			h := newHandler(tc.repo)
-
 			w := httptest.NewRecorder()
-			r := httptest.NewRequest(http.MethodGet, "/v1/subscriptions/totalcost?"+tc.query, nil)
-
-			h.TotalCost(w, r)
+			r := httptest.NewRequest(http.MethodGet, "/v1/subscriptions/export"+tc.query, nil)
+			newHandler(tc.repo).Export(w, r)
 
 			if w.Code != tc.wantCode {
 				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
 			}
-			if tc.wantInBody != "" && !strings.Contains(w.Body.String(), tc.wantInBody) {
-				t.Fatalf("body should contain %q, got %s", tc.wantInBody, w.Body.String())
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tc.wantType {
+				t.Fatalf("Content-Type: want %q, got %q", tc.wantType, ct)
+			}
+			if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment; filename=") || !strings.Contains(cd, tc.wantFile) {
+				t.Fatalf("Content-Disposition: want %q, got %q", tc.wantFile, cd)
+			}
+			lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+			if len(lines) != len(tc.wantLines) {
+				t.Fatalf("want %d lines, got %d:\n%s", len(tc.wantLines), len(lines), w.Body.String())
+			}
+			for i, want := range tc.wantLines {
+				if !strings.Contains(lines[i], want) {
+					t.Fatalf("line %d: want %q in %q", i, want, lines[i])
+				}
 			}
 		})
 	}
+
+	t.Run("InterruptedAfterStart", func(t *testing.T) {
+		defer func() {
+			if v := recover(); v != http.ErrAbortHandler {
+				t.Fatalf("want panic(http.ErrAbortHandler) to drop the connection, got %v", v)
+			}
+		}()
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/v1/subscriptions/export", nil)
+		newHandler(brokenStreamRepo{repo}).Export(w, r)
+	})
+
+	t.Run("ClientGone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/v1/subscriptions/export", nil).WithContext(ctx)
+		newHandler(repo).Export(w, r)
+		if strings.Contains(w.Body.String(), "Netflix") {
+			t.Fatalf("canceled export must not write rows, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("SlowStream", func(t *testing.T) {
+		// вся выгрузка дольше ExportGrace и WriteTimeout сервера, но пауза между подписками короче:
+		// дедлайн продлевается на каждой прочитанной подписке, а не только при отправке записей
+		slow := mockrepo.NewMockRepo()
+		owner := addUser(slow)
+		for i := range 5 {
+			_, _ = slow.AddSub(context.Background(), domain.Subscription{ServiceName: fmt.Sprintf("Service %d", i), Price: 100, UserID: owner,
+				StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)})
+		}
+		h := newHandler(slowStreamRepo{slow, 50 * time.Millisecond})
+		h.ExportGrace = 150 * time.Millisecond
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(h.Export))
+		srv.Config.WriteTimeout = 50 * time.Millisecond
+		srv.Start()
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/v1/subscriptions/export")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if lines := strings.Split(strings.TrimSpace(string(body)), "\n"); len(lines) != 6 {
+			t.Fatalf("want header and 5 rows, got %q", body)
+		}
+	})
+}
+
+// ---------- BATCH ----------
+
+func TestBatch(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	userID := addUser(repo)
+	existing, _ := repo.AddSub(context.Background(), domain.Subscription{
+		ServiceName: "Spotify", Price: 300, UserID: userID,
+		StartDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+	})
+	data := func(name string, price int) CreateRequest {
+		return CreateRequest{ServiceName: name, Price: price, UserID: userID, StartDate: ym(1, 2025), EndDate: ym(12, 2025)}
+	}
+	count := func() int {
+		page, _ := repo.ListSubs(context.Background(), domain.SubscriptionFilter{})
+		return len(page.Subs)
+	}
+
+	cases := []struct {
+		name        string
+		repo        domain.SubscriptionRepository
+		req         BatchRequest
+		wantCode    int
+		wantStatus  []int // коды по операциям
+		wantApplied bool
+		wantCount   int // подписок после запроса
+	}{
+		{
+			name: "Atomic_RollbackOnNotFound",
+			repo: repo,
+			req: BatchRequest{Mode: BatchAtomic, Operations: []BatchOperation{
+				{Op: "create", Data: data("Netflix", 500)},
+				{Op: "update", ID: uuid.NewString(), Data: data("Nope", 1)},
+				{Op: "create", Data: data("Kinopoisk", 300)},
+			}},
+			wantCode: http.StatusNotFound, wantStatus: []int{424, 404, 424}, wantCount: 1,
+		},
+		{
+			name: "Atomic_ValidationRejectsWholeBatch",
+			repo: repo,
+			req: BatchRequest{Mode: BatchAtomic, Operations: []BatchOperation{
+				{Op: "create", Data: data("Netflix", 500)},
+				{Op: "create", Data: data("", 0)},
+				{Op: "upsert"},
+			}},
+			wantCode: http.StatusBadRequest, wantStatus: []int{424, 400, 400}, wantCount: 1,
+		},
+		{
+			name: "Atomic_StaleVersion",
+			repo: repo,
+			req: BatchRequest{Mode: BatchAtomic, Operations: []BatchOperation{
+				{Op: "create", Data: data("Netflix", 500)},
+				{Op: "delete", ID: existing.ID, Version: 99},
+			}},
+			wantCode: http.StatusPreconditionFailed, wantStatus: []int{424, 412}, wantCount: 1,
+		},
+		{
+			name: "BestEffort_Partial",
+			repo: repo,
+			req: BatchRequest{Mode: BatchBestEffort, Operations: []BatchOperation{
+				{Op: "create", Data: data("Netflix", 500)},
+				{Op: "create", Data: func() CreateRequest { d := data("Ghost", 100); d.UserID = uuid.NewString(); return d }()},
+				{Op: "create", Data: data("", 0)},
+				{Op: "update", ID: existing.ID, Version: existing.Version, Data: data("Spotify", 450)},
+				{Op: "delete", ID: uuid.NewString()},
+			}},
+			wantCode: http.StatusOK, wantStatus: []int{200, 400, 400, 200, 404}, wantApplied: true, wantCount: 2,
+		},
+		{
+			name: "Atomic_AllApplied",
+			repo: repo,
+			req: BatchRequest{Mode: BatchAtomic, Operations: []BatchOperation{
+				{Op: "create", Data: data("Kinopoisk", 300)},
+				{Op: "delete", ID: existing.ID},
+			}},
+			wantCode: http.StatusOK, wantStatus: []int{200, 200}, wantApplied: true, wantCount: 2,
+		},
+		{name: "BadMode", repo: repo, req: BatchRequest{Mode: "yolo", Operations: []BatchOperation{{Op: "delete", ID: uuid.NewString()}}}, wantCode: http.StatusBadRequest, wantCount: 2},
+		{name: "Empty", repo: repo, req: BatchRequest{Mode: BatchAtomic}, wantCode: http.StatusBadRequest, wantCount: 2},
+		{name: "Timeout", repo: timeoutRepo{}, req: BatchRequest{Mode: BatchAtomic, Operations: []BatchOperation{{Op: "delete", ID: uuid.NewString()}}}, wantCode: http.StatusGatewayTimeout, wantCount: 2},
+		{name: "Internal", repo: internalErrRepo{}, req: BatchRequest{Mode: BatchAtomic, Operations: []BatchOperation{{Op: "delete", ID: uuid.NewString()}}}, wantCode: http.StatusInternalServerError, wantCount: 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/v1/subscriptions:batch", mustJSON(tc.req))
+			newHandler(tc.repo).Batch(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
+			}
+			if got := count(); got != tc.wantCount {
+				t.Fatalf("want %d subscriptions after batch, got %d", tc.wantCount, got)
+			}
+			if tc.wantStatus == nil {
+				return
+			}
+			var resp BatchResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.Applied != tc.wantApplied {
+				t.Fatalf("applied: want %t, got %t", tc.wantApplied, resp.Applied)
+			}
+			got := make([]int, len(resp.Results))
+			for i, res := range resp.Results {
+				got[i] = res.Status
+				if res.Status == http.StatusOK && res.SubID == "" {
+					t.Fatalf("result %d: subscription_id is empty", i)
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.wantStatus) {
+				t.Fatalf("statuses: want %v, got %v. body=%+v", tc.wantStatus, got, resp.Results)
+			}
+		})
+	}
+}
+
+// ---------- ETAG / IF-MATCH ----------
+
+func TestOptimisticConcurrency(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	h := newHandler(repo)
+	sub, _ := repo.AddSub(context.Background(), domain.Subscription{
+		ServiceName: "Spotify", Price: 300, UserID: addUser(repo),
+		StartDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	do := func(method string, header, value string, body io.Reader, fn http.HandlerFunc) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/v1/subscriptions/"+sub.ID, body)
+		r.SetPathValue("id", sub.ID)
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+		if method == http.MethodPatch {
+			r.Header.Set("Content-Type", MergePatchContentType)
+		}
+		w := httptest.NewRecorder()
+		fn(w, r)
+		return w
+	}
+	put := func(price int) io.Reader {
+		return mustJSON(UpdateRequest{
+			ID: sub.ID, ServiceName: "Spotify", Price: price, UserID: sub.UserID,
+			StartDate: YearMonth(sub.StartDate), EndDate: YearMonth(sub.EndDate),
+		})
+	}
+
+	steps := []struct {
+		name     string
+		method   string
+		header   string
+		value    string
+		body     io.Reader
+		fn       http.HandlerFunc
+		wantCode int
+		wantETag string
+	}{
+		{"Get", http.MethodGet, "", "", nil, h.Get, http.StatusOK, `"1"`},
+		{"Get_NotModified", http.MethodGet, "If-None-Match", `"1"`, nil, h.Get, http.StatusNotModified, `"1"`},
+		{"Get_NotModifiedWeak", http.MethodGet, "If-None-Match", `W/"1"`, nil, h.Get, http.StatusNotModified, `"1"`},
+		{"Get_Stale", http.MethodGet, "If-None-Match", `"0"`, nil, h.Get, http.StatusOK, `"1"`},
+		{"Put_ZeroNeverMatches", http.MethodPut, "If-Match", `"0"`, put(400), h.Update, http.StatusPreconditionFailed, ""},
+		{"Patch_NegativeNeverMatches", http.MethodPatch, "If-Match", `"-1"`, strings.NewReader(`{"price": 1}`), h.Patch, http.StatusPreconditionFailed, ""},
+		{"Delete_ZeroNeverMatches", http.MethodDelete, "If-Match", `"0"`, nil, h.Delete, http.StatusPreconditionFailed, ""},
+		{"Put_Match", http.MethodPut, "If-Match", `"1"`, put(400), h.Update, http.StatusOK, `"2"`},
+		{"Put_Stale", http.MethodPut, "If-Match", `"1"`, put(500), h.Update, http.StatusPreconditionFailed, ""},
+		{"Patch_Stale", http.MethodPatch, "If-Match", `"1"`, strings.NewReader(`{"price": 1}`), h.Patch, http.StatusPreconditionFailed, ""},
+		{"Patch_Match", http.MethodPatch, "If-Match", `"2"`, strings.NewReader(`{"price": 600}`), h.Patch, http.StatusOK, `"3"`},
+		{"Put_Unconditional", http.MethodPut, "", "", put(700), h.Update, http.StatusOK, `"4"`},
+		{"Delete_WeakNeverMatches", http.MethodDelete, "If-Match", `W/"4"`, nil, h.Delete, http.StatusPreconditionFailed, ""},
+		{"Delete_ListNoMatch", http.MethodDelete, "If-Match", `"1", "2"`, nil, h.Delete, http.StatusPreconditionFailed, ""},
+		{"Delete_ListMatch", http.MethodDelete, "If-Match", `"3", "4"`, nil, h.Delete, http.StatusOK, ""},
+		{"Delete_Gone", http.MethodDelete, "If-Match", "*", nil, h.Delete, http.StatusNotFound, ""},
+	}
+	for _, st := range steps {
+		w := do(st.method, st.header, st.value, st.body, st.fn)
+		if w.Code != st.wantCode {
+			t.Fatalf("%s: want %d, got %d. body=%s", st.name, st.wantCode, w.Code, w.Body.String())
+		}
+		if st.wantETag != "" && w.Header().Get("ETag") != st.wantETag {
+			t.Fatalf("%s: want ETag %s, got %q", st.name, st.wantETag, w.Header().Get("ETag"))
+		}
+	}
+}
+
+// ---------- AUDIT FIELDS ----------
+
+func TestAuditFields(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	h := newHandler(repo)
+	userID := addUser(repo)
+
+	// создание от имени alice
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v1/subscriptions", mustJSON(CreateRequest{
+		ServiceName: "Netflix", Price: 500, UserID: userID, StartDate: ym(7, 2025), EndDate: ym(7, 2026),
+	}))
+	h.Create(w, r.WithContext(domain.WithActor(r.Context(), "alice")))
+	if w.Code != http.StatusOK {
+		t.Fatalf("create: want 200, got %d. body=%s", w.Code, w.Body.String())
+	}
+	var created CUDResponse
+	_ = json.NewDecoder(w.Body).Decode(&created)
+	before, _ := repo.GetSub(context.Background(), created.SubID)
+
+	// обновление от имени bob
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPut, "/v1/subscriptions/"+created.SubID, mustJSON(UpdateRequest{
+		ID: created.SubID, ServiceName: "Netflix", Price: 700, UserID: userID, StartDate: ym(7, 2025), EndDate: ym(7, 2026),
+	}))
+	r.SetPathValue("id", created.SubID)
+	h.Update(w, r.WithContext(domain.WithActor(r.Context(), "bob")))
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: want 200, got %d. body=%s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/v1/subscriptions/"+created.SubID, nil)
+	r.SetPathValue("id", created.SubID)
+	h.Get(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("get: want 200, got %d. body=%s", w.Code, w.Body.String())
+	}
+	var dto SubscriptionDTO
+	if err := json.NewDecoder(w.Body).Decode(&dto); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if dto.ID != created.SubID {
+		t.Fatalf("id: want %s, got %q", created.SubID, dto.ID)
+	}
+	if dto.CreatedBy != "alice" || dto.UpdatedBy != "bob" {
+		t.Fatalf("want created_by=alice updated_by=bob, got %q/%q", dto.CreatedBy, dto.UpdatedBy)
+	}
+	if _, err := time.Parse(time.RFC3339, dto.CreatedAt); err != nil {
+		t.Fatalf("created_at: %v", err)
+	}
+	after, _ := repo.GetSub(context.Background(), created.SubID)
+	if !after.CreatedAt.Equal(before.CreatedAt) {
+		t.Fatalf("created_at must not change on update")
+	}
+	if !after.UpdatedAt.After(before.UpdatedAt) {
+		t.Fatalf("updated_at must advance on update: %v -> %v", before.UpdatedAt, after.UpdatedAt)
+	}
+}
+
+// ---------- DELETE ----------
+
+func TestDelete_Various(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	sub, _ := repo.AddSub(context.Background(), domain.Subscription{
+		ServiceName: "YouTube", Price: 199, UserID: addUser(repo),
+		StartDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	cases := []struct {
+		name     string
+		repo     domain.SubscriptionRepository
+		id       string
+		wantCode int
+	}{
+		{"OK", repo, sub.ID, http.StatusOK},
+		{"BadGUID", repo, "bad-guid", http.StatusBadRequest},
+		{"NotFound", repo, uuid.NewString(), http.StatusNotFound},
+		{"Timeout", timeoutRepo{}, uuid.NewString(), http.StatusGatewayTimeout},
+		{"Internal", internalErrRepo{}, uuid.NewString(), http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newHandler(tc.repo)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodDelete, "/v1/subscriptions/"+tc.id, nil)
+			r.SetPathValue("id", tc.id)
+
+			h.Delete(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// ---------- LIST ----------
+
+func TestList_Various(t *testing.T) {
+	okRepo := mockrepo.NewMockRepo()
+	_, _ = okRepo.AddSub(context.Background(), domain.Subscription{
+		ServiceName: "A", Price: 1, UserID: addUser(okRepo),
+		StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+	})
+	_, _ = okRepo.AddSub(context.Background(), domain.Subscription{
+		ServiceName: "B", Price: 2, UserID: addUser(okRepo),
+		StartDate: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	cases := []struct {
+		name     string
+		repo     domain.SubscriptionRepository
+		wantCode int
+		wantLen  int // expected number of items (if 200)
+	}{
+		{"OK", okRepo, http.StatusOK, 2},
+		{"Timeout", timeoutRepo{}, http.StatusGatewayTimeout, 0},
+		{"Internal", internalErrRepo{}, http.StatusInternalServerError, 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newHandler(tc.repo)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/v1/subscriptions", nil)
+
+			h.List(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
+			}
+			if tc.wantCode == http.StatusOK {
+				var resp ListResponse
+				_ = json.Unmarshal(w.Body.Bytes(), &resp)
+				if len(resp.Subs) != tc.wantLen {
+					t.Fatalf("want %d items, got %d", tc.wantLen, len(resp.Subs))
+				}
+			}
+		})
+	}
+}
+
+func TestList_FiltersSortPagination(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	alice, bob := addUser(repo), addUser(repo)
+	seed := []struct {
+		user    string
+		service string
+		price   int
+		from    YearMonth
+		to      YearMonth
+	}{
+		{alice, "Netflix", 500, ym(1, 2025), ym(12, 2025)},
+		{alice, "Spotify", 300, ym(3, 2025), ym(5, 2025)},
+		{alice, "YouTube", 200, ym(6, 2025), ym(9, 2025)},
+		{bob, "Netflix", 700, ym(2, 2025), ym(4, 2025)},
+		{bob, "Okko", 100, ym(1, 2026), ym(6, 2026)},
+	}
+	for _, x := range seed {
+		_, _ = repo.AddSub(context.Background(), domain.Subscription{
+			ServiceName: x.service, Price: x.price, UserID: x.user,
+			StartDate: x.from.ToTime(), EndDate: x.to.ToTime(),
+		})
+	}
+
+	list := func(t *testing.T, query string) (int, ListResponse, string) {
+		t.Helper()
+		h := newHandler(repo)
+		w := httptest.NewRecorder()
+		h.List(w, httptest.NewRequest(http.MethodGet, "/v1/subscriptions?"+query, nil))
+		var resp ListResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp, w.Body.String()
+	}
+	prices := func(subs []SubscriptionDTO) []int {
+		out := make([]int, 0, len(subs))
+		for _, s := range subs {
+			out = append(out, s.Price)
+		}
+		return out
+	}
+
+	filters := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{"ByUser", "user_id=" + bob + "&sort=price", []int{100, 700}},
+		{"ByService", "service_name=Netflix&sort=price", []int{500, 700}},
+		{"ActiveAt", "active_at=04-2025&sort=price", []int{300, 500, 700}},
+		{"PriceRange", "price_min=200&price_max=500&sort=price", []int{200, 300, 500}},
+		{"Period", "from=07-2025&to=03-2026&sort=price", []int{100, 200, 500}},
+		{"SortDesc", "sort=-price", []int{700, 500, 300, 200, 100}},
+		{"SortMulti", "sort=service_name,-price", []int{700, 500, 100, 300, 200}},
+		{"DefaultSortStartDate", "", []int{500, 700, 300, 200, 100}},
+	}
+	for _, tc := range filters {
+		t.Run(tc.name, func(t *testing.T) {
+			code, resp, body := list(t, tc.query)
+			if code != http.StatusOK {
+				t.Fatalf("want 200, got %d. body=%s", code, body)
+			}
+			if got := prices(resp.Subs); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Fatalf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("Pagination", func(t *testing.T) {
+		var got []int
+		query := "limit=2&sort=-price"
+		for pages := 0; ; pages++ {
+			if pages > 5 {
+				t.Fatal("pagination does not terminate")
+			}
+			code, resp, body := list(t, query)
+			if code != http.StatusOK {
+				t.Fatalf("want 200, got %d. body=%s", code, body)
+			}
+			got = append(got, prices(resp.Subs)...)
+			if resp.NextCursor == "" {
+				break
+			}
+			query = "limit=2&sort=-price&cursor=" + resp.NextCursor
+		}
+		if fmt.Sprint(got) != fmt.Sprint([]int{700, 500, 300, 200, 100}) {
+			t.Fatalf("pages joined: got %v", got)
+		}
+	})
+	t.Run("DefaultLimit", func(t *testing.T) {
+		// без limit и cursor — весь список, как до пагинации; продолжение по курсору без limit — страница по умолчанию
+		f, err := ParseListQuery(url.Values{})
+		if err != nil || f.Limit != 0 {
+			t.Fatalf("no limit and cursor: want unlimited, got limit=%d err=%v", f.Limit, err)
+		}
+		cursor := EncodeCursor(domain.Subscription{ID: uuid.NewString()}, f.OrderBy())
+		f, err = ParseListQuery(url.Values{"cursor": {cursor}})
+		if err != nil || f.Limit != DefaultListLimit {
+			t.Fatalf("cursor without limit: want limit=%d, got %d err=%v", DefaultListLimit, f.Limit, err)
+		}
+	})
+
+	bad := []struct {
+		name       string
+		query      string
+		wantInBody string
+	}{
+		{"BadUser", "user_id=nope", "user_id"},
+		{"BadActiveAt", "active_at=2025-01", "active_at"},
+		{"BadPrice", "price_min=-1", "price_min"},
+		{"PriceRange", "price_min=10&price_max=5", "price range"},
+		{"UnknownSortField", "sort=user_id", "sort"},
+		{"BadLimit", "limit=100000", "limit"},
+		{"BadCursor", "cursor=bogus", "cursor"},
+	}
+	for _, tc := range bad {
+		t.Run("Bad_"+tc.name, func(t *testing.T) {
+			code, _, body := list(t, tc.query)
+			if code != http.StatusBadRequest || !strings.Contains(body, tc.wantInBody) {
+				t.Fatalf("want 400 with %q, got %d. body=%s", tc.wantInBody, code, body)
+			}
+		})
+	}
+
+	t.Run("Bad_CursorOtherSort", func(t *testing.T) {
+		_, resp, _ := list(t, "limit=1&sort=price")
+		code, _, body := list(t, "limit=1&sort=-price&cursor="+resp.NextCursor)
+		if code != http.StatusBadRequest || !strings.Contains(body, "cursor") {
+			t.Fatalf("want 400 for cursor of another sort, got %d. body=%s", code, body)
+		}
+	})
+}
+
+// ---------- TOTALCOST ----------
+
+func TestTotalCost_Various(t *testing.T) {
+	okRepo := mockrepo.NewMockRepo()
+	userID := addUser(okRepo)
+
+	// подходящие
+	_, _ = okRepo.AddSub(context.Background(), domain.Subscription{
+		ServiceName: "Yandex Plus", Price: 400, UserID: userID,
+		StartDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC),
+	})
+	_, _ = okRepo.AddSub(context.Background(), domain.Subscription{
+		ServiceName: "Yandex Plus", Price: 300, UserID: userID,
+		StartDate: time.Date(2025, 7, 15, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC),
+	})
+
+	cases := []struct {
+		name       string
+		repo       domain.SubscriptionRepository
+		query      string
+		wantCode   int
+		wantInBody string
+	}{
+		{
+			name:     "OK",
+			repo:     okRepo,
+			query:    "user_id=" + userID + "&service_name=Yandex%20Plus&from=07-2025&to=08-2025",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:       "BadUserID",
+			repo:       okRepo,
+			query:      "user_id=bad-guid&service_name=Yandex%20Plus&from=07-2025&to=08-2025",
+			wantCode:   http.StatusBadRequest,
+			wantInBody: "user_id",
+		},
+		{
+			name:       "BadFromFormat",
+			repo:       okRepo,
+			query:      "user_id=" + userID + "&service_name=Yandex%20Plus&from=bad&to=08-2025",
+			wantCode:   http.StatusBadRequest,
+			wantInBody: "from",
+		},
+		{
+			name:       "BadToFormat",
+			repo:       okRepo,
+			query:      "user_id=" + userID + "&service_name=Yandex%20Plus&from=07-2025&to=bad",
+			wantCode:   http.StatusBadRequest,
+			wantInBody: "to",
+		},
+		{
+			name:       "StartAfterEnd",
+			repo:       okRepo,
+			query:      "user_id=" + userID + "&service_name=Yandex%20Plus&from=09-2025&to=08-2025",
+			wantCode:   http.StatusBadRequest,
+			wantInBody: "date range",
+		},
+		{
+			name:       "Timeout",
+			repo:       timeoutRepo{},
+			query:      "user_id=" + userID + "&service_name=Yandex%20Plus&from=07-2025&to=08-2025",
+			wantCode:   http.StatusGatewayTimeout,
+			wantInBody: "timed out",
+		},
+		{
+			name:     "Internal",
+			repo:     internalErrRepo{},
+			query:    "user_id=" + userID + "&service_name=Yandex%20Plus&from=07-2025&to=08-2025",
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newHandler(tc.repo)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/v1/subscriptions/totalcost?"+tc.query, nil)
+
+			h.TotalCost(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
+			}
+			if tc.wantInBody != "" && !strings.Contains(w.Body.String(), tc.wantInBody) {
+				t.Fatalf("body should contain %q, got %s", tc.wantInBody, w.Body.String())
+			}
+		})
+	}
+}
+
+// ---------- TENANTS ----------
+
+func TestTenantIsolation(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	other, _ := repo.AddTenant(context.Background(), domain.Tenant{Name: "other"})
+	h := newHandler(repo)
+
+	ctxA := domain.WithTenant(context.Background(), domain.DefaultTenantID)
+	ctxB := domain.WithTenant(context.Background(), other.ID)
+
+	userID := addUser(repo)
+	sub, err := repo.AddSub(ctxA, domain.Subscription{
+		ServiceName: "Netflix", Price: 500, UserID: userID,
+		StartDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	do := func(ctx context.Context, method, target string, body any, fn http.HandlerFunc) *httptest.ResponseRecorder {
+		var r *http.Request
+		if body != nil {
+			r = httptest.NewRequest(method, target, mustJSON(body))
+		} else {
+			r = httptest.NewRequest(method, target, nil)
+		}
+		r = r.WithContext(ctx)
+		r.SetPathValue("id", sub.ID)
+		w := httptest.NewRecorder()
+		fn(w, r)
+		return w
+	}
+
+	t.Run("GetOtherTenant", func(t *testing.T) {
+		if w := do(ctxB, http.MethodGet, "/v1/subscriptions/"+sub.ID, nil, h.Get); w.Code != http.StatusNotFound {
+			t.Fatalf("want 404, got %d", w.Code)
+		}
+		if w := do(ctxA, http.MethodGet, "/v1/subscriptions/"+sub.ID, nil, h.Get); w.Code != http.StatusOK {
+			t.Fatalf("owner: want 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("ListOtherTenant", func(t *testing.T) {
+		w := do(ctxB, http.MethodGet, "/v1/subscriptions", nil, h.List)
+		var resp ListResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		if len(resp.Subs) != 0 {
+			t.Fatalf("want 0 items, got %d", len(resp.Subs))
+		}
+	})
+
+	t.Run("TotalCostOtherTenant", func(t *testing.T) {
+		q := "?user_id=" + userID + "&service_name=Netflix&from=07-2025&to=07-2026"
+		w := do(ctxB, http.MethodGet, "/v1/subscriptions/totalcost"+q, nil, h.TotalCost)
+		var resp TotalCostResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.TotalCost != 0 {
+			t.Fatalf("want 0, got %d", resp.TotalCost)
+		}
+	})
+
+	t.Run("UpdateOtherTenant", func(t *testing.T) {
+		req := UpdateRequest{
+			ID: sub.ID, ServiceName: "Hijacked", Price: 1, UserID: userID,
+			StartDate: YearMonth(sub.StartDate), EndDate: YearMonth(sub.EndDate),
+		}
+		if w := do(ctxB, http.MethodPut, "/v1/subscriptions/"+sub.ID, req, h.Update); w.Code != http.StatusNotFound {
+			t.Fatalf("want 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("DeleteOtherTenant", func(t *testing.T) {
+		if w := do(ctxB, http.MethodDelete, "/v1/subscriptions/"+sub.ID, nil, h.Delete); w.Code != http.StatusNotFound {
+			t.Fatalf("want 404, got %d", w.Code)
+		}
+		got, err := repo.GetSub(ctxA, sub.ID)
+		if err != nil || got.ServiceName != "Netflix" {
+			t.Fatalf("owner's subscription changed: %+v, %v", got, err)
+		}
+	})
+}
+
+func TestProblemDetails(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	okUser := addUser(repo)
+	h := newHandler(repo)
+
+	t.Run("ValidationFields", func(t *testing.T) {
+		body := CreateRequest{ServiceName: "A", Price: 1, UserID: okUser, StartDate: ym(7, 2025), EndDate: ym(8, 2025), Tags: []string{"ok", " "}, Notes: strings.Repeat("x", MaxNotesLen+1)}
+		r := httptest.NewRequest(http.MethodPost, "/v1/subscriptions", mustJSON(body))
+		w := httptest.NewRecorder()
+		w.Header().Set(v1.HeaderRequestID, "req-1")
+		h.Create(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("want 400, got %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != v1.ProblemContentType {
+			t.Fatalf("content type = %q", ct)
+		}
+		var p v1.Problem
+		if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+			t.Fatal(err)
+		}
+		if p.Code != v1.CodeValidation || p.Status != 400 || p.RequestID != "req-1" || p.Type == "" {
+			t.Fatalf("unexpected problem: %+v", p)
+		}
+		want := []string{"notes", "tags[1]"}
+		if len(p.Errors) != len(want) {
+			t.Fatalf("errors = %+v", p.Errors)
+		}
+		for i, f := range p.Errors {
+			if f.Field != want[i] || f.Message == "" {
+				t.Fatalf("errors[%d] = %+v, want field %q", i, f, want[i])
+			}
+		}
+	})
+
+	strict := []struct {
+		name string
+		body string
+	}{
+		{"UnknownField", `{"service_name":"A","price":1,"user_id":"` + okUser + `","start_date":"07-2025","colour":"red"}`},
+		{"TrailingData", `{"service_name":"A","price":1,"user_id":"` + okUser + `","start_date":"07-2025"}{}`},
+	}
+	for _, tc := range strict {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/v1/subscriptions", strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+			h.Create(w, r)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("want 400, got %d. body=%s", w.Code, w.Body.String())
+			}
+			var p v1.Problem
+			_ = json.Unmarshal(w.Body.Bytes(), &p)
+			if p.Code != v1.CodeInvalidJSON {
+				t.Fatalf("code = %q, want %q", p.Code, v1.CodeInvalidJSON)
+			}
+		})
+	}
+}
+
+// язык ответа выбирает mw.WithLocale; здесь он задан заголовком ответа напрямую
+func TestLocalizedErrors(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	okUser := addUser(repo)
+	h := newHandler(repo)
+
+	t.Run("Problem", func(t *testing.T) {
+		body := CreateRequest{ServiceName: " ", Price: 1, UserID: okUser, StartDate: ym(7, 2025), EndDate: ym(8, 2025)}
+		r := httptest.NewRequest(http.MethodPost, "/v1/subscriptions", mustJSON(body))
+		w := httptest.NewRecorder()
+		w.Header().Set(v1.HeaderContentLanguage, "ru")
+		h.Create(w, r)
+
+		var p v1.Problem
+		_ = json.Unmarshal(w.Body.Bytes(), &p)
+		if p.Code != v1.CodeValidation || len(p.Errors) != 1 {
+			t.Fatalf("unexpected problem: %+v", p)
+		}
+		if f := p.Errors[0]; f.Field != "service_name" || f.Code != "required" || f.Message != "обязательное поле" {
+			t.Fatalf("errors[0] = %+v", f)
+		}
+		if p.Detail != "service_name: обязательное поле" {
+			t.Fatalf("detail = %q", p.Detail)
+		}
+	})
+
+	t.Run("BatchItems", func(t *testing.T) {
+		req := BatchRequest{Mode: BatchAtomic, Operations: []BatchOperation{
+			{Op: "create", Data: CreateRequest{ServiceName: "A", Price: 1, UserID: okUser, StartDate: ym(7, 2025), EndDate: ym(8, 2025)}},
+			{Op: "create", Data: CreateRequest{ServiceName: "B", Price: 0, UserID: okUser, StartDate: ym(7, 2025), EndDate: ym(8, 2025)}},
+		}}
+		r := httptest.NewRequest(http.MethodPost, "/v1/subscriptions:batch", mustJSON(req))
+		w := httptest.NewRecorder()
+		w.Header().Set(v1.HeaderContentLanguage, "ru")
+		h.Batch(w, r)
+
+		var resp BatchResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		if len(resp.Results) != 2 {
+			t.Fatalf("results = %+v", resp.Results)
+		}
+		if got := resp.Results[0].Error; got != "не применено: пакет откатился" {
+			t.Fatalf("results[0].error = %q", got)
+		}
+		if got := resp.Results[1].Error; got != "price: должно быть больше 0" {
+			t.Fatalf("results[1].error = %q", got)
+		}
+	})
+
+	t.Run("ImportRows", func(t *testing.T) {
+		csv := "service_name,price,user_id,start_date,end_date\nA,abc," + okUser + ",07-2025,\n"
+		r := httptest.NewRequest(http.MethodPost, "/v1/subscriptions/import?dry_run=true", strings.NewReader(csv))
+		w := httptest.NewRecorder()
+		w.Header().Set(v1.HeaderContentLanguage, "ru")
+		h.Import(w, r)
+
+		var resp ImportResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		if len(resp.Errors) != 1 || resp.Errors[0].Error != "price: должно быть целым числом; end_date: обязательное поле (MM-YYYY)" {
+			t.Fatalf("errors = %+v", resp.Errors)
+		}
+	})
+}
+
+// ---------- EVENTS ----------
+
+type publishedEvent struct {
+	event string
+	data  any
+}
+
+// recordingPublisher запоминает опубликованные события
+type recordingPublisher struct{ got []publishedEvent }
+
+func (p *recordingPublisher) Publish(ctx context.Context, event string, data any) error {
+	p.got = append(p.got, publishedEvent{event, data})
+	return nil
+}
+
+func TestEvents(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	userID := addUser(repo)
+	events := &recordingPublisher{}
+	h := newHandler(repo)
+	h.Events = events
+
+	body := CreateRequest{ServiceName: "Netflix", Price: 500, UserID: userID, StartDate: ym(7, 2025), EndDate: ym(6, 2026)}
+	w := httptest.NewRecorder()
+	h.Create(w, httptest.NewRequest(http.MethodPost, "/v1/subscriptions", mustJSON(body)))
+	var created CUDResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/v1/subscriptions/"+created.SubID, mustJSON(UpdateRequest{
+		ID: created.SubID, ServiceName: "Netflix", Price: 700, UserID: userID, StartDate: ym(7, 2025), EndDate: ym(6, 2026),
+	}))
+	r.SetPathValue("id", created.SubID)
+	h.Update(w, r)
+
+	// неудачное изменение события не порождает
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/v1/subscriptions/x", nil)
+	r.SetPathValue("id", uuid.NewString())
+	h.Delete(w, r)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/v1/subscriptions/x", nil)
+	r.SetPathValue("id", created.SubID)
+	h.Delete(w, r)
+
+	// из пакета — только после фиксации транзакции
+	w = httptest.NewRecorder()
+	h.Batch(w, httptest.NewRequest(http.MethodPost, "/v1/subscriptions:batch", mustJSON(BatchRequest{Mode: BatchAtomic,
+		Operations: []BatchOperation{{Op: "create", Data: body}, {Op: "delete", ID: uuid.NewString()}}})))
+	w = httptest.NewRecorder()
+	h.Batch(w, httptest.NewRequest(http.MethodPost, "/v1/subscriptions:batch", mustJSON(BatchRequest{Mode: BatchBestEffort,
+		Operations: []BatchOperation{{Op: "create", Data: body}, {Op: "delete", ID: uuid.NewString()}}})))
+
+	// импорт — событие на каждую строку, пробный прогон ничего не публикует
+	csv := "service_name,price,user_id,start_date,end_date\n" +
+		"Okko,300," + userID + ",01-2025,12-2025\n" +
+		"Kion,200," + userID + ",02-2025,12-2025\n"
+	for _, target := range []string{"/v1/subscriptions/import?dry_run=true", "/v1/subscriptions/import"} {
+		w = httptest.NewRecorder()
+		h.Import(w, httptest.NewRequest(http.MethodPost, target, strings.NewReader(csv)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("import %s: want 200, got %d. body=%s", target, w.Code, w.Body.String())
+		}
+	}
+
+	want := []string{
+		domain.EventSubscriptionCreated,
+		domain.EventSubscriptionUpdated,
+		domain.EventSubscriptionDeleted,
+		domain.EventSubscriptionCreated,
+		domain.EventSubscriptionCreated,
+		domain.EventSubscriptionCreated,
+	}
+	if len(events.got) != len(want) {
+		t.Fatalf("want %d events, got %+v", len(want), events.got)
+	}
+	for i, e := range events.got {
+		if e.event != want[i] {
+			t.Fatalf("event %d: want %s, got %s", i, want[i], e.event)
+		}
+	}
+	if dto, ok := events.got[1].data.(SubscriptionDTO); !ok || dto.Price != 700 || dto.ID != created.SubID {
+		t.Fatalf("updated event data: %+v", events.got[1].data)
+	}
+	if del, ok := events.got[2].data.(DeletedEvent); !ok || del.ID != created.SubID || del.UserID != userID {
+		t.Fatalf("deleted event data: %+v", events.got[2].data)
+	}
+	if dto, ok := events.got[4].data.(SubscriptionDTO); !ok || dto.ID == "" || dto.UserID != userID || dto.Version != 1 {
+		t.Fatalf("imported event data: %+v", events.got[4].data)
+	}
+
+	t.Run("ImportBatch", func(t *testing.T) {
+		// события импорта уходят одной пачкой и не на контексте запроса, который к этому времени может истечь
+		batches := &batchPublisher{}
+		h := newHandler(repo)
+		h.Events = batches
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		w := httptest.NewRecorder()
+		h.Import(w, httptest.NewRequest(http.MethodPost, "/v1/subscriptions/import", strings.NewReader(csv)).WithContext(ctx))
+		if w.Code != http.StatusOK {
+			t.Fatalf("want 200, got %d. body=%s", w.Code, w.Body.String())
+		}
+		if len(batches.sizes) != 1 || batches.sizes[0] != 2 || batches.ctxErr != nil {
+			t.Fatalf("want one live batch of 2 events, got sizes=%v ctx err=%v", batches.sizes, batches.ctxErr)
+		}
+	})
+}
+
+// batchPublisher запоминает размеры пачек и состояние контекста, с которым они пришли
+type batchPublisher struct {
+	recordingPublisher
+	sizes  []int
+	ctxErr error
+}
+
+func (p *batchPublisher) PublishBatch(ctx context.Context, event string, data []any) error {
+	p.sizes, p.ctxErr = append(p.sizes, len(data)), ctx.Err()
+	return nil
+}
+
+// ---------- STREAM (SSE) ----------
+
+type sseFrame struct{ id, event, data string }
+
+// readFrame читает одно событие потока, пропуская комментарии и retry
+func readFrame(t *testing.T, rd *bufio.Reader) sseFrame {
+	t.Helper()
+	var f sseFrame
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && (f.event != "" || f.data != ""):
+			return f
+		case strings.HasPrefix(line, "id: "):
+			f.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			f.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			f.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	userA, userB := addUser(repo), addUser(repo)
+	changes := sse.NewBroker(100)
+	h := newHandler(repo)
+	h.Events, h.Changes, h.Heartbeat = Feed{Broker: changes}, changes, 20*time.Millisecond
+
+	// WriteTimeout меньше времени жизни потока: обработчик сам продлевает дедлайн
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(h.Stream))
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+	defer changes.Close()
+
+	connect := func(query, lastID string) (*bufio.Reader, func()) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/v1/subscriptions/events"+query, nil)
+		if lastID != "" {
+			req.Header.Set("Last-Event-ID", lastID)
+		}
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != sse.ContentType {
+			t.Fatalf("status=%d content-type=%q", resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+		return bufio.NewReader(resp.Body), func() { resp.Body.Close() }
+	}
+	create := func(userID string) string {
+		w := httptest.NewRecorder()
+		h.Create(w, httptest.NewRequest(http.MethodPost, "/v1/subscriptions", mustJSON(CreateRequest{
+			ServiceName: "Netflix", Price: 500, UserID: userID, StartDate: ym(7, 2025), EndDate: ym(6, 2026)})))
+		var resp CUDResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.SubID
+	}
+
+	onlyA, closeA := connect("?user_id="+userA, "")
+	defer closeA()
+	all, closeAll := connect("", "")
+	time.Sleep(250 * time.Millisecond) // дольше WriteTimeout: поток должен остаться живым
+
+	create(userB)
+	idA := create(userA)
+
+	if f := readFrame(t, onlyA); f.event != domain.EventSubscriptionCreated || !strings.Contains(f.data, idA) {
+		t.Fatalf("user_id filter: got %+v", f)
+	}
+	first := readFrame(t, all)
+	second := readFrame(t, all)
+	if !strings.Contains(first.data, userB) || !strings.Contains(second.data, idA) {
+		t.Fatalf("unfiltered stream: %+v, %+v", first, second)
+	}
+	closeAll()
+
+	// удаление, пропущенное отключённым клиентом, дочитывается по Last-Event-ID
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/v1/subscriptions/"+idA, nil)
+	r.SetPathValue("id", idA)
+	h.Delete(w, r)
+	if f := readFrame(t, onlyA); f.event != domain.EventSubscriptionDeleted || !strings.Contains(f.data, `"user_id":"`+userA+`"`) {
+		t.Fatalf("deleted event: %+v", f)
+	}
+
+	resumed, closeResumed := connect("", second.id)
+	defer closeResumed()
+	if f := readFrame(t, resumed); f.event != domain.EventSubscriptionDeleted || f.id == second.id {
+		t.Fatalf("replay: %+v", f)
+	}
+
+	stale, closeStale := connect("", "0-1")
+	defer closeStale()
+	if f := readFrame(t, stale); f.event != EventResync {
+		t.Fatalf("unknown Last-Event-ID: want resync, got %+v", f)
+	}
+}
+
+func TestStream_BadUserID(t *testing.T) {
+	h := newHandler(mockrepo.NewMockRepo())
+	h.Changes = sse.NewBroker(1)
+	w := httptest.NewRecorder()
+	h.Stream(w, httptest.NewRequest(http.MethodGet, "/v1/subscriptions/events?user_id=nope", nil))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "user_id") {
+		t.Fatalf("want 400 for bad user_id, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+// ---------- SYNC ----------
+
+func getSync(t *testing.T, h *Handler, query string) (int, SyncResponse, []byte) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.Sync(w, httptest.NewRequest(http.MethodGet, "/v1/sync?"+query, nil))
+	var resp SyncResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+	return w.Code, resp, w.Body.Bytes()
+}
+
+// syncOps — изменения в виде "op:id[@version]" в порядке ответа
+func syncOps(changes []SyncChangeDTO) []string {
+	out := make([]string, len(changes))
+	for i, c := range changes {
+		out[i] = c.Op + ":" + c.ID
+		if c.Subscription != nil {
+			out[i] += fmt.Sprintf("@%d", c.Subscription.Version)
+		}
+	}
+	return out
+}
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	repo := mockrepo.NewMockRepo()
+	alice, bob := addUser(repo), addUser(repo)
+	add := func(userID, name string) domain.Subscription {
+		s, err := repo.AddSub(ctx, domain.Subscription{ServiceName: name, Price: 100, UserID: userID,
+			StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)})
+		if err != nil {
+			t.Fatalf("add: %v", err)
+		}
+		return s
+	}
+	h := newHandler(repo)
+
+	s1, s2 := add(alice, "Netflix"), add(bob, "Spotify")
+	code, full, body := getSync(t, h, "")
+	if code != http.StatusOK {
+		t.Fatalf("initial: want 200, got %d. body=%s", code, body)
+	}
+	if want := []string{"upsert:" + s1.ID + "@1", "upsert:" + s2.ID + "@1"}; !slices.Equal(syncOps(full.Changes), want) {
+		t.Fatalf("initial: want %v, got %v", want, syncOps(full.Changes))
+	}
+	if full.HasMore || full.Next == "" {
+		t.Fatalf("initial: want next token without more pages, got %+v", full)
+	}
+
+	s1.Price = 150
+	s1, _ = repo.UpdateSub(ctx, s1, s1.Version)
+	_, _ = repo.DeleteSub(ctx, s2.ID, domain.AnyVersion)
+	s3 := add(bob, "Kinopoisk")
+	wantDelta := []string{"upsert:" + s1.ID + "@2", "delete:" + s2.ID, "upsert:" + s3.ID + "@1"}
+
+	t.Run("Delta", func(t *testing.T) {
+		_, delta, _ := getSync(t, h, "since="+full.Next)
+		if !slices.Equal(syncOps(delta.Changes), wantDelta) {
+			t.Fatalf("want %v, got %v", wantDelta, syncOps(delta.Changes))
+		}
+		tomb := delta.Changes[1]
+		if tomb.UserID != bob || tomb.DeletedAt == "" || tomb.Subscription != nil {
+			t.Fatalf("tombstone: want user_id and deleted_at only, got %+v", tomb)
+		}
+		_, empty, _ := getSync(t, h, "since="+delta.Next)
+		if len(empty.Changes) != 0 || empty.HasMore || empty.Next == "" {
+			t.Fatalf("after delta: want no changes and a new token, got %+v", empty)
+		}
+	})
+	t.Run("Pages", func(t *testing.T) {
+		var got []string
+		token := full.Next
+		for range len(wantDelta) + 1 {
+			_, page, _ := getSync(t, h, "limit=1&since="+token)
+			got, token = append(got, syncOps(page.Changes)...), page.Next
+			if !page.HasMore {
+				break
+			}
+		}
+		if !slices.Equal(got, wantDelta) {
+			t.Fatalf("want %v, got %v", wantDelta, got)
+		}
+	})
+	t.Run("UserFilter", func(t *testing.T) {
+		_, delta, _ := getSync(t, h, "user_id="+alice+"&since="+full.Next)
+		if want := []string{"upsert:" + s1.ID + "@2"}; !slices.Equal(syncOps(delta.Changes), want) {
+			t.Fatalf("want %v, got %v", want, syncOps(delta.Changes))
+		}
+	})
+	t.Run("InitialSkipsTombstones", func(t *testing.T) {
+		_, again, _ := getSync(t, h, "")
+		if want := []string{"upsert:" + s1.ID + "@2", "upsert:" + s3.ID + "@1"}; !slices.Equal(syncOps(again.Changes), want) {
+			t.Fatalf("want %v, got %v", want, syncOps(again.Changes))
+		}
+	})
+	t.Run("ExpiredToken", func(t *testing.T) {
+		old := encodeSyncToken(domain.SyncCursor{Seq: 1}, time.Now().Add(-DefaultSyncTokenTTL-time.Hour))
+		code, _, body := getSync(t, h, "since="+old)
+		if code != http.StatusGone {
+			t.Fatalf("want 410, got %d. body=%s", code, body)
+		}
+		var p v1.Problem
+		_ = json.Unmarshal(body, &p)
+		if p.Code != v1.CodeGone {
+			t.Fatalf("want code %q, got %q", v1.CodeGone, p.Code)
+		}
+	})
+
+	for _, tc := range []struct{ name, query string }{
+		{"MalformedToken", "since=not-a-token"},
+		{"BadUserID", "user_id=nope"},
+		{"BadLimit", "limit=0"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if code, _, body := getSync(t, h, tc.query); code != http.StatusBadRequest {
+				t.Fatalf("want 400, got %d. body=%s", code, body)
+			}
+		})
+	}
+}
+
+func TestSyncPush(t *testing.T) {
+	ctx := context.Background()
+	repo := mockrepo.NewMockRepo()
+	userID := addUser(repo)
+	add := func(name string) domain.Subscription {
+		s, _ := repo.AddSub(ctx, domain.Subscription{ServiceName: name, Price: 100, UserID: userID,
+			StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)})
+		return s
+	}
+	data := func(name string, price int) CreateRequest {
+		return CreateRequest{ServiceName: name, Price: price, UserID: userID, StartDate: ym(1, 2025), EndDate: ym(12, 2025)}
+	}
+
+	edited, removed, changed, gone := add("Netflix"), add("Spotify"), add("Okko"), add("Ivi")
+	// пока клиент был офлайн, changed изменили, а gone удалили на сервере
+	changed.Price = 999
+	changed, _ = repo.UpdateSub(ctx, changed, changed.Version)
+	_, _ = repo.DeleteSub(ctx, gone.ID, domain.AnyVersion)
+
+	req := SyncPushRequest{Changes: []BatchOperation{
+		{Op: "create", Data: data("Kinopoisk", 300)},
+		{Op: "update", ID: edited.ID, Version: 1, Data: data("Netflix", 500)},
+		{Op: "update", ID: changed.ID, Version: 1, Data: data("Okko", 200)},
+		{Op: "update", ID: gone.ID, Version: 1, Data: data("Ivi", 200)},
+		{Op: "delete", ID: gone.ID, Version: 1},
+		{Op: "delete", ID: removed.ID, Version: 1},
+		{Op: "delete", ID: changed.ID, Version: 1},
+		{Op: "update", ID: uuid.NewString(), Version: 1, Data: data("Ghost", 1)},
+		{Op: "update", ID: edited.ID, Data: data("Netflix", 700)},
+	}}
+	w := httptest.NewRecorder()
+	newHandler(repo).Push(w, httptest.NewRequest(http.MethodPost, "/v1/sync", mustJSON(req)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d. body=%s", w.Code, w.Body.String())
+	}
+	var resp SyncPushResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	got := make([]string, len(resp.Results))
+	for i, res := range resp.Results {
+		got[i] = fmt.Sprintf("%d %s", res.Status, res.Conflict)
+	}
+	want := []string{
+		"200 ",                 // create
+		"200 ",                 // update на актуальной версии
+		"412 version_mismatch", // подписку изменили на сервере
+		"410 deleted",          // правка удалённой на сервере подписки: удаление побеждает
+		"200 ",                 // повторное удаление безопасно
+		"200 ",                 // delete на актуальной версии
+		"412 version_mismatch", // удаление подписки, изменённой на сервере
+		"404 ",                 // подписки не было
+		"400 ",                 // update без версии
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("results:\nwant %q\ngot  %q", want, got)
+	}
+
+	if cur := resp.Results[2].Current; cur == nil || cur.Version != changed.Version || cur.Price != 999 {
+		t.Fatalf("conflict: want current server copy, got %+v", cur)
+	}
+	if res := resp.Results[3]; res.DeletedAt == "" || res.Error == "" {
+		t.Fatalf("deleted conflict: want deleted_at and error, got %+v", res)
+	}
+	if res := resp.Results[4]; res.DeletedAt == "" || res.Error != "" {
+		t.Fatalf("repeated delete: want deleted_at without error, got %+v", res)
+	}
+	if res := resp.Results[1]; res.Version != 2 {
+		t.Fatalf("update: want version 2, got %d", res.Version)
+	}
+	if s, _ := repo.GetSub(ctx, changed.ID); s.Price != 999 {
+		t.Fatalf("server copy must win, got price %d", s.Price)
+	}
+	if _, err := repo.GetSub(ctx, removed.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("removed: want not found, got %v", err)
+	}
+
+	t.Run("SamePush", func(t *testing.T) {
+		// конфликты внутри одного push считаются по состоянию транзакции, а не по уже зафиксированному
+		deleted, updated := add("Wink"), add("Start")
+		req := SyncPushRequest{Changes: []BatchOperation{
+			{Op: "delete", ID: deleted.ID, Version: 1},
+			{Op: "update", ID: deleted.ID, Version: 1, Data: data("Wink", 200)},
+			{Op: "update", ID: updated.ID, Version: 1, Data: data("Start", 200)},
+			{Op: "update", ID: updated.ID, Version: 1, Data: data("Start", 300)},
+		}}
+		w := httptest.NewRecorder()
+		newHandler(repo).Push(w, httptest.NewRequest(http.MethodPost, "/v1/sync", mustJSON(req)))
+		var resp SyncPushResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if res := resp.Results[1]; res.Status != http.StatusGone || res.Conflict != ConflictDeleted || res.DeletedAt == "" {
+			t.Fatalf("update after delete: want 410 deleted, got %+v", res)
+		}
+		if cur := resp.Results[3].Current; resp.Results[3].Status != http.StatusPreconditionFailed || cur == nil || cur.Version != 2 || cur.Price != 200 {
+			t.Fatalf("second update: want 412 with current from this push, got %+v", resp.Results[3])
+		}
+	})
+	t.Run("Empty", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newHandler(repo).Push(w, httptest.NewRequest(http.MethodPost, "/v1/sync", mustJSON(SyncPushRequest{})))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("want 400, got %d", w.Code)
+		}
+	})
+	t.Run("Timeout", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		body := mustJSON(SyncPushRequest{Changes: []BatchOperation{{Op: "delete", ID: uuid.NewString(), Version: 1}}})
+		newHandler(timeoutRepo{}).Push(w, httptest.NewRequest(http.MethodPost, "/v1/sync", body))
+		if w.Code != http.StatusGatewayTimeout {
+			t.Fatalf("want 504, got %d", w.Code)
+		}
+	})
+}
+
+// ---------- TRASH ----------
+
+func TestTrash(t *testing.T) {
+	ctx := context.Background()
+	repo := mockrepo.NewMockRepo()
+	userID := addUser(repo)
+	add := func(name string, price int) domain.Subscription {
+		s, _ := repo.AddSub(ctx, domain.Subscription{
+			ServiceName: name, Price: price, UserID: userID,
+			StartDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC),
+		})
+		return s
+	}
+	kept := add("Yandex Plus", 400)
+	first := add("Yandex Plus", 300)
+	second := add("Netflix", 700)
+
+	h := newHandler(repo)
+	events := &recordingPublisher{}
+	h.Events = events
+	del := func(id string) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "/v1/subscriptions/"+id, nil)
+		r.SetPathValue("id", id)
+		h.Delete(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("delete: want 200, got %d. body=%s", w.Code, w.Body.String())
+		}
+	}
+	del(first.ID)
+	time.Sleep(10 * time.Millisecond) // deleted_at различается
+	del(second.ID)
+
+	t.Run("HiddenFromQueries", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/v1/subscriptions/"+first.ID, nil)
+		r.SetPathValue("id", first.ID)
+		h.Get(w, r)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("get trashed: want 404, got %d", w.Code)
+		}
+
+		w = httptest.NewRecorder()
+		h.List(w, httptest.NewRequest(http.MethodGet, "/v1/subscriptions", nil))
+		var list ListResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &list)
+		if len(list.Subs) != 1 || list.Subs[0].ID != kept.ID {
+			t.Fatalf("list: want only %s, got %+v", kept.ID, list.Subs)
+		}
+
+		w = httptest.NewRecorder()
+		h.TotalCost(w, httptest.NewRequest(http.MethodGet,
+			"/v1/subscriptions/totalcost?user_id="+userID+"&service_name=Yandex%20Plus&from=07-2025&to=08-2025", nil))
+		var total TotalCostResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &total)
+		if total.TotalCost != 400 {
+			t.Fatalf("total cost: want 400 without trashed, got %d", total.TotalCost)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.Trash(w, httptest.NewRequest(http.MethodGet, "/v1/trash", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("want 200, got %d. body=%s", w.Code, w.Body.String())
+		}
+		var resp ListResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		if len(resp.Subs) != 2 || resp.Subs[0].ID != second.ID || resp.Subs[1].ID != first.ID {
+			t.Fatalf("want recently deleted first, got %+v", resp.Subs)
+		}
+		if resp.Subs[0].DeletedAt == "" {
+			t.Fatal("deleted_at must be set")
+		}
+
+		w = httptest.NewRecorder()
+		h.Trash(w, httptest.NewRequest(http.MethodGet, "/v1/trash?sort=deleted_at&limit=1", nil))
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		if len(resp.Subs) != 1 || resp.Subs[0].ID != first.ID || resp.NextCursor == "" {
+			t.Fatalf("page 1: %+v", resp)
+		}
+		w = httptest.NewRecorder()
+		h.Trash(w, httptest.NewRequest(http.MethodGet, "/v1/trash?sort=deleted_at&limit=1&cursor="+resp.NextCursor, nil))
+		resp = ListResponse{}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		if len(resp.Subs) != 1 || resp.Subs[0].ID != second.ID || resp.NextCursor != "" {
+			t.Fatalf("page 2: %+v", resp)
+		}
+	})
+
+	t.Run("DeletedAtOnlyInTrash", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		h.List(w, httptest.NewRequest(http.MethodGet, "/v1/subscriptions?sort=deleted_at", nil))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("want 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("ListErrors", func(t *testing.T) {
+		for _, tc := range []struct {
+			repo     domain.SubscriptionRepository
+			query    string
+			wantCode int
+		}{
+			{repo, "limit=0", http.StatusBadRequest},
+			{timeoutRepo{}, "", http.StatusGatewayTimeout},
+			{internalErrRepo{}, "", http.StatusInternalServerError},
+		} {
+			w := httptest.NewRecorder()
+			newHandler(tc.repo).Trash(w, httptest.NewRequest(http.MethodGet, "/v1/trash?"+tc.query, nil))
+			if w.Code != tc.wantCode {
+				t.Fatalf("%q: want %d, got %d", tc.query, tc.wantCode, w.Code)
+			}
+		}
+	})
+
+	cases := []struct {
+		name     string
+		repo     domain.SubscriptionRepository
+		id       string
+		wantCode int
+	}{
+		{"OK", repo, first.ID, http.StatusOK},
+		{"AlreadyRestored", repo, first.ID, http.StatusNotFound},
+		{"NotTrashed", repo, kept.ID, http.StatusNotFound},
+		{"BadGUID", repo, "bad-guid", http.StatusBadRequest},
+		{"Timeout", timeoutRepo{}, uuid.NewString(), http.StatusGatewayTimeout},
+		{"Internal", internalErrRepo{}, uuid.NewString(), http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run("Restore"+tc.name, func(t *testing.T) {
+			hh := newHandler(tc.repo)
+			hh.Events = events
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/v1/subscriptions/"+tc.id+"/restore", nil)
+			r.SetPathValue("id", tc.id)
+
+			hh.Restore(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	restored, err := repo.GetSub(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("restored subscription must be visible: %v", err)
+	}
+	if restored.Version != first.Version+1 || !restored.DeletedAt.IsZero() {
+		t.Fatalf("restored: want version %d without deleted_at, got %+v", first.Version+1, restored)
+	}
+	last := events.got[len(events.got)-1]
+	if dto, ok := last.data.(SubscriptionDTO); last.event != domain.EventSubscriptionRestored || !ok || dto.ID != first.ID {
+		t.Fatalf("want restored event, got %+v", last)
+	}
+
+	t.Run("Purge", func(t *testing.T) {
+		if n, err := repo.PurgeTrash(ctx, time.Now().Add(time.Hour)); err != nil || n != 1 {
+			t.Fatalf("purge: want 1, got %d, %v", n, err)
+		}
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/subscriptions/"+second.ID+"/restore", nil)
+		r.SetPathValue("id", second.ID)
+		h.Restore(w, r)
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("restore purged: want 404, got %d", w.Code)
+		}
+		if _, err := repo.GetTombstone(ctx, second.ID); err != nil {
+			t.Fatalf("purged subscription must leave a tombstone: %v", err)
+		}
+	})
 }
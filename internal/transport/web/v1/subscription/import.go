@@ -0,0 +1,383 @@
+package subscription
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/i18n"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+const (
+	MaxImportRows     = 10000
+	DefaultDateFormat = "MM-YYYY"
+
+	// importPublishTimeout — на постановку событий импорта в очередь, отдельно от таймаута запроса
+	importPublishTimeout = 30 * time.Second
+)
+
+// importFields — поля подписки, которые читаются из CSV
+var importFields = []string{"service_name", "price", "user_id", "start_date", "end_date"}
+
+// ImportOptions — параметры разбора CSV
+type ImportOptions struct {
+	DryRun     bool
+	Columns    map[string]string // поле -> заголовок колонки; по умолчанию совпадают
+	DateFormat string            // формат дат для людей, например DD.MM.YYYY
+	layout     string            // он же в нотации time.Parse
+	Delimiter  rune
+}
+
+// ParseImportOptions разбирает query-параметры POST /v1/subscriptions/import:
+// dry_run, columns=поле:заголовок,..., date_format (YYYY, MM, DD) и delimiter
+func ParseImportOptions(q url.Values) (ImportOptions, error) {
+	var errs []v1.FieldError
+	opts := ImportOptions{Columns: make(map[string]string, len(importFields)), DateFormat: DefaultDateFormat, Delimiter: ','}
+	for _, f := range importFields {
+		opts.Columns[f] = f
+	}
+
+	if v := q.Get("dry_run"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, v1.Field("dry_run", "boolean"))
+		}
+		opts.DryRun = b
+	}
+
+	if v := q.Get("columns"); v != "" {
+		for _, pair := range strings.Split(v, ",") {
+			field, header, ok := strings.Cut(pair, ":")
+			field, header = strings.TrimSpace(field), strings.TrimSpace(header)
+			switch {
+			case !ok || header == "":
+				errs = append(errs, v1.Field("columns", "invalid_mapping", "value", strconv.Quote(pair)))
+			case !slices.Contains(importFields, field):
+				errs = append(errs, v1.Field("columns", "unknown_field", "value", strconv.Quote(field), "allowed", strings.Join(importFields, ", ")))
+			default:
+				opts.Columns[field] = header
+			}
+		}
+	}
+
+	if v := q.Get("date_format"); v != "" {
+		opts.DateFormat = v
+	}
+	if !strings.Contains(opts.DateFormat, "YYYY") || !strings.Contains(opts.DateFormat, "MM") {
+		errs = append(errs, v1.Field("date_format", "invalid_date_pattern"))
+	}
+	opts.layout = strings.NewReplacer("YYYY", "2006", "MM", "01", "DD", "02").Replace(opts.DateFormat)
+
+	if v := q.Get("delimiter"); v != "" {
+		r, size := utf8.DecodeRuneInString(v)
+		if size != len(v) || r == '"' || r == '\r' || r == '\n' {
+			errs = append(errs, v1.Field("delimiter", "single_char"))
+		} else {
+			opts.Delimiter = r
+		}
+	}
+
+	return opts, v1.Fields(errs)
+}
+
+// errImportFile — файл нельзя разобрать целиком (нет колонок, битый CSV, слишком много строк);
+// key — сообщение detail.<key> из каталога
+type errImportFile struct {
+	key  string
+	args i18n.Args
+}
+
+func (e errImportFile) Error() string {
+	return v1.Messages.Text(v1.Messages.Default(), "detail."+e.key, e.args)
+}
+
+// importRow — валидная строка файла; Line — как в файле (заголовок — 1)
+type importRow struct {
+	Line int
+	Sub  domain.Subscription
+}
+
+// parseImportCSV читает CSV и проверяет каждую строку по правилам ValidateCreateRequest.
+// Возвращает валидные строки и ошибки невалидных.
+func parseImportCSV(r io.Reader, opts ImportOptions) ([]importRow, []ImportRowError, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = opts.Delimiter
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errImportFile{key: "import_no_header"}
+	}
+	if err != nil {
+		return nil, nil, errImportFile{"import_invalid_csv", i18n.Args{"error": err.Error()}}
+	}
+	idx := make(map[string]int, len(header))
+	for i, h := range header {
+		if i == 0 {
+			h = strings.TrimPrefix(h, "\ufeff") // BOM из Excel
+		}
+		idx[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	col := make(map[string]int, len(importFields))
+	var missing []string
+	for _, f := range importFields {
+		i, ok := idx[strings.ToLower(opts.Columns[f])]
+		if !ok {
+			missing = append(missing, fmt.Sprintf("%s (%q)", f, opts.Columns[f]))
+			continue
+		}
+		col[f] = i
+	}
+	if len(missing) > 0 {
+		return nil, nil, errImportFile{"import_missing_columns", i18n.Args{"columns": strings.Join(missing, ", ")}}
+	}
+
+	var (
+		valid   []importRow
+		rowErrs = []ImportRowError{}
+		rows    int
+	)
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, errImportFile{"import_invalid_csv", i18n.Args{"error": err.Error()}}
+		}
+		if rows++; rows > MaxImportRows {
+			return nil, nil, errImportFile{"import_too_many_rows", i18n.Args{"max": MaxImportRows}}
+		}
+		line, _ := cr.FieldPos(0)
+
+		req, err := importRowToRequest(rec, col, opts)
+		if err != nil {
+			rowErrs = append(rowErrs, ImportRowError{Line: line, Error: err.Error(), err: err})
+			continue
+		}
+		valid = append(valid, importRow{Line: line, Sub: MapCreateReqToDomain(req)})
+	}
+	if rows == 0 {
+		return nil, nil, errImportFile{key: "import_no_rows"}
+	}
+	return valid, rowErrs, nil
+}
+
+// rejectUnknownUsers проверяет user_id всех строк одним запросом UsersByIDs: строки с пользователями,
+// которых нет в тенанте, переходят в ошибки со своим номером. Ошибки остаются упорядоченными по строкам.
+func (h *Handler) rejectUnknownUsers(ctx context.Context, rows []importRow, rowErrs []ImportRowError) ([]importRow, []ImportRowError, error) {
+	if h.Users == nil || len(rows) == 0 {
+		return rows, rowErrs, nil
+	}
+	var ids []string
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		if !seen[row.Sub.UserID] {
+			seen[row.Sub.UserID] = true
+			ids = append(ids, row.Sub.UserID)
+		}
+	}
+	users, err := h.Users.UsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	known := make(map[string]bool, len(users))
+	for _, u := range users {
+		known[u.ID] = true
+	}
+
+	valid := make([]importRow, 0, len(rows))
+	for _, row := range rows {
+		if known[row.Sub.UserID] {
+			valid = append(valid, row)
+			continue
+		}
+		err := v1.NewFieldError("user_id", "unknown_user")
+		rowErrs = append(rowErrs, ImportRowError{Line: row.Line, Error: err.Error(), err: err})
+	}
+	slices.SortFunc(rowErrs, func(a, b ImportRowError) int { return a.Line - b.Line })
+	return valid, rowErrs, nil
+}
+
+// importRowToRequest собирает CreateRequest из строки; ошибки разбора и валидации объединяются
+func importRowToRequest(rec []string, col map[string]int, opts ImportOptions) (CreateRequest, error) {
+	get := func(f string) string {
+		if i := col[f]; i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	var (
+		req      CreateRequest
+		errs     []v1.FieldError
+		badField = map[string]bool{}
+	)
+	req.ServiceName = get("service_name")
+	req.UserID = get("user_id")
+	if v := get("price"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			errs = append(errs, v1.Field("price", "integer"))
+			badField["price"] = true
+		}
+		req.Price = n
+	}
+	parseDate := func(f string) YearMonth {
+		v := get(f)
+		if v == "" {
+			return YearMonth{}
+		}
+		t, err := time.Parse(opts.layout, v)
+		if err != nil {
+			errs = append(errs, v1.Field(f, "invalid_format", "format", opts.DateFormat))
+			badField[f] = true
+			return YearMonth{}
+		}
+		// подписка помесячная: день отбрасываем
+		return YearMonth(time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC))
+	}
+	req.StartDate = parseDate("start_date")
+	req.EndDate = parseDate("end_date")
+
+	// те же правила, что у POST /v1/subscriptions; для полей, которые не разобрались, ошибка уже есть
+	var ve *v1.ValidationError
+	if errors.As(ValidateCreateRequest(req), &ve) {
+		for _, fe := range ve.Fields {
+			if !badField[fe.Field] {
+				errs = append(errs, fe)
+			}
+		}
+	}
+	return req, v1.Fields(errs)
+}
+
+// Import godoc
+// @Summary      Import subscriptions from CSV
+// @Description  Загрузить подписки из CSV (первая строка — заголовок). Каждая строка проверяется по правилам POST /v1/subscriptions, включая существование user_id.
+// @Description  Если есть ошибки, ничего не записывается и возвращается 400 с отчётом; dry_run=true только проверяет файл.
+// @Tags         subscriptions
+// @Accept       text/csv
+// @Produce      json
+// @Param        request          body      string  true   "CSV"
+// @Param        dry_run          query     bool    false  "Только проверить, не записывая"
+// @Param        columns          query     string  false  "Соответствие полей колонкам: service_name:Сервис,price:Цена"
+// @Param        date_format      query     string  false  "Формат дат: YYYY, MM, DD (по умолчанию MM-YYYY)"
+// @Param        delimiter        query     string  false  "Разделитель (по умолчанию ,)"
+// @Param        Idempotency-Key  header    string  false  "Ключ идемпотентности: повтор возвращает сохранённый ответ"
+// @Success      200      {object}  subscription.ImportResponse
+// @Failure      400      {object}  subscription.ImportResponse
+// @Failure      504      {object}  v1.Problem
+// @Failure      500      {object}  v1.Problem
+// @Router       /v1/subscriptions/import [post]
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
+	const op = "subscription.import"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	opts, err := ParseImportOptions(r.URL.Query())
+	if err != nil {
+		logx.Error(h.Log, reqID, op, "invalid query", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+
+	rows, rowErrs, err := parseImportCSV(r.Body, opts)
+	defer r.Body.Close()
+	if err != nil {
+		var fileErr errImportFile
+		if !errors.As(err, &fileErr) {
+			// MaxBytesReader и обрыв соединения
+			fileErr = errImportFile{"import_invalid_body", i18n.Args{"error": err.Error()}}
+		}
+		logx.Error(h.Log, reqID, op, "invalid file", fileErr)
+		v1.WriteProblem(w, v1.Problem{Status: http.StatusBadRequest, Key: fileErr.key, Args: fileErr.args})
+		return
+	}
+
+	// COPY крупного файла дольше обычного запроса
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	// пользователи проверяются и при dry_run: иначе пробный прогон обещал бы импорт, который не пройдёт
+	rows, rowErrs, err = h.rejectUnknownUsers(ctx, rows, rowErrs)
+	if err != nil {
+		if v1.IsTimeout(err) {
+			logx.Error(h.Log, reqID, op, "users lookup timeout", err)
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+			return
+		}
+		logx.Error(h.Log, reqID, op, "users lookup failed", err)
+		v1.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+	for i := range rowErrs {
+		rowErrs[i].Error = v1.Localize(w, rowErrs[i].err)
+	}
+
+	resp := ImportResponse{
+		DryRun: opts.DryRun,
+		Total:  len(rows) + len(rowErrs),
+		Valid:  len(rows),
+		Errors: rowErrs,
+	}
+	if len(rowErrs) > 0 && !opts.DryRun {
+		logx.Info(h.Log, reqID, op, "rejected", "rows", resp.Total, "invalid", len(rowErrs))
+		v1.WriteJSON(w, http.StatusBadRequest, resp)
+		return
+	}
+	if opts.DryRun {
+		logx.Info(h.Log, reqID, op, "dry run", "rows", resp.Total, "invalid", len(rowErrs))
+		v1.WriteJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	subs := make([]domain.Subscription, len(rows))
+	for i, row := range rows {
+		subs[i] = row.Sub
+	}
+	created, err := h.Repo.ImportSubs(ctx, subs)
+	if err != nil {
+		switch {
+		case v1.IsTimeout(err):
+			logx.Error(h.Log, reqID, op, "repo timeout", err)
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+		case errors.Is(err, domain.ErrUserNotFound):
+			// пользователя удалили после проверки
+			logx.Info(h.Log, reqID, op, "unknown user")
+			v1.WriteValidationError(w, v1.NewFieldError("user_id", "unknown_user"))
+		case errors.Is(err, domain.ErrTenantNotFound):
+			logx.Info(h.Log, reqID, op, "tenant not found")
+			v1.WriteMessage(w, http.StatusNotFound, "tenant_not_found")
+		default:
+			logx.Error(h.Log, reqID, op, "import failed", err)
+			v1.WriteError(w, http.StatusInternalServerError, "")
+		}
+		return
+	}
+
+	resp.Imported = len(created)
+	logx.Info(h.Log, reqID, op, "imported", "rows", len(created))
+	v1.WriteJSON(w, http.StatusOK, resp)
+
+	// импорт уже сохранён: события уходят одной пачкой и не зависят от остатка таймаута запроса
+	pubCtx, pubCancel := context.WithTimeout(context.WithoutCancel(ctx), importPublishTimeout)
+	defer pubCancel()
+	data := make([]any, len(created))
+	for i, sub := range created {
+		data[i] = MapDomainToDTO(sub)
+	}
+	h.publishBatch(pubCtx, reqID, op, domain.EventSubscriptionCreated, data)
+}
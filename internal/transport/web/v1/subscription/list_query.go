@@ -0,0 +1,202 @@
+package subscription
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+const (
+	// DefaultListLimit — размер страницы, если передан cursor без limit.
+	// Без limit и cursor список отдаётся целиком, как до появления пагинации.
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+// ParseListQuery разбирает фильтры, сортировку и пагинацию GET /v1/subscriptions
+func ParseListQuery(q url.Values) (domain.SubscriptionFilter, error) {
+	return parseListQuery(q, false)
+}
+
+// ParseTrashQuery — то же для корзины GET /v1/trash: дополнительно можно сортировать по deleted_at,
+// по умолчанию — недавно удалённые первыми
+func ParseTrashQuery(q url.Values) (domain.SubscriptionFilter, error) {
+	return parseListQuery(q, true)
+}
+
+func parseListQuery(q url.Values, trashed bool) (domain.SubscriptionFilter, error) {
+	var errs []v1.FieldError
+	f := domain.SubscriptionFilter{
+		ServiceName: strings.TrimSpace(q.Get("service_name")),
+		Trashed:     trashed,
+	}
+
+	if v := q.Get("user_id"); v != "" {
+		if ValidateGUID(v) != nil {
+			errs = append(errs, guidErr("user_id", v))
+		}
+		f.UserID = v
+	}
+
+	parseYM := func(key string) time.Time {
+		v := q.Get(key)
+		if v == "" {
+			return time.Time{}
+		}
+		ym, err := YMFromStr(v)
+		if err != nil {
+			errs = append(errs, v1.Field(key, "invalid_format", "format", "MM-YYYY"))
+		}
+		return ym.ToTime()
+	}
+	f.ActiveAt = parseYM("active_at")
+	f.From = parseYM("from")
+	f.To = parseYM("to")
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		errs = append(errs, v1.Field("date range", "from_after_to"))
+	}
+
+	parsePrice := func(key string) *int {
+		v := q.Get(key)
+		if v == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			errs = append(errs, v1.Field(key, "non_negative_integer"))
+			return nil
+		}
+		return &n
+	}
+	f.PriceMin = parsePrice("price_min")
+	f.PriceMax = parsePrice("price_max")
+	if f.PriceMin != nil && f.PriceMax != nil && *f.PriceMin > *f.PriceMax {
+		errs = append(errs, v1.Field("price range", "price_min_after_max"))
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxListLimit {
+			errs = append(errs, v1.Field("limit", "between", "min", 1, "max", MaxListLimit))
+		} else {
+			f.Limit = n
+		}
+	}
+
+	allowed := domain.SortableFields
+	if trashed {
+		allowed = domain.TrashSortableFields
+	}
+	sort, sortErr := parseSort(q.Get("sort"), allowed)
+	f.Sort = sort
+
+	var cursorErr error
+	if v := q.Get("cursor"); v != "" && sortErr == nil {
+		f.After, cursorErr = DecodeCursor(v, f.OrderBy())
+	}
+	if f.Limit == 0 && q.Get("cursor") != "" {
+		f.Limit = DefaultListLimit
+	}
+
+	return f, v1.JoinValidation(v1.Fields(errs), sortErr, cursorErr)
+}
+
+// ParseSort разбирает "price,-start_date": поля через запятую, «-» — по убыванию
+func ParseSort(s string) ([]domain.SortField, error) {
+	return parseSort(s, domain.SortableFields)
+}
+
+func parseSort(s string, allowed []string) ([]domain.SortField, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var out []domain.SortField
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		sf := domain.SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if !slices.Contains(allowed, sf.Field) {
+			return nil, v1.NewFieldError("sort", "unknown_field", "value", strconv.Quote(sf.Field), "allowed", strings.Join(allowed, ", "))
+		}
+		if seen[sf.Field] {
+			return nil, v1.NewFieldError("sort", "duplicate_field", "value", strconv.Quote(sf.Field))
+		}
+		seen[sf.Field] = true
+		out = append(out, sf)
+	}
+	return out, nil
+}
+
+func formatSort(order []domain.SortField) string {
+	parts := make([]string, 0, len(order))
+	for _, sf := range order {
+		if sf.Desc {
+			parts = append(parts, "-"+sf.Field)
+		} else {
+			parts = append(parts, sf.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// ---- курсор ----
+
+// listCursor — ключ последней выданной подписки; клиенту отдаётся как непрозрачная строка.
+// Sort фиксирует порядок, для которого курсор выдан: с другим sort он недействителен.
+type listCursor struct {
+	Sort        string    `json:"s"`
+	ID          string    `json:"id"`
+	ServiceName string    `json:"sn"`
+	Price       int       `json:"p"`
+	StartDate   time.Time `json:"sd"`
+	EndDate     time.Time `json:"ed"`
+	CreatedAt   time.Time `json:"ca"`
+	UpdatedAt   time.Time `json:"ua"`
+	DeletedAt   time.Time `json:"da,omitzero"`
+}
+
+func EncodeCursor(last domain.Subscription, order []domain.SortField) string {
+	b, _ := json.Marshal(listCursor{
+		Sort:        formatSort(order),
+		ID:          last.ID,
+		ServiceName: last.ServiceName,
+		Price:       last.Price,
+		StartDate:   last.StartDate,
+		EndDate:     last.EndDate,
+		CreatedAt:   last.CreatedAt,
+		UpdatedAt:   last.UpdatedAt,
+		DeletedAt:   last.DeletedAt,
+	})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string, order []domain.SortField) (*domain.Subscription, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, v1.NewFieldError("cursor", "malformed")
+	}
+	var c listCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == "" {
+		return nil, v1.NewFieldError("cursor", "malformed")
+	}
+	if c.Sort != formatSort(order) {
+		return nil, v1.NewFieldError("cursor", "cursor_sort_mismatch")
+	}
+	return &domain.Subscription{
+		ID:          c.ID,
+		ServiceName: c.ServiceName,
+		Price:       c.Price,
+		StartDate:   c.StartDate,
+		EndDate:     c.EndDate,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+		DeletedAt:   c.DeletedAt,
+	}, nil
+}
@@ -1,6 +1,12 @@
 package subscription
 
-import "github.com/EgorLis/my-subs/internal/domain"
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
 
 // --- запросы -> домен ---
 
@@ -11,6 +17,8 @@ func MapCreateReqToDomain(req CreateRequest) domain.Subscription {
 		UserID:      req.UserID,
 		StartDate:   req.StartDate.ToTime(),
 		EndDate:     req.EndDate.ToTime(),
+		Notes:       strings.TrimSpace(req.Notes),
+		Tags:        normalizeTags(req.Tags),
 	}
 }
 
@@ -22,6 +30,34 @@ func MapUpdateReqToDomain(req UpdateRequest) domain.Subscription {
 		UserID:      req.UserID,
 		StartDate:   req.StartDate.ToTime(),
 		EndDate:     req.EndDate.ToTime(),
+		Notes:       strings.TrimSpace(req.Notes),
+		Tags:        normalizeTags(req.Tags),
+	}
+}
+
+// normalizeTags приводит метки к нижнему регистру и убирает повторы, сохраняя порядок
+func normalizeTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t != "" && !slices.Contains(out, t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// batchUpdateRequest — операция update пакета в виде PUT-запроса
+func batchUpdateRequest(op BatchOperation) UpdateRequest {
+	return UpdateRequest{
+		ID:          op.ID,
+		ServiceName: op.Data.ServiceName,
+		Price:       op.Data.Price,
+		UserID:      op.Data.UserID,
+		StartDate:   op.Data.StartDate,
+		EndDate:     op.Data.EndDate,
+		Notes:       op.Data.Notes,
+		Tags:        op.Data.Tags,
 	}
 }
 
@@ -29,12 +65,32 @@ func MapUpdateReqToDomain(req UpdateRequest) domain.Subscription {
 
 func MapDomainToDTO(sub domain.Subscription) SubscriptionDTO {
 	return SubscriptionDTO{
+		ID:          sub.ID,
 		ServiceName: sub.ServiceName,
 		Price:       sub.Price,
 		UserID:      sub.UserID,
 		StartDate:   YearMonth(sub.StartDate),
 		EndDate:     YearMonth(sub.EndDate),
+		Notes:       sub.Notes,
+		Tags:        append([]string{}, sub.Tags...),
+		CreatedAt:   sub.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:   sub.UpdatedAt.UTC().Format(time.RFC3339),
+		CreatedBy:   sub.CreatedBy,
+		UpdatedBy:   sub.UpdatedBy,
+		Version:     sub.Version,
+		DeletedAt:   formatDeletedAt(sub.DeletedAt),
+	}
+}
+
+func formatDeletedAt(t time.Time) string {
+	if t.IsZero() {
+		return ""
 	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func MapDomainToDeletedEvent(sub domain.Subscription) DeletedEvent {
+	return DeletedEvent{ID: sub.ID, UserID: sub.UserID}
 }
 
 func MapDomainListToDTO(subs []domain.Subscription) []SubscriptionDTO {
@@ -44,3 +100,26 @@ func MapDomainListToDTO(subs []domain.Subscription) []SubscriptionDTO {
 	}
 	return out
 }
+
+func MapSyncChangesToDTO(changes []domain.SyncChange) []SyncChangeDTO {
+	out := make([]SyncChangeDTO, 0, len(changes))
+	for _, c := range changes {
+		if t := c.Deleted; t != nil {
+			out = append(out, SyncChangeDTO{Op: SyncDelete, ID: t.ID, UserID: t.UserID, DeletedAt: t.DeletedAt.UTC().Format(time.RFC3339)})
+			continue
+		}
+		dto := MapDomainToDTO(c.Sub)
+		out = append(out, SyncChangeDTO{Op: SyncUpsert, ID: dto.ID, UserID: dto.UserID, Subscription: &dto})
+	}
+	return out
+}
+
+func MapChargeToDTO(c domain.Charge) ChargeDTO {
+	return ChargeDTO{
+		SubscriptionID: c.SubscriptionID,
+		UserID:         c.UserID,
+		ServiceName:    c.ServiceName,
+		Month:          YearMonth(c.Month),
+		Amount:         c.Amount,
+	}
+}
@@ -0,0 +1,360 @@
+package subscription
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/EgorLis/my-subs/internal/i18n"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json" // RFC 7396
+	JSONPatchContentType  = "application/json-patch+json"  // RFC 6902
+)
+
+// PatchError — патч некорректен или не применяется к текущему документу.
+// Key и Args — сообщение detail.<Key> из каталога; Conflict выставляется, когда не прошла операция test.
+// Для JSON Patch Op — операция, на которой патч остановился (Index с нуля).
+type PatchError struct {
+	Key      string
+	Args     []any // пары имя, значение, как у v1.WriteMessage
+	Conflict bool
+	Op       *PatchErrorOp
+}
+
+// PatchErrorOp — операция JSON Patch, которую не удалось применить
+type PatchErrorOp struct {
+	Index    int
+	Op, Path string
+}
+
+// Error — текст на языке каталога по умолчанию, для логов
+func (e *PatchError) Error() string {
+	reason := v1.Messages.Text(v1.Messages.Default(), "detail."+e.Key, i18nArgs(e.Args))
+	if e.Op == nil {
+		return reason
+	}
+	return v1.Messages.Text(v1.Messages.Default(), "detail.patch_operation", i18n.Args{
+		"index": e.Op.Index, "op": e.Op.Op, "path": e.Op.Path, "reason": reason,
+	})
+}
+
+// Write пишет ошибку в ответ на языке клиента: причина переводится отдельно и подставляется в описание операции
+func (e *PatchError) Write(w http.ResponseWriter, status int) {
+	if e.Op == nil {
+		v1.WriteMessage(w, status, e.Key, e.Args...)
+		return
+	}
+	v1.WriteMessage(w, status, "patch_operation", "index", e.Op.Index, "op", e.Op.Op, "path", e.Op.Path,
+		"reason", v1.Message(w, e.Key, e.Args...))
+}
+
+func patchError(key string, kv ...any) error {
+	return &PatchError{Key: key, Args: kv}
+}
+
+// i18nArgs переводит пары имя, значение в аргументы каталога
+func i18nArgs(kv []any) i18n.Args {
+	args := make(i18n.Args, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		if name, ok := kv[i].(string); ok {
+			args[name] = kv[i+1]
+		}
+	}
+	return args
+}
+
+// ApplyPatch применяет патч типа contentType к JSON-документу doc и возвращает результат
+func ApplyPatch(doc []byte, contentType string, patch []byte) ([]byte, error) {
+	var target any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	switch contentType {
+	case MergePatchContentType:
+		var p any
+		if err := json.Unmarshal(patch, &p); err != nil {
+			return nil, patchError("patch_invalid_json")
+		}
+		if _, ok := p.(map[string]any); !ok {
+			return nil, patchError("patch_not_object")
+		}
+		target = mergePatch(target, p)
+	case JSONPatchContentType:
+		var ops []patchOp
+		if err := json.Unmarshal(patch, &ops); err != nil {
+			return nil, patchError("patch_not_array")
+		}
+		for i, op := range ops {
+			var err error
+			if target, err = op.apply(target); err != nil {
+				if pe, ok := err.(*PatchError); ok {
+					pe.Op = &PatchErrorOp{Index: i, Op: op.Op, Path: op.Path}
+				}
+				return nil, err
+			}
+		}
+	default:
+		return nil, patchError("patch_unknown_type", "type", contentType)
+	}
+
+	return json.Marshal(target)
+}
+
+// ---- JSON Merge Patch (RFC 7396) ----
+
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+	return t
+}
+
+// ---- JSON Patch (RFC 6902) ----
+
+type patchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+func (op patchOp) value() (any, error) {
+	if op.Value == nil {
+		return nil, patchError("patch_value_required")
+	}
+	var v any
+	if err := json.Unmarshal(op.Value, &v); err != nil {
+		return nil, patchError("patch_value_invalid")
+	}
+	return v, nil
+}
+
+func (op patchOp) apply(doc any) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		v, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, v)
+	case "remove":
+		doc, _, err := pointerRemove(doc, path)
+		return doc, err
+	case "replace":
+		v, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return v, nil
+		}
+		if doc, _, err = pointerRemove(doc, path); err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, v)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		var v any
+		if op.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, patchError("patch_move_into_itself")
+			}
+			doc, v, err = pointerRemove(doc, from)
+		} else {
+			v, err = pointerGet(doc, from)
+			v = deepCopy(v)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, v)
+	case "test":
+		want, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		got, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(got, want) {
+			return nil, &PatchError{Key: "patch_test_failed", Conflict: true}
+		}
+		return doc, nil
+	}
+	return nil, patchError("patch_unknown_op", "op", op.Op)
+}
+
+// parsePointer разбирает JSON Pointer (RFC 6901): "/a/b~1c" -> ["a", "b/c"]
+func parsePointer(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(s, "/") {
+		return nil, patchError("patch_invalid_path", "path", s)
+	}
+	parts := strings.Split(s[1:], "/")
+	for i, p := range parts {
+		parts[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(p)
+	}
+	return parts, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func arrayIndex(tok string, n int, allowEnd bool) (int, error) {
+	if allowEnd && tok == "-" {
+		return n, nil
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i < 0 || i > n || (i == n && !allowEnd) || (tok != "0" && strings.HasPrefix(tok, "0")) {
+		return 0, patchError("patch_index_out_of_range", "index", tok)
+	}
+	return i, nil
+}
+
+func pointerGet(doc any, path []string) (any, error) {
+	for _, tok := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			v, ok := node[tok]
+			if !ok {
+				return nil, patchError("patch_path_not_found")
+			}
+			doc = v
+		case []any:
+			i, err := arrayIndex(tok, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, patchError("patch_path_not_found")
+		}
+	}
+	return doc, nil
+}
+
+// pointerAdd вставляет value по path и возвращает (возможно новый) корень документа
+func pointerAdd(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := pointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		node[last] = value
+		return doc, nil
+	case []any:
+		i, err := arrayIndex(last, len(node), true)
+		if err != nil {
+			return nil, err
+		}
+		node = append(node[:i:i], append([]any{value}, node[i:]...)...)
+		return pointerSet(doc, path[:len(path)-1], node)
+	}
+	return nil, patchError("patch_path_not_found")
+}
+
+// pointerSet заменяет существующее значение по path (нужно, когда append вернул новый срез)
+func pointerSet(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := pointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		node[last] = value
+		return doc, nil
+	case []any:
+		i, err := arrayIndex(last, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = value
+		return doc, nil
+	}
+	return nil, patchError("patch_path_not_found")
+}
+
+// pointerRemove удаляет значение по path и возвращает новый корень и удалённое значение
+func pointerRemove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, patchError("patch_remove_root")
+	}
+	parent, err := pointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	last := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		v, ok := node[last]
+		if !ok {
+			return nil, nil, patchError("patch_path_not_found")
+		}
+		delete(node, last)
+		return doc, v, nil
+	case []any:
+		i, err := arrayIndex(last, len(node), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		v := node[i]
+		node = append(node[:i:i], node[i+1:]...)
+		doc, err = pointerSet(doc, path[:len(path)-1], node)
+		return doc, v, err
+	}
+	return nil, nil, patchError("patch_path_not_found")
+}
+
+func deepCopy(v any) any {
+	b, _ := json.Marshal(v)
+	var out any
+	_ = json.Unmarshal(b, &out)
+	return out
+}
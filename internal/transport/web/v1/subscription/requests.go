@@ -6,8 +6,11 @@ type CreateRequest struct {
 	UserID      string    `json:"user_id"`
 	StartDate   YearMonth `json:"start_date"`
 	EndDate     YearMonth `json:"end_date"`
+	Notes       string    `json:"notes"`
+	Tags        []string  `json:"tags"`
 }
 
+// UpdateRequest — тело PUT /v1/subscriptions/{id}; ID можно не передавать, он берётся из пути.
 type UpdateRequest struct {
 	ID          string    `json:"id"`
 	ServiceName string    `json:"service_name"`
@@ -15,4 +18,27 @@ type UpdateRequest struct {
 	UserID      string    `json:"user_id"`
 	StartDate   YearMonth `json:"start_date"`
 	EndDate     YearMonth `json:"end_date"`
+	Notes       string    `json:"notes"`
+	Tags        []string  `json:"tags"`
+}
+
+// BatchRequest — набор операций POST /v1/subscriptions:batch
+type BatchRequest struct {
+	Mode       string           `json:"mode" enums:"atomic,best_effort"` // по умолчанию atomic
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation — одна операция пакета.
+// create: data; update: id и data (все поля, как в PUT); delete: id. version — ожидаемая версия, 0 — без проверки.
+type BatchOperation struct {
+	Op      string        `json:"op" enums:"create,update,delete"`
+	ID      string        `json:"id,omitempty"`
+	Version int           `json:"version,omitempty"`
+	Data    CreateRequest `json:"data"`
+}
+
+// SyncPushRequest — изменения клиента для POST /v1/sync в порядке, в котором они сделаны офлайн.
+// Операции — как в BatchOperation; для update и delete version обязательна.
+type SyncPushRequest struct {
+	Changes []BatchOperation `json:"changes"`
 }
@@ -1,11 +1,29 @@
 package subscription
 
 type SubscriptionDTO struct {
+	ID          string    `json:"id"`
 	ServiceName string    `json:"service_name"`
 	Price       int       `json:"price"`
 	UserID      string    `json:"user_id"`
 	StartDate   YearMonth `json:"start_date"`
 	EndDate     YearMonth `json:"end_date"`
+	Notes       string    `json:"notes"`
+	Tags        []string  `json:"tags"`
+	CreatedAt   string    `json:"created_at"`
+	UpdatedAt   string    `json:"updated_at"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	UpdatedBy   string    `json:"updated_by,omitempty"`
+	Version     int       `json:"version"`
+	DeletedAt   string    `json:"deleted_at,omitempty"` // только в корзине
+}
+
+// ChargeDTO — ежемесячное списание по подписке
+type ChargeDTO struct {
+	SubscriptionID string    `json:"subscription_id"`
+	UserID         string    `json:"user_id"`
+	ServiceName    string    `json:"service_name"`
+	Month          YearMonth `json:"month"`
+	Amount         int       `json:"amount"`
 }
 
 // ответ для CREATE, UPDATE, DELETE,
@@ -15,7 +33,8 @@ type CUDResponse struct {
 }
 
 type ListResponse struct {
-	Subs []SubscriptionDTO `json:"subscriptions"`
+	Subs       []SubscriptionDTO `json:"subscriptions"`
+	NextCursor string            `json:"next_cursor,omitempty"`
 }
 
 type TotalCostResponse struct {
@@ -25,3 +44,87 @@ type TotalCostResponse struct {
 	To          YearMonth `json:"to"`
 	TotalCost   int       `json:"total_cost"`
 }
+
+type BatchResponse struct {
+	Mode    string            `json:"mode"`
+	Applied bool              `json:"applied"` // изменения зафиксированы (в best_effort — успешные операции)
+	Results []BatchItemResult `json:"results"`
+}
+
+// BatchItemResult — результат операции; Status — HTTP-код, который вернул бы одиночный запрос
+type BatchItemResult struct {
+	Index   int    `json:"index"`
+	Op      string `json:"op"`
+	Status  int    `json:"status"`
+	SubID   string `json:"subscription_id,omitempty"`
+	Version int    `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// SyncResponse — ответ GET /v1/sync; Next передаётся как since в следующий запрос
+type SyncResponse struct {
+	Changes []SyncChangeDTO `json:"changes"`
+	Next    string          `json:"next"`
+	HasMore bool            `json:"has_more"`
+}
+
+// SyncChangeDTO — подписка (op=upsert) или надгробие удалённой подписки (op=delete)
+type SyncChangeDTO struct {
+	Op           string           `json:"op" enums:"upsert,delete"`
+	ID           string           `json:"id"`
+	UserID       string           `json:"user_id"`
+	Subscription *SubscriptionDTO `json:"subscription,omitempty"`
+	DeletedAt    string           `json:"deleted_at,omitempty"`
+}
+
+type SyncPushResponse struct {
+	Results []SyncPushResult `json:"results"`
+}
+
+// SyncPushResult — результат операции POST /v1/sync; Status — как у BatchItemResult.
+// Conflict объясняет 412 и 410, Current — подписка на сервере при конфликте версий.
+type SyncPushResult struct {
+	Index     int              `json:"index"`
+	Op        string           `json:"op"`
+	Status    int              `json:"status"`
+	SubID     string           `json:"subscription_id,omitempty"`
+	Version   int              `json:"version,omitempty"`
+	Conflict  string           `json:"conflict,omitempty" enums:"version_mismatch,deleted"`
+	Current   *SubscriptionDTO `json:"current,omitempty"`
+	DeletedAt string           `json:"deleted_at,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// ImportResponse — отчёт об импорте CSV; Imported заполняется только при реальной записи
+type ImportResponse struct {
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total"`
+	Valid    int              `json:"valid"`
+	Imported int              `json:"imported"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// ImportRowError — ошибки одной строки; Line — номер строки в файле (заголовок — 1)
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+
+	err error // для перевода Error на язык ответа
+}
+
+type SearchResponse struct {
+	Results []SearchResultDTO `json:"results"`
+}
+
+type SearchResultDTO struct {
+	Subscription SubscriptionDTO  `json:"subscription"`
+	Score        float64          `json:"score"`
+	Highlights   SearchHighlights `json:"highlights"`
+}
+
+// SearchHighlights — поля с совпадениями: HTML-экранированный текст, совпавшие слова в <mark>
+type SearchHighlights struct {
+	ServiceName string   `json:"service_name,omitempty"`
+	Notes       string   `json:"notes,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
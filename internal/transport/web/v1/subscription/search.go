@@ -0,0 +1,197 @@
+package subscription
+
+import (
+	"context"
+	"html"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+	MaxSearchQueryLen  = 100
+
+	// notesSnippetLen — сколько символов заметки показывать вокруг первого совпадения
+	notesSnippetLen = 160
+)
+
+// ParseSearchQuery разбирает q, user_id и limit запроса GET /v1/subscriptions/search
+func ParseSearchQuery(q url.Values) (domain.SearchQuery, error) {
+	var errs []v1.FieldError
+	sq := domain.SearchQuery{Text: strings.TrimSpace(q.Get("q")), Limit: DefaultSearchLimit}
+
+	switch n := utf8.RuneCountInString(sq.Text); {
+	case n == 0:
+		errs = append(errs, v1.Field("q", "required"))
+	case n > MaxSearchQueryLen:
+		errs = append(errs, v1.Field("q", "max_length", "max", MaxSearchQueryLen))
+	case len(domain.SearchWords(sq.Text)) == 0:
+		errs = append(errs, v1.Field("q", "no_letters"))
+	}
+	if v := q.Get("user_id"); v != "" {
+		if ValidateGUID(v) != nil {
+			errs = append(errs, guidErr("user_id", v))
+		}
+		sq.UserID = v
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxSearchLimit {
+			errs = append(errs, v1.Field("limit", "between", "min", 1, "max", MaxSearchLimit))
+		} else {
+			sq.Limit = n
+		}
+	}
+	return sq, v1.Fields(errs)
+}
+
+// highlight экранирует текст для HTML и оборачивает в <mark> слова, похожие на слова запроса
+func highlight(text string, terms []string) (string, bool) {
+	var sb strings.Builder
+	found := false
+	for len(text) > 0 {
+		// отделяем слово (буквы и цифры) от разделителей перед ним
+		start := strings.IndexFunc(text, isWordRune)
+		if start < 0 {
+			sb.WriteString(html.EscapeString(text))
+			break
+		}
+		sb.WriteString(html.EscapeString(text[:start]))
+		text = text[start:]
+		end := strings.IndexFunc(text, func(r rune) bool { return !isWordRune(r) })
+		if end < 0 {
+			end = len(text)
+		}
+		word := text[:end]
+		text = text[end:]
+
+		if matchesAny(strings.ToLower(word), terms) {
+			found = true
+			sb.WriteString("<mark>" + html.EscapeString(word) + "</mark>")
+		} else {
+			sb.WriteString(html.EscapeString(word))
+		}
+	}
+	return sb.String(), found
+}
+
+func isWordRune(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+func matchesAny(word string, terms []string) bool {
+	for _, t := range terms {
+		if domain.WordSimilarity(t, word) >= domain.SearchThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// notesSnippet — фрагмент длинной заметки вокруг первого совпадения
+func notesSnippet(notes string, terms []string) string {
+	r := []rune(notes)
+	if len(r) <= notesSnippetLen {
+		return notes
+	}
+	from := 0
+	for i := 0; i < len(r); {
+		j := i
+		for j < len(r) && isWordRune(r[j]) {
+			j++
+		}
+		if j > i && matchesAny(strings.ToLower(string(r[i:j])), terms) {
+			from = max(0, i-notesSnippetLen/4)
+			break
+		}
+		i = j + 1
+	}
+	to := min(len(r), from+notesSnippetLen)
+	from = max(0, to-notesSnippetLen)
+	out := string(r[from:to])
+	if from > 0 {
+		out = "…" + out
+	}
+	if to < len(r) {
+		out += "…"
+	}
+	return out
+}
+
+func mapSearchHit(hit domain.SearchHit, terms []string) SearchResultDTO {
+	res := SearchResultDTO{
+		Subscription: MapDomainToDTO(hit.Sub),
+		Score:        math.Round(hit.Score*1000) / 1000,
+	}
+	if s, ok := highlight(hit.Sub.ServiceName, terms); ok {
+		res.Highlights.ServiceName = s
+	}
+	if s, ok := highlight(notesSnippet(hit.Sub.Notes, terms), terms); ok {
+		res.Highlights.Notes = s
+	}
+	for _, tag := range hit.Sub.Tags {
+		if s, ok := highlight(tag, terms); ok {
+			res.Highlights.Tags = append(res.Highlights.Tags, s)
+		}
+	}
+	return res
+}
+
+// Search godoc
+// @Summary      Search subscriptions
+// @Description  Нечёткий поиск по названию сервиса, заметкам и меткам с учётом опечаток (pg_trgm).
+// @Description  Результаты отсортированы по score; в highlights совпавшие слова обёрнуты в <mark>, текст экранирован для HTML.
+// @Tags         subscriptions
+// @Produce      json
+// @Param        q        query  string  true   "Строка поиска"
+// @Param        user_id  query  string  false  "ID пользователя"
+// @Param        limit    query  int     false  "Сколько результатов вернуть (1..100, по умолчанию 20)"
+// @Success      200  {object}  subscription.SearchResponse
+// @Failure      400  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/subscriptions/search [get]
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	const op = "subscription.search"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	sq, err := ParseSearchQuery(r.URL.Query())
+	if err != nil {
+		logx.Error(h.Log, reqID, op, "validation failed", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	hits, err := h.Repo.SearchSubs(ctx, sq)
+	if err != nil {
+		if v1.IsTimeout(err) {
+			logx.Error(h.Log, reqID, op, "timeout", err)
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+			return
+		}
+		logx.Error(h.Log, reqID, op, "repo search failed", err)
+		v1.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	terms := domain.SearchWords(sq.Text)
+	resp := SearchResponse{Results: make([]SearchResultDTO, 0, len(hits))}
+	for _, hit := range hits {
+		resp.Results = append(resp.Results, mapSearchHit(hit, terms))
+	}
+	logx.Info(h.Log, reqID, op, "returned", "q", sq.Text, "count", len(resp.Results))
+	v1.WriteJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,167 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	"github.com/EgorLis/my-subs/internal/transport/web/sse"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+const (
+	// EventResync — Last-Event-ID больше нет в буфере: клиенту нужно перечитать список целиком
+	EventResync = "resync"
+
+	// DefaultStreamHeartbeat — как часто в пустой поток пишется комментарий, чтобы прокси не закрыли соединение
+	DefaultStreamHeartbeat = 15 * time.Second
+	streamRetry            = 3 * time.Second
+)
+
+// Publishers рассылает событие всем получателям, например вебхукам и потоку SSE
+type Publishers []EventPublisher
+
+func (ps Publishers) Publish(ctx context.Context, event string, data any) error {
+	var errs []error
+	for _, p := range ps {
+		if err := p.Publish(ctx, event, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (ps Publishers) PublishBatch(ctx context.Context, event string, data []any) error {
+	var errs []error
+	for _, p := range ps {
+		if err := PublishBatch(ctx, p, event, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PublishBatch отправляет пачку событий: получателю с BatchPublisher — разом, остальным — по одному
+func PublishBatch(ctx context.Context, p EventPublisher, event string, data []any) error {
+	if bp, ok := p.(BatchPublisher); ok {
+		return bp.PublishBatch(ctx, event, data)
+	}
+	var errs []error
+	for _, d := range data {
+		if err := p.Publish(ctx, event, d); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Feed публикует события в поток GET /v1/subscriptions/events
+type Feed struct {
+	Broker *sse.Broker
+}
+
+func (f Feed) Publish(ctx context.Context, event string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	f.Broker.Publish(sse.Event{Type: event, Tenant: domain.TenantOrDefault(ctx), Key: eventUserID(data), Data: b})
+	return nil
+}
+
+// eventUserID — владелец подписки из данных события, по нему фильтрует ?user_id=
+func eventUserID(data any) string {
+	switch d := data.(type) {
+	case SubscriptionDTO:
+		return d.UserID
+	case DeletedEvent:
+		return d.UserID
+	}
+	return ""
+}
+
+// Stream godoc
+// @Summary      Stream subscription changes (SSE)
+// @Description  Поток Server-Sent Events с изменениями подписок тенанта: subscription.created, subscription.updated,
+// @Description  subscription.restored (data — подписка, как в GET) и subscription.deleted (data — id и user_id).
+// @Description  При переподключении Last-Event-ID (или ?last_event_id=) досылает пропущенные события из буфера;
+// @Description  если их там уже нет, первым приходит событие resync — список нужно перечитать.
+// @Tags         subscriptions
+// @Produce      text/event-stream
+// @Param        user_id        query   string  false  "Только подписки пользователя"
+// @Param        Last-Event-ID  header  string  false  "ID последнего полученного события"
+// @Param        last_event_id  query   string  false  "То же, что Last-Event-ID, для первого подключения"
+// @Success      200  {string}  string  "text/event-stream"
+// @Failure      400  {object}  v1.Problem
+// @Router       /v1/subscriptions/events [get]
+func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	const op = "subscription.stream"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	userID := r.URL.Query().Get("user_id")
+	if userID != "" {
+		if err := ValidateGUID(userID); err != nil {
+			logx.Error(h.Log, reqID, op, "bad user_id", err, "user_id", userID)
+			v1.WriteValidationError(w, v1.Fields([]v1.FieldError{guidErr("user_id", userID)}))
+			return
+		}
+	}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+
+	replay, sub, resync := h.Changes.Subscribe(domain.TenantOrDefault(r.Context()), userID, lastID)
+	defer sub.Close()
+
+	heartbeat := h.Heartbeat
+	if heartbeat <= 0 {
+		heartbeat = DefaultStreamHeartbeat
+	}
+	sw, err := sse.NewWriter(w, 2*heartbeat, streamRetry)
+	if err != nil {
+		logx.Error(h.Log, reqID, op, "write failed", err)
+		return
+	}
+	logx.Info(h.Log, reqID, op, "connected", "user_id", userID, "last_event_id", lastID,
+		"replay", len(replay), "resync", resync)
+
+	if resync {
+		if err := sw.Event(sse.Event{Type: EventResync, Data: []byte("{}")}); err != nil {
+			return
+		}
+	}
+	for _, e := range replay {
+		if err := sw.Event(e); err != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			logx.Info(h.Log, reqID, op, "disconnected")
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				// отстал или сервер останавливается: клиент переподключится с Last-Event-ID
+				logx.Info(h.Log, reqID, op, "closed by server")
+				return
+			}
+			err = sw.Event(e)
+		case <-ticker.C:
+			err = sw.Comment("ping")
+		}
+		if err != nil {
+			logx.Error(h.Log, reqID, op, "write failed", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package subscription
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+const (
+	DefaultSyncLimit = 500
+	MaxSyncLimit     = 1000
+	// DefaultSyncTokenTTL — сколько живёт токен GET /v1/sync; надгробия хранятся не меньше
+	DefaultSyncTokenTTL = 30 * 24 * time.Hour
+)
+
+// Операции в ответе GET /v1/sync
+const (
+	SyncUpsert = "upsert"
+	SyncDelete = "delete"
+)
+
+// Конфликты в ответе POST /v1/sync
+const (
+	ConflictVersion = "version_mismatch"
+	ConflictDeleted = "deleted"
+)
+
+// syncToken — позиция клиента в журнале изменений; клиенту отдаётся как непрозрачная строка.
+// IssuedAt — момент, на который у клиента полная копия: по нему проверяется срок жизни.
+type syncToken struct {
+	Seq      uint64 `json:"s"`
+	ID       string `json:"i,omitempty"`
+	IssuedAt int64  `json:"t"`
+}
+
+func encodeSyncToken(c domain.SyncCursor, issued time.Time) string {
+	b, _ := json.Marshal(syncToken{Seq: c.Seq, ID: c.ID, IssuedAt: issued.Unix()})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSyncToken(s string) (domain.SyncCursor, time.Time, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return domain.SyncCursor{}, time.Time{}, v1.NewFieldError("since", "malformed")
+	}
+	var t syncToken
+	if err := json.Unmarshal(raw, &t); err != nil || t.IssuedAt <= 0 {
+		return domain.SyncCursor{}, time.Time{}, v1.NewFieldError("since", "malformed")
+	}
+	return domain.SyncCursor{Seq: t.Seq, ID: t.ID}, time.Unix(t.IssuedAt, 0), nil
+}
+
+// ParseSyncQuery разбирает since, user_id и limit запроса GET /v1/sync.
+// issued — время выдачи токена since; нулевое, если since не передан (первая синхронизация).
+func ParseSyncQuery(q url.Values) (sq domain.SyncQuery, issued time.Time, err error) {
+	var errs []v1.FieldError
+	sq.Limit = DefaultSyncLimit
+
+	if v := q.Get("user_id"); v != "" {
+		if ValidateGUID(v) != nil {
+			errs = append(errs, guidErr("user_id", v))
+		}
+		sq.UserID = v
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxSyncLimit {
+			errs = append(errs, v1.Field("limit", "between", "min", 1, "max", MaxSyncLimit))
+		} else {
+			sq.Limit = n
+		}
+	}
+
+	var tokenErr error
+	if v := q.Get("since"); v != "" {
+		sq.After, issued, tokenErr = decodeSyncToken(v)
+		// без since клиент получает полную копию, и надгробия ему не нужны
+		sq.Tombstones = true
+	}
+	return sq, issued, v1.JoinValidation(v1.Fields(errs), tokenErr)
+}
+
+func (h *Handler) syncTokenTTL() time.Duration {
+	if h.SyncTokenTTL > 0 {
+		return h.SyncTokenTTL
+	}
+	return DefaultSyncTokenTTL
+}
+
+// Sync godoc
+// @Summary      Delta sync
+// @Description  Изменения подписок после токена since: созданные и изменённые приходят с op=upsert и последним
+// @Description  состоянием подписки, удалённые — с op=delete (надгробие: id, user_id, deleted_at).
+// @Description  Без since — все подписки (первая копия). next — since для следующего запроса; при has_more=true
+// @Description  нужно сразу запросить следующую страницу. Токен живёт SYNC_TOKEN_TTL; с устаревшим токеном — 410,
+// @Description  клиент начинает заново без since.
+// @Tags         sync
+// @Produce      json
+// @Param        since    query     string  false  "Токен из next предыдущего ответа"
+// @Param        user_id  query     string  false  "Только подписки пользователя"
+// @Param        limit    query     int     false  "Размер страницы (1..1000, по умолчанию 500)"
+// @Success      200      {object}  subscription.SyncResponse
+// @Failure      400      {object}  v1.Problem
+// @Failure      410      {object}  v1.Problem
+// @Failure      504      {object}  v1.Problem
+// @Failure      500      {object}  v1.Problem
+// @Router       /v1/sync [get]
+func (h *Handler) Sync(w http.ResponseWriter, r *http.Request) {
+	const op = "subscription.sync"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	q, issued, err := ParseSyncQuery(r.URL.Query())
+	if err != nil {
+		logx.Error(h.Log, reqID, op, "validation failed", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+	now := time.Now()
+	if !issued.IsZero() && now.Sub(issued) > h.syncTokenTTL() {
+		logx.Info(h.Log, reqID, op, "token expired", "issued_at", issued.UTC().Format(time.RFC3339))
+		v1.WriteMessage(w, http.StatusGone, "sync_token_expired")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	page, err := h.Repo.SyncSubs(ctx, q)
+	if err != nil {
+		if v1.IsTimeout(err) {
+			logx.Error(h.Log, reqID, op, "timeout", err)
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+			return
+		}
+		logx.Error(h.Log, reqID, op, "repo sync failed", err)
+		v1.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	// пока страницы не кончились, копия клиента актуальна лишь на момент первого запроса цепочки
+	if !page.HasMore || issued.IsZero() {
+		issued = now
+	}
+	resp := SyncResponse{
+		Changes: MapSyncChangesToDTO(page.Changes),
+		Next:    encodeSyncToken(page.Next, issued),
+		HasMore: page.HasMore,
+	}
+	logx.Info(h.Log, reqID, op, "returned", "count", len(resp.Changes), "has_more", page.HasMore)
+	v1.WriteJSON(w, http.StatusOK, resp)
+}
+
+// Push godoc
+// @Summary      Push offline changes
+// @Description  Применить изменения, сделанные клиентом офлайн. Операции — как в :batch, но для update и delete
+// @Description  обязательна version, на которой клиент основывал изменение. Конфликт не прерывает остальные операции,
+// @Description  успешные фиксируются вместе. Правила конфликтов:
+// @Description  - подписку изменили на сервере (version устарела) — 412, conflict=version_mismatch, current — версия сервера;
+// @Description  - подписку удалили на сервере — update получает 410, conflict=deleted: удаление побеждает правку;
+// @Description  - delete уже удалённой подписки — 200 с deleted_at: повтор удаления безопасен.
+// @Description  Повтор запроса после обрыва связи — с тем же Idempotency-Key.
+// @Tags         sync
+// @Accept       json
+// @Produce      json
+// @Param        request          body      subscription.SyncPushRequest  true   "Изменения клиента"
+// @Param        Idempotency-Key  header    string                        false  "Ключ идемпотентности: повтор возвращает сохранённый ответ"
+// @Success      200      {object}  subscription.SyncPushResponse
+// @Failure      400      {object}  v1.Problem
+// @Failure      504      {object}  v1.Problem
+// @Failure      500      {object}  v1.Problem
+// @Router       /v1/sync [post]
+func (h *Handler) Push(w http.ResponseWriter, r *http.Request) {
+	const op = "subscription.push"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req SyncPushRequest
+	if err := v1.DecodeJSON(r.Body, &req); err != nil {
+		logx.Error(h.Log, reqID, op, "invalid JSON", err)
+		v1.WriteDecodeError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := ValidateSyncPushRequest(req); err != nil {
+		logx.Error(h.Log, reqID, op, "validation failed", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+
+	resp := SyncPushResponse{Results: make([]SyncPushResult, len(req.Changes))}
+	for i, o := range req.Changes {
+		resp.Results[i] = SyncPushResult{Index: i, Op: o.Op}
+		if err := ValidateSyncPushOperation(o); err != nil {
+			resp.Results[i].Status, resp.Results[i].Error = http.StatusBadRequest, v1.Localize(w, err)
+		}
+	}
+
+	// как best_effort в :batch: конфликты остаются в ответе, успешные операции фиксируются вместе
+	var events []batchEvent
+	err := h.Repo.InTx(ctx, func(tx domain.SubscriptionTx) error {
+		events = events[:0]
+		for i, o := range req.Changes {
+			res := &resp.Results[i]
+			if res.Status != 0 {
+				continue // не прошла валидацию
+			}
+			sub, err := applyBatchOperation(ctx, tx, o)
+			if err != nil {
+				if err := h.pushItemError(ctx, tx, w, o, err, res); err != nil {
+					return err
+				}
+				continue
+			}
+			res.Status, res.SubID, res.Version = http.StatusOK, sub.ID, sub.Version
+			events = append(events, newBatchEvent(o.Op, sub))
+		}
+		return nil
+	})
+
+	switch {
+	case err == nil:
+		logx.Info(h.Log, reqID, op, "applied", "changes", len(req.Changes))
+		for _, e := range events {
+			h.publish(ctx, reqID, op, e.event, e.data)
+		}
+		v1.WriteJSON(w, http.StatusOK, resp)
+	case v1.IsTimeout(err):
+		logx.Error(h.Log, reqID, op, "repo timeout", err)
+		v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+	default:
+		logx.Error(h.Log, reqID, op, "push failed", err)
+		v1.WriteError(w, http.StatusInternalServerError, "")
+	}
+}
+
+// pushItemError заполняет результат операции, которую не удалось применить, по правилам Push:
+// при устаревшей версии отдаёт текущую подписку, для удалённой на сервере — надгробие,
+// остальные ошибки — как в :batch. Возвращает ошибку, если она не относится к операции.
+func (h *Handler) pushItemError(ctx context.Context, tx domain.SubscriptionTx, w http.ResponseWriter, o BatchOperation, opErr error, res *SyncPushResult) error {
+	switch {
+	case errors.Is(opErr, domain.ErrVersionMismatch):
+		cur, err := tx.GetSub(ctx, o.ID)
+		if err != nil {
+			return err
+		}
+		dto := MapDomainToDTO(cur)
+		res.Status, res.Conflict, res.Current = http.StatusPreconditionFailed, ConflictVersion, &dto
+		res.Error = v1.Message(w, "version_mismatch")
+		return nil
+	case errors.Is(opErr, domain.ErrNotFound):
+		t, err := tx.GetTombstone(ctx, o.ID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		if err == nil {
+			res.SubID, res.DeletedAt = t.ID, t.DeletedAt.UTC().Format(time.RFC3339)
+			if o.Op == "delete" {
+				res.Status = http.StatusOK
+			} else {
+				res.Status, res.Conflict, res.Error = http.StatusGone, ConflictDeleted, v1.Message(w, "subscription_deleted")
+			}
+			return nil
+		}
+		// подписки не было или её надгробие уже удалено — 404, как у одиночного запроса
+	}
+	status, key, ok := batchItemError(opErr)
+	if !ok {
+		return opErr
+	}
+	res.Status, res.Error = status, v1.Message(w, key)
+	return nil
+}
@@ -0,0 +1,115 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+// Trash godoc
+// @Summary      List trash
+// @Description  Удалённые подписки, которые ещё можно восстановить. Фильтры, курсор и limit — как у GET /v1/subscriptions;
+// @Description  дополнительно можно сортировать по deleted_at, по умолчанию — недавно удалённые первыми.
+// @Tags         subscriptions
+// @Produce      json
+// @Param        user_id       query  string  false  "ID пользователя"
+// @Param        service_name  query  string  false  "Название подписки"
+// @Param        sort          query  string  false  "Сортировка, по умолчанию -deleted_at"
+// @Param        limit         query  int     false  "Размер страницы (1..500); без limit и cursor — все записи, с cursor — по умолчанию 50"
+// @Param        cursor        query  string  false  "Курсор из next_cursor предыдущей страницы"
+// @Success      200  {object}  subscription.ListResponse
+// @Failure      400  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/trash [get]
+func (h *Handler) Trash(w http.ResponseWriter, r *http.Request) {
+	const op = "subscription.trash"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	f, err := ParseTrashQuery(r.URL.Query())
+	if err != nil {
+		logx.Error(h.Log, reqID, op, "validation failed", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	page, err := h.Repo.ListSubs(ctx, f)
+	if err != nil {
+		if v1.IsTimeout(err) {
+			logx.Error(h.Log, reqID, op, "timeout", err)
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+			return
+		}
+		logx.Error(h.Log, reqID, op, "repo list failed", err)
+		v1.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	resp := &ListResponse{Subs: MapDomainListToDTO(page.Subs)}
+	if page.HasMore && len(page.Subs) > 0 {
+		resp.NextCursor = EncodeCursor(page.Subs[len(page.Subs)-1], f.OrderBy())
+	}
+	logx.Info(h.Log, reqID, op, "returned", "count", len(resp.Subs), "has_more", page.HasMore)
+	v1.WriteJSON(w, http.StatusOK, resp)
+}
+
+// Restore godoc
+// @Summary      Restore subscription from trash
+// @Description  Вернуть удалённую подписку из корзины. Версия подписки увеличивается на 1.
+// @Tags         subscriptions
+// @Produce      json
+// @Param        id   path      string  true  "Subscription ID (GUID)"
+// @Success      200  {object}  subscription.CUDResponse
+// @Header       200  {string}  ETag  "Новая версия подписки"
+// @Failure      400  {object}  v1.Problem
+// @Failure      404  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/subscriptions/{id}/restore [post]
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	const op = "subscription.restore"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	id := r.PathValue("id")
+	if err := ValidateGUID(id); err != nil {
+		logx.Error(h.Log, reqID, op, "bad id", err, "id", id)
+		v1.WriteValidationError(w, v1.NewFieldError("id", "invalid_guid", "value", strconv.Quote(id)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	sub, err := h.Repo.RestoreSub(ctx, id)
+	if err != nil {
+		if v1.IsTimeout(err) {
+			logx.Error(h.Log, reqID, op, "timeout", err, "id", id)
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			logx.Info(h.Log, reqID, op, "not in trash", "id", id)
+			v1.WriteMessage(w, http.StatusNotFound, "not_in_trash")
+			return
+		}
+		logx.Error(h.Log, reqID, op, "repo restore failed", err, "id", id)
+		v1.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	resp := &CUDResponse{SubID: id, Status: RESTORED}
+	w.Header().Set("ETag", ETag(sub.Version))
+	logx.Info(h.Log, reqID, op, "restored", "id", id, "version", sub.Version)
+	h.publish(ctx, reqID, op, domain.EventSubscriptionRestored, MapDomainToDTO(sub))
+	v1.WriteJSON(w, http.StatusOK, resp)
+}
@@ -1,11 +1,14 @@
 package subscription
 
 import (
-	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/EgorLis/my-subs/internal/domain"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
 	"github.com/google/uuid"
 )
 
@@ -29,90 +32,176 @@ func isStartLessEnd(a, b YearMonth) bool {
 	return tb.After(ta)
 }
 
-// аккумулируем ошибки в один error
-func joinErrs(errs []string) error {
-	if len(errs) == 0 {
-		return nil
-	}
-	return errors.New(strings.Join(errs, "; "))
+// guidErr — ошибка поля с некорректным GUID
+func guidErr(field, id string) v1.FieldError {
+	return v1.Field(field, "invalid_guid", "value", strconv.Quote(id))
 }
 
 // ---- ВАЛИДАТОРЫ ЗАПРОСОВ ----
 
 func ValidateCreateRequest(req CreateRequest) error {
-	var errs []string
+	var errs []v1.FieldError
 
 	if strings.TrimSpace(req.ServiceName) == "" {
-		errs = append(errs, "service_name: required")
+		errs = append(errs, v1.Field("service_name", "required"))
 	}
 	if req.Price <= 0 {
-		errs = append(errs, "price: must be > 0")
+		errs = append(errs, v1.Field("price", "gt", "min", 0))
 	}
-	if err := ValidateGUID(req.UserID); err != nil {
-		errs = append(errs, "user_id: "+err.Error())
+	if ValidateGUID(req.UserID) != nil {
+		errs = append(errs, guidErr("user_id", req.UserID))
 	}
 	if isZeroYM(req.StartDate) {
-		errs = append(errs, "start_date: required (MM-YYYY)")
+		errs = append(errs, v1.Field("start_date", "required_month"))
 	}
 	if isZeroYM(req.EndDate) {
-		errs = append(errs, "end_date: required (MM-YYYY)")
+		errs = append(errs, v1.Field("end_date", "required_month"))
 	}
 
 	if !isZeroYM(req.StartDate) && !isZeroYM(req.EndDate) && !isStartLessEnd(req.StartDate, req.EndDate) {
-		errs = append(errs, "date range: start_date must be <= end_date")
+		errs = append(errs, v1.Field("date range", "start_after_end"))
 	}
+	errs = append(errs, validateNotesTags(req.Notes, req.Tags)...)
 
-	return joinErrs(errs)
+	return v1.Fields(errs)
 }
 
 func ValidateUpdateRequest(req UpdateRequest) error {
-	var errs []string
+	var errs []v1.FieldError
 
-	if err := ValidateGUID(req.ID); err != nil {
-		errs = append(errs, "id: "+err.Error())
+	if ValidateGUID(req.ID) != nil {
+		errs = append(errs, guidErr("id", req.ID))
 	}
 	if strings.TrimSpace(req.ServiceName) == "" {
-		errs = append(errs, "service_name: required")
+		errs = append(errs, v1.Field("service_name", "required"))
 	}
 	if req.Price < 0 {
-		errs = append(errs, "price: must be >= 0")
+		errs = append(errs, v1.Field("price", "gte", "min", 0))
 	}
-	if err := ValidateGUID(req.UserID); err != nil {
-		errs = append(errs, "user_id: "+err.Error())
+	if ValidateGUID(req.UserID) != nil {
+		errs = append(errs, guidErr("user_id", req.UserID))
 	}
 	if isZeroYM(req.StartDate) {
-		errs = append(errs, "start_date: required (MM-YYYY)")
+		errs = append(errs, v1.Field("start_date", "required_month"))
 	}
 	if isZeroYM(req.EndDate) {
-		errs = append(errs, "end_date: required (MM-YYYY)")
+		errs = append(errs, v1.Field("end_date", "required_month"))
 	}
 	if !isZeroYM(req.StartDate) && !isZeroYM(req.EndDate) && !isStartLessEnd(req.StartDate, req.EndDate) {
-		errs = append(errs, "date range: start_date must be <= end_date")
+		errs = append(errs, v1.Field("date range", "start_after_end"))
 	}
+	errs = append(errs, validateNotesTags(req.Notes, req.Tags)...)
 
-	return joinErrs(errs)
+	return v1.Fields(errs)
+}
+
+const (
+	MaxNotesLen = 1000
+	MaxTags     = 10
+	MaxTagLen   = 32
+)
+
+func validateNotesTags(notes string, tags []string) []v1.FieldError {
+	var errs []v1.FieldError
+	if utf8.RuneCountInString(notes) > MaxNotesLen {
+		errs = append(errs, v1.Field("notes", "max_length", "max", MaxNotesLen))
+	}
+	if len(tags) > MaxTags {
+		errs = append(errs, v1.Field("tags", "max_items", "max", MaxTags))
+	}
+	for i, t := range tags {
+		if n := utf8.RuneCountInString(strings.TrimSpace(t)); n == 0 || n > MaxTagLen {
+			errs = append(errs, v1.Field(fmt.Sprintf("tags[%d]", i), "length_between", "min", 1, "max", MaxTagLen))
+		}
+	}
+	return errs
 }
 
 func ValidateTotalCostQuery(userID, serviceName string, from, to YearMonth) error {
-	var errs []string
+	var errs []v1.FieldError
 
-	if err := ValidateGUID(userID); err != nil {
-		errs = append(errs, "user_id: "+err.Error())
+	if ValidateGUID(userID) != nil {
+		errs = append(errs, guidErr("user_id", userID))
 	}
 
 	if strings.TrimSpace(serviceName) == "" {
-		errs = append(errs, "service_name: required")
+		errs = append(errs, v1.Field("service_name", "required"))
 	}
 
 	if isZeroYM(from) {
-		errs = append(errs, "from: required (MM-YYYY)")
+		errs = append(errs, v1.Field("from", "required_month"))
 	}
 	if isZeroYM(to) {
-		errs = append(errs, "to: required (MM-YYYY)")
+		errs = append(errs, v1.Field("to", "required_month"))
 	}
 	if !isZeroYM(from) && !isZeroYM(to) && !isStartLessEnd(from, to) {
-		errs = append(errs, "date range: from must be <= to")
+		errs = append(errs, v1.Field("date range", "from_after_to"))
+	}
+
+	return v1.Fields(errs)
+}
+
+const (
+	BatchAtomic     = "atomic"
+	BatchBestEffort = "best_effort"
+	MaxBatchSize    = 1000
+)
+
+func ValidateBatchRequest(req BatchRequest) error {
+	var errs []v1.FieldError
+
+	switch req.Mode {
+	case BatchAtomic, BatchBestEffort:
+	default:
+		errs = append(errs, v1.Field("mode", "one_of", "values", BatchAtomic+", "+BatchBestEffort))
+	}
+	if len(req.Operations) == 0 {
+		errs = append(errs, v1.Field("operations", "required"))
 	}
+	if len(req.Operations) > MaxBatchSize {
+		errs = append(errs, v1.Field("operations", "max_items", "max", MaxBatchSize))
+	}
+
+	return v1.Fields(errs)
+}
 
-	return joinErrs(errs)
+// ValidateBatchOperation проверяет операцию по правилам соответствующего одиночного запроса
+func ValidateBatchOperation(op BatchOperation) error {
+	if op.Version < 0 {
+		return v1.NewFieldError("version", "gte", "min", 0)
+	}
+	switch op.Op {
+	case "create":
+		return ValidateCreateRequest(op.Data)
+	case "update":
+		return ValidateUpdateRequest(batchUpdateRequest(op))
+	case "delete":
+		if ValidateGUID(op.ID) != nil {
+			return v1.Fields([]v1.FieldError{guidErr("id", op.ID)})
+		}
+		return nil
+	}
+	return v1.NewFieldError("op", "one_of", "values", "create, update, delete")
+}
+
+func ValidateSyncPushRequest(req SyncPushRequest) error {
+	if len(req.Changes) == 0 {
+		return v1.NewFieldError("changes", "required")
+	}
+	if len(req.Changes) > MaxBatchSize {
+		return v1.NewFieldError("changes", "max_items", "max", MaxBatchSize)
+	}
+	return nil
+}
+
+// ValidateSyncPushOperation — как ValidateBatchOperation, но update и delete без версии не принимаются:
+// без неё нельзя понять, видел ли клиент последние изменения на сервере
+func ValidateSyncPushOperation(op BatchOperation) error {
+	if op.Op != "create" && op.Version == domain.AnyVersion {
+		if err := ValidateBatchOperation(op); err != nil {
+			return v1.JoinValidation(err, v1.NewFieldError("version", "required"))
+		}
+		return v1.NewFieldError("version", "required")
+	}
+	return ValidateBatchOperation(op)
 }
@@ -0,0 +1,117 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	Log  *log.Logger
+	Repo domain.TenantRepository
+}
+
+// Create godoc
+// @Summary      Create tenant
+// @Description  Создать организацию (тенант)
+// @Tags         tenants
+// @Accept       json
+// @Produce      json
+// @Param        request  body      tenant.CreateRequest  true  "Tenant payload"
+// @Success      201      {object}  tenant.TenantDTO
+// @Failure      400      {object}  v1.Problem
+// @Failure      504      {object}  v1.Problem
+// @Failure      500      {object}  v1.Problem
+// @Router       /v1/tenants [post]
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	const op = "tenant.create"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req CreateRequest
+	if err := v1.DecodeJSON(r.Body, &req); err != nil {
+		logx.Error(h.Log, reqID, op, "invalid JSON", err)
+		v1.WriteDecodeError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := ValidateCreateRequest(req); err != nil {
+		logx.Error(h.Log, reqID, op, "validation failed", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+
+	t, err := h.Repo.AddTenant(ctx, MapCreateReqToDomain(req))
+	if err != nil {
+		if v1.IsTimeout(err) {
+			logx.Error(h.Log, reqID, op, "repo timeout", err)
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+			return
+		}
+		logx.Error(h.Log, reqID, op, "repo add failed", err)
+		v1.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	logx.Info(h.Log, reqID, op, "created", "tenant_id", t.ID, "name", t.Name)
+	v1.WriteJSON(w, http.StatusCreated, MapDomainToDTO(t))
+}
+
+// Get godoc
+// @Summary      Get tenant by ID
+// @Description  Получить организацию (тенант) по идентификатору
+// @Tags         tenants
+// @Produce      json
+// @Param        id   path      string  true  "Tenant ID (GUID)"
+// @Success      200  {object}  tenant.TenantDTO
+// @Failure      400  {object}  v1.Problem
+// @Failure      404  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/tenants/{id} [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	const op = "tenant.get"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	id := r.PathValue("id")
+	if _, err := uuid.Parse(id); err != nil {
+		logx.Error(h.Log, reqID, op, "bad id", err, "id", id)
+		v1.WriteValidationError(w, v1.NewFieldError("id", "invalid_guid", "value", strconv.Quote(id)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	t, err := h.Repo.GetTenant(ctx, id)
+	if err != nil {
+		if v1.IsTimeout(err) {
+			logx.Error(h.Log, reqID, op, "timeout", err, "id", id)
+			v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+			return
+		}
+		if errors.Is(err, domain.ErrTenantNotFound) {
+			logx.Info(h.Log, reqID, op, "not found", "id", id)
+			v1.WriteMessage(w, http.StatusNotFound, "not_found")
+			return
+		}
+		logx.Error(h.Log, reqID, op, "repo get failed", err, "id", id)
+		v1.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	logx.Info(h.Log, reqID, op, "returned", "id", id)
+	v1.WriteJSON(w, http.StatusOK, MapDomainToDTO(t))
+}
@@ -0,0 +1,15 @@
+package tenant
+
+import (
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
+
+func MapCreateReqToDomain(req CreateRequest) domain.Tenant {
+	return domain.Tenant{Name: req.Name}
+}
+
+func MapDomainToDTO(t domain.Tenant) TenantDTO {
+	return TenantDTO{ID: t.ID, Name: t.Name, CreatedAt: t.CreatedAt.UTC().Format(time.RFC3339)}
+}
@@ -0,0 +1,5 @@
+package tenant
+
+type CreateRequest struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,7 @@
+package tenant
+
+type TenantDTO struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+}
@@ -0,0 +1,14 @@
+package tenant
+
+import (
+	"strings"
+
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+func ValidateCreateRequest(req CreateRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return v1.NewFieldError("name", "required")
+	}
+	return nil
+}
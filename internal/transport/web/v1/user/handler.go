@@ -0,0 +1,262 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+	"github.com/google/uuid"
+)
+
+const DELETED = "user deleted"
+
+type Handler struct {
+	Log          *log.Logger
+	Repo         domain.UserRepository
+	DeletePolicy domain.UserDeletePolicy
+	Events       subscription.EventPublisher // subscription.deleted для подписок, удалённых вместе с пользователем; nil — не публикуются
+}
+
+// Create godoc
+// @Summary      Create user
+// @Description  Создать пользователя с настройками профиля
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request          body      user.CreateRequest  true   "User payload"
+// @Param        Idempotency-Key  header    string              false  "Ключ идемпотентности: повтор возвращает сохранённый ответ"
+// @Success      201      {object}  user.UserDTO
+// @Failure      400      {object}  v1.Problem
+// @Failure      409      {object}  v1.Problem
+// @Failure      422      {object}  v1.Problem
+// @Failure      504      {object}  v1.Problem
+// @Failure      500      {object}  v1.Problem
+// @Router       /v1/users [post]
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	const op = "user.create"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req CreateRequest
+	if err := v1.DecodeJSON(r.Body, &req); err != nil {
+		logx.Error(h.Log, reqID, op, "invalid JSON", err)
+		v1.WriteDecodeError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	u := MapCreateReqToDomain(req)
+	if err := ValidateProfile(u.DisplayName, u.Email, u.Currency, u.Locale, u.Timezone); err != nil {
+		logx.Error(h.Log, reqID, op, "validation failed", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+
+	created, err := h.Repo.AddUser(ctx, u)
+	if err != nil {
+		h.writeRepoError(w, reqID, op, "repo add failed", err, "")
+		return
+	}
+
+	logx.Info(h.Log, reqID, op, "created", "user_id", created.ID)
+	v1.WriteJSON(w, http.StatusCreated, MapDomainToDTO(created))
+}
+
+// Get godoc
+// @Summary      Get user by ID
+// @Description  Получить пользователя по идентификатору
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "User ID (GUID)"
+// @Success      200  {object}  user.UserDTO
+// @Failure      400  {object}  v1.Problem
+// @Failure      404  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/users/{id} [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	const op = "user.get"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	id, ok := h.pathID(w, r, reqID, op)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	u, err := h.Repo.GetUser(ctx, id)
+	if err != nil {
+		h.writeRepoError(w, reqID, op, "repo get failed", err, id)
+		return
+	}
+
+	logx.Info(h.Log, reqID, op, "returned", "id", id)
+	v1.WriteJSON(w, http.StatusOK, MapDomainToDTO(u))
+}
+
+// Update godoc
+// @Summary      Update user
+// @Description  Обновить профиль пользователя
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string              true  "User ID (GUID)"
+// @Param        request  body      user.UpdateRequest  true  "User payload"
+// @Success      200      {object}  user.UserDTO
+// @Failure      400      {object}  v1.Problem
+// @Failure      404      {object}  v1.Problem
+// @Failure      409      {object}  v1.Problem
+// @Failure      504      {object}  v1.Problem
+// @Failure      500      {object}  v1.Problem
+// @Router       /v1/users/{id} [put]
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	const op = "user.update"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	id, ok := h.pathID(w, r, reqID, op)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req UpdateRequest
+	if err := v1.DecodeJSON(r.Body, &req); err != nil {
+		logx.Error(h.Log, reqID, op, "invalid JSON", err)
+		v1.WriteDecodeError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	u := MapUpdateReqToDomain(id, req)
+	if err := ValidateProfile(u.DisplayName, u.Email, u.Currency, u.Locale, u.Timezone); err != nil {
+		logx.Error(h.Log, reqID, op, "validation failed", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+
+	updated, err := h.Repo.UpdateUser(ctx, u)
+	if err != nil {
+		h.writeRepoError(w, reqID, op, "repo update failed", err, id)
+		return
+	}
+
+	logx.Info(h.Log, reqID, op, "updated", "id", id)
+	v1.WriteJSON(w, http.StatusOK, MapDomainToDTO(updated))
+}
+
+// Delete godoc
+// @Summary      Delete user
+// @Description  Удалить пользователя. В зависимости от USER_DELETE_POLICY подписки удаляются вместе с ним (cascade) или удаление запрещается (restrict).
+// @Description  При cascade каждая подписка удаляется как DELETE /v1/subscriptions/{id}: запись в журнале и событие subscription.deleted
+// @Tags         users
+// @Produce      json
+// @Param        id   path      string  true  "User ID (GUID)"
+// @Success      200  {object}  user.CUDResponse
+// @Failure      400  {object}  v1.Problem
+// @Failure      404  {object}  v1.Problem
+// @Failure      409  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/users/{id} [delete]
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	const op = "user.delete"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	id, ok := h.pathID(w, r, reqID, op)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	cascade := h.DeletePolicy == domain.UserDeleteCascade
+	subs, err := h.Repo.DeleteUser(ctx, id, cascade)
+	if err != nil {
+		h.writeRepoError(w, reqID, op, "repo delete failed", err, id)
+		return
+	}
+
+	logx.Info(h.Log, reqID, op, "deleted", "id", id, "cascade", cascade, "subscriptions", len(subs))
+	v1.WriteJSON(w, http.StatusOK, &CUDResponse{UserID: id, Status: DELETED})
+	if h.Events == nil {
+		return
+	}
+	// изменение уже сохранено, поэтому сбой публикации только логируется
+	for _, sub := range subs {
+		if err := h.Events.Publish(ctx, domain.EventSubscriptionDeleted, subscription.MapDomainToDeletedEvent(sub)); err != nil {
+			logx.Error(h.Log, reqID, op, "publish event failed", err, "event", domain.EventSubscriptionDeleted)
+		}
+	}
+}
+
+// List godoc
+// @Summary      List users
+// @Description  Получить список пользователей
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  user.ListResponse
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/users [get]
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	const op = "user.list"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	users, err := h.Repo.ListUsers(ctx)
+	if err != nil {
+		h.writeRepoError(w, reqID, op, "repo list failed", err, "")
+		return
+	}
+
+	resp := &ListResponse{Users: MapDomainListToDTO(users)}
+	logx.Info(h.Log, reqID, op, "returned", "count", len(resp.Users))
+	v1.WriteJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) pathID(w http.ResponseWriter, r *http.Request, reqID, op string) (string, bool) {
+	id := r.PathValue("id")
+	if _, err := uuid.Parse(id); err != nil {
+		logx.Error(h.Log, reqID, op, "bad id", err, "id", id)
+		v1.WriteValidationError(w, v1.NewFieldError("id", "invalid_guid", "value", strconv.Quote(id)))
+		return "", false
+	}
+	return id, true
+}
+
+func (h *Handler) writeRepoError(w http.ResponseWriter, reqID, op, msg string, err error, id string) {
+	switch {
+	case v1.IsTimeout(err):
+		logx.Error(h.Log, reqID, op, "repo timeout", err, "id", id)
+		v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+	case errors.Is(err, domain.ErrUserNotFound):
+		logx.Info(h.Log, reqID, op, "not found", "id", id)
+		v1.WriteMessage(w, http.StatusNotFound, "not_found")
+	case errors.Is(err, domain.ErrEmailTaken):
+		logx.Info(h.Log, reqID, op, "email taken", "id", id)
+		v1.WriteMessage(w, http.StatusConflict, "email_taken")
+	case errors.Is(err, domain.ErrUserHasSubscriptions):
+		logx.Info(h.Log, reqID, op, "user has subscriptions", "id", id)
+		v1.WriteMessage(w, http.StatusConflict, "user_has_subscriptions")
+	default:
+		logx.Error(h.Log, reqID, op, msg, err, "id", id)
+		v1.WriteError(w, http.StatusInternalServerError, "")
+	}
+}
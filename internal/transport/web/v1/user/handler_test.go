@@ -0,0 +1,178 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	mockrepo "github.com/EgorLis/my-subs/internal/infra/database/mock"
+	"github.com/EgorLis/my-subs/internal/transport/web/v1/subscription"
+	"github.com/google/uuid"
+)
+
+// ---------- helpers ----------
+
+func newHandler(repo domain.UserRepository, policy domain.UserDeletePolicy) *Handler {
+	return &Handler{Log: log.New(io.Discard, "", 0), Repo: repo, DeletePolicy: policy}
+}
+
+type publishedEvent struct {
+	event string
+	data  any
+}
+
+// recordingPublisher запоминает опубликованные события
+type recordingPublisher struct{ got []publishedEvent }
+
+func (p *recordingPublisher) Publish(ctx context.Context, event string, data any) error {
+	p.got = append(p.got, publishedEvent{event, data})
+	return nil
+}
+
+func mustJSON(v any) *bytes.Reader {
+	b, _ := json.Marshal(v)
+	return bytes.NewReader(b)
+}
+
+// ---------- CREATE ----------
+
+func TestCreate_Various(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	_, _ = repo.AddUser(context.Background(), domain.User{DisplayName: "Taken", Email: "taken@example.com"})
+
+	cases := []struct {
+		name       string
+		body       CreateRequest
+		wantCode   int
+		wantInBody string
+	}{
+		{"OK_Defaults", CreateRequest{DisplayName: "Ivan"}, http.StatusCreated, `"currency":"RUB"`},
+		{"OK_Full", CreateRequest{DisplayName: "John", Email: "john@example.com", Currency: "USD", Locale: "en-US", Timezone: "America/New_York"}, http.StatusCreated, `"timezone":"America/New_York"`},
+		{"MissingName", CreateRequest{}, http.StatusBadRequest, "display_name"},
+		{"BadEmail", CreateRequest{DisplayName: "A", Email: "not-an-email"}, http.StatusBadRequest, "email"},
+		{"BadCurrency", CreateRequest{DisplayName: "A", Currency: "rub"}, http.StatusBadRequest, "currency"},
+		{"BadLocale", CreateRequest{DisplayName: "A", Locale: "Russian"}, http.StatusBadRequest, "locale"},
+		{"BadTimezone", CreateRequest{DisplayName: "A", Timezone: "Mars/Olympus"}, http.StatusBadRequest, "timezone"},
+		{"EmailTaken", CreateRequest{DisplayName: "B", Email: "TAKEN@example.com"}, http.StatusConflict, "email"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newHandler(repo, domain.UserDeleteRestrict)
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/v1/users", mustJSON(tc.body))
+
+			h.Create(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
+			}
+			if tc.wantInBody != "" && !strings.Contains(w.Body.String(), tc.wantInBody) {
+				t.Fatalf("body should contain %q, got %s", tc.wantInBody, w.Body.String())
+			}
+		})
+	}
+}
+
+// ---------- GET / UPDATE ----------
+
+func TestGetUpdate(t *testing.T) {
+	repo := mockrepo.NewMockRepo()
+	u, _ := repo.AddUser(context.Background(), domain.User{DisplayName: "Ivan", Currency: "RUB", Locale: "ru", Timezone: "UTC"})
+	h := newHandler(repo, domain.UserDeleteRestrict)
+
+	get := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/v1/users/"+id, nil)
+		r.SetPathValue("id", id)
+		h.Get(w, r)
+		return w
+	}
+
+	if w := get(u.ID); w.Code != http.StatusOK {
+		t.Fatalf("get: want 200, got %d", w.Code)
+	}
+	if w := get(uuid.NewString()); w.Code != http.StatusNotFound {
+		t.Fatalf("get unknown: want 404, got %d", w.Code)
+	}
+	if w := get("bad"); w.Code != http.StatusBadRequest {
+		t.Fatalf("get bad id: want 400, got %d", w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/v1/users/"+u.ID, mustJSON(UpdateRequest{DisplayName: "Ivan P.", Locale: "en", Timezone: "Europe/Moscow"}))
+	r.SetPathValue("id", u.ID)
+	h.Update(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update: want 200, got %d. body=%s", w.Code, w.Body.String())
+	}
+	got, _ := repo.GetUser(context.Background(), u.ID)
+	if got.DisplayName != "Ivan P." || got.Locale != "en" || got.Timezone != "Europe/Moscow" {
+		t.Fatalf("user not updated: %+v", got)
+	}
+}
+
+// ---------- DELETE ----------
+
+func TestDelete_Policy(t *testing.T) {
+	for _, policy := range []domain.UserDeletePolicy{domain.UserDeleteRestrict, domain.UserDeleteCascade} {
+		t.Run(string(policy), func(t *testing.T) {
+			ctx := context.Background()
+			repo := mockrepo.NewMockRepo()
+			u, _ := repo.AddUser(ctx, domain.User{DisplayName: "Ivan"})
+			sub, err := repo.AddSub(ctx, domain.Subscription{
+				ServiceName: "Netflix", Price: 500, UserID: u.ID,
+				StartDate: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+				EndDate:   time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+			})
+			if err != nil {
+				t.Fatalf("add sub: %v", err)
+			}
+
+			h := newHandler(repo, policy)
+			events := &recordingPublisher{}
+			h.Events = events
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodDelete, "/v1/users/"+u.ID, nil)
+			r.SetPathValue("id", u.ID)
+			h.Delete(w, r)
+
+			_, subErr := repo.GetSub(ctx, sub.ID)
+			audit, _ := repo.ListAudit(ctx, domain.AuditQuery{SubscriptionID: sub.ID, Action: domain.AuditDelete})
+			switch policy {
+			case domain.UserDeleteRestrict:
+				if w.Code != http.StatusConflict || subErr != nil {
+					t.Fatalf("restrict: want 409 and subscription kept, got %d, %v", w.Code, subErr)
+				}
+				if len(events.got) != 0 || len(audit.Entries) != 0 {
+					t.Fatalf("restrict: want no events and audit, got %+v, %+v", events.got, audit.Entries)
+				}
+			case domain.UserDeleteCascade:
+				if w.Code != http.StatusOK || subErr == nil {
+					t.Fatalf("cascade: want 200 and subscription removed, got %d, %v", w.Code, subErr)
+				}
+				// подписка удаляется как DELETE /v1/subscriptions/{id}: журнал и событие
+				if len(audit.Entries) != 1 {
+					t.Fatalf("cascade: want delete audit entry, got %+v", audit.Entries)
+				}
+				want := subscription.DeletedEvent{ID: sub.ID, UserID: u.ID}
+				if len(events.got) != 1 || events.got[0].event != domain.EventSubscriptionDeleted || events.got[0].data != want {
+					t.Fatalf("cascade: want %s %+v, got %+v", domain.EventSubscriptionDeleted, want, events.got)
+				}
+				// без владельца восстановить нечего
+				if _, err := repo.RestoreSub(ctx, sub.ID); !errors.Is(err, domain.ErrNotFound) {
+					t.Fatalf("cascade: want trash purged, restore got %v", err)
+				}
+			}
+		})
+	}
+}
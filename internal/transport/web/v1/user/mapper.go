@@ -0,0 +1,67 @@
+package user
+
+import (
+	"strings"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
+
+// --- запросы -> домен ---
+
+func MapCreateReqToDomain(req CreateRequest) domain.User {
+	return withDefaults(domain.User{
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		Email:       strings.TrimSpace(req.Email),
+		Currency:    req.Currency,
+		Locale:      req.Locale,
+		Timezone:    req.Timezone,
+	})
+}
+
+func MapUpdateReqToDomain(id string, req UpdateRequest) domain.User {
+	return withDefaults(domain.User{
+		ID:          id,
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		Email:       strings.TrimSpace(req.Email),
+		Currency:    req.Currency,
+		Locale:      req.Locale,
+		Timezone:    req.Timezone,
+	})
+}
+
+func withDefaults(u domain.User) domain.User {
+	if u.Currency == "" {
+		u.Currency = domain.DefaultCurrency
+	}
+	if u.Locale == "" {
+		u.Locale = domain.DefaultLocale
+	}
+	if u.Timezone == "" {
+		u.Timezone = domain.DefaultTimezone
+	}
+	return u
+}
+
+// --- домен -> DTO/Response ---
+
+func MapDomainToDTO(u domain.User) UserDTO {
+	return UserDTO{
+		ID:          u.ID,
+		DisplayName: u.DisplayName,
+		Email:       u.Email,
+		Currency:    u.Currency,
+		Locale:      u.Locale,
+		Timezone:    u.Timezone,
+		CreatedAt:   u.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:   u.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func MapDomainListToDTO(users []domain.User) []UserDTO {
+	out := make([]UserDTO, 0, len(users))
+	for _, u := range users {
+		out = append(out, MapDomainToDTO(u))
+	}
+	return out
+}
@@ -0,0 +1,18 @@
+package user
+
+// CreateRequest — профиль пользователя; пустые currency, locale и timezone заменяются значениями по умолчанию
+type CreateRequest struct {
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email"`
+	Currency    string `json:"currency"`
+	Locale      string `json:"locale"`
+	Timezone    string `json:"timezone"`
+}
+
+type UpdateRequest struct {
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email"`
+	Currency    string `json:"currency"`
+	Locale      string `json:"locale"`
+	Timezone    string `json:"timezone"`
+}
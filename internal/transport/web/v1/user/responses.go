@@ -0,0 +1,22 @@
+package user
+
+type UserDTO struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email,omitempty"`
+	Currency    string `json:"currency"`
+	Locale      string `json:"locale"`
+	Timezone    string `json:"timezone"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// ответ для CREATE, UPDATE, DELETE
+type CUDResponse struct {
+	UserID string `json:"user_id"`
+	Status string `json:"status"`
+}
+
+type ListResponse struct {
+	Users []UserDTO `json:"users"`
+}
@@ -0,0 +1,43 @@
+package user
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+var (
+	currencyRe = regexp.MustCompile(`^[A-Z]{3}$`)
+	localeRe   = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+)
+
+// ValidateProfile проверяет профиль после подстановки значений по умолчанию
+func ValidateProfile(displayName, email, currency, locale, timezone string) error {
+	var errs []v1.FieldError
+
+	if strings.TrimSpace(displayName) == "" {
+		errs = append(errs, v1.Field("display_name", "required"))
+	}
+	if email = strings.TrimSpace(email); email != "" {
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			errs = append(errs, v1.Field("email", "invalid_email"))
+		}
+	}
+	if !currencyRe.MatchString(currency) {
+		errs = append(errs, v1.Field("currency", "invalid_currency"))
+	}
+	if !localeRe.MatchString(locale) {
+		errs = append(errs, v1.Field("locale", "invalid_locale"))
+	}
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+		errs = append(errs, v1.Field("timezone", "invalid_timezone"))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return v1.Fields(errs)
+}
@@ -0,0 +1,337 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	"github.com/EgorLis/my-subs/internal/transport/web/logx"
+	"github.com/EgorLis/my-subs/internal/transport/web/mw"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+	"github.com/google/uuid"
+)
+
+const DELETED = "webhook deleted"
+
+type Handler struct {
+	Log  *log.Logger
+	Repo domain.WebhookRepository
+}
+
+// Create godoc
+// @Summary      Create webhook
+// @Description  Зарегистрировать адрес, на который POST-ом отправляются события subscription.created, subscription.updated, subscription.deleted и subscription.restored.
+// @Description  Тело подписывается HMAC-SHA256 секретом вебхука: X-Webhook-Signature: t=<unix>,v1=<hex HMAC("<unix>.<body>")>.
+// @Description  Секрет возвращается только в этом ответе; если он не передан, сервер генерирует его сам.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        request  body      webhook.CreateRequest  true  "Webhook payload"
+// @Success      201      {object}  webhook.WebhookDTO
+// @Failure      400      {object}  v1.Problem
+// @Failure      404      {object}  v1.Problem
+// @Failure      504      {object}  v1.Problem
+// @Failure      500      {object}  v1.Problem
+// @Router       /v1/webhooks [post]
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	const op = "webhook.create"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req CreateRequest
+	if err := v1.DecodeJSON(r.Body, &req); err != nil {
+		logx.Error(h.Log, reqID, op, "invalid JSON", err)
+		v1.WriteDecodeError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := ValidateCreateRequest(req); err != nil {
+		logx.Error(h.Log, reqID, op, "validation failed", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = newSecret(); err != nil {
+			logx.Error(h.Log, reqID, op, "generate secret failed", err)
+			v1.WriteError(w, http.StatusInternalServerError, "")
+			return
+		}
+	}
+
+	created, err := h.Repo.AddWebhook(ctx, MapCreateReqToDomain(req, secret))
+	if err != nil {
+		h.writeRepoError(w, reqID, op, "repo add failed", err, "")
+		return
+	}
+
+	resp := MapDomainToDTO(created)
+	resp.Secret = created.Secret
+	logx.Info(h.Log, reqID, op, "created", "webhook_id", created.ID, "events", len(created.Events))
+	v1.WriteJSON(w, http.StatusCreated, resp)
+}
+
+// Get godoc
+// @Summary      Get webhook by ID
+// @Description  Получить вебхук; секрет не возвращается
+// @Tags         webhooks
+// @Produce      json
+// @Param        id   path      string  true  "Webhook ID (GUID)"
+// @Success      200  {object}  webhook.WebhookDTO
+// @Failure      400  {object}  v1.Problem
+// @Failure      404  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/webhooks/{id} [get]
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	const op = "webhook.get"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	id, ok := h.pathID(w, r, reqID, op, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	wh, err := h.Repo.GetWebhook(ctx, id)
+	if err != nil {
+		h.writeRepoError(w, reqID, op, "repo get failed", err, id)
+		return
+	}
+
+	logx.Info(h.Log, reqID, op, "returned", "id", id)
+	v1.WriteJSON(w, http.StatusOK, MapDomainToDTO(wh))
+}
+
+// List godoc
+// @Summary      List webhooks
+// @Description  Получить вебхуки тенанта; секреты не возвращаются
+// @Tags         webhooks
+// @Produce      json
+// @Success      200  {object}  webhook.ListResponse
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/webhooks [get]
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	const op = "webhook.list"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	hooks, err := h.Repo.ListWebhooks(ctx)
+	if err != nil {
+		h.writeRepoError(w, reqID, op, "repo list failed", err, "")
+		return
+	}
+
+	resp := &ListResponse{Webhooks: MapDomainListToDTO(hooks)}
+	logx.Info(h.Log, reqID, op, "returned", "count", len(resp.Webhooks))
+	v1.WriteJSON(w, http.StatusOK, resp)
+}
+
+// Delete godoc
+// @Summary      Delete webhook
+// @Description  Удалить вебхук вместе с журналом доставок; неотправленные доставки отменяются
+// @Tags         webhooks
+// @Produce      json
+// @Param        id   path      string  true  "Webhook ID (GUID)"
+// @Success      200  {object}  webhook.CUDResponse
+// @Failure      400  {object}  v1.Problem
+// @Failure      404  {object}  v1.Problem
+// @Failure      504  {object}  v1.Problem
+// @Failure      500  {object}  v1.Problem
+// @Router       /v1/webhooks/{id} [delete]
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	const op = "webhook.delete"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	id, ok := h.pathID(w, r, reqID, op, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Repo.DeleteWebhook(ctx, id); err != nil {
+		h.writeRepoError(w, reqID, op, "repo delete failed", err, id)
+		return
+	}
+
+	logx.Info(h.Log, reqID, op, "deleted", "id", id)
+	v1.WriteJSON(w, http.StatusOK, &CUDResponse{WebhookID: id, Status: DELETED})
+}
+
+// Deliveries godoc
+// @Summary      List webhook deliveries
+// @Description  Журнал доставок вебхука, новые первыми: статус, число попыток, код последнего ответа и ошибка
+// @Tags         webhooks
+// @Produce      json
+// @Param        id     path      string  true   "Webhook ID (GUID)"
+// @Param        limit  query     int     false  "Сколько доставок вернуть (1..200, по умолчанию 50)"
+// @Success      200    {object}  webhook.DeliveriesResponse
+// @Failure      400    {object}  v1.Problem
+// @Failure      404    {object}  v1.Problem
+// @Failure      504    {object}  v1.Problem
+// @Failure      500    {object}  v1.Problem
+// @Router       /v1/webhooks/{id}/deliveries [get]
+func (h *Handler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	const op = "webhook.deliveries"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	id, ok := h.pathID(w, r, reqID, op, "id")
+	if !ok {
+		return
+	}
+	limit, err := ParseDeliveriesLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		logx.Error(h.Log, reqID, op, "validation failed", err)
+		v1.WriteValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	list, err := h.Repo.ListDeliveries(ctx, id, limit)
+	if err != nil {
+		h.writeRepoError(w, reqID, op, "repo list deliveries failed", err, id)
+		return
+	}
+
+	resp := &DeliveriesResponse{Deliveries: MapDeliveryListToDTO(list)}
+	logx.Info(h.Log, reqID, op, "returned", "id", id, "count", len(resp.Deliveries))
+	v1.WriteJSON(w, http.StatusOK, resp)
+}
+
+// Delivery godoc
+// @Summary      Get webhook delivery
+// @Description  Доставка вместе с отправленным телом события
+// @Tags         webhooks
+// @Produce      json
+// @Param        id           path      string  true  "Webhook ID (GUID)"
+// @Param        delivery_id  path      string  true  "Delivery ID (GUID)"
+// @Success      200          {object}  webhook.DeliveryDTO
+// @Failure      400          {object}  v1.Problem
+// @Failure      404          {object}  v1.Problem
+// @Failure      504          {object}  v1.Problem
+// @Failure      500          {object}  v1.Problem
+// @Router       /v1/webhooks/{id}/deliveries/{delivery_id} [get]
+func (h *Handler) Delivery(w http.ResponseWriter, r *http.Request) {
+	const op = "webhook.delivery"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	id, ok := h.pathID(w, r, reqID, op, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := h.pathID(w, r, reqID, op, "delivery_id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	d, err := h.Repo.GetDelivery(ctx, id, deliveryID)
+	if err != nil {
+		h.writeRepoError(w, reqID, op, "repo get delivery failed", err, deliveryID)
+		return
+	}
+
+	logx.Info(h.Log, reqID, op, "returned", "id", id, "delivery_id", deliveryID)
+	v1.WriteJSON(w, http.StatusOK, MapDeliveryWithPayloadToDTO(d))
+}
+
+// Redeliver godoc
+// @Summary      Redeliver webhook event
+// @Description  Поставить событие доставки в очередь ещё раз: создаётся новая доставка с тем же event_id и телом,
+// @Description  её отправит фоновая задача. Получатель может отбрасывать повторы по event_id.
+// @Tags         webhooks
+// @Produce      json
+// @Param        id           path      string  true  "Webhook ID (GUID)"
+// @Param        delivery_id  path      string  true  "Delivery ID (GUID)"
+// @Success      202          {object}  webhook.DeliveryDTO
+// @Failure      400          {object}  v1.Problem
+// @Failure      404          {object}  v1.Problem
+// @Failure      504          {object}  v1.Problem
+// @Failure      500          {object}  v1.Problem
+// @Router       /v1/webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *Handler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	const op = "webhook.redeliver"
+	reqID := mw.RequestIDFromCtx(r.Context())
+
+	id, ok := h.pathID(w, r, reqID, op, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := h.pathID(w, r, reqID, op, "delivery_id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	d, err := h.Repo.RedeliverWebhook(ctx, id, deliveryID)
+	if err != nil {
+		h.writeRepoError(w, reqID, op, "repo redeliver failed", err, deliveryID)
+		return
+	}
+
+	logx.Info(h.Log, reqID, op, "queued", "id", id, "from", deliveryID, "delivery_id", d.ID)
+	v1.WriteJSON(w, http.StatusAccepted, MapDeliveryToDTO(d))
+}
+
+func (h *Handler) pathID(w http.ResponseWriter, r *http.Request, reqID, op, name string) (string, bool) {
+	id := r.PathValue(name)
+	if _, err := uuid.Parse(id); err != nil {
+		logx.Error(h.Log, reqID, op, "bad id", err, name, id)
+		v1.WriteValidationError(w, v1.NewFieldError(name, "invalid_guid", "value", strconv.Quote(id)))
+		return "", false
+	}
+	return id, true
+}
+
+func (h *Handler) writeRepoError(w http.ResponseWriter, reqID, op, msg string, err error, id string) {
+	switch {
+	case v1.IsTimeout(err):
+		logx.Error(h.Log, reqID, op, "repo timeout", err, "id", id)
+		v1.WriteMessage(w, http.StatusGatewayTimeout, "timeout")
+	case errors.Is(err, domain.ErrWebhookNotFound):
+		logx.Info(h.Log, reqID, op, "webhook not found", "id", id)
+		v1.WriteMessage(w, http.StatusNotFound, "webhook_not_found")
+	case errors.Is(err, domain.ErrDeliveryNotFound):
+		logx.Info(h.Log, reqID, op, "delivery not found", "id", id)
+		v1.WriteMessage(w, http.StatusNotFound, "delivery_not_found")
+	case errors.Is(err, domain.ErrTenantNotFound):
+		logx.Info(h.Log, reqID, op, "tenant not found")
+		v1.WriteMessage(w, http.StatusNotFound, "tenant_not_found")
+	default:
+		logx.Error(h.Log, reqID, op, msg, err, "id", id)
+		v1.WriteError(w, http.StatusInternalServerError, "")
+	}
+}
+
+// newSecret — случайный ключ подписи, если клиент не передал свой
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,181 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	mockrepo "github.com/EgorLis/my-subs/internal/infra/database/mock"
+	"github.com/google/uuid"
+)
+
+// ---------- helpers ----------
+
+func newHandler(repo domain.WebhookRepository) *Handler {
+	return &Handler{Log: log.New(io.Discard, "", 0), Repo: repo}
+}
+
+func mustJSON(v any) *bytes.Reader {
+	b, _ := json.Marshal(v)
+	return bytes.NewReader(b)
+}
+
+// ---------- CREATE ----------
+
+func TestCreate_Various(t *testing.T) {
+	cases := []struct {
+		name       string
+		body       CreateRequest
+		wantCode   int
+		wantInBody string
+	}{
+		{"OK_AllEvents", CreateRequest{URL: "https://example.com/hook"}, http.StatusCreated, `"subscription.deleted"`},
+		{"OK_GeneratedSecret", CreateRequest{URL: "http://localhost:9000/hook"}, http.StatusCreated, `"secret":"whsec_`},
+		{"OK_OwnSecret", CreateRequest{URL: "https://example.com/hook", Secret: "0123456789abcdef"}, http.StatusCreated, `"secret":"0123456789abcdef"`},
+		{"OK_SomeEvents", CreateRequest{URL: "https://example.com/hook", Events: []string{"subscription.deleted", "subscription.created"}},
+			http.StatusCreated, `"events":["subscription.created","subscription.deleted"]`},
+		{"MissingURL", CreateRequest{}, http.StatusBadRequest, "url"},
+		{"BadScheme", CreateRequest{URL: "ftp://example.com"}, http.StatusBadRequest, "url"},
+		{"RelativeURL", CreateRequest{URL: "/hook"}, http.StatusBadRequest, "url"},
+		{"UnknownEvent", CreateRequest{URL: "https://example.com", Events: []string{"user.created"}}, http.StatusBadRequest, "events[0]"},
+		{"ShortSecret", CreateRequest{URL: "https://example.com", Secret: "short"}, http.StatusBadRequest, "secret"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newHandler(mockrepo.NewMockRepo())
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/v1/webhooks", mustJSON(tc.body))
+
+			h.Create(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
+			}
+			if tc.wantInBody != "" && !strings.Contains(w.Body.String(), tc.wantInBody) {
+				t.Fatalf("body should contain %q, got %s", tc.wantInBody, w.Body.String())
+			}
+		})
+	}
+}
+
+// ---------- GET / LIST / DELETE ----------
+
+func TestGetListDelete(t *testing.T) {
+	ctx := context.Background()
+	repo := mockrepo.NewMockRepo()
+	wh, _ := repo.AddWebhook(ctx, domain.Webhook{URL: "https://example.com", Secret: "s3cret-s3cret-s3cret", Events: domain.WebhookEvents})
+	h := newHandler(repo)
+
+	get := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/v1/webhooks/"+id, nil)
+		r.SetPathValue("id", id)
+		h.Get(w, r)
+		return w
+	}
+
+	w := get(wh.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("get: want 200, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "s3cret") {
+		t.Fatalf("secret must not be returned: %s", w.Body.String())
+	}
+	if w := get(uuid.NewString()); w.Code != http.StatusNotFound {
+		t.Fatalf("get unknown: want 404, got %d", w.Code)
+	}
+	if w := get("bad"); w.Code != http.StatusBadRequest {
+		t.Fatalf("get bad id: want 400, got %d", w.Code)
+	}
+	// вебхуки другого тенанта не видны
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/v1/webhooks/"+wh.ID, nil).WithContext(domain.WithTenant(ctx, "other"))
+	r.SetPathValue("id", wh.ID)
+	h.Get(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("get from other tenant: want 404, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.List(w, httptest.NewRequest(http.MethodGet, "/v1/webhooks", nil))
+	var list ListResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if w.Code != http.StatusOK || len(list.Webhooks) != 1 || list.Webhooks[0].Secret != "" {
+		t.Fatalf("list: code=%d body=%s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/v1/webhooks/"+wh.ID, nil)
+	r.SetPathValue("id", wh.ID)
+	h.Delete(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("delete: want 200, got %d", w.Code)
+	}
+	if w := get(wh.ID); w.Code != http.StatusNotFound {
+		t.Fatalf("get after delete: want 404, got %d", w.Code)
+	}
+}
+
+// ---------- DELIVERIES / REDELIVER ----------
+
+func TestDeliveriesAndRedeliver(t *testing.T) {
+	ctx := context.Background()
+	repo := mockrepo.NewMockRepo()
+	wh, _ := repo.AddWebhook(ctx, domain.Webhook{URL: "https://example.com", Secret: "s", Events: domain.WebhookEvents})
+	_, _ = repo.EnqueueDeliveries(ctx, domain.EventSubscriptionCreated, []domain.WebhookEvent{{ID: "evt-1", Payload: []byte(`{"id":"evt-1"}`)}})
+	list, _ := repo.ListDeliveries(ctx, wh.ID, 0)
+	first := list[0]
+	h := newHandler(repo)
+
+	req := func(method, target, id, deliveryID string, fn http.HandlerFunc) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, target, nil)
+		r.SetPathValue("id", id)
+		r.SetPathValue("delivery_id", deliveryID)
+		fn(w, r)
+		return w
+	}
+
+	cases := []struct {
+		name       string
+		method     string
+		target     string
+		id, did    string
+		fn         http.HandlerFunc
+		wantCode   int
+		wantInBody string
+	}{
+		{"List", http.MethodGet, "/v1/webhooks/x/deliveries", wh.ID, "", h.Deliveries, http.StatusOK, `"event_id":"evt-1"`},
+		{"List_BadLimit", http.MethodGet, "/v1/webhooks/x/deliveries?limit=0", wh.ID, "", h.Deliveries, http.StatusBadRequest, "limit"},
+		{"List_UnknownWebhook", http.MethodGet, "/v1/webhooks/x/deliveries", uuid.NewString(), "", h.Deliveries, http.StatusNotFound, ""},
+		{"Get_WithPayload", http.MethodGet, "/", wh.ID, first.ID, h.Delivery, http.StatusOK, `"payload":{"id":"evt-1"}`},
+		{"Get_Unknown", http.MethodGet, "/", wh.ID, uuid.NewString(), h.Delivery, http.StatusNotFound, ""},
+		{"Redeliver", http.MethodPost, "/", wh.ID, first.ID, h.Redeliver, http.StatusAccepted, `"status":"pending"`},
+		{"Redeliver_BadID", http.MethodPost, "/", wh.ID, "bad", h.Redeliver, http.StatusBadRequest, "delivery_id"},
+		{"Redeliver_Unknown", http.MethodPost, "/", wh.ID, uuid.NewString(), h.Redeliver, http.StatusNotFound, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := req(tc.method, tc.target, tc.id, tc.did, tc.fn)
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d, got %d. body=%s", tc.wantCode, w.Code, w.Body.String())
+			}
+			if tc.wantInBody != "" && !strings.Contains(w.Body.String(), tc.wantInBody) {
+				t.Fatalf("body should contain %q, got %s", tc.wantInBody, w.Body.String())
+			}
+		})
+	}
+
+	list, _ = repo.ListDeliveries(ctx, wh.ID, 0)
+	if len(list) != 2 || list[0].EventID != "evt-1" || list[0].ID == first.ID {
+		t.Fatalf("redeliver must add a delivery of the same event: %+v", list)
+	}
+}
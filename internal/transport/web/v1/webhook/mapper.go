@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+)
+
+// --- запросы -> домен ---
+
+func MapCreateReqToDomain(req CreateRequest, secret string) domain.Webhook {
+	events := make([]string, 0, len(domain.WebhookEvents))
+	// порядок как в domain.WebhookEvents, без повторов
+	for _, e := range domain.WebhookEvents {
+		if len(req.Events) == 0 || contains(req.Events, e) {
+			events = append(events, e)
+		}
+	}
+	return domain.Webhook{URL: strings.TrimSpace(req.URL), Events: events, Secret: secret}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// --- домен -> DTO/Response ---
+
+func MapDomainToDTO(wh domain.Webhook) WebhookDTO {
+	return WebhookDTO{
+		ID:        wh.ID,
+		URL:       wh.URL,
+		Events:    append([]string{}, wh.Events...),
+		CreatedAt: wh.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func MapDomainListToDTO(hooks []domain.Webhook) []WebhookDTO {
+	out := make([]WebhookDTO, 0, len(hooks))
+	for _, wh := range hooks {
+		out = append(out, MapDomainToDTO(wh))
+	}
+	return out
+}
+
+func MapDeliveryToDTO(d domain.WebhookDelivery) DeliveryDTO {
+	dto := DeliveryDTO{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		EventID:        d.EventID,
+		Event:          d.Event,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:      d.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if d.Status == domain.DeliveryPending {
+		dto.NextAttemptAt = d.NextAttemptAt.UTC().Format(time.RFC3339)
+	}
+	return dto
+}
+
+// MapDeliveryWithPayloadToDTO — доставка вместе с отправленным телом
+func MapDeliveryWithPayloadToDTO(d domain.WebhookDelivery) DeliveryDTO {
+	dto := MapDeliveryToDTO(d)
+	dto.Payload = json.RawMessage(d.Payload)
+	return dto
+}
+
+func MapDeliveryListToDTO(list []domain.WebhookDelivery) []DeliveryDTO {
+	out := make([]DeliveryDTO, 0, len(list))
+	for _, d := range list {
+		out = append(out, MapDeliveryToDTO(d))
+	}
+	return out
+}
@@ -0,0 +1,9 @@
+package webhook
+
+// CreateRequest — адрес получателя и события; пустой events подписывает на все,
+// пустой secret генерируется сервером
+type CreateRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
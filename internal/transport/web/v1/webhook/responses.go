@@ -0,0 +1,40 @@
+package webhook
+
+import "encoding/json"
+
+type WebhookDTO struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	Secret    string   `json:"secret,omitempty"` // только в ответе на создание
+	CreatedAt string   `json:"created_at"`
+}
+
+// ответ для DELETE
+type CUDResponse struct {
+	WebhookID string `json:"webhook_id"`
+	Status    string `json:"status"`
+}
+
+type ListResponse struct {
+	Webhooks []WebhookDTO `json:"webhooks"`
+}
+
+type DeliveryDTO struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhook_id"`
+	EventID        string          `json:"event_id"`
+	Event          string          `json:"event"`
+	Status         string          `json:"status" enums:"pending,succeeded,failed"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  string          `json:"next_attempt_at,omitempty"` // только для pending
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      string          `json:"created_at"`
+	UpdatedAt      string          `json:"updated_at"`
+	Payload        json.RawMessage `json:"payload,omitempty" swaggertype:"object"` // только в ответе на запрос одной доставки
+}
+
+type DeliveriesResponse struct {
+	Deliveries []DeliveryDTO `json:"deliveries"`
+}
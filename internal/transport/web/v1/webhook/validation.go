@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/EgorLis/my-subs/internal/domain"
+	v1 "github.com/EgorLis/my-subs/internal/transport/web/v1"
+)
+
+const (
+	maxURLLength    = 2048
+	minSecretLength = 16
+	maxSecretLength = 256
+
+	DefaultDeliveriesLimit = 50
+	MaxDeliveriesLimit     = 200
+)
+
+// ValidateCreateRequest проверяет адрес, события и секрет нового вебхука
+func ValidateCreateRequest(req CreateRequest) error {
+	var errs []v1.FieldError
+
+	raw := strings.TrimSpace(req.URL)
+	switch u, err := url.Parse(raw); {
+	case raw == "":
+		errs = append(errs, v1.Field("url", "required"))
+	case len(raw) > maxURLLength:
+		errs = append(errs, v1.Field("url", "max_length", "max", maxURLLength))
+	case err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "":
+		errs = append(errs, v1.Field("url", "invalid_url"))
+	}
+
+	for i, e := range req.Events {
+		if !contains(domain.WebhookEvents, e) {
+			errs = append(errs, v1.Field("events["+strconv.Itoa(i)+"]", "one_of",
+				"values", strings.Join(domain.WebhookEvents, ", ")))
+		}
+	}
+
+	if n := len(req.Secret); n > 0 && (n < minSecretLength || n > maxSecretLength) {
+		errs = append(errs, v1.Field("secret", "length_between", "min", minSecretLength, "max", maxSecretLength))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return v1.Fields(errs)
+}
+
+// ParseDeliveriesLimit разбирает ?limit= журнала доставок
+func ParseDeliveriesLimit(raw string) (int, error) {
+	if raw == "" {
+		return DefaultDeliveriesLimit, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 || n > MaxDeliveriesLimit {
+		return 0, v1.NewFieldError("limit", "between", "min", 1, "max", MaxDeliveriesLimit)
+	}
+	return n, nil
+}
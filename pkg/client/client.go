@@ -0,0 +1,214 @@
+// Package client — Go-клиент REST API my-subs (/v1).
+//
+// Все методы принимают context: отмена и дедлайн прерывают и запрос, и ожидание между повторами.
+// Запросы, которые можно безопасно повторить (GET, PUT, DELETE и POST с ключом идемпотентности),
+// повторяются с экспоненциальной задержкой при 5xx и сетевых ошибках.
+// Ошибки API возвращаются как *APIError или типизированные BadRequestError, NotFoundError,
+// ConflictError и PreconditionFailedError.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	HeaderRequestID      = "X-Request-ID"
+	HeaderTenantID       = "X-Tenant-ID"
+	HeaderIdempotencyKey = "Idempotency-Key"
+
+	DefaultMaxRetries   = 3
+	DefaultRetryBackoff = 200 * time.Millisecond
+	maxRetryBackoff     = 5 * time.Second
+)
+
+// Client — клиент API. Поля можно менять до первого запроса; методы безопасны для параллельного вызова.
+type Client struct {
+	BaseURL      string       // например http://localhost:8001
+	HTTPClient   *http.Client // nil — http.DefaultClient
+	TenantID     string       // X-Tenant-ID; пусто — тенант по умолчанию
+	Token        string       // Authorization: Bearer, если сервис стоит за прокси с аутентификацией
+	Language     string       // Accept-Language: язык сообщений об ошибках (en, ru); пусто — язык сервиса по умолчанию
+	MaxRetries   int          // сколько раз повторять запрос после первой попытки; 0 — не повторять
+	RetryBackoff time.Duration
+}
+
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:      strings.TrimRight(baseURL, "/"),
+		HTTPClient:   &http.Client{Timeout: 30 * time.Second},
+		MaxRetries:   DefaultMaxRetries,
+		RetryBackoff: DefaultRetryBackoff,
+	}
+}
+
+type ctxKey int
+
+const (
+	ctxRequestID ctxKey = iota
+	ctxIdempotencyKey
+)
+
+// WithRequestID задаёт X-Request-ID для запросов с этим контекстом; без него id генерируется на каждый вызов
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxRequestID, id)
+}
+
+// WithIdempotencyKey задаёт Idempotency-Key для POST-запросов; без него ключ генерируется на каждый вызов,
+// чтобы повторы после 5xx не создавали дубликатов
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, ctxIdempotencyKey, key)
+}
+
+func fromCtx(ctx context.Context, key ctxKey) string {
+	v, _ := ctx.Value(key).(string)
+	return v
+}
+
+// request — описание одного вызова API; body перечитывается при каждой попытке
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        []byte
+	contentType string
+	header      http.Header
+	idempotent  bool // POST с ключом идемпотентности
+}
+
+func jsonRequest(method, path string, in any) (request, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return request{}, fmt.Errorf("encode request: %w", err)
+	}
+	return request{method: method, path: path, body: body, contentType: "application/json"}, nil
+}
+
+func (r request) retryable() bool {
+	switch r.method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return r.idempotent
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// do выполняет запрос с повторами. Успешный ответ (2xx, 304) возвращается с открытым телом,
+// остальные превращаются в ошибку.
+func (c *Client) do(ctx context.Context, req request) (*http.Response, error) {
+	reqID := fromCtx(ctx, ctxRequestID)
+	if reqID == "" {
+		reqID = uuid.NewString()
+	}
+	idemKey := ""
+	if req.idempotent {
+		if idemKey = fromCtx(ctx, ctxIdempotencyKey); idemKey == "" {
+			idemKey = uuid.NewString()
+		}
+	}
+
+	u := c.BaseURL + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		hr, err := http.NewRequestWithContext(ctx, req.method, u, bytes.NewReader(req.body))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range req.header {
+			hr.Header[k] = v
+		}
+		if req.contentType != "" {
+			hr.Header.Set("Content-Type", req.contentType)
+		}
+		hr.Header.Set(HeaderRequestID, reqID)
+		if c.TenantID != "" {
+			hr.Header.Set(HeaderTenantID, c.TenantID)
+		}
+		if c.Token != "" {
+			hr.Header.Set("Authorization", "Bearer "+c.Token)
+		}
+		if c.Language != "" {
+			hr.Header.Set("Accept-Language", c.Language)
+		}
+		if idemKey != "" {
+			hr.Header.Set(HeaderIdempotencyKey, idemKey)
+		}
+
+		resp, err := c.httpClient().Do(hr)
+		if err == nil && (resp.StatusCode < 300 || resp.StatusCode == http.StatusNotModified) {
+			return resp, nil
+		}
+
+		var apiErr error
+		if err == nil {
+			apiErr = newAPIError(resp, reqID)
+			resp.Body.Close()
+		} else if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		retry := req.retryable() && attempt < c.MaxRetries && (err != nil || resp.StatusCode >= http.StatusInternalServerError)
+		if !retry {
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", req.method, req.path, err)
+			}
+			return nil, apiErr
+		}
+		if err := sleep(ctx, c.backoff(attempt)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// backoff — экспоненциальная задержка с разбросом до половины интервала
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.RetryBackoff << attempt
+	if d <= 0 || d > maxRetryBackoff {
+		d = maxRetryBackoff
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// doJSON выполняет запрос и декодирует тело ответа в out (если out != nil)
+func (c *Client) doJSON(ctx context.Context, req request, out any) (http.Header, error) {
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotModified {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("decode %s %s response: %w", req.method, req.path, err)
+		}
+	}
+	return resp.Header, nil
+}
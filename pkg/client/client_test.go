@@ -0,0 +1,298 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/EgorLis/my-subs/internal/config"
+	mockrepo "github.com/EgorLis/my-subs/internal/infra/database/mock"
+	"github.com/EgorLis/my-subs/internal/transport/web"
+	"github.com/EgorLis/my-subs/pkg/client"
+	"github.com/google/uuid"
+)
+
+// newRouter — настоящий роутер приложения на mock-репозитории
+func newRouter(t *testing.T) http.Handler {
+	t.Helper()
+	cfg := &config.Config{
+		UserDeletePolicy:      "restrict",
+		IdempotencyTTL:        time.Hour,
+		CalendarAlarmDays:     3,
+		CalendarHorizonMonths: 12,
+		GraphQLMaxDepth:       8,
+		GraphQLMaxComplexity:  5000,
+	}
+	return web.New(log.New(io.Discard, "", 0), cfg, mockrepo.NewMockRepo()).Handler()
+}
+
+func newClient(t *testing.T, h http.Handler) *client.Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	c := client.New(srv.URL)
+	c.RetryBackoff = time.Millisecond
+	return c
+}
+
+func input(userID string) client.SubscriptionInput {
+	return client.SubscriptionInput{
+		ServiceName: "Netflix", Price: 500, UserID: userID,
+		StartDate: client.YM(2025, time.January), EndDate: client.YM(2025, time.March),
+		Tags: []string{"Video"},
+	}
+}
+
+func TestClient_Subscriptions(t *testing.T) {
+	c := newClient(t, newRouter(t))
+	ctx := context.Background()
+
+	if err := c.Healthz(ctx); err != nil {
+		t.Fatalf("healthz: %v", err)
+	}
+	u, err := c.CreateUser(ctx, client.UserInput{DisplayName: "Ivan", Currency: "RUB"})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	created, err := c.CreateSubscription(ctx, input(u.ID))
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.ID == "" || created.Version != 1 {
+		t.Fatalf("unexpected create result: %+v", created)
+	}
+
+	sub, err := c.GetSubscription(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if sub.StartDate != client.YM(2025, time.January) || sub.Tags[0] != "video" || sub.CreatedAt.IsZero() {
+		t.Fatalf("unexpected subscription: %+v", sub)
+	}
+
+	in := input(u.ID)
+	in.Price = 700
+	upd, err := c.UpdateSubscription(ctx, created.ID, in, sub.Version)
+	if err != nil || upd.Version != 2 {
+		t.Fatalf("update: %+v %v", upd, err)
+	}
+	patched, err := c.MergePatchSubscription(ctx, created.ID, map[string]any{"notes": "семейный"}, 2)
+	if err != nil || patched.Version != 3 {
+		t.Fatalf("merge patch: %+v %v", patched, err)
+	}
+	patched, err = c.JSONPatchSubscription(ctx, created.ID, []client.PatchOperation{{Op: "replace", Path: "/price", Value: 800}}, 0)
+	if err != nil || patched.Version != 4 {
+		t.Fatalf("json patch: %+v %v", patched, err)
+	}
+
+	page, err := c.ListSubscriptions(ctx, client.ListParams{UserID: u.ID, Limit: 10})
+	if err != nil || len(page.Subscriptions) != 1 || page.Subscriptions[0].Price != 800 {
+		t.Fatalf("list: %+v %v", page, err)
+	}
+	total, err := c.TotalCost(ctx, client.TotalCostParams{UserID: u.ID, ServiceName: "Netflix",
+		From: client.YM(2025, time.January), To: client.YM(2025, time.March)})
+	if err != nil || total != 800 {
+		t.Fatalf("total cost: %d %v", total, err)
+	}
+	hits, err := c.SearchSubscriptions(ctx, client.SearchParams{Query: "netflx"})
+	if err != nil || len(hits) != 1 {
+		t.Fatalf("search: %+v %v", hits, err)
+	}
+	sugg, err := c.SuggestServices(ctx, "net", 0)
+	if err != nil || len(sugg) != 1 || sugg[0].Name != "Netflix" {
+		t.Fatalf("suggest: %+v %v", sugg, err)
+	}
+
+	body, err := c.ExportSubscriptions(ctx, client.ExportParams{Format: "ndjson"})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if !strings.Contains(string(data), created.ID) {
+		t.Fatalf("export missing subscription: %s", data)
+	}
+
+	if err := c.DeleteSubscription(ctx, created.ID, 4); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+}
+
+func TestClient_TypedErrors(t *testing.T) {
+	c := newClient(t, newRouter(t))
+	ctx := context.Background()
+	u, _ := c.CreateUser(ctx, client.UserInput{DisplayName: "Ivan", Currency: "RUB"})
+	sub, _ := c.CreateSubscription(ctx, input(u.ID))
+
+	var badReq *client.BadRequestError
+	bad := input(u.ID)
+	bad.Price = 0
+	_, err := c.CreateSubscription(ctx, bad)
+	if !errors.As(err, &badReq) || !strings.Contains(badReq.Message, "price") {
+		t.Fatalf("want BadRequestError about price, got %v", err)
+	}
+	if badReq.Code != "validation_failed" || len(badReq.Fields) != 1 || badReq.Fields[0].Code != "gt" {
+		t.Fatalf("want field error price/gt, got %+v", badReq.APIError)
+	}
+
+	// сообщения на языке из Accept-Language, коды не меняются
+	ru := *c
+	ru.Language = "ru"
+	_, err = ru.CreateSubscription(ctx, bad)
+	if !errors.As(err, &badReq) || badReq.Message != "price: должно быть больше 0" || badReq.Fields[0].Code != "gt" {
+		t.Fatalf("want russian message, got %v", err)
+	}
+
+	var notFound *client.NotFoundError
+	_, err = c.GetSubscription(ctx, uuid.NewString())
+	if !errors.As(err, &notFound) {
+		t.Fatalf("want NotFoundError, got %v", err)
+	}
+
+	var conflict *client.ConflictError
+	if err := c.DeleteUser(ctx, u.ID); !errors.As(err, &conflict) {
+		t.Fatalf("want ConflictError, got %v", err)
+	}
+
+	var precondition *client.PreconditionFailedError
+	_, err = c.UpdateSubscription(ctx, sub.ID, input(u.ID), 42)
+	if !errors.As(err, &precondition) {
+		t.Fatalf("want PreconditionFailedError, got %v", err)
+	}
+
+	// типизированные ошибки разворачиваются в общий *APIError
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("want *APIError, got %v", err)
+	}
+}
+
+func TestClient_RequestID(t *testing.T) {
+	var seen atomic.Value
+	router := newRouter(t)
+	c := newClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen.Store(r.Header.Get(client.HeaderRequestID))
+		router.ServeHTTP(w, r)
+	}))
+
+	ctx := client.WithRequestID(context.Background(), "trace-42")
+	_, err := c.GetSubscription(ctx, uuid.NewString())
+	var notFound *client.NotFoundError
+	if !errors.As(err, &notFound) || notFound.RequestID != "trace-42" {
+		t.Fatalf("want request id in error, got %v", err)
+	}
+	if seen.Load() != "trace-42" {
+		t.Fatalf("want X-Request-ID trace-42, got %v", seen.Load())
+	}
+
+	// без id в контексте клиент генерирует свой
+	_, _ = c.ListUsers(context.Background())
+	if id, _ := seen.Load().(string); uuid.Validate(id) != nil {
+		t.Fatalf("want generated request id, got %q", id)
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	router := newRouter(t)
+
+	cases := []struct {
+		name      string
+		status    int // код, который возвращают первые failures попыток
+		failures  int32
+		lost      bool // сервер выполнил запрос, но ответ потерялся
+		wantCalls int32
+		wantErr   bool
+	}{
+		{name: "RetryOn503", status: http.StatusServiceUnavailable, failures: 2, wantCalls: 3},
+		{name: "RetryOn504", status: http.StatusGatewayTimeout, failures: 1, wantCalls: 2},
+		{name: "LostResponse_NoDuplicate", status: http.StatusBadGateway, failures: 1, lost: true, wantCalls: 2},
+		{name: "GiveUp", status: http.StatusInternalServerError, failures: 10, wantCalls: 4, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			c := newClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/v1/subscriptions" {
+					router.ServeHTTP(w, r)
+					return
+				}
+				if calls.Add(1) > tc.failures {
+					router.ServeHTTP(w, r)
+					return
+				}
+				if tc.lost {
+					router.ServeHTTP(httptest.NewRecorder(), r)
+				}
+				http.Error(w, `{"error":"unavailable"}`, tc.status)
+			}))
+			ctx := context.Background()
+			u, _ := c.CreateUser(ctx, client.UserInput{DisplayName: "Ivan", Currency: "RUB"})
+
+			_, err := c.CreateSubscription(ctx, input(u.ID))
+			if tc.wantErr {
+				var apiErr *client.APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tc.status {
+					t.Fatalf("want APIError %d, got %v", tc.status, err)
+				}
+			} else if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			if got := calls.Load(); got != tc.wantCalls {
+				t.Fatalf("want %d calls, got %d", tc.wantCalls, got)
+			}
+
+			page, err := c.ListSubscriptions(ctx, client.ListParams{UserID: u.ID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := 1
+			if tc.wantErr {
+				want = 0
+			}
+			if len(page.Subscriptions) != want {
+				t.Fatalf("want %d subscriptions, got %d", want, len(page.Subscriptions))
+			}
+		})
+	}
+}
+
+func TestClient_NoRetryWithoutIdempotency(t *testing.T) {
+	var calls atomic.Int32
+	c := newClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, `{"error":"boom"}`, http.StatusInternalServerError)
+	}))
+
+	if _, err := c.CreateTenant(context.Background(), "acme"); err == nil {
+		t.Fatal("want error")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("want a single call for POST without Idempotency-Key, got %d", calls.Load())
+	}
+}
+
+func TestClient_ContextCanceled(t *testing.T) {
+	c := newClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "", http.StatusServiceUnavailable)
+	}))
+	c.RetryBackoff = time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.ListUsers(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("client kept retrying after context deadline")
+	}
+}
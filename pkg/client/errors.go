@@ -0,0 +1,87 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// APIError — ответ API с ошибкой (application/problem+json).
+// Code — стабильный код ошибки (например validation_failed), Fields — ошибки по полям запроса.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	RequestID  string
+	Fields     []FieldError
+}
+
+// FieldError — ошибка одного поля запроса; Code — стабильный код сообщения (required, invalid_guid, ...),
+// Message — текст на языке из Client.Language
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("my-subs: %d %s (request_id=%s)", e.StatusCode, msg, e.RequestID)
+}
+
+// BadRequestError — 400: запрос не прошёл проверку
+type BadRequestError struct{ APIError }
+
+// NotFoundError — 404: объект (или тенант) не найден
+type NotFoundError struct{ APIError }
+
+// ConflictError — 409: например, email уже занят или у пользователя есть подписки
+type ConflictError struct{ APIError }
+
+// PreconditionFailedError — 412: версия подписки не совпала с ожидаемой
+type PreconditionFailedError struct{ APIError }
+
+// Unwrap позволяет получить общий *APIError через errors.As
+func (e *BadRequestError) Unwrap() error         { return &e.APIError }
+func (e *NotFoundError) Unwrap() error           { return &e.APIError }
+func (e *ConflictError) Unwrap() error           { return &e.APIError }
+func (e *PreconditionFailedError) Unwrap() error { return &e.APIError }
+
+func newAPIError(resp *http.Response, reqID string) error {
+	e := APIError{StatusCode: resp.StatusCode, RequestID: reqID}
+	if id := resp.Header.Get(HeaderRequestID); id != "" {
+		e.RequestID = id
+	}
+	var body struct {
+		Title     string       `json:"title"`
+		Detail    string       `json:"detail"`
+		Code      string       `json:"code"`
+		RequestID string       `json:"request_id"`
+		Errors    []FieldError `json:"errors"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body); err == nil {
+		e.Code, e.Message, e.Fields = body.Code, body.Detail, body.Errors
+		if e.Message == "" {
+			e.Message = body.Title
+		}
+		if body.RequestID != "" {
+			e.RequestID = body.RequestID
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadRequest:
+		return &BadRequestError{e}
+	case http.StatusNotFound:
+		return &NotFoundError{e}
+	case http.StatusConflict:
+		return &ConflictError{e}
+	case http.StatusPreconditionFailed:
+		return &PreconditionFailedError{e}
+	}
+	return &e
+}
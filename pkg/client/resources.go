@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// --- health ---
+
+// Healthz — GET /v1/healthz: процесс жив
+func (c *Client) Healthz(ctx context.Context) error {
+	return c.ping(ctx, "/v1/healthz")
+}
+
+// Readyz — GET /v1/readyz: сервис готов принимать запросы (БД доступна)
+func (c *Client) Readyz(ctx context.Context) error {
+	return c.ping(ctx, "/v1/readyz")
+}
+
+func (c *Client) ping(ctx context.Context, path string) error {
+	resp, err := c.do(ctx, request{method: http.MethodGet, path: path})
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// --- services ---
+
+// SuggestServices — GET /v1/services/suggest; limit 0 — значение сервера по умолчанию
+func (c *Client) SuggestServices(ctx context.Context, prefix string, limit int) ([]ServiceSuggestion, error) {
+	q := url.Values{}
+	setStr(q, "prefix", prefix)
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var resp struct {
+		Suggestions []ServiceSuggestion `json:"suggestions"`
+	}
+	_, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/v1/services/suggest", query: q}, &resp)
+	return resp.Suggestions, err
+}
+
+// --- anomalies ---
+
+// ListAnomalies — GET /v1/anomalies
+func (c *Client) ListAnomalies(ctx context.Context, p AnomalyParams) ([]Anomaly, error) {
+	q := url.Values{}
+	setStr(q, "kind", p.Kind)
+	setStr(q, "subject", p.Subject)
+	setStr(q, "severity", p.Severity)
+	setYM(q, "from", p.From)
+	setYM(q, "to", p.To)
+
+	var resp struct {
+		Anomalies []Anomaly `json:"anomalies"`
+	}
+	_, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/v1/anomalies", query: q}, &resp)
+	return resp.Anomalies, err
+}
+
+// --- tenants ---
+
+// CreateTenant — POST /v1/tenants. Не повторяется: у эндпоинта нет ключа идемпотентности.
+func (c *Client) CreateTenant(ctx context.Context, name string) (Tenant, error) {
+	req, err := jsonRequest(http.MethodPost, "/v1/tenants", map[string]string{"name": name})
+	if err != nil {
+		return Tenant{}, err
+	}
+	var t Tenant
+	_, err = c.doJSON(ctx, req, &t)
+	return t, err
+}
+
+// GetTenant — GET /v1/tenants/{id}
+func (c *Client) GetTenant(ctx context.Context, id string) (Tenant, error) {
+	var t Tenant
+	_, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/v1/tenants/" + url.PathEscape(id)}, &t)
+	return t, err
+}
+
+// --- users ---
+
+// CreateUser — POST /v1/users
+func (c *Client) CreateUser(ctx context.Context, in UserInput) (User, error) {
+	req, err := jsonRequest(http.MethodPost, "/v1/users", in)
+	if err != nil {
+		return User{}, err
+	}
+	req.idempotent = true
+
+	var u User
+	_, err = c.doJSON(ctx, req, &u)
+	return u, err
+}
+
+// GetUser — GET /v1/users/{id}
+func (c *Client) GetUser(ctx context.Context, id string) (User, error) {
+	var u User
+	_, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/v1/users/" + url.PathEscape(id)}, &u)
+	return u, err
+}
+
+// ListUsers — GET /v1/users
+func (c *Client) ListUsers(ctx context.Context) ([]User, error) {
+	var resp struct {
+		Users []User `json:"users"`
+	}
+	_, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/v1/users"}, &resp)
+	return resp.Users, err
+}
+
+// UpdateUser — PUT /v1/users/{id}
+func (c *Client) UpdateUser(ctx context.Context, id string, in UserInput) (User, error) {
+	req, err := jsonRequest(http.MethodPut, "/v1/users/"+url.PathEscape(id), in)
+	if err != nil {
+		return User{}, err
+	}
+	var u User
+	_, err = c.doJSON(ctx, req, &u)
+	return u, err
+}
+
+// DeleteUser — DELETE /v1/users/{id}; ConflictError, если у пользователя есть подписки (политика restrict)
+func (c *Client) DeleteUser(ctx context.Context, id string) error {
+	_, err := c.doJSON(ctx, request{method: http.MethodDelete, path: "/v1/users/" + url.PathEscape(id)}, nil)
+	return err
+}
+
+// --- calendar ---
+
+// IssueCalendarToken — POST /v1/users/{id}/calendar-token; предыдущий токен перестаёт действовать
+func (c *Client) IssueCalendarToken(ctx context.Context, userID string) (CalendarToken, error) {
+	var t CalendarToken
+	_, err := c.doJSON(ctx, request{method: http.MethodPost, path: "/v1/users/" + url.PathEscape(userID) + "/calendar-token"}, &t)
+	return t, err
+}
+
+// RevokeCalendarToken — DELETE /v1/users/{id}/calendar-token
+func (c *Client) RevokeCalendarToken(ctx context.Context, userID string) error {
+	_, err := c.doJSON(ctx, request{method: http.MethodDelete, path: "/v1/users/" + url.PathEscape(userID) + "/calendar-token"}, nil)
+	return err
+}
+
+// CalendarFeed — GET /v1/users/{id}/calendar.ics; alarmDays < 0 — значение сервера по умолчанию
+func (c *Client) CalendarFeed(ctx context.Context, userID, token string, alarmDays int) ([]byte, error) {
+	q := url.Values{"token": {token}}
+	if alarmDays >= 0 {
+		q.Set("alarm_days", strconv.Itoa(alarmDays))
+	}
+	resp, err := c.do(ctx, request{method: http.MethodGet, path: "/v1/users/" + url.PathEscape(userID) + "/calendar.ics", query: q})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
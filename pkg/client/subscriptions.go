@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ifMatch — заголовок условного изменения; version 0 — без проверки
+func ifMatch(version int) http.Header {
+	if version == 0 {
+		return nil
+	}
+	return http.Header{"If-Match": {`"` + strconv.Itoa(version) + `"`}}
+}
+
+// versionFromETag — версия подписки из ETag ответа ("3" -> 3); 0, если заголовка нет
+func versionFromETag(h http.Header) int {
+	v, _ := strconv.Atoi(strings.Trim(h.Get("ETag"), `"`))
+	return v
+}
+
+func setYM(q url.Values, key string, ym YearMonth) {
+	if !ym.IsZero() {
+		q.Set(key, ym.String())
+	}
+}
+
+func setStr(q url.Values, key, v string) {
+	if v != "" {
+		q.Set(key, v)
+	}
+}
+
+func (p ListParams) values() url.Values {
+	q := url.Values{}
+	setStr(q, "user_id", p.UserID)
+	setStr(q, "service_name", p.ServiceName)
+	setYM(q, "active_at", p.ActiveAt)
+	setYM(q, "from", p.From)
+	setYM(q, "to", p.To)
+	if p.PriceMin != nil {
+		q.Set("price_min", strconv.Itoa(*p.PriceMin))
+	}
+	if p.PriceMax != nil {
+		q.Set("price_max", strconv.Itoa(*p.PriceMax))
+	}
+	setStr(q, "sort", p.Sort)
+	if p.Limit > 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+	}
+	setStr(q, "cursor", p.Cursor)
+	return q
+}
+
+// CreateSubscription — POST /v1/subscriptions
+func (c *Client) CreateSubscription(ctx context.Context, in SubscriptionInput) (Mutation, error) {
+	req, err := jsonRequest(http.MethodPost, "/v1/subscriptions", in)
+	if err != nil {
+		return Mutation{}, err
+	}
+	req.idempotent = true
+
+	var m Mutation
+	h, err := c.doJSON(ctx, req, &m)
+	if err != nil {
+		return Mutation{}, err
+	}
+	m.Version = versionFromETag(h)
+	return m, nil
+}
+
+// GetSubscription — GET /v1/subscriptions/{id}
+func (c *Client) GetSubscription(ctx context.Context, id string) (Subscription, error) {
+	var s Subscription
+	_, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/v1/subscriptions/" + url.PathEscape(id)}, &s)
+	return s, err
+}
+
+// UpdateSubscription — PUT /v1/subscriptions/{id}: заменяет подписку целиком.
+// version — ожидаемая версия (If-Match), 0 — без проверки; при несовпадении — PreconditionFailedError.
+func (c *Client) UpdateSubscription(ctx context.Context, id string, in SubscriptionInput, version int) (Mutation, error) {
+	body := struct {
+		ID string `json:"id"`
+		SubscriptionInput
+	}{ID: id, SubscriptionInput: in}
+	req, err := jsonRequest(http.MethodPut, "/v1/subscriptions/"+url.PathEscape(id), body)
+	if err != nil {
+		return Mutation{}, err
+	}
+	req.header = ifMatch(version)
+	return c.mutate(ctx, req)
+}
+
+// MergePatchSubscription — PATCH /v1/subscriptions/{id} с application/merge-patch+json (RFC 7396)
+func (c *Client) MergePatchSubscription(ctx context.Context, id string, patch map[string]any, version int) (Mutation, error) {
+	return c.patch(ctx, id, "application/merge-patch+json", patch, version)
+}
+
+// JSONPatchSubscription — PATCH /v1/subscriptions/{id} с application/json-patch+json (RFC 6902)
+func (c *Client) JSONPatchSubscription(ctx context.Context, id string, ops []PatchOperation, version int) (Mutation, error) {
+	return c.patch(ctx, id, "application/json-patch+json", ops, version)
+}
+
+func (c *Client) patch(ctx context.Context, id, contentType string, patch any, version int) (Mutation, error) {
+	req, err := jsonRequest(http.MethodPatch, "/v1/subscriptions/"+url.PathEscape(id), patch)
+	if err != nil {
+		return Mutation{}, err
+	}
+	req.contentType = contentType
+	req.header = ifMatch(version)
+	return c.mutate(ctx, req)
+}
+
+func (c *Client) mutate(ctx context.Context, req request) (Mutation, error) {
+	var m Mutation
+	h, err := c.doJSON(ctx, req, &m)
+	if err != nil {
+		return Mutation{}, err
+	}
+	m.Version = versionFromETag(h)
+	return m, nil
+}
+
+// DeleteSubscription — DELETE /v1/subscriptions/{id}; version — как в UpdateSubscription
+func (c *Client) DeleteSubscription(ctx context.Context, id string, version int) error {
+	req := request{method: http.MethodDelete, path: "/v1/subscriptions/" + url.PathEscape(id), header: ifMatch(version)}
+	_, err := c.doJSON(ctx, req, nil)
+	return err
+}
+
+// ListSubscriptions — одна страница GET /v1/subscriptions; следующая — с Cursor = NextCursor
+func (c *Client) ListSubscriptions(ctx context.Context, p ListParams) (SubscriptionPage, error) {
+	var page SubscriptionPage
+	_, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/v1/subscriptions", query: p.values()}, &page)
+	return page, err
+}
+
+// ExportSubscriptions — GET /v1/subscriptions/export. Тело ответа (CSV или NDJSON) читается потоком,
+// закрыть его должен вызывающий.
+func (c *Client) ExportSubscriptions(ctx context.Context, p ExportParams) (io.ReadCloser, error) {
+	lp := p.ListParams
+	lp.Limit, lp.Cursor = 0, ""
+	q := lp.values()
+	setStr(q, "format", p.Format)
+	setStr(q, "kind", p.Kind)
+
+	resp, err := c.do(ctx, request{method: http.MethodGet, path: "/v1/subscriptions/export", query: q})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// SearchSubscriptions — GET /v1/subscriptions/search
+func (c *Client) SearchSubscriptions(ctx context.Context, p SearchParams) ([]SearchResult, error) {
+	q := url.Values{"q": {p.Query}}
+	setStr(q, "user_id", p.UserID)
+	if p.Limit > 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+	}
+	var resp struct {
+		Results []SearchResult `json:"results"`
+	}
+	_, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/v1/subscriptions/search", query: q}, &resp)
+	return resp.Results, err
+}
+
+// TotalCost — GET /v1/subscriptions/totalcost
+func (c *Client) TotalCost(ctx context.Context, p TotalCostParams) (int, error) {
+	q := url.Values{}
+	q.Set("user_id", p.UserID)
+	q.Set("service_name", p.ServiceName)
+	q.Set("from", p.From.String())
+	q.Set("to", p.To.String())
+
+	var resp struct {
+		TotalCost int `json:"total_cost"`
+	}
+	_, err := c.doJSON(ctx, request{method: http.MethodGet, path: "/v1/subscriptions/totalcost", query: q}, &resp)
+	return resp.TotalCost, err
+}
+
+// BatchSubscriptions — POST /v1/subscriptions:batch
+func (c *Client) BatchSubscriptions(ctx context.Context, in BatchRequest) (BatchResult, error) {
+	req, err := jsonRequest(http.MethodPost, "/v1/subscriptions:batch", in)
+	if err != nil {
+		return BatchResult{}, err
+	}
+	req.idempotent = true
+
+	var res BatchResult
+	_, err = c.doJSON(ctx, req, &res)
+	return res, err
+}
+
+// ImportSubscriptions — POST /v1/subscriptions/import с CSV в теле
+func (c *Client) ImportSubscriptions(ctx context.Context, csv []byte, opts ImportOptions) (ImportResult, error) {
+	q := url.Values{}
+	if opts.DryRun {
+		q.Set("dry_run", "true")
+	}
+	setStr(q, "columns", opts.Columns)
+	setStr(q, "date_format", opts.DateFormat)
+	setStr(q, "delimiter", opts.Delimiter)
+
+	req := request{method: http.MethodPost, path: "/v1/subscriptions/import", query: q,
+		body: csv, contentType: "text/csv", idempotent: true}
+	var res ImportResult
+	_, err := c.doJSON(ctx, req, &res)
+	return res, err
+}
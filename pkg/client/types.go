@@ -0,0 +1,260 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// YearMonth — месяц в формате API "MM-YYYY"; нулевое значение — месяц не задан
+type YearMonth struct {
+	Year  int
+	Month time.Month
+}
+
+func YM(year int, month time.Month) YearMonth {
+	return YearMonth{Year: year, Month: month}
+}
+
+// ParseYearMonth разбирает "MM-YYYY"
+func ParseYearMonth(s string) (YearMonth, error) {
+	t, err := time.Parse("01-2006", s)
+	if err != nil {
+		return YearMonth{}, fmt.Errorf("year month %q: expected MM-YYYY", s)
+	}
+	return YearMonth{Year: t.Year(), Month: t.Month()}, nil
+}
+
+func (ym YearMonth) IsZero() bool { return ym.Year == 0 && ym.Month == 0 }
+
+func (ym YearMonth) String() string {
+	if ym.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%02d-%04d", int(ym.Month), ym.Year)
+}
+
+func (ym YearMonth) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(ym.String())), nil
+}
+
+func (ym *YearMonth) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" {
+		*ym = YearMonth{}
+		return nil
+	}
+	v, err := ParseYearMonth(s)
+	if err != nil {
+		return err
+	}
+	*ym = v
+	return nil
+}
+
+// --- подписки ---
+
+type Subscription struct {
+	ID          string    `json:"id"`
+	ServiceName string    `json:"service_name"`
+	Price       int       `json:"price"`
+	UserID      string    `json:"user_id"`
+	StartDate   YearMonth `json:"start_date"`
+	EndDate     YearMonth `json:"end_date"`
+	Notes       string    `json:"notes"`
+	Tags        []string  `json:"tags"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	UpdatedBy   string    `json:"updated_by,omitempty"`
+	Version     int       `json:"version"`
+}
+
+// SubscriptionInput — поля подписки для создания и полной замены
+type SubscriptionInput struct {
+	ServiceName string    `json:"service_name"`
+	Price       int       `json:"price"`
+	UserID      string    `json:"user_id"`
+	StartDate   YearMonth `json:"start_date"`
+	EndDate     YearMonth `json:"end_date"`
+	Notes       string    `json:"notes,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+}
+
+// Mutation — результат создания или изменения подписки; Version — новая версия из ETag
+type Mutation struct {
+	ID      string `json:"subscription_id"`
+	Status  string `json:"status"`
+	Version int    `json:"-"`
+}
+
+// ListParams — фильтры GET /v1/subscriptions; пустые поля не передаются
+type ListParams struct {
+	UserID      string
+	ServiceName string
+	ActiveAt    YearMonth
+	PriceMin    *int
+	PriceMax    *int
+	From        YearMonth
+	To          YearMonth
+	Sort        string // "price,-start_date"
+	Limit       int
+	Cursor      string // NextCursor предыдущей страницы
+}
+
+type SubscriptionPage struct {
+	Subscriptions []Subscription `json:"subscriptions"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+type TotalCostParams struct {
+	UserID      string
+	ServiceName string
+	From        YearMonth
+	To          YearMonth
+}
+
+// PatchOperation — операция JSON Patch (RFC 6902)
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// ExportParams — фильтры как у ListParams (без пагинации), формат и тип выгрузки
+type ExportParams struct {
+	Format string // csv (по умолчанию) или ndjson
+	Kind   string // subscriptions (по умолчанию) или charges
+	ListParams
+}
+
+type SearchParams struct {
+	Query  string
+	UserID string
+	Limit  int
+}
+
+type SearchResult struct {
+	Subscription Subscription     `json:"subscription"`
+	Score        float64          `json:"score"`
+	Highlights   SearchHighlights `json:"highlights"`
+}
+
+type SearchHighlights struct {
+	ServiceName string   `json:"service_name,omitempty"`
+	Notes       string   `json:"notes,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+const (
+	BatchAtomic     = "atomic"
+	BatchBestEffort = "best_effort"
+)
+
+type BatchRequest struct {
+	Mode       string           `json:"mode,omitempty"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation — create: Data; update: ID и Data; delete: ID. Version 0 — без проверки версии.
+type BatchOperation struct {
+	Op      string            `json:"op"`
+	ID      string            `json:"id,omitempty"`
+	Version int               `json:"version,omitempty"`
+	Data    SubscriptionInput `json:"data"`
+}
+
+type BatchResult struct {
+	Mode    string            `json:"mode"`
+	Applied bool              `json:"applied"`
+	Results []BatchItemResult `json:"results"`
+}
+
+type BatchItemResult struct {
+	Index   int    `json:"index"`
+	Op      string `json:"op"`
+	Status  int    `json:"status"`
+	ID      string `json:"subscription_id,omitempty"`
+	Version int    `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type ImportOptions struct {
+	DryRun     bool
+	Columns    string // "service_name:Сервис,price:Цена"
+	DateFormat string // например DD.MM.YYYY
+	Delimiter  string
+}
+
+type ImportResult struct {
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total"`
+	Valid    int              `json:"valid"`
+	Imported int              `json:"imported"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// --- остальные ресурсы ---
+
+type ServiceSuggestion struct {
+	Name          string `json:"name"`
+	Subscriptions int    `json:"subscriptions"`
+	TypicalPrice  int    `json:"typical_price"`
+}
+
+type AnomalyParams struct {
+	Kind     string // service_price или user_spend
+	Subject  string
+	Severity string // low, medium, high
+	From     YearMonth
+	To       YearMonth
+}
+
+type Anomaly struct {
+	ID         string    `json:"id"`
+	Kind       string    `json:"kind"`
+	Subject    string    `json:"subject"`
+	Month      YearMonth `json:"month"`
+	Amount     int       `json:"amount"`
+	Baseline   float64   `json:"baseline"`
+	Ratio      float64   `json:"ratio"`
+	Severity   string    `json:"severity"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+type Tenant struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type User struct {
+	ID          string    `json:"id"`
+	DisplayName string    `json:"display_name"`
+	Email       string    `json:"email,omitempty"`
+	Currency    string    `json:"currency"`
+	Locale      string    `json:"locale"`
+	Timezone    string    `json:"timezone"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type UserInput struct {
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email,omitempty"`
+	Currency    string `json:"currency,omitempty"`
+	Locale      string `json:"locale,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+}
+
+type CalendarToken struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}